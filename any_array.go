@@ -0,0 +1,53 @@
+package sqlr
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SelectAnyArray is similar to Select, except that slice arguments are passed
+// to the database as a single PostgreSQL array instead of being expanded into
+// a list of placeholders. The query should compare against the array using
+// "= any(?)":
+//  n, err := sess.SelectAnyArray(&rows, "select {} from users where id = any(?)", ids)
+// Because the SQL text is the same regardless of the number of elements in
+// the slice, the database server can reuse the query plan, and there is no
+// limit on the number of elements.
+//
+// Slices of strings and integers are supported. SelectAnyArray is only
+// supported for the PostgreSQL dialect.
+func (sess *Session) SelectAnyArray(rows interface{}, query string, args ...interface{}) (int, error) {
+	if name := dialectName(sess.schema.getDialect()); name != dialectPostgres {
+		return 0, fmt.Errorf("SelectAnyArray is not supported for dialect %s", name)
+	}
+	arrayArgs, err := anyArrayArgs(args)
+	if err != nil {
+		return 0, err
+	}
+	return sess.Select(rows, query, arrayArgs...)
+}
+
+// anyArrayArgs returns a copy of args, with each slice argument
+// replaced with a value that is passed to the database as an array.
+func anyArrayArgs(args []interface{}) ([]interface{}, error) {
+	newArgs := make([]interface{}, len(args))
+	for i, arg := range args {
+		newArgs[i] = arg
+		if arg == nil {
+			continue
+		}
+		argValue := reflect.ValueOf(arg)
+		if argValue.Kind() != reflect.Slice {
+			continue
+		}
+		switch argValue.Type().Elem().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			newArgs[i] = newPGArrayCell(fmt.Sprintf("arg %d", i+1), argValue)
+		case reflect.Uint8:
+			// []byte is a scalar value
+		default:
+			return nil, fmt.Errorf("cannot pass %s as an array in arg %d", argValue.Type(), i+1)
+		}
+	}
+	return newArgs, nil
+}
@@ -0,0 +1,126 @@
+package sqlr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSelectAnyArray(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 3)
+	defer db.Close()
+
+	tests := []struct {
+		name      string
+		anyArray  bool
+		options   []SchemaOption
+		query     string
+		args      []interface{}
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "in list",
+			query:     "select {} from rows where id in (?)",
+			args:      []interface{}{[]int64{1, 2, 3}},
+			wantQuery: `select "id", "name" from rows where id in ($1,$2,$3)`,
+			wantArgs:  []interface{}{int64(1), int64(2), int64(3)},
+		},
+		{
+			name:      "in values",
+			options:   []SchemaOption{WithSliceExpansion(ExpandValues)},
+			query:     "select {} from rows where id in (?)",
+			args:      []interface{}{[]int64{1, 2, 3}},
+			wantQuery: `select "id", "name" from rows where id in (VALUES ($1),($2),($3))`,
+			wantArgs:  []interface{}{int64(1), int64(2), int64(3)},
+		},
+		{
+			name:      "any int array",
+			anyArray:  true,
+			query:     "select {} from rows where id = any(?)",
+			args:      []interface{}{[]int64{1, 2, 3}},
+			wantQuery: `select "id", "name" from rows where id = any($1)`,
+			wantArgs:  []interface{}{"{1,2,3}"},
+		},
+		{
+			name:      "any string array",
+			anyArray:  true,
+			query:     "select {} from rows where name = any(?) and id > ?",
+			args:      []interface{}{[]string{"a", "b"}, 0},
+			wantQuery: `select "id", "name" from rows where name = any($1) and id > $2`,
+			wantArgs:  []interface{}{`{"a","b"}`, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]SchemaOption{WithDialect(Postgres), WithLastQuery()}, tt.options...)
+			sess := NewSession(context.Background(), db, NewSchema(options...))
+			defer sess.Close()
+			var rows []Row
+			var err error
+			if tt.anyArray {
+				_, err = sess.SelectAnyArray(&rows, tt.query, tt.args...)
+			} else {
+				_, err = sess.Select(&rows, tt.query, tt.args...)
+			}
+			wantNoError(t, err)
+			if got, want := len(rows), 3; got != want {
+				t.Errorf("got=%d, want=%d", got, want)
+			}
+			query, args := sess.LastQuery()
+			if query != tt.wantQuery {
+				t.Errorf("got=%q, want=%q", query, tt.wantQuery)
+			}
+			for i, arg := range args {
+				if cell, ok := arg.(*pgArrayCell); ok {
+					v, err := cell.Value()
+					wantNoError(t, err)
+					args[i] = v
+				}
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("got=%v, want=%v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSelectAnyArrayErrors(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 1)
+	defer db.Close()
+	var rows []Row
+
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(MySQL)))
+	defer sess.Close()
+	_, err := sess.SelectAnyArray(&rows, "select {} from rows where id = any(?)", []int64{1})
+	if err == nil {
+		t.Error("want error for MySQL dialect, got nil")
+	}
+
+	sess = NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+	_, err = sess.SelectAnyArray(&rows, "select {} from rows where id = any(?)", []float64{1})
+	if err == nil {
+		t.Error("want error for []float64, got nil")
+	}
+
+	if _, err := NewSchemaE(WithSliceExpansion(SliceExpansion(99))); err == nil {
+		t.Error("want error for invalid slice expansion, got nil")
+	}
+	for _, dialect := range []Dialect{MySQL, MSSQL, Oracle} {
+		if _, err := NewSchemaE(WithSliceExpansion(ExpandValues), WithDialect(dialect)); err == nil {
+			t.Errorf("%s: want error for ExpandValues, got nil", dialectName(dialect))
+		}
+	}
+	if _, err := NewSchemaE(WithDialect(SQLite), WithSliceExpansion(ExpandValues)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
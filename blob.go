@@ -0,0 +1,157 @@
+package sqlr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// BlobChunkSize is the number of bytes read from the database in each query
+// by the reader returned from ReadBlob.
+var BlobChunkSize = 1 << 20
+
+// ReadBlob returns a reader that streams the contents of a large binary
+// column, without reading the entire column into memory. The row identifies
+// the database row by its primary key, and fieldName is the name of the struct
+// field for the column, which must be a []byte field. The field in the row is
+// not updated:
+//  r, err := sess.ReadBlob(&Document{ID: id}, "Content")
+//  if err != nil {
+//      return err
+//  }
+//  defer r.Close()
+//  _, err = io.Copy(w, r)
+// The column is read in chunks of BlobChunkSize bytes, with one query for each
+// chunk, so the database server only sends the part of the column being read.
+// Because the chunks are read by separate queries, a consistent result requires
+// that the column is not modified while it is being read, eg by reading it in
+// a transaction.
+//
+// If there is no database row with the primary key, sql.ErrNoRows is returned.
+// A NULL column is read as an empty stream.
+func (sess *Session) ReadBlob(row interface{}, fieldName string) (io.ReadCloser, error) {
+	query, err := readBlobSQL(sess.schema, row, fieldName)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := sess.schema.Prepare(row, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// copy the row, so that the primary key cannot change while reading
+	rowCopy := reflect.New(stmt.tbl.rowType)
+	rowCopy.Elem().Set(stmt.tbl.mustGetRowValue(row))
+
+	r := &blobReader{
+		sess: sess,
+		stmt: stmt,
+		row:  rowCopy.Interface(),
+	}
+	// read the first chunk now, so that a missing row is reported immediately
+	if err := r.readChunk(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// readBlobSQL returns the SQL for reading a chunk of the column for the field.
+// The first argument is the one-based offset of the chunk, and the second
+// argument is the chunk length.
+func readBlobSQL(schema *Schema, row interface{}, fieldName string) (string, error) {
+	dialect := schema.getDialect()
+	tbl, err := schema.tableFor(row)
+	if err != nil {
+		return "", err
+	}
+	if len(tbl.PrimaryKey()) == 0 {
+		return "", fmt.Errorf("ReadBlob requires a primary key in %s", tbl.rowType)
+	}
+	var col *Column
+	for _, c := range tbl.Columns() {
+		if c.info.FieldNames == fieldName {
+			col = c
+			break
+		}
+	}
+	if col == nil {
+		return "", fmt.Errorf("unknown field %q in %s", fieldName, tbl.rowType)
+	}
+	if col.JSON() || col.fieldType().Kind() != reflect.Slice || col.fieldType().Elem().Kind() != reflect.Uint8 {
+		return "", fmt.Errorf("field %q must be a binary column", fieldName)
+	}
+	substr := "substr"
+	if dialectName(dialect) == dialectMSSQL {
+		substr = "substring"
+	}
+	return fmt.Sprintf(
+		"select %s(%s, ?, ?) from %s where {}",
+		substr, dialect.Quote(col.Name()), dialect.Quote(tbl.nameFor(row)),
+	), nil
+}
+
+// blobReader reads a binary column in chunks.
+type blobReader struct {
+	sess   *Session
+	stmt   *Stmt
+	row    interface{}
+	offset int    // zero-based offset of the next chunk
+	buf    []byte // unread contents of the current chunk
+	eof    bool   // no more chunks to read
+	closed bool
+}
+
+// readChunk reads the next chunk of the column into the buffer.
+func (r *blobReader) readChunk() error {
+	chunkSize := BlobChunkSize
+	args, err := r.stmt.getArgs(r.row, []interface{}{r.offset + 1, chunkSize})
+	if err != nil {
+		return err
+	}
+	rows, err := r.sess.querier.QueryContext(r.sess.context, r.stmt.String(), args...)
+	if err != nil {
+		return r.stmt.tbl.wrapRowError(err, r.row, "cannot read blob")
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return r.stmt.tbl.wrapRowError(err, r.row, "cannot read blob")
+		}
+		return sql.ErrNoRows
+	}
+	var chunk []byte
+	if err := rows.Scan(&chunk); err != nil {
+		return r.stmt.tbl.wrapRowError(err, r.row, "cannot read blob")
+	}
+	r.buf = chunk
+	r.offset += len(chunk)
+	r.eof = len(chunk) < chunkSize
+	return nil
+}
+
+// Read implements the io.Reader interface.
+func (r *blobReader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, errors.New("read on closed blob reader")
+	}
+	for len(r.buf) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		if err := r.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// Close implements the io.Closer interface.
+func (r *blobReader) Close() error {
+	r.closed = true
+	r.buf = nil
+	return nil
+}
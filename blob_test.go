@@ -0,0 +1,102 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReadBlobSQL(t *testing.T) {
+	type Document struct {
+		ID       int64 `sql:"primary key"`
+		Name     string
+		Content  []byte
+		Metadata []byte `sql:"json"`
+	}
+	type NoKey struct {
+		Content []byte
+	}
+	tests := []struct {
+		dialect   Dialect
+		row       interface{}
+		fieldName string
+		want      string
+		errText   string
+	}{
+		{
+			dialect:   Postgres,
+			row:       &Document{ID: 1},
+			fieldName: "Content",
+			want:      `select substr("content", $1, $2) from "document" where "id" = $3`,
+		},
+		{
+			dialect:   SQLite,
+			row:       &Document{ID: 1},
+			fieldName: "Content",
+			want:      "select substr(`content`, ?, ?) from `document` where `id` = ?",
+		},
+		{
+			dialect:   MSSQL,
+			row:       &Document{ID: 1},
+			fieldName: "Content",
+			want:      "select substring([content], ?, ?) from [document] where [id] = ?",
+		},
+		{
+			dialect:   Postgres,
+			row:       &Document{ID: 1},
+			fieldName: "Name",
+			errText:   `field "Name" must be a binary column`,
+		},
+		{
+			dialect:   Postgres,
+			row:       &Document{ID: 1},
+			fieldName: "Metadata",
+			errText:   `field "Metadata" must be a binary column`,
+		},
+		{
+			dialect:   Postgres,
+			row:       &Document{ID: 1},
+			fieldName: "Missing",
+			errText:   `unknown field "Missing" in sqlr.Document`,
+		},
+		{
+			dialect:   Postgres,
+			row:       &NoKey{},
+			fieldName: "Content",
+			errText:   `ReadBlob requires a primary key in sqlr.NoKey`,
+		},
+	}
+	for i, tt := range tests {
+		db := &FakeDB{queryErr: errors.New("query error")}
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(tt.dialect)))
+		_, err := sess.ReadBlob(tt.row, tt.fieldName)
+		if err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+			continue
+		}
+		if tt.errText != "" {
+			if got, want := err.Error(), tt.errText; got != want {
+				t.Errorf("%d: got=%q, want=%q", i, got, want)
+			}
+			continue
+		}
+		if got, want := db.queries, []string{tt.want}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, want)
+		}
+	}
+}
+
+func TestReadBlobNoRows(t *testing.T) {
+	type Document struct {
+		ID      int64 `sql:"primary key"`
+		Content []byte
+	}
+	db := rowsDB(t, 0)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	if _, err := sess.ReadBlob(&Document{ID: 1}, "Content"); err != sql.ErrNoRows {
+		t.Errorf("got=%v, want=%v", err, sql.ErrNoRows)
+	}
+}
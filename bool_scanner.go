@@ -0,0 +1,54 @@
+package sqlr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// boolScanner scans a boolean value, accepting the integer, floating
+// point and text representations returned by some databases.
+type boolScanner struct {
+	value *bool
+}
+
+func (bs boolScanner) Scan(v interface{}) error {
+	b, ok := boolValue(v)
+	if !ok {
+		return fmt.Errorf("cannot convert %T to bool", v)
+	}
+	*bs.value = b
+	return nil
+}
+
+// boolValue interprets a value scanned from the database as a boolean.
+// Returns false for the second value if v cannot be interpreted as a boolean.
+func boolValue(v interface{}) (bool, bool) {
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case int64:
+		return v != 0, true
+	case float64:
+		return v != 0, true
+	case []byte:
+		if len(v) == 1 && v[0] <= 1 {
+			// a MySQL BIT(1) column
+			return v[0] == 1, true
+		}
+		return parseBool(string(v))
+	case string:
+		return parseBool(v)
+	}
+	return false, false
+}
+
+func parseBool(s string) (bool, bool) {
+	if b, err := strconv.ParseBool(s); err == nil {
+		return b, true
+	}
+	// numeric types are returned as text by some drivers, eg "1.0"
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f != 0, true
+	}
+	return false, false
+}
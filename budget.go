@@ -0,0 +1,124 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is returned by a session created with NewSessionWithBudget
+// once the cumulative time spent in database calls exceeds the budget.
+var ErrBudgetExceeded = errors.New("session query budget exceeded")
+
+// NewSessionWithBudget returns a new, request-scoped session that limits the
+// cumulative time spent executing queries to budget.
+//
+// The time spent in each call to the database is deducted from the budget.
+// When the budget is exhausted, the session's context is canceled, which
+// interrupts any query in progress, and all subsequent queries fail with
+// ErrBudgetExceeded. This is stricter than a timeout for each query, and
+// protects the database from a single request that runs many expensive queries.
+//
+// Only the time spent in the database call is measured. For a query that
+// returns rows, this does not include the time spent reading the rows.
+func NewSessionWithBudget(ctx context.Context, querier Querier, schema *Schema, budget time.Duration) *Session {
+	sess := NewSession(ctx, querier, schema)
+	// wrap the session's querier, which may already be wrapped, eg to
+	// record the last query
+	sess.querier = &budgetQuerier{
+		querier: sess.querier,
+		budget: &queryBudget{
+			cancel:    sess.cancel,
+			remaining: budget,
+		},
+	}
+	return sess
+}
+
+// budgetQuerier is a Querier that measures the time spent in each call to
+// the database, and cancels the session once the budget has been exhausted.
+type budgetQuerier struct {
+	querier Querier
+	budget  *queryBudget // shared with the sessions for transactions started by InTx
+}
+
+// queryBudget is the time remaining for the database calls of a session.
+type queryBudget struct {
+	cancel func()
+
+	mu        sync.Mutex
+	remaining time.Duration
+	exhausted bool
+}
+
+// ExecContext implements the Querier interface.
+func (bq *budgetQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stop, err := bq.budget.start()
+	if err != nil {
+		return nil, err
+	}
+	result, err := bq.querier.ExecContext(ctx, query, args...)
+	return result, stop(err)
+}
+
+// QueryContext implements the Querier interface.
+func (bq *budgetQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stop, err := bq.budget.start()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := bq.querier.QueryContext(ctx, query, args...)
+	return rows, stop(err)
+}
+
+// base returns the querier that performs the database calls.
+func (bq *budgetQuerier) base() Querier {
+	return bq.querier
+}
+
+// start is called at the start of a database call. It returns ErrBudgetExceeded
+// if the budget has been exhausted. Otherwise it returns a function that must be
+// called when the database call is complete, which deducts the elapsed time from
+// the budget and returns the error to report for the call.
+func (qb *queryBudget) start() (func(error) error, error) {
+	qb.mu.Lock()
+	remaining, exhausted := qb.remaining, qb.exhausted
+	qb.mu.Unlock()
+	if exhausted || remaining <= 0 {
+		qb.exhaust()
+		return nil, ErrBudgetExceeded
+	}
+
+	startTime := time.Now()
+
+	// interrupt the call if it runs past the remaining budget
+	timer := time.AfterFunc(remaining, qb.exhaust)
+
+	stop := func(err error) error {
+		timer.Stop()
+		elapsed := time.Since(startTime)
+		qb.mu.Lock()
+		qb.remaining -= elapsed
+		exhausted := qb.exhausted || qb.remaining <= 0
+		qb.mu.Unlock()
+		if exhausted {
+			qb.exhaust()
+			if err != nil {
+				// the call was most likely interrupted by the cancellation
+				err = ErrBudgetExceeded
+			}
+		}
+		return err
+	}
+	return stop, nil
+}
+
+// exhaust marks the budget as exhausted and cancels the session's context.
+func (qb *queryBudget) exhaust() {
+	qb.mu.Lock()
+	qb.exhausted = true
+	qb.mu.Unlock()
+	qb.cancel()
+}
@@ -0,0 +1,108 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// slowDB is a Querier where every call takes the same amount of time,
+// unless the context is canceled first.
+type slowDB struct {
+	FakeDB
+	delay time.Duration
+	calls int
+}
+
+func (db *slowDB) wait(ctx context.Context) error {
+	db.calls++
+	select {
+	case <-time.After(db.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (db *slowDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if err := db.wait(ctx); err != nil {
+		return nil, err
+	}
+	return db.FakeDB.ExecContext(ctx, query, args...)
+}
+
+func (db *slowDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if err := db.wait(ctx); err != nil {
+		return nil, err
+	}
+	return db.FakeDB.QueryContext(ctx, query, args...)
+}
+
+func TestSessionBudget(t *testing.T) {
+	db := &slowDB{delay: 80 * time.Millisecond}
+	schema := NewSchema(WithDialect(Postgres))
+	sess := NewSessionWithBudget(context.Background(), db, schema, 200*time.Millisecond)
+	defer sess.Close()
+
+	// first two calls are within the budget
+	for i := 0; i < 2; i++ {
+		if _, err := sess.Exec("update t set n = n + 1"); err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+	}
+	if err := sess.Context().Err(); err != nil {
+		t.Fatalf("unexpected context error: %v", err)
+	}
+
+	// third call is interrupted when the budget is exhausted
+	start := time.Now()
+	if _, err := sess.Exec("update t set n = n + 1"); err != ErrBudgetExceeded {
+		t.Fatalf("got=%v, want=%v", err, ErrBudgetExceeded)
+	}
+	if elapsed := time.Since(start); elapsed >= db.delay {
+		t.Errorf("call was not interrupted: elapsed=%v", elapsed)
+	}
+	if sess.Context().Err() == nil {
+		t.Error("want session context canceled")
+	}
+
+	// subsequent calls fail without calling the database
+	if _, err := sess.Exec("update t set n = n + 1"); err != ErrBudgetExceeded {
+		t.Fatalf("got=%v, want=%v", err, ErrBudgetExceeded)
+	}
+	if got, want := db.calls, 3; got != want {
+		t.Errorf("calls: got=%d, want=%d", got, want)
+	}
+}
+
+func TestSessionBudgetQuery(t *testing.T) {
+	db := &slowDB{delay: 30 * time.Millisecond}
+	schema := NewSchema(WithDialect(Postgres))
+	sess := NewSessionWithBudget(context.Background(), db, schema, 20*time.Millisecond)
+	defer sess.Close()
+
+	var rows []struct {
+		ID int `sql:"primary key"`
+	}
+	if _, err := sess.Select(&rows, "select {} from t"); err != ErrBudgetExceeded {
+		t.Fatalf("got=%v, want=%v", err, ErrBudgetExceeded)
+	}
+}
+
+func TestSessionBudgetWrapsQuerier(t *testing.T) {
+	db := &FakeDB{}
+	schema := NewSchema(WithDialect(Postgres), WithLastQuery())
+	sess := NewSessionWithBudget(context.Background(), db, schema, time.Second)
+	defer sess.Close()
+
+	if got, want := baseQuerier(sess.querier), Querier(db); got != want {
+		t.Errorf("got=%T, want=%T", got, want)
+	}
+	if _, err := sess.Exec("update t set n = n + 1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := sess.LastQuery(); got != "update t set n = n + 1" {
+		t.Errorf("got=%q, want=%q", got, "update t set n = n + 1")
+	}
+}
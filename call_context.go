@@ -0,0 +1,84 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// ExecContext is similar to Exec, except that the query uses ctx instead of
+// the session's context. This is useful for setting a deadline on a single
+// query without affecting the rest of the session. The query is still
+// canceled if the session is closed.
+func (sess *Session) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := sess.callContext(ctx)
+	defer cancel()
+	return sess.execContext(ctx, &struct{}{}, query, args)
+}
+
+// SelectContext is similar to Select, except that the query uses ctx instead
+// of the session's context. This is useful for setting a deadline on a single
+// query without affecting the rest of the session:
+//  ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+//  defer cancel()
+//  n, err := sess.SelectContext(ctx, &rows, "select {} from orders where {}", customerID)
+// The query is still canceled if the session is closed.
+func (sess *Session) SelectContext(ctx context.Context, rows interface{}, query string, args ...interface{}) (int, error) {
+	ctx, cancel := sess.callContext(ctx)
+	defer cancel()
+	return sess.selectContext(ctx, rows, query, args)
+}
+
+// QueryContext is similar to Query, except that the query uses ctx instead of
+// the session's context. The rows returned are closed if ctx is canceled or
+// the session is closed. The rows must be closed when they are no longer
+// needed, as closing them releases the context of the query.
+func (sess *Session) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	ctx, cancel := sess.callContext(ctx)
+	rows, err := sess.queryContext(ctx, query, args)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &Rows{Rows: rows, cancel: cancel}, nil
+}
+
+// Rows is the result of a query performed by QueryContext. It has the same
+// methods as sql.Rows, and closing the rows also releases the context that
+// was created for the query.
+type Rows struct {
+	*sql.Rows
+	cancel context.CancelFunc
+}
+
+// Close closes the rows and releases the context of the query.
+func (rows *Rows) Close() error {
+	err := rows.Rows.Close()
+	rows.cancel()
+	return err
+}
+
+// callContext returns a context for a single call that is derived from ctx,
+// and which is also canceled when the session is closed. The returned cancel
+// function releases the resources associated with the call, and must be called
+// when the call has finished.
+func (sess *Session) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-sess.context.Done():
+			cancel()
+		case <-ctx.Done():
+		case <-stop:
+		}
+	}()
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() { close(stop) })
+		cancel()
+	}
+}
@@ -0,0 +1,116 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// ctxDB is a querier that records the context of each call.
+type ctxDB struct {
+	ctxs []context.Context
+	db   Querier // performs queries, if not nil
+}
+
+func (db *ctxDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db.ctxs = append(db.ctxs, ctx)
+	return &FakeDB{}, nil
+}
+
+func (db *ctxDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	db.ctxs = append(db.ctxs, ctx)
+	if db.db != nil {
+		return db.db.QueryContext(ctx, query, args...)
+	}
+	return nil, errors.New("query failed")
+}
+
+func TestCallContext(t *testing.T) {
+	type Row struct {
+		ID int64 `sql:"primary key"`
+	}
+	type ctxKey struct{}
+	db := &ctxDB{}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	_, err := sess.ExecContext(ctx, "delete from rows where id = ?", 1)
+	wantNoError(t, err)
+	var rows []Row
+	if _, err := sess.SelectContext(ctx, &rows, "select {} from rows"); err == nil {
+		t.Error("got=nil, want=error")
+	}
+	if _, err := sess.QueryContext(ctx, "select id from rows"); err == nil {
+		t.Error("got=nil, want=error")
+	}
+	if got, want := len(db.ctxs), 3; got != want {
+		t.Fatalf("got=%d, want=%d", got, want)
+	}
+	for i, callCtx := range db.ctxs {
+		if got, want := callCtx.Value(ctxKey{}), "value"; got != want {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+	}
+
+	// the exec and select contexts are canceled on return, and
+	// the query context is canceled because the query failed
+	for i, callCtx := range db.ctxs {
+		if callCtx.Err() == nil {
+			t.Errorf("%d: want context canceled", i)
+		}
+	}
+	sess.Close()
+}
+
+func TestQueryContextSessionClosed(t *testing.T) {
+	rowsDB := rowsDB(t, 3)
+	defer rowsDB.Close()
+	db := &ctxDB{db: rowsDB}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+
+	rows, err := sess.QueryContext(context.Background(), "select id, name from rows")
+	wantNoError(t, err)
+	defer rows.Close()
+
+	// the query context is canceled when the session is closed
+	queryCtx := db.ctxs[0]
+	if err := queryCtx.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sess.Close()
+	select {
+	case <-queryCtx.Done():
+	case <-time.After(time.Second):
+		t.Error("query context not canceled when session closed")
+	}
+}
+
+func TestQueryContextRowsClosed(t *testing.T) {
+	rowsDB := rowsDB(t, 3)
+	defer rowsDB.Close()
+	db := &ctxDB{db: rowsDB}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	rows, err := sess.QueryContext(context.Background(), "select id, name from rows")
+	wantNoError(t, err)
+	var n int
+	for rows.Next() {
+		n++
+	}
+	if got, want := n, 3; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	queryCtx := db.ctxs[0]
+	if err := queryCtx.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// closing the rows releases the query context
+	wantNoError(t, rows.Close())
+	if queryCtx.Err() == nil {
+		t.Error("query context not canceled when rows closed")
+	}
+}
@@ -18,6 +18,19 @@ const (
 
 var whiteSpaceRE = regexp.MustCompile(`\s`)
 
+// niladicFunctions are SQL functions that are called without parentheses,
+// and so can look like a table name in a query such as "select current_timestamp".
+var niladicFunctions = map[string]bool{
+	"current_date":      true,
+	"current_time":      true,
+	"current_timestamp": true,
+	"current_user":      true,
+	"localtime":         true,
+	"localtimestamp":    true,
+	"session_user":      true,
+	"system_user":       true,
+}
+
 // checkSQL inspects the contents of sql, and performs the following
 // replacements. The sole purpose of this is to minimise typing for
 // commonly used statement patterns.
@@ -29,12 +42,16 @@ var whiteSpaceRE = regexp.MustCompile(`\s`)
 // Note that we do not allow "DELETE FROM <table>" or "DELETE <table>"
 // similar because that is actually valid SQL, and has the rather uncommon
 // effect of deleting everything in the table.
+//
+// A table name must be an identifier, so queries without a FROM clause such
+// as "select 1" or "select current_timestamp" are left alone.
 func checkSQL(sql string) (string, error) {
 	const maxWords = 3 // if the SQL has more than this number of words, leave it alone
 	scan := scanner.New(strings.NewReader(sql))
 	scan.IgnoreWhiteSpace = true
 	scan.AddKeywords("insert", "update", "delete", "select", "into", "from")
 	words := make([]string, 0, maxWords)
+	tokens := make([]scanner.Token, 0, maxWords)
 	for scan.Scan() {
 		if len(words) >= maxWords {
 			// the input is longer than the max number of words, then don't change it
@@ -45,13 +62,19 @@ func checkSQL(sql string) (string, error) {
 		} else {
 			words = append(words, scan.Text())
 		}
+		tokens = append(tokens, scan.Token())
 	}
 	match := func(args ...string) bool {
 		if len(args) != len(words) {
 			return false
 		}
 		for i, word := range args {
-			if word != "" && word != words[i] {
+			if word == "" {
+				// matches a table name
+				if tokens[i] != scanner.IDENT || niladicFunctions[strings.ToLower(words[i])] {
+					return false
+				}
+			} else if word != words[i] {
 				return false
 			}
 		}
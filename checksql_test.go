@@ -28,6 +28,22 @@ func TestCheckSQL(t *testing.T) {
 			in:  "  select     from\ttblname ",
 			out: `select {} from tblname where {}`,
 		},
+		{
+			in:  "select 1",
+			out: "select 1",
+		},
+		{
+			in:  "select 'x'",
+			out: "select 'x'",
+		},
+		{
+			in:  "select current_timestamp",
+			out: "select current_timestamp",
+		},
+		{
+			in:  "select now()",
+			out: "select now()",
+		},
 		{
 			in:      "delete from my_table",
 			errText: `will not delete all rows in table my_table: use database/sql if you want to do this`,
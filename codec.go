@@ -0,0 +1,14 @@
+package sqlr
+
+// Codec marshals the value of a field into the bytes stored in its database
+// column, and unmarshals the bytes read from the column back into the field.
+// A codec is specified for a column using the Codec field of ColumnConfig,
+// and can be used to store a field using an encoding other than JSON, such as
+// gob or msgpack, or to encrypt the contents of a column.
+//
+// The signatures match json.Marshal and json.Unmarshal: Marshal is passed the
+// value of the field, and Unmarshal is passed a pointer to the field.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
@@ -0,0 +1,159 @@
+package sqlr
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// gobCodec is a Codec that encodes values using encoding/gob.
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// failCodec is a Codec that always fails.
+type failCodec struct{}
+
+func (failCodec) Marshal(v interface{}) ([]byte, error) {
+	return nil, errors.New("marshal failed")
+}
+
+func (failCodec) Unmarshal(data []byte, v interface{}) error {
+	return errors.New("unmarshal failed")
+}
+
+func TestCodec(t *testing.T) {
+	type Payload struct {
+		Name   string
+		Values []int
+	}
+	type Row struct {
+		ID      int64 `sql:"primary key"`
+		Payload *Payload
+	}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithTables(TablesConfig{
+			(*Row)(nil): TableConfig{
+				Columns: ColumnsConfig{
+					"Payload": ColumnConfig{Codec: gobCodec{}},
+				},
+			},
+		}),
+	)
+	col := schema.TableFor(Row{}).Columns()[1]
+	if got, want := col.JSON(), false; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := fieldTypeFamily(col), familyBinary; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	payload := &Payload{Name: "name", Values: []int{1, 2, 3}}
+	row := Row{ID: 1, Payload: payload}
+	stmt, err := schema.Prepare(row, "insert into rows({}) values({})")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := stmt.getArgs(&row, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, ok := args[1].([]byte)
+	if !ok {
+		t.Fatalf("got=%T, want=[]byte", args[1])
+	}
+	wantData, err := gobCodec{}.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, wantData) {
+		t.Errorf("got=% x, want=% x", data, wantData)
+	}
+
+	// a nil pointer is stored as NULL
+	nilRow := Row{ID: 2}
+	args, err = stmt.getArgs(&nilRow, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args[1] != nil {
+		t.Errorf("got=%v, want=nil", args[1])
+	}
+
+	// round trip
+	var got Row
+	scanValue, jc := bindCell(col, reflect.ValueOf(&got).Elem(), false)
+	if jc == nil {
+		t.Fatal("got=nil, want=cell to unmarshal")
+	}
+	*(scanValue.(*[]byte)) = data
+	if err := jc.Unmarshal(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Payload, payload) {
+		t.Errorf("got=%+v, want=%+v", got.Payload, payload)
+	}
+
+	// NULL is unmarshaled as the zero value
+	jc.data = nil
+	if err := jc.Unmarshal(); err != nil {
+		t.Fatal(err)
+	}
+	if got.Payload != nil {
+		t.Errorf("got=%+v, want=nil", got.Payload)
+	}
+}
+
+func TestCodecErrors(t *testing.T) {
+	type Row struct {
+		ID      int64 `sql:"primary key"`
+		Payload []string
+	}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithTables(TablesConfig{
+			(*Row)(nil): TableConfig{
+				Columns: ColumnsConfig{
+					"Payload": ColumnConfig{Codec: failCodec{}},
+				},
+			},
+		}),
+	)
+	row := Row{ID: 1, Payload: []string{"a"}}
+	stmt, err := schema.Prepare(row, "insert into rows({}) values({})")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stmt.getArgs(&row, nil)
+	if err == nil {
+		t.Fatal("got=nil, want=error")
+	}
+	if got, want := err.Error(), `cannot marshal field "Payload": marshal failed`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	col := schema.TableFor(Row{}).Columns()[1]
+	var got Row
+	_, jc := bindCell(col, reflect.ValueOf(&got).Elem(), false)
+	jc.data = []byte("data")
+	err = jc.Unmarshal()
+	if err == nil {
+		t.Fatal("got=nil, want=error")
+	}
+	if got, want := err.Error(), `cannot unmarshal field "Payload": unmarshal failed`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
@@ -0,0 +1,50 @@
+package sqlr
+
+import "fmt"
+
+// Collate returns an SQL expression that applies collation to column, using
+// the collation syntax of the schema's dialect. Column is included in the
+// expression as-is, so it can be an expression such as "u.name". The result
+// can be used for case-insensitive or accent-insensitive comparisons in a
+// WHERE clause, and for sorting user-facing text in an ORDER BY clause:
+//  name, err := schema.Collate("name", "NOCASE")
+//  if err != nil {
+//      return err
+//  }
+//  _, err = session.Select(&users, "select {} from users where "+name+" = ? order by "+name, "smith")
+//
+// For MySQL, SQLite and SQL Server the collation name is used as-is, eg
+// "name collate utf8mb4_general_ci" or "name collate NOCASE". For Postgres and
+// other dialects the collation name is a quoted identifier, eg `name collate "C"`.
+//
+// The collation name can only contain letters, digits, underscores, hyphens
+// and periods. An error is returned for any other collation name.
+func (s *Schema) Collate(column, collation string) (string, error) {
+	if !isCollationName(collation) {
+		return "", fmt.Errorf("invalid collation name %q", collation)
+	}
+	dialect := s.getDialect()
+	switch dialectName(dialect) {
+	case dialectMySQL, dialectSQLite, dialectMSSQL:
+		return fmt.Sprintf("%s collate %s", column, collation), nil
+	}
+	return fmt.Sprintf("%s collate %s", column, dialect.Quote(collation)), nil
+}
+
+// isCollationName reports whether name is a valid collation name.
+func isCollationName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, ch := range name {
+		switch {
+		case ch >= 'a' && ch <= 'z',
+			ch >= 'A' && ch <= 'Z',
+			ch >= '0' && ch <= '9',
+			ch == '_', ch == '-', ch == '.':
+			continue
+		}
+		return false
+	}
+	return true
+}
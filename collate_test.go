@@ -0,0 +1,78 @@
+package sqlr
+
+import "testing"
+
+func TestCollate(t *testing.T) {
+	tests := []struct {
+		dialect   Dialect
+		collation string
+		want      string
+		errText   string
+	}{
+		{
+			dialect:   SQLite,
+			collation: "NOCASE",
+			want:      "name collate NOCASE",
+		},
+		{
+			dialect:   MySQL,
+			collation: "utf8mb4_general_ci",
+			want:      "name collate utf8mb4_general_ci",
+		},
+		{
+			dialect:   MSSQL,
+			collation: "Latin1_General_CI_AI",
+			want:      "name collate Latin1_General_CI_AI",
+		},
+		{
+			dialect:   Postgres,
+			collation: "en-US-x-icu",
+			want:      `name collate "en-US-x-icu"`,
+		},
+		{
+			dialect:   SQLite,
+			collation: "nocase; drop table users",
+			errText:   `invalid collation name "nocase; drop table users"`,
+		},
+		{
+			dialect:   MySQL,
+			collation: "",
+			errText:   `invalid collation name ""`,
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		got, err := schema.Collate("name", tt.collation)
+		if tt.errText != "" {
+			if err == nil || err.Error() != tt.errText {
+				t.Errorf("%d: got=%v, want=%q", i, err, tt.errText)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if want := tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+
+	// collated expression in the where and order by clauses
+	type User struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	schema := NewSchema(WithDialect(SQLite))
+	name, err := schema.Collate("name", "NOCASE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt, err := schema.Prepare(User{}, "select {} from users where "+name+" = ? order by "+name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stmt.String(), "select `id`, `name` from users where name collate NOCASE = ? order by name collate NOCASE"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
@@ -2,6 +2,7 @@ package sqlr
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -31,9 +32,11 @@ func newColumns(allColumns []*Column) columnList {
 // Parse parses the text inside the curly braces to obtain more information
 // about how to render the column list. It is not very sophisticated at the moment,
 // currently the only recognised values are:
-//  "alias n" => use alias "n" for each column in the list
-//  "pk"      => primary key columns only
-//  "all"     => all columns
+//  "alias n"      => use alias "n" for each column in the list
+//  "pk"           => primary key columns only
+//  "all"          => all columns, regardless of the clause, except that
+//                    columns with a read expression are not written
+//  "exclude a, b" => exclude columns a and b from the list
 func (cols columnList) Parse(clause sqlClause, text string) (columnList, error) {
 	cols2 := cols
 	cols2.clause = clause
@@ -69,7 +72,7 @@ func (cols columnList) Parse(clause sqlClause, text string) (columnList, error)
 						return columnList{}, fmt.Errorf("missing ident after 'alias'")
 					}
 				case "all":
-					cols2.filter = columnFilterAll
+					cols2.filter = clause.allFilter()
 					needScan = true
 				case "pk":
 					cols2.filter = columnFilterPK
@@ -102,6 +105,11 @@ func (cols columnList) Parse(clause sqlClause, text string) (columnList, error)
 		}
 	}
 	if err := scan.Err(); err != nil {
+		if serr, ok := err.(*scanner.Error); ok {
+			// the position within the braces is not meaningful to the caller,
+			// who will be told the position of the braces in the query
+			return columnList{}, errors.New(serr.Msg)
+		}
 		return columnList{}, err
 	}
 
@@ -133,8 +141,20 @@ func (cols columnList) String(dialect Dialect, counter func() int) string {
 			}
 		}
 		switch cols.clause {
-		case clauseSelectColumns, clauseSelectOrderBy:
-			if cols.alias != "" {
+		case clauseSelectColumns,
+			clauseInsertReturning, clauseUpdateReturning, clauseDeleteReturning:
+			if col.readExpr != "" {
+				// the expression is aliased to the column name for scanning
+				buf.WriteString("(" + col.readExpr + ") as " + quotedColumnName(col))
+			} else {
+				if cols.alias != "" {
+					buf.WriteString(cols.alias)
+					buf.WriteRune('.')
+				}
+				buf.WriteString(quotedColumnName(col))
+			}
+		case clauseSelectOrderBy:
+			if cols.alias != "" && col.readExpr == "" {
 				buf.WriteString(cols.alias)
 				buf.WriteRune('.')
 			}
@@ -142,7 +162,7 @@ func (cols columnList) String(dialect Dialect, counter func() int) string {
 		case clauseInsertColumns:
 			buf.WriteString(quotedColumnName(col))
 		case clauseInsertValues:
-			buf.WriteString(placeholder())
+			buf.WriteString(writeValue(col, dialect, placeholder))
 		case clauseUpdateSet, clauseUpdateWhere, clauseDeleteWhere, clauseSelectWhere:
 			if cols.alias != "" {
 				buf.WriteString(cols.alias)
@@ -150,7 +170,11 @@ func (cols columnList) String(dialect Dialect, counter func() int) string {
 			}
 			buf.WriteString(quotedColumnName(col))
 			buf.WriteString(" = ")
-			buf.WriteString(placeholder())
+			if cols.clause == clauseUpdateSet {
+				buf.WriteString(writeValue(col, dialect, placeholder))
+			} else {
+				buf.WriteString(placeholder())
+			}
 		}
 	}
 	return buf.String()
@@ -175,19 +199,62 @@ func columnFilterAll(col *Column) bool {
 	return true
 }
 
+// columnFilterWritable is the filter for all columns except any columns
+// with a read expression, which are not columns of the table
+func columnFilterWritable(col *Column) bool {
+	return col.readExpr == ""
+}
+
 // columnFilterPK is the filter for primary key columns only
 func columnFilterPK(col *Column) bool {
 	return col.PrimaryKey()
 }
 
 // columnFilterInsertable is the filter for all columns except the autoincrement
-// column (if it exists)
+// column (if it exists), any columns generated by the database and any columns
+// with a read expression
 func columnFilterInsertable(col *Column) bool {
-	return !col.AutoIncrement()
+	return !col.AutoIncrement() && !col.Generated() && col.readExpr == ""
 }
 
 // columnFitlerUpdateable is the filter for all columns not part of the primary key,
-// and not autoincrement
+// not autoincrement and without a read expression
 func columnFilterUpdateable(col *Column) bool {
-	return !col.PrimaryKey() && !col.AutoIncrement()
+	return !col.PrimaryKey() && !col.AutoIncrement() && col.readExpr == ""
+}
+
+// writeValue returns the SQL for the value written to the column in an
+// insert or update statement. This is a placeholder, unless the column has
+// a write expression, in which case the placeholder in the expression is
+// replaced. For PostgreSQL, the placeholder for a jsonb column is cast
+// to jsonb.
+func writeValue(col *Column, dialect Dialect, placeholder func() string) string {
+	if col.writeExpr == "" {
+		if col.jsonb && isPostgres(dialect) {
+			return placeholder() + "::jsonb"
+		}
+		return placeholder()
+	}
+	scan := scanner.New(strings.NewReader(col.writeExpr))
+	var buf bytes.Buffer
+	for scan.Scan() {
+		if scan.Token() == scanner.PLACEHOLDER {
+			buf.WriteString(placeholder())
+		} else {
+			buf.WriteString(scan.Text())
+		}
+	}
+	return buf.String()
+}
+
+// countPlaceholders returns the number of placeholders in the SQL expression.
+func countPlaceholders(expr string) (int, error) {
+	scan := scanner.New(strings.NewReader(expr))
+	var n int
+	for scan.Scan() {
+		if scan.Token() == scanner.PLACEHOLDER {
+			n++
+		}
+	}
+	return n, scan.Err()
 }
@@ -0,0 +1,159 @@
+package sqlr
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// typeFamily is a broad classification of column types. It is used to
+// compare the type of a struct field with the type of a database column,
+// where the exact type names vary considerably between SQL dialects.
+type typeFamily int
+
+const (
+	familyUnknown typeFamily = iota
+	familyBool
+	familyInteger
+	familyFloat
+	familyText
+	familyBinary
+	familyTime
+)
+
+func (f typeFamily) String() string {
+	switch f {
+	case familyBool:
+		return "bool"
+	case familyInteger:
+		return "integer"
+	case familyFloat:
+		return "float"
+	case familyText:
+		return "text"
+	case familyBinary:
+		return "binary"
+	case familyTime:
+		return "time"
+	}
+	return "unknown"
+}
+
+// fieldTypeFamily returns the type family for the struct field associated
+// with the column. Returns familyUnknown if the field type cannot be
+// classified, which is the case for types that implement sql.Scanner.
+func fieldTypeFamily(col *Column) typeFamily {
+	if col.codec != nil && !col.JSON() {
+		return familyBinary
+	}
+	if col.epoch != 0 {
+		// time stored as a Unix time integer
+		return familyInteger
+	}
+	if col.JSON() {
+		if col.Gzip() {
+			return familyBinary
+		}
+		return familyText
+	}
+	fieldType := col.fieldType()
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType == timeType {
+		return familyTime
+	}
+	if reflect.PtrTo(fieldType).Implements(wellKnownTypes.scannerType) {
+		return familyUnknown
+	}
+	switch fieldType.Kind() {
+	case reflect.Bool:
+		return familyBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return familyInteger
+	case reflect.Float32, reflect.Float64:
+		return familyFloat
+	case reflect.String:
+		return familyText
+	case reflect.Slice:
+		if fieldType.Elem().Kind() == reflect.Uint8 {
+			return familyBinary
+		}
+	}
+	return familyUnknown
+}
+
+// dbTypeFamily returns the type family for a database type name, as
+// reported by the database's information schema. The rules are loosely
+// based on the SQLite type affinity rules, which work reasonably well
+// for the type names used by other databases.
+func dbTypeFamily(dbType string) typeFamily {
+	dbType = strings.ToLower(dbType)
+	switch {
+	case dbType == "":
+		return familyUnknown
+	case strings.HasPrefix(dbType, "bool") || dbType == "bit":
+		return familyBool
+	case isIntegerDBType(dbType):
+		return familyInteger
+	case strings.Contains(dbType, "char"),
+		strings.Contains(dbType, "clob"),
+		strings.Contains(dbType, "text"),
+		strings.HasPrefix(dbType, "json"),
+		strings.HasPrefix(dbType, "uuid"):
+		return familyText
+	case strings.Contains(dbType, "blob"),
+		strings.Contains(dbType, "binary"),
+		strings.HasPrefix(dbType, "bytea"):
+		return familyBinary
+	case strings.Contains(dbType, "time"),
+		strings.HasPrefix(dbType, "date"):
+		return familyTime
+	case strings.Contains(dbType, "real"),
+		strings.Contains(dbType, "floa"),
+		strings.Contains(dbType, "doub"),
+		strings.HasPrefix(dbType, "numeric"),
+		strings.HasPrefix(dbType, "decimal"):
+		return familyFloat
+	}
+	return familyUnknown
+}
+
+// isIntegerDBType reports whether the lower case database type name is an
+// integer type. Each word in the name is checked, so that "int unsigned" and
+// "bigint(20)" are integer types, but "interval" and "point" are not.
+func isIntegerDBType(dbType string) bool {
+	words := strings.FieldsFunc(dbType, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		switch word {
+		case "int", "integer", "tinyint", "smallint", "mediumint", "bigint",
+			"int2", "int4", "int8", "serial", "smallserial", "bigserial":
+			return true
+		}
+	}
+	return false
+}
+
+// compatibleFamilies reports whether a field with type family fieldFamily
+// can be stored in a database column with type family dbFamily.
+func compatibleFamilies(fieldFamily, dbFamily typeFamily) bool {
+	if fieldFamily == familyUnknown || dbFamily == familyUnknown {
+		// cannot tell, so assume the best
+		return true
+	}
+	if fieldFamily == dbFamily {
+		return true
+	}
+	switch fieldFamily {
+	case familyBool:
+		// MySQL and SQLite store booleans as integers
+		return dbFamily == familyInteger
+	case familyFloat:
+		// a float field can hold any value from an integer column
+		return dbFamily == familyInteger
+	}
+	return false
+}
@@ -21,6 +21,7 @@ type dataLoader struct {
 
 	thunks  map[interface{}]*thunkT // map of all thunks
 	pending map[interface{}]*thunkT // map of thunks that have not been called yet
+	group   *BatchGroup             // batch group, or nil if the loader is not in a group
 }
 
 // Call implements the loader function, whose responsibility is to locate
@@ -168,7 +169,11 @@ func (thunk *thunkT) Call(args []reflect.Value) (result []reflect.Value) {
 		panic(fmt.Sprintf("thunk function called with arguments: %v", args))
 	}
 	if thunk.pending {
-		thunk.loader.performQuery(thunk)
+		if thunk.loader.group != nil {
+			thunk.loader.group.Dispatch()
+		} else {
+			thunk.loader.performQuery(thunk)
+		}
 		if thunk.pending {
 			panic("thunk should no longer be pending")
 		}
@@ -197,16 +202,81 @@ func init() {
 //
 // See the package description and the examples for more detail.
 func Make(loaderFuncPtr interface{}, queryFunc interface{}, keyFunc interface{}) {
-	loader := dataLoader{
+	makeLoader(loaderFuncPtr, queryFunc, keyFunc)
+}
+
+// makeLoader makes a data loader function and returns the associated loader.
+func makeLoader(loaderFuncPtr interface{}, queryFunc interface{}, keyFunc interface{}) *dataLoader {
+	loader := &dataLoader{
 		thunks:  make(map[interface{}]*thunkT),
 		pending: make(map[interface{}]*thunkT),
 	}
-	processLoaderFuncPtr(&loader, loaderFuncPtr)
-	processQueryFunc(&loader, queryFunc)
-	processKeyFunc(&loader, keyFunc)
+	processLoaderFuncPtr(loader, loaderFuncPtr)
+	processQueryFunc(loader, queryFunc)
+	processKeyFunc(loader, keyFunc)
 	loaderFuncValue := reflect.MakeFunc(reflect.TypeOf(loaderFuncPtr).Elem(), loader.Call)
 	loaderFuncPtrValue := reflect.ValueOf(loaderFuncPtr)
 	loaderFuncPtrValue.Elem().Set(loaderFuncValue)
+	return loader
+}
+
+// dispatch performs queries until there are no pending thunks.
+func (loader *dataLoader) dispatch() {
+	for len(loader.pending) > 0 {
+		for _, thunk := range loader.pending {
+			loader.performQuery(thunk)
+			break
+		}
+	}
+}
+
+// BatchGroup coordinates the queries performed by a group of loader functions.
+//
+// Without a batch group, each loader function performs a query for its
+// pending keys when one of its thunks is first called. When loader functions
+// are in a batch group, calling any pending thunk performs the queries for
+// all of the pending keys of all loader functions in the group. The program
+// can also call Dispatch at a convenient point, for example after all of the
+// loader functions for one level of a GraphQL query have been called. This
+// mirrors the scheduling of the facebook/dataloader JavaScript package.
+//
+// Like loader functions, a batch group is not safe for concurrent use by
+// multiple goroutines.
+type BatchGroup struct {
+	loaders []*dataLoader
+}
+
+// NewBatchGroup returns a new batch group with no loader functions.
+func NewBatchGroup() *BatchGroup {
+	return &BatchGroup{}
+}
+
+// Make a data loader function that is a member of the batch group. The
+// arguments are the same as for the Make function.
+func (group *BatchGroup) Make(loaderFuncPtr interface{}, queryFunc interface{}, keyFunc interface{}) {
+	loader := makeLoader(loaderFuncPtr, queryFunc, keyFunc)
+	loader.group = group
+	group.loaders = append(group.loaders, loader)
+}
+
+// Dispatch performs the queries for all of the pending keys of all loader
+// functions in the group. Each loader function performs one query (or more
+// if it has more pending keys than can be included in a single query).
+func (group *BatchGroup) Dispatch() {
+	for {
+		var dispatched bool
+		for _, loader := range group.loaders {
+			if len(loader.pending) > 0 {
+				loader.dispatch()
+				dispatched = true
+			}
+		}
+		if !dispatched {
+			// a query function might call a loader function, so keep
+			// going until there are no more pending keys
+			return
+		}
+	}
 }
 
 func processKeyFunc(loader *dataLoader, keyFunc interface{}) {
@@ -327,6 +397,14 @@ func isKeyType(t reflect.Type) bool {
 	case reflect.Array:
 		// arrays are acceptable key types, for example [16]byte
 		return isKeyType(t.Elem())
+	case reflect.Struct:
+		// structs of key types are acceptable, for example a composite key
+		for i := 0; i < t.NumField(); i++ {
+			if !isKeyType(t.Field(i).Type) {
+				return false
+			}
+		}
+		return t.NumField() > 0
 	case reflect.String, reflect.Int:
 		return true
 	case reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
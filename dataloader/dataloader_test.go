@@ -179,3 +179,123 @@ func TestKnownTypes(t *testing.T) {
 		t.Errorf("type %v does not implement %v", errType, knownTypes.errorType)
 	}
 }
+
+func TestBatchGroup(t *testing.T) {
+	type User struct {
+		ID   int
+		Name string
+	}
+	type Team struct {
+		ID   int
+		Name string
+	}
+	type UserThunk func() (*User, error)
+	type TeamThunk func() (*Team, error)
+
+	var userQueries, teamQueries [][]int
+
+	var userLoader func(id int) UserThunk
+	var teamLoader func(id int) TeamThunk
+
+	group := NewBatchGroup()
+	group.Make(&userLoader, func(ids []int) ([]*User, error) {
+		userQueries = append(userQueries, ids)
+		var users []*User
+		for _, id := range ids {
+			users = append(users, &User{ID: id, Name: fmt.Sprintf("user %d", id)})
+		}
+		return users, nil
+	}, func(u *User) int {
+		return u.ID
+	})
+	group.Make(&teamLoader, func(ids []int) ([]*Team, error) {
+		teamQueries = append(teamQueries, ids)
+		var teams []*Team
+		for _, id := range ids {
+			teams = append(teams, &Team{ID: id, Name: fmt.Sprintf("team %d", id)})
+		}
+		return teams, nil
+	}, func(t *Team) int {
+		return t.ID
+	})
+
+	userThunks := []UserThunk{userLoader(1), userLoader(2)}
+	teamThunks := []TeamThunk{teamLoader(10), teamLoader(20), teamLoader(30)}
+
+	// calling one thunk dispatches the pending keys for every loader in the group
+	user, err := userThunks[0]()
+	if err != nil {
+		t.Fatalf("got err=%v, want err=nil", err)
+	}
+	if got, want := user.Name, "user 1"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := len(userQueries), 1; got != want {
+		t.Errorf("got %d user queries, want %d", got, want)
+	}
+	if got, want := len(teamQueries), 1; got != want {
+		t.Fatalf("got %d team queries, want %d", got, want)
+	}
+	if got, want := len(teamQueries[0]), 3; got != want {
+		t.Errorf("got %d team keys, want %d", got, want)
+	}
+	for i, thunk := range teamThunks {
+		team, err := thunk()
+		if err != nil {
+			t.Errorf("%d: got err=%v, want err=nil", i, err)
+			continue
+		}
+		if got, want := team.Name, fmt.Sprintf("team %d", (i+1)*10); got != want {
+			t.Errorf("%d: got %v, want %v", i, got, want)
+		}
+	}
+	if got, want := len(teamQueries), 1; got != want {
+		t.Errorf("got %d team queries, want %d", got, want)
+	}
+
+	// explicit dispatch
+	userLoader(3)
+	teamLoader(40)
+	group.Dispatch()
+	if got, want := len(userQueries), 2; got != want {
+		t.Errorf("got %d user queries, want %d", got, want)
+	}
+	if got, want := len(teamQueries), 2; got != want {
+		t.Errorf("got %d team queries, want %d", got, want)
+	}
+
+	// nothing pending
+	group.Dispatch()
+	if got, want := len(userQueries)+len(teamQueries), 4; got != want {
+		t.Errorf("got %d queries, want %d", got, want)
+	}
+}
+
+func TestIsKeyType(t *testing.T) {
+	type RowID string
+	type RowKey struct {
+		TenantID int
+		Name     RowID
+	}
+	type BadKey struct {
+		TenantID int
+		Score    float64
+	}
+	tests := []struct {
+		value interface{}
+		want  bool
+	}{
+		{value: 1, want: true},
+		{value: RowID("x"), want: true},
+		{value: [16]byte{}, want: true},
+		{value: RowKey{}, want: true},
+		{value: struct{}{}, want: false},
+		{value: BadKey{}, want: false},
+		{value: 1.5, want: false},
+	}
+	for i, tt := range tests {
+		if got, want := isKeyType(reflect.TypeOf(tt.value)), tt.want; got != want {
+			t.Errorf("%d: %T: got=%v, want=%v", i, tt.value, got, want)
+		}
+	}
+}
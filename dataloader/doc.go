@@ -40,6 +40,15 @@ or any custom type that is based on a string or integral type:
 
  var loader func(id RowID) RowThunk
 
+A struct whose fields are all string or integral types can be used
+as a composite key:
+ type RowKey struct {
+     TenantID int
+     Name     string
+ }
+
+ var loader func(key RowKey) RowThunk
+
 The loader examples so far return a pointer to a struct (*Row), and
 this is a common use case. There are other common use cases, however:
  // loader returns an array of Rows associated with a foreign key, OtherID
@@ -85,5 +94,23 @@ the key and the value.
  func getKey(row *Row) (OtherID, int) {
 	 return row.OtherID, row.Count
  }
+
+Batch Groups
+
+By default each loader function performs its query independently,
+when the first of its pending thunks is called. Loader functions
+made using a batch group are dispatched together: calling any
+pending thunk performs one query for each loader function in the
+group that has pending keys.
+ group := dataloader.NewBatchGroup()
+ group.Make(&loadUser, queryUsers, getUserKey)
+ group.Make(&loadTeam, queryTeams, getTeamKey)
+
+ // ... call loadUser and loadTeam to create thunks ...
+
+ group.Dispatch()
+The Dispatch method can be called explicitly at a convenient
+point, such as after all of the fields at one level of a GraphQL
+query have been resolved.
 */
 package dataloader
@@ -0,0 +1,94 @@
+package sqlr
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// dateLayout is the format used to pass date-only values to the database.
+// A date is passed as text rather than as a time.Time, because drivers send
+// a time.Time as a timestamp, and the database converts the timestamp to a
+// date using the session time zone, which can result in the previous or the
+// next day being stored. All of the supported dialects accept this format
+// for a DATE column, and SQLite stores it as text.
+const dateLayout = "2006-01-02"
+
+// dateCell is used to scan a DATE column into a time.Time field. The time
+// portion is discarded, and the field is set to midnight UTC on the date.
+type dateCell struct {
+	colname   string
+	cellValue reflect.Value
+}
+
+func newDateCell(colname string, cellValue reflect.Value) *dateCell {
+	return &dateCell{colname: colname, cellValue: cellValue}
+}
+
+// Scan implements the sql.Scanner interface.
+func (c *dateCell) Scan(src interface{}) error {
+	if src == nil {
+		c.cellValue.Set(reflect.Zero(c.cellValue.Type()))
+		return nil
+	}
+	var t time.Time
+	switch v := src.(type) {
+	case time.Time:
+		// the date is the date in the time's own location, which for
+		// most drivers is the location the date was scanned in
+		t = v
+	case []byte:
+		var err error
+		if t, err = parseDate(string(v)); err != nil {
+			return fmt.Errorf("cannot scan column %q: %v", c.colname, err)
+		}
+	case string:
+		var err error
+		if t, err = parseDate(v); err != nil {
+			return fmt.Errorf("cannot scan column %q: %v", c.colname, err)
+		}
+	default:
+		return fmt.Errorf("cannot scan column %q: unsupported type %T for date", c.colname, src)
+	}
+	t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if c.cellValue.Kind() == reflect.Ptr {
+		c.cellValue.Set(reflect.ValueOf(&t))
+	} else {
+		c.cellValue.Set(reflect.ValueOf(t))
+	}
+	return nil
+}
+
+// parseDate parses the text representation of a date. Some drivers return
+// a DATE column as a timestamp, so any time portion is ignored.
+func parseDate(s string) (time.Time, error) {
+	if len(s) > len(dateLayout) {
+		s = s[:len(dateLayout)]
+	}
+	return time.Parse(dateLayout, s)
+}
+
+// dateArg returns the argument value for a time.Time field that is stored
+// in a DATE column, or nil for a nil pointer. If emptyNull is set, the zero
+// time is stored as NULL.
+func dateArg(fieldValue reflect.Value, emptyNull bool) interface{} {
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return nil
+		}
+		fieldValue = fieldValue.Elem()
+	}
+	t := fieldValue.Interface().(time.Time)
+	if emptyNull && t.IsZero() {
+		return nil
+	}
+	return t.Format(dateLayout)
+}
+
+// isTimeType reports whether t is time.Time or a pointer to time.Time.
+func isTimeType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == timeType
+}
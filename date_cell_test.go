@@ -0,0 +1,87 @@
+package sqlr
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDateCellScan(t *testing.T) {
+	want := time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		src  interface{}
+		want time.Time
+	}{
+		{src: "2020-03-15", want: want},
+		{src: []byte("2020-03-15"), want: want},
+		{src: "2020-03-15 00:00:00+00:00", want: want},
+		{src: time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC), want: want},
+		{src: time.Date(2020, 3, 15, 23, 30, 0, 0, time.FixedZone("AEST", 10*3600)), want: want},
+		{src: nil, want: time.Time{}},
+	}
+	for i, tt := range tests {
+		var field time.Time
+		var ptrField *time.Time
+		if err := newDateCell("date", reflect.ValueOf(&field).Elem()).Scan(tt.src); err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if err := newDateCell("date", reflect.ValueOf(&ptrField).Elem()).Scan(tt.src); err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if !field.Equal(tt.want) || field.Location() != time.UTC {
+			t.Errorf("%d: got=%v, want=%v", i, field, tt.want)
+		}
+		if tt.src == nil {
+			if ptrField != nil {
+				t.Errorf("%d: got=%v, want=nil", i, *ptrField)
+			}
+		} else if ptrField == nil || !ptrField.Equal(tt.want) {
+			t.Errorf("%d: got=%v, want=%v", i, ptrField, tt.want)
+		}
+	}
+
+	var field time.Time
+	for _, src := range []interface{}{"not a date", int64(20200315)} {
+		if err := newDateCell("date", reflect.ValueOf(&field).Elem()).Scan(src); err == nil {
+			t.Errorf("%v: got=nil, want=error", src)
+		}
+	}
+}
+
+func TestDateArgs(t *testing.T) {
+	type Row struct {
+		ID       int64      `sql:"primary key"`
+		Born     time.Time  `sql:"born date"`
+		Died     *time.Time `sql:"died date"`
+		Joined   time.Time  `sql:"joined date null"`
+		Modified time.Time
+	}
+	local := time.FixedZone("AEST", 10*3600)
+	born := time.Date(1970, 1, 2, 0, 30, 0, 0, local)
+	modified := time.Date(2020, 1, 2, 0, 30, 0, 0, local)
+	db := &FakeDB{rowsAffected: 1}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres), WithLastQuery()))
+	defer sess.Close()
+
+	row := Row{ID: 1, Born: born, Modified: modified}
+	wantNoError(t, sess.InsertRow(&row))
+	_, args := sess.LastQuery()
+	if want := []interface{}{int64(1), "1970-01-02", nil, nil, modified}; !reflect.DeepEqual(args, want) {
+		t.Errorf("got=%v\nwant=%v", args, want)
+	}
+
+	for _, dialect := range []Dialect{Postgres, MySQL, SQLite, MSSQL} {
+		schema := NewSchema(WithDialect(dialect))
+		query, err := schema.TableFor(Row{}).CreateTableSQL()
+		wantNoError(t, err)
+		for _, name := range []string{"born", "died", "joined"} {
+			if want := dialect.Quote(name) + " date"; !strings.Contains(query, want) {
+				t.Errorf("%s: want %q in %q", dialectName(dialect), want, query)
+			}
+		}
+	}
+}
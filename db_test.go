@@ -1,10 +1,15 @@
 package sqlr
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"reflect"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -551,6 +556,124 @@ func TestScalarQuery(t *testing.T) {
 			t.Fatalf("got=%v, want=%v", got, want)
 		}
 	}
+
+	{
+		var q func(query string, args ...interface{}) (string, error)
+		sess.MakeQuery(&q)
+
+		s, err := q("select cast(number as text) from numbers where number = $1", 3)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, want := s, "3"; got != want {
+			t.Fatalf("got=%v, want=%v", got, want)
+		}
+	}
+
+	{
+		var q func(query string, args ...interface{}) (bool, error)
+		sess.MakeQuery(&q)
+
+		b, err := q("select number > 2 from numbers where number = $1", 3)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, want := b, true; got != want {
+			t.Fatalf("got=%v, want=%v", got, want)
+		}
+	}
+
+	{
+		var q func(query string, args ...interface{}) (float64, error)
+		sess.MakeQuery(&q)
+
+		avg, err := q("select avg(number)::float8 from numbers")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, want := avg, 2.5; got != want {
+			t.Fatalf("got=%v, want=%v", got, want)
+		}
+	}
+
+	{
+		var q func(query string, args ...interface{}) (time.Time, error)
+		sess.MakeQuery(&q)
+
+		want := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+		tm, err := q("select $1::timestamptz", want)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got := tm; !got.Equal(want) {
+			t.Fatalf("got=%v, want=%v", got, want)
+		}
+	}
+
+	{
+		var q func(query string, args ...interface{}) (string, error)
+		sess.MakeQuery(&q)
+
+		_, err := q("select cast(number as text) from numbers where number < 0")
+		if got, want := err, sql.ErrNoRows; got != want {
+			t.Fatalf("got=%v, want=%v", got, want)
+		}
+	}
+
+	{
+		var q func(query string, args ...interface{}) (*int, error)
+		sess.MakeQuery(&q)
+
+		max, err := q("select max(number) from numbers")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if max == nil {
+			t.Fatalf("got=nil, want=%v", rowCount-1)
+		}
+		if got, want := *max, rowCount-1; got != want {
+			t.Fatalf("got=%v, want=%v", got, want)
+		}
+
+		// max of an empty set is null
+		max, err = q("select max(number) from numbers where number < 0")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if max != nil {
+			t.Fatalf("got=%v, want=nil", *max)
+		}
+
+		// no rows returned
+		max, err = q("select number from numbers where number < 0")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if max != nil {
+			t.Fatalf("got=%v, want=nil", *max)
+		}
+	}
+
+	{
+		var q func(query string, args ...interface{}) (sql.NullString, error)
+		sess.MakeQuery(&q)
+
+		s, err := q("select max(cast(number as text)) from numbers where number < 0")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if s.Valid {
+			t.Fatalf("got=%q, want invalid", s.String)
+		}
+
+		s, err = q("select cast(number as text) from numbers where number < 0")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if s.Valid {
+			t.Fatalf("got=%q, want invalid", s.String)
+		}
+	}
 }
 
 func TestQuery(t *testing.T) {
@@ -1406,6 +1529,1800 @@ func TestExcludeColumns(t *testing.T) {
 	check(row.N3, 3)
 }
 
+func TestTableDiff(t *testing.T) {
+	ctx := context.Background()
+	db := sqliteDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `
+		create table diff_table(
+			id integer primary key,
+			name text,
+			created_at integer,
+			legacy text
+		)
+	`)
+
+	type Row struct {
+		ID        int `sql:"primary key" table:"diff_table"`
+		Name      string
+		CreatedAt time.Time
+		Email     string
+	}
+
+	schema := NewSchema(ForDB(db))
+	tbl := schema.TableFor(Row{})
+	added, removed, changed, err := tbl.Diff(ctx, db)
+	wantNoError(t, err)
+
+	check := func(diffs []ColumnDiff, want ...string) {
+		t.Helper()
+		var got []string
+		for _, d := range diffs {
+			got = append(got, d.ColumnName)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("got=%v, want=%v", got, want)
+		}
+	}
+	check(added, "email")
+	check(removed, "legacy")
+	check(changed, "created_at")
+
+	type Missing struct {
+		ID int `sql:"primary key"`
+	}
+	_, _, _, err = schema.TableFor(Missing{}).Diff(ctx, db)
+	if err == nil {
+		t.Fatal("expected error for missing table, got nil")
+	}
+}
+
+func TestFromlessQuery(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `drop sequence if exists test_seq`)
+	defer mustExec(t, db, `drop sequence if exists test_seq`)
+	mustExec(t, db, `create sequence test_seq`)
+
+	schema := NewSchema(ForDB(db))
+	sess := NewSession(context.Background(), db, schema)
+
+	{
+		rows, err := sess.Query("select 1")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		var n int
+		for rows.Next() {
+			if err := rows.Scan(&n); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, want := n, 1; got != want {
+			t.Fatalf("got=%v, want=%v", got, want)
+		}
+	}
+	{
+		var q func(query string, args ...interface{}) (int, error)
+		sess.MakeQuery(&q)
+		n, err := q("select 1")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, want := n, 1; got != want {
+			t.Fatalf("got=%v, want=%v", got, want)
+		}
+	}
+	{
+		var q func(query string, args ...interface{}) (time.Time, error)
+		sess.MakeQuery(&q)
+		for _, query := range []string{"select now()", "select current_timestamp"} {
+			tm, err := q(query)
+			if err != nil {
+				t.Fatalf("%s: expected no error, got %v", query, err)
+			}
+			if tm.IsZero() {
+				t.Fatalf("%s: got zero time", query)
+			}
+		}
+	}
+	{
+		var q func(query string, args ...interface{}) (int64, error)
+		sess.MakeQuery(&q)
+		for i := int64(1); i <= 3; i++ {
+			n, err := q("select nextval('test_seq')")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got, want := n, i; got != want {
+				t.Fatalf("got=%v, want=%v", got, want)
+			}
+		}
+	}
+}
+
+func TestExecMulti(t *testing.T) {
+	// SQLite supports multiple statements in one call
+	db := sqliteDB(t)
+	defer db.Close()
+
+	schema := NewSchema(ForDB(db))
+	sess := NewSession(context.Background(), db, schema)
+
+	_, err := sess.ExecMulti(`
+		create table numbers(number integer, name text);
+		insert into numbers(number, name) values(?, ?);
+		insert into numbers(number, name) values(?, 'two; or more');
+	`, 1, "one", 2)
+	wantNoError(t, err)
+
+	var count int
+	err = db.QueryRow("select count(*) from numbers").Scan(&count)
+	wantNoError(t, err)
+	if got, want := count, 2; got != want {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+
+	var name string
+	err = db.QueryRow("select name from numbers where number = 2").Scan(&name)
+	wantNoError(t, err)
+	if got, want := name, "two; or more"; got != want {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+}
+
+func TestSelectAggregate(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `create table orders(id integer primary key, customer text, amount real)`)
+	mustExec(t, db, `insert into orders(customer, amount) values('alice', 10), ('alice', 15), ('bob', 7)`)
+
+	schema := NewSchema(ForDB(db))
+	sess := NewSession(context.Background(), db, schema)
+
+	{
+		var rows []struct {
+			Customer string
+			Cnt      int     `sql:"cnt"`
+			Total    float64 `sql:"total_amount"`
+		}
+		n, err := sess.Select(&rows, `
+			select customer, count(*) as cnt, sum(amount) as total_amount
+			from orders
+			group by customer
+			order by customer`)
+		wantNoError(t, err)
+		if got, want := n, 2; got != want {
+			t.Fatalf("got=%v, want=%v", got, want)
+		}
+		if got, want := rows[0].Cnt, 2; got != want {
+			t.Errorf("got=%v, want=%v", got, want)
+		}
+		if got, want := rows[0].Total, 25.0; got != want {
+			t.Errorf("got=%v, want=%v", got, want)
+		}
+		if got, want := rows[1].Customer, "bob"; got != want {
+			t.Errorf("got=%v, want=%v", got, want)
+		}
+	}
+	{
+		// tagged fields in an embedded struct have a column name
+		// prefixed by the struct field name, so match on the tag
+		type Stats struct {
+			Cnt   int     `sql:"cnt"`
+			Total float64 `sql:"total_amount"`
+		}
+		var rows []struct {
+			Customer string
+			Stats    Stats
+		}
+		n, err := sess.Select(&rows, `
+			select customer, count(*) as cnt, sum(amount) as total_amount
+			from orders
+			group by customer
+			order by customer`)
+		wantNoError(t, err)
+		if got, want := n, 2; got != want {
+			t.Fatalf("got=%v, want=%v", got, want)
+		}
+		if got, want := rows[1].Stats.Cnt, 1; got != want {
+			t.Errorf("got=%v, want=%v", got, want)
+		}
+		if got, want := rows[1].Stats.Total, 7.0; got != want {
+			t.Errorf("got=%v, want=%v", got, want)
+		}
+	}
+}
+
+func TestPostgresArrays(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `drop table if exists array_rows`)
+	defer mustExec(t, db, `drop table if exists array_rows`)
+	mustExec(t, db, `create table array_rows(id int primary key, tags text[], nums integer[], ids bigint[])`)
+
+	type ArrayRow struct {
+		ID   int `sql:"primary key"`
+		Tags []string
+		Nums []int
+		IDs  []int64 `sql:"ids"`
+	}
+
+	schema := NewSchema(ForDB(db), WithPostgresArrays())
+	sess := NewSession(context.Background(), db, schema)
+
+	rows := []*ArrayRow{
+		{ID: 1, Tags: []string{"a", "b c", `d"e`}, Nums: []int{1, 2, 3}, IDs: []int64{1 << 40}},
+		{ID: 2, Tags: []string{}, Nums: []int{}, IDs: []int64{}},
+		{ID: 3},
+	}
+	for _, row := range rows {
+		wantNoError(t, sess.InsertRow(row))
+	}
+
+	var got []*ArrayRow
+	_, err := sess.Select(&got, "select {} from array_rows order by id")
+	wantNoError(t, err)
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("got=%+v, want=%+v", got, rows)
+	}
+
+	// slices passed as arguments are still expanded for IN clauses
+	got = nil
+	_, err = sess.Select(&got, "select {} from array_rows where id in (?) order by id", []int{1, 3})
+	wantNoError(t, err)
+	if got, want := len(got), 2; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+}
+
+func TestMakeExecInsert(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `create table widget(id integer primary key autoincrement, name text)`)
+
+	type Widget struct {
+		ID   int64 `sql:"primary key autoincrement"`
+		Name string
+	}
+
+	schema := NewSchema(ForDB(db))
+	sess := NewSession(context.Background(), db, schema)
+
+	var insert func(row *Widget) error
+	var insertID func(row *Widget) (int64, error)
+	sess.MakeExec(&insert, &insertID)
+
+	w1 := &Widget{Name: "one"}
+	wantNoError(t, insert(w1))
+	if got, want := w1.ID, int64(1); got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	w2 := &Widget{Name: "two"}
+	id, err := insertID(w2)
+	wantNoError(t, err)
+	if got, want := id, int64(2); got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := w2.ID, id; got != want {
+		t.Errorf("back-filled id: got=%v, want=%v", got, want)
+	}
+
+	if _, err := insertID(nil); err == nil {
+		t.Error("got=nil, want=error")
+	}
+}
+
+func TestSelectNamed(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `create table widgets(id integer primary key, category text)`)
+	mustExec(t, db, `insert into widgets(id, category) values(1, 'tools'), (2, 'tools'), (3, 'toys'), (4, 'tools')`)
+
+	schema := NewSchema(ForDB(db))
+	sess := NewSession(context.Background(), db, schema)
+
+	var rows []struct {
+		ID       int64 `sql:"primary key"`
+		Category string
+	}
+	n, err := sess.SelectNamed(&rows,
+		"select {} from widgets where id in (:ids) and category = :category order by id",
+		map[string]interface{}{
+			"category": "tools",
+			"ids":      []int{1, 3, 4},
+		})
+	wantNoError(t, err)
+	if got, want := n, 2; got != want {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+	if got, want := rows[1].ID, int64(4); got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+}
+
+func TestMaxRows(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `create table numbers(id integer primary key)`)
+	mustExec(t, db, `insert into numbers(id) values(1), (2), (3), (4)`)
+
+	schema := NewSchema(ForDB(db), WithMaxRows(3))
+	sess := NewSession(context.Background(), db, schema)
+
+	type Number struct {
+		ID int64 `sql:"primary key"`
+	}
+
+	{
+		var rows []*Number
+		n, err := sess.Select(&rows, "select {} from numbers where id <= 3 order by id")
+		wantNoError(t, err)
+		if got, want := n, 3; got != want {
+			t.Errorf("got=%v, want=%v", got, want)
+		}
+	}
+	{
+		var rows []*Number
+		_, err := sess.Select(&rows, "select {} from numbers order by id")
+		if got, want := err, ErrTooManyRows; got != want {
+			t.Fatalf("got=%v, want=%v", got, want)
+		}
+		if got, want := len(rows), 3; got != want {
+			t.Errorf("got=%v, want=%v", got, want)
+		}
+		if got, want := db.Stats().InUse, 0; got != want {
+			t.Errorf("rows not closed: got=%v, want=%v", got, want)
+		}
+	}
+}
+
+func TestEachRawBytes(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `create table documents(id integer primary key, body blob)`)
+	mustExec(t, db, `insert into documents(id, body) values(1, 'first'), (2, 'second'), (3, 'third')`)
+
+	schema := NewSchema(ForDB(db))
+	sess := NewSession(context.Background(), db, schema)
+
+	type Document struct {
+		ID   int64 `sql:"primary key"`
+		Body sql.RawBytes
+	}
+
+	var bodies []string
+	n, err := sess.Each(func(doc *Document) error {
+		// the raw bytes are only valid within the callback
+		bodies = append(bodies, string(doc.Body))
+		return nil
+	}, "select {} from documents order by id")
+	wantNoError(t, err)
+	if got, want := n, 3; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := strings.Join(bodies, ","), "first,second,third"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	// callback error stops iteration
+	errStop := errors.New("stop")
+	n, err = sess.Each(func(doc *Document) error {
+		return errStop
+	}, "select {} from documents order by id")
+	if got, want := err, errStop; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := n, 1; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	// raw bytes are copied by Select
+	var docs []Document
+	_, err = sess.Select(&docs, "select {} from documents order by id")
+	wantNoError(t, err)
+	if got, want := string(docs[0].Body), "first"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+}
+
+func TestInsertSelect(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `create table orders(id integer primary key, customer text, amount real)`)
+	mustExec(t, db, `create table order_archive(archive_id integer primary key autoincrement, order_id integer, customer text, amount real)`)
+	mustExec(t, db, `insert into orders(id, customer, amount) values(1, 'alice', 10), (2, 'bob', 7), (3, 'carol', 12)`)
+
+	type OrderArchive struct {
+		ArchiveID int64 `sql:"primary key autoincrement"`
+		OrderID   int64
+		Customer  string
+		Amount    float64
+	}
+
+	schema := NewSchema(ForDB(db))
+	sess := NewSession(context.Background(), db, schema)
+
+	n, err := sess.InsertSelect(&OrderArchive{},
+		"select id, customer, amount from orders where id in (?) order by id",
+		[]int{1, 3})
+	wantNoError(t, err)
+	if got, want := n, 2; got != want {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+
+	var rows []*OrderArchive
+	_, err = sess.Select(&rows, "select {} from order_archive order by archive_id")
+	wantNoError(t, err)
+	if got, want := len(rows), 2; got != want {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+	if got, want := rows[1].Customer, "carol"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := rows[1].OrderID, int64(3); got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+}
+
+func TestUpdateRowExpecting(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `create table document(id integer primary key, version integer not null, body text)`)
+	mustExec(t, db, `insert into document(id, version, body) values(1, 3, 'original')`)
+
+	type Document struct {
+		ID      int64 `sql:"primary key"`
+		Version int64 `sql:"version"`
+		Body    string
+	}
+
+	schema := NewSchema(ForDB(db))
+	sess := NewSession(context.Background(), db, schema)
+
+	// expected version does not match
+	doc := Document{ID: 1, Body: "changed"}
+	n, err := sess.UpdateRowExpecting(&doc, 2)
+	if got, want := n, 0; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	lockErr, ok := err.(*OptimisticLockingError)
+	if !ok {
+		t.Fatalf("got=%v, want=*OptimisticLockingError", err)
+	}
+	if got, want := lockErr.ExpectedVersion, int64(2); got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := lockErr.ActualVersion, int64(3); got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := doc.Version, int64(0); got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	// expected version matches, regardless of the version in the struct
+	n, err = sess.UpdateRowExpecting(&doc, 3)
+	wantNoError(t, err)
+	if got, want := n, 1; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := doc.Version, int64(4); got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	var body string
+	var version int64
+	err = db.QueryRow("select body, version from document where id = 1").Scan(&body, &version)
+	wantNoError(t, err)
+	if got, want := body, "changed"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := version, int64(4); got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	// row type without a version field
+	type Unversioned struct {
+		ID   int64 `sql:"primary key"`
+		Body string
+	}
+	if _, err := sess.UpdateRowExpecting(&Unversioned{ID: 1}, 1); err == nil {
+		t.Error("got=nil, want=error")
+	}
+}
+
+func TestNewConnSession(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(4)
+
+	schema := NewSchema(ForDB(db))
+	sess, err := NewConnSession(context.Background(), db, schema)
+	wantNoError(t, err)
+	defer sess.Close()
+
+	// a session variable set by one query is visible to subsequent queries
+	// because they all use the same connection
+	_, err = sess.Exec(`set sqlr.test_value = 'pinned'`)
+	wantNoError(t, err)
+	for i := 0; i < 10; i++ {
+		var settings []struct {
+			Value string
+		}
+		_, err = sess.Select(&settings, `select current_setting('sqlr.test_value') as value`)
+		wantNoError(t, err)
+		if got, want := len(settings), 1; got != want {
+			t.Fatalf("%d: got=%v, want=%v", i, got, want)
+		}
+		if got, want := settings[0].Value, "pinned"; got != want {
+			t.Fatalf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+
+	// temporary tables are also visible for the lifetime of the session
+	_, err = sess.Exec(`create temporary table conn_session_test(id integer primary key, name text)`)
+	wantNoError(t, err)
+	_, err = sess.Exec(`insert into conn_session_test(id, name) values(1, 'one')`)
+	wantNoError(t, err)
+	var rows []struct {
+		ID   int `sql:"primary key"`
+		Name string
+	}
+	_, err = sess.Select(&rows, `select {} from conn_session_test order by {}`)
+	wantNoError(t, err)
+	if got, want := len(rows), 1; got != want {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+
+	if err := sess.Close(); err != nil {
+		t.Fatalf("got=%v, want=nil", err)
+	}
+	if got, want := db.Stats().InUse, 0; got != want {
+		t.Errorf("connections in use: got=%v, want=%v", got, want)
+	}
+	// closing a second time is harmless
+	if err := sess.Close(); err != nil {
+		t.Fatalf("got=%v, want=nil", err)
+	}
+}
+
+func TestSelectHierarchy(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `create table customers(id integer primary key, name text)`)
+	mustExec(t, db, `create table orders(id integer primary key, customer_id integer, total integer)`)
+	mustExec(t, db, `insert into customers(id, name) values(1, 'alice'), (2, 'bob'), (3, 'carol')`)
+	mustExec(t, db, `insert into orders(id, customer_id, total) values(10, 1, 100), (11, 1, 110), (12, 3, 120), (13, 1, 130)`)
+
+	type Order struct {
+		ID         int64 `sql:"primary key"`
+		CustomerID int64
+		Total      int
+	}
+	type Customer struct {
+		ID     int64 `sql:"primary key"`
+		Name   string
+		Orders []*Order
+	}
+
+	schema := NewSchema(ForDB(db))
+	sess := NewSession(context.Background(), db, schema)
+
+	var customers []*Customer
+	n, err := sess.SelectHierarchy(&customers, "ID", "Orders", `
+		select {alias c}, o.id, o.customer_id, o.total
+		from customers c
+		left join orders o on o.customer_id = c.id
+		order by c.id, o.id`)
+	wantNoError(t, err)
+	if got, want := n, 3; got != want {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+	want := []*Customer{
+		{ID: 1, Name: "alice", Orders: []*Order{
+			{ID: 10, CustomerID: 1, Total: 100},
+			{ID: 11, CustomerID: 1, Total: 110},
+			{ID: 13, CustomerID: 1, Total: 130},
+		}},
+		{ID: 2, Name: "bob"},
+		{ID: 3, Name: "carol", Orders: []*Order{
+			{ID: 12, CustomerID: 3, Total: 120},
+		}},
+	}
+	if got := customers; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%+v, want=%+v", got, want)
+	}
+
+	// slice of structs, and children as structs
+	type CustomerValue struct {
+		ID     int64 `sql:"primary key"`
+		Name   string
+		Orders []Order
+	}
+	var values []CustomerValue
+	n, err = sess.SelectHierarchy(&values, "ID", "Orders", `
+		select c.id, c.name, o.id, o.customer_id, o.total
+		from customers c
+		join orders o on o.customer_id = c.id
+		where c.id = ?
+		order by o.id`, 1)
+	wantNoError(t, err)
+	if got, want := n, 1; got != want {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+	if got, want := len(values[0].Orders), 3; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	// a child whose columns are zero values, but not null, is included
+	mustExec(t, db, `insert into orders(id, customer_id, total) values(14, 2, 0)`)
+	customers = nil
+	n, err = sess.SelectHierarchy(&customers, "ID", "Orders", `
+		select {alias c}, o.total
+		from customers c
+		left join orders o on o.customer_id = c.id
+		where c.id in (2, 3)
+		order by c.id`)
+	wantNoError(t, err)
+	if got, want := n, 2; got != want {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+	want = []*Customer{
+		{ID: 2, Name: "bob", Orders: []*Order{{Total: 0}}},
+		{ID: 3, Name: "carol", Orders: []*Order{{Total: 120}}},
+	}
+	if got := customers; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%+v, want=%+v", got, want)
+	}
+}
+
+func TestSelectExists(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `create table widgets(id integer primary key, name text, active boolean)`)
+	mustExec(t, db, `insert into widgets(id, name, active) values(0, 'f', 0), (1, 'one', 1), (2, 'two', null)`)
+
+	schema := NewSchema(ForDB(db))
+	sess := NewSession(context.Background(), db, schema)
+
+	type Flag bool
+	var exists func(query string, args ...interface{}) (bool, error)
+	var selectFlag func(query string, args ...interface{}) (Flag, error)
+	sess.MakeQuery(&exists, &selectFlag)
+
+	tests := []struct {
+		query string
+		args  []interface{}
+		want  bool
+	}{
+		// select exists pattern, which SQLite returns as an integer
+		{`select exists(select 1 from widgets where name = ?)`, []interface{}{"one"}, true},
+		{`select exists(select 1 from widgets where name = ?)`, []interface{}{"three"}, false},
+		// plain row presence
+		{`select id, name from widgets where id = ?`, []interface{}{2}, true},
+		{`select id, name from widgets where id = ?`, []interface{}{3}, false},
+		{`select name from widgets where id in (?)`, []interface{}{[]int{1, 2}}, true},
+		// the value of the first column does not matter
+		{`select id from widgets where name = ?`, []interface{}{"f"}, true},
+		{`select name from widgets where id = ?`, []interface{}{0}, true},
+		{`select active, id from widgets where id = ?`, []interface{}{2}, false},
+		{`select null from widgets where id = ?`, []interface{}{1}, true},
+		// boolean column
+		{`select active from widgets where id = ?`, []interface{}{0}, false},
+		{`select active from widgets where id = ?`, []interface{}{1}, true},
+		{`select active from widgets where id = ?`, []interface{}{2}, false},
+		{`select not exists(select 1 from widgets where name = ?)`, []interface{}{"three"}, true},
+		{`-- comment
+		  SELECT EXISTS (select 1 from widgets where id = ?)`, []interface{}{0}, true},
+	}
+	for i, tt := range tests {
+		got, err := exists(tt.query, tt.args...)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if want := tt.want; got != want {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+	}
+
+	// a named bool type is a scalar query, SQLite returns the result as an integer
+	if got, err := selectFlag(`select exists(select 1 from widgets where name = ?)`, "two"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if !got {
+		t.Errorf("got=%v, want=true", got)
+	}
+	if _, err := selectFlag(`select 1 from widgets where name = ?`, "three"); err != sql.ErrNoRows {
+		t.Errorf("got=%v, want=%v", err, sql.ErrNoRows)
+	}
+}
+
+func TestHstore(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `create extension if not exists hstore`)
+	mustExec(t, db, `drop table if exists hstore_rows`)
+	defer mustExec(t, db, `drop table if exists hstore_rows`)
+	mustExec(t, db, `create table hstore_rows(id integer primary key, attrs hstore)`)
+
+	type Row struct {
+		ID    int64             `sql:"primary key"`
+		Attrs map[string]string `sql:"attrs hstore"`
+	}
+
+	schema := NewSchema(ForDB(db))
+	sess := NewSession(context.Background(), db, schema)
+
+	rows := []Row{
+		{ID: 1, Attrs: map[string]string{"color": "red", "size": "extra large", `quote"`: `back\slash`}},
+		{ID: 2, Attrs: map[string]string{}},
+		{ID: 3},
+	}
+	for _, row := range rows {
+		wantNoError(t, sess.InsertRow(&row))
+	}
+
+	var got []Row
+	_, err := sess.Select(&got, `select {} from hstore_rows order by id`)
+	wantNoError(t, err)
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("got=%#v, want=%#v", got, rows)
+	}
+}
+
+func TestExecScanPostgres(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `drop table if exists exec_scan_widgets`)
+	mustExec(t, db, `create table exec_scan_widgets(id serial primary key, name text not null, version int not null default 1)`)
+	defer mustExec(t, db, `drop table exec_scan_widgets`)
+
+	type Widget struct {
+		ID      int `sql:"primary key autoincrement"`
+		Name    string
+		Version int
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	sess := NewSession(context.Background(), db, schema)
+
+	w := Widget{Name: "one"}
+	n, err := sess.ExecScan(&w, `insert into exec_scan_widgets(name) values({field Name}) returning {}`)
+	wantNoError(t, err)
+	if n != 1 || w.ID == 0 || w.Version != 1 {
+		t.Errorf("got n=%d, row=%+v", n, w)
+	}
+
+	var updated []Widget
+	n, err = sess.ExecScan(&updated, `update exec_scan_widgets set version = version + 1 where name = ? returning {}`, "one")
+	wantNoError(t, err)
+	if n != 1 || updated[0].ID != w.ID || updated[0].Version != 2 {
+		t.Errorf("got n=%d, rows=%+v", n, updated)
+	}
+}
+
+func TestPostgresEnum(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `drop table if exists enum_widgets`)
+	mustExec(t, db, `drop type if exists widget_status`)
+	mustExec(t, db, `create type widget_status as enum('active', 'inactive', 'retired')`)
+	mustExec(t, db, `create table enum_widgets(id int primary key, status widget_status not null)`)
+	defer mustExec(t, db, `drop type widget_status`)
+	defer mustExec(t, db, `drop table enum_widgets`)
+
+	type Widget struct {
+		ID     int `sql:"primary key"`
+		Status testStatus
+	}
+	// the program does not know about the "retired" label
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithEnum(testStatus(""), "active", "inactive"),
+		WithTables(TablesConfig{
+			(*Widget)(nil): TableConfig{TableName: "enum_widgets"},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+
+	w := Widget{ID: 1, Status: "inactive"}
+	err := sess.InsertRow(&w)
+	wantNoError(t, err)
+	if err := sess.InsertRow(&Widget{ID: 2, Status: "retired"}); err == nil {
+		t.Error("expected error inserting unknown label")
+	}
+
+	var got Widget
+	_, err = sess.Select(&got, `select {} from enum_widgets where id = ?`, 1)
+	wantNoError(t, err)
+	if got != w {
+		t.Errorf("got=%+v, want=%+v", got, w)
+	}
+
+	mustExec(t, db, `update enum_widgets set status = 'retired' where id = 1`)
+	_, err = sess.Select(&got, `select {} from enum_widgets where id = ?`, 1)
+	if err == nil || !strings.Contains(err.Error(), `unknown value "retired"`) {
+		t.Errorf("got err=%v, want unknown value error", err)
+	}
+}
+
+func TestUpsertOnConflictPartialIndex(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `drop table if exists upsert_users`)
+	mustExec(t, db, `create table upsert_users(id int primary key, email text not null, name text not null, deleted boolean not null default false)`)
+	mustExec(t, db, `create unique index upsert_users_email on upsert_users(email) where not deleted`)
+	defer mustExec(t, db, `drop table upsert_users`)
+
+	type User struct {
+		ID      int `sql:"primary key"`
+		Email   string
+		Name    string
+		Deleted bool
+	}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithTables(TablesConfig{
+			(*User)(nil): TableConfig{TableName: "upsert_users"},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+
+	// a deleted user with the same email does not conflict
+	wantNoError(t, sess.InsertRow(&User{ID: 1, Email: "a@example.com", Name: "old", Deleted: true}))
+	_, err := sess.UpsertOnConflict(&User{ID: 2, Email: "a@example.com", Name: "first"}, "email", "where not deleted")
+	wantNoError(t, err)
+	_, err = sess.UpsertOnConflict(&User{ID: 3, Email: "a@example.com", Name: "second"}, "email", "where not deleted")
+	wantNoError(t, err)
+
+	var users []User
+	_, err = sess.Select(&users, `select {} from upsert_users order by id`)
+	wantNoError(t, err)
+	want := []User{
+		{ID: 1, Email: "a@example.com", Name: "old", Deleted: true},
+		{ID: 2, Email: "a@example.com", Name: "second"},
+	}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("got=%+v\nwant=%+v", users, want)
+	}
+
+	// without the predicate the partial index cannot be inferred
+	if _, err = sess.UpsertOnConflict(&User{ID: 4, Email: "a@example.com"}, "email", ""); err == nil {
+		t.Error("expected error without predicate")
+	}
+}
+
+func TestUpdateJSON(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `drop table if exists update_json_widgets`)
+	mustExec(t, db, `create table update_json_widgets(id int primary key, attributes jsonb)`)
+	defer mustExec(t, db, `drop table update_json_widgets`)
+
+	type Widget struct {
+		ID         int                    `sql:"primary key"`
+		Attributes map[string]interface{} `sql:"json"`
+	}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithTables(TablesConfig{
+			(*Widget)(nil): TableConfig{TableName: "update_json_widgets"},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+
+	w := Widget{ID: 1, Attributes: map[string]interface{}{"color": "blue", "size": "large"}}
+	wantNoError(t, sess.InsertRow(&w))
+	wantNoError(t, sess.InsertRow(&Widget{ID: 2}))
+
+	n, err := sess.UpdateJSON(&w, "Attributes", map[string]interface{}{"color": "red", "weight": 10})
+	wantNoError(t, err)
+	if n != 1 {
+		t.Errorf("got n=%d, want 1", n)
+	}
+	_, err = sess.UpdateJSON(&Widget{ID: 2}, "Attributes", map[string]interface{}{"color": "green"})
+	wantNoError(t, err)
+
+	var got []Widget
+	_, err = sess.Select(&got, `select {} from update_json_widgets order by id`)
+	wantNoError(t, err)
+	want := []Widget{
+		{ID: 1, Attributes: map[string]interface{}{"color": "red", "size": "large", "weight": float64(10)}},
+		{ID: 2, Attributes: map[string]interface{}{"color": "green"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%+v\nwant=%+v", got, want)
+	}
+}
+
+func TestSetSearchPath(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+
+	for _, tenant := range []string{"search_path_a", "search_path_b"} {
+		mustExec(t, db, `drop schema if exists `+tenant+` cascade`)
+		mustExec(t, db, `create schema `+tenant)
+		mustExec(t, db, `create table `+tenant+`.widgets(id int primary key, name text not null)`)
+		mustExec(t, db, `insert into `+tenant+`.widgets(id, name) values(1, '`+tenant+`')`)
+		defer mustExec(t, db, `drop schema `+tenant+` cascade`)
+	}
+
+	type Widget struct {
+		ID   int `sql:"primary key"`
+		Name string
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	for _, tenant := range []string{"search_path_a", "search_path_b"} {
+		sess, err := NewConnSession(context.Background(), db, schema)
+		wantNoError(t, err)
+		wantNoError(t, sess.SetSearchPath(tenant, "public"))
+		var widgets []Widget
+		_, err = sess.Select(&widgets, `select {} from widgets`)
+		wantNoError(t, err)
+		if len(widgets) != 1 || widgets[0].Name != tenant {
+			t.Errorf("%s: got=%+v", tenant, widgets)
+		}
+		sess.Close()
+	}
+
+	// not supported without a pinned connection
+	sess := NewSession(context.Background(), db, schema)
+	if err := sess.SetSearchPath("search_path_a"); err == nil {
+		t.Error("expected error for session without pinned connection")
+	}
+}
+
+func TestShardedRows(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `create table users_0(id integer primary key, name text not null)`)
+	mustExec(t, db, `create table users_1(id integer primary key, name text not null)`)
+
+	type User struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	schema := NewSchema(
+		ForDB(db),
+		WithTables(TablesConfig{
+			(*User)(nil): TableConfig{
+				TableName: "users",
+				ShardFunc: func(row interface{}) string {
+					return fmt.Sprintf("users_%d", row.(*User).ID%2)
+				},
+			},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+
+	for id := int64(1); id <= 4; id++ {
+		wantNoError(t, sess.InsertRow(&User{ID: id, Name: fmt.Sprint("user ", id)}))
+	}
+	_, err := sess.UpdateRow(&User{ID: 3, Name: "updated"})
+	wantNoError(t, err)
+
+	for shard, want := range map[string][]int64{"users_0": {2, 4}, "users_1": {1, 3}} {
+		var ids []int64
+		rows, err := db.Query(`select id from ` + shard + ` order by id`)
+		wantNoError(t, err)
+		for rows.Next() {
+			var id int64
+			wantNoError(t, rows.Scan(&id))
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if !reflect.DeepEqual(ids, want) {
+			t.Errorf("%s: got=%v, want=%v", shard, ids, want)
+		}
+	}
+
+	user := User{ID: 3}
+	ok, err := sess.GetRow(&user)
+	wantNoError(t, err)
+	if !ok || user.Name != "updated" {
+		t.Errorf("got ok=%v, user=%+v", ok, user)
+	}
+	ok, err = sess.GetRow(&User{ID: 5})
+	wantNoError(t, err)
+	if ok {
+		t.Error("got ok=true, want false")
+	}
+}
+
+func TestSelectByKeysVia(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+	// an in-memory database is private to its connection
+	db.SetMaxOpenConns(1)
+
+	type Widget struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	schema := NewSchema(ForDB(db))
+	sess, err := NewConnSession(context.Background(), db, schema)
+	wantNoError(t, err)
+	defer sess.Close()
+
+	const rowCount = 5000
+	_, err = sess.Exec(`create table widget(id integer primary key, name text not null)`)
+	wantNoError(t, err)
+	// a permanent table with a name like the temporary table is not touched
+	_, err = sess.Exec(`create table sqlr_keys(id integer)`)
+	wantNoError(t, err)
+	for id := 1; id <= rowCount; id++ {
+		_, err := sess.Exec(`insert into widget(id, name) values(?, ?)`, id, fmt.Sprint("widget ", id))
+		wantNoError(t, err)
+	}
+
+	// every third key, plus some keys that do not exist, and some duplicates
+	var keys []int64
+	for id := int64(3); id <= rowCount+300; id += 3 {
+		keys = append(keys, id)
+	}
+	keys = append(keys, 3, 6, 9)
+	wantIDs := make(map[int64]bool)
+	for _, key := range keys {
+		if key <= rowCount {
+			wantIDs[key] = true
+		}
+	}
+
+	for _, strategy := range []KeyStrategy{KeysInChunks, KeysTempTable} {
+		var rows []*Widget
+		n, err := sess.SelectByKeysVia(&rows, keys, strategy)
+		wantNoError(t, err)
+		if got, want := n, len(wantIDs); got != want {
+			t.Errorf("strategy %d: got n=%d, want=%d", strategy, got, want)
+		}
+		gotIDs := make(map[int64]bool)
+		for _, row := range rows {
+			if row.Name != fmt.Sprint("widget ", row.ID) {
+				t.Errorf("strategy %d: got=%+v", strategy, row)
+			}
+			gotIDs[row.ID] = true
+		}
+		if !reflect.DeepEqual(gotIDs, wantIDs) {
+			t.Errorf("strategy %d: got %d ids, want %d ids", strategy, len(gotIDs), len(wantIDs))
+		}
+	}
+	_, err = sess.Exec(`select id from sqlr_keys`)
+	wantNoError(t, err)
+}
+
+func TestInsertRowGeneratedPostgres(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `drop table if exists generated_widgets`)
+	mustExec(t, db, `create table generated_widgets(
+		id serial primary key,
+		name text not null,
+		inserted timestamptz not null default now(),
+		status text not null default 'new'
+	)`)
+	defer mustExec(t, db, `drop table generated_widgets`)
+
+	type Widget struct {
+		ID       int `sql:"primary key autoincrement"`
+		Name     string
+		Inserted time.Time `sql:"inserted generated"`
+		Status   string    `sql:"status generated"`
+	}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithTables(TablesConfig{
+			(*Widget)(nil): TableConfig{TableName: "generated_widgets"},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+
+	before := time.Now().Add(-time.Minute)
+	for i := 1; i <= 2; i++ {
+		w := Widget{Name: fmt.Sprintf("widget %d", i)}
+		wantNoError(t, sess.InsertRow(&w))
+		if got, want := w.ID, i; got != want {
+			t.Errorf("got id=%d, want %d", got, want)
+		}
+		if w.Inserted.Before(before) {
+			t.Errorf("got inserted=%v, want after %v", w.Inserted, before)
+		}
+		if got, want := w.Status, "new"; got != want {
+			t.Errorf("got status=%q, want %q", got, want)
+		}
+	}
+}
+
+func TestIntBoolSQLite(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	type Widget struct {
+		ID     int64 `sql:"primary key"`
+		Active bool  `sql:"active intbool"`
+		Hidden *bool `sql:"hidden intbool"`
+		Name   string
+	}
+	schema := NewSchema(
+		WithDialect(SQLite),
+		WithTables(TablesConfig{
+			(*Widget)(nil): TableConfig{TableName: "int_bool_widgets"},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	_, err := sess.Exec(`create table int_bool_widgets(id integer primary key, active integer not null, hidden integer, name text)`)
+	wantNoError(t, err)
+	yes := true
+	wantNoError(t, sess.InsertRow(&Widget{ID: 1, Active: true, Hidden: &yes, Name: "one"}))
+	wantNoError(t, sess.InsertRow(&Widget{ID: 2, Active: false, Name: "two"}))
+	_, err = sess.Exec(`insert into int_bool_widgets(id, active, hidden, name) values(3, 2, 0, 'three')`)
+	wantNoError(t, err)
+
+	// bool fields are stored as 0/1
+	var stored []struct {
+		ID     int64
+		Active int64
+		Hidden *int64
+	}
+	_, err = sess.Select(&stored, `select id, active, hidden from int_bool_widgets order by id`)
+	wantNoError(t, err)
+	if got, want := fmt.Sprintf("%d/%d %d/%v", stored[0].Active, *stored[0].Hidden, stored[1].Active, stored[1].Hidden), "1/1 0/<nil>"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	var widgets []Widget
+	_, err = sess.Select(&widgets, `select {} from int_bool_widgets order by id`)
+	wantNoError(t, err)
+	no := false
+	want := []Widget{
+		{ID: 1, Active: true, Hidden: &yes, Name: "one"},
+		{ID: 2, Active: false, Name: "two"},
+		{ID: 3, Active: true, Hidden: &no, Name: "three"},
+	}
+	if !reflect.DeepEqual(widgets, want) {
+		t.Errorf("got=%+v\nwant=%+v", widgets, want)
+	}
+}
+
+func TestUpdateRowReturningTrigger(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `drop table if exists update_returning_widgets`)
+	mustExec(t, db, `create table update_returning_widgets(
+		id int primary key,
+		name text not null,
+		revision int not null default 0
+	)`)
+	defer mustExec(t, db, `drop table update_returning_widgets`)
+	mustExec(t, db, `create or replace function update_returning_bump() returns trigger as $$
+		begin
+			new.revision := old.revision + 1;
+			return new;
+		end
+		$$ language plpgsql`)
+	defer mustExec(t, db, `drop function update_returning_bump()`)
+	mustExec(t, db, `create trigger update_returning_bump before update on update_returning_widgets
+		for each row execute procedure update_returning_bump()`)
+
+	type Widget struct {
+		ID       int `sql:"primary key"`
+		Name     string
+		Revision int
+	}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithTables(TablesConfig{
+			(*Widget)(nil): TableConfig{TableName: "update_returning_widgets"},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+
+	w := Widget{ID: 1, Name: "one"}
+	wantNoError(t, sess.InsertRow(&w))
+	for i := 1; i <= 2; i++ {
+		w.Name = fmt.Sprintf("one v%d", i)
+		wantNoError(t, sess.UpdateRowReturning(&w))
+		if got, want := w.Revision, i; got != want {
+			t.Errorf("got revision=%d, want %d", got, want)
+		}
+	}
+	if err := sess.UpdateRowReturning(&Widget{ID: 2}); err != sql.ErrNoRows {
+		t.Errorf("got=%v, want=%v", err, sql.ErrNoRows)
+	}
+}
+
+func TestReadBlobSQLite(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	type Document struct {
+		ID      int64 `sql:"primary key"`
+		Content []byte
+	}
+	schema := NewSchema(
+		WithDialect(SQLite),
+		WithTables(TablesConfig{
+			(*Document)(nil): TableConfig{TableName: "blob_documents"},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	_, err := sess.Exec(`create table blob_documents(id integer primary key, content blob)`)
+	wantNoError(t, err)
+
+	defer func(n int) { BlobChunkSize = n }(BlobChunkSize)
+	BlobChunkSize = 7
+	contents := [][]byte{
+		nil,
+		[]byte("short"),
+		[]byte("exactly 14 b.."),
+		bytes.Repeat([]byte("0123456789"), 100),
+	}
+	for i, content := range contents {
+		wantNoError(t, sess.InsertRow(&Document{ID: int64(i + 1), Content: content}))
+	}
+	for i, content := range contents {
+		r, err := sess.ReadBlob(&Document{ID: int64(i + 1)}, "Content")
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		got, err := ioutil.ReadAll(r)
+		wantNoError(t, err)
+		wantNoError(t, r.Close())
+		if !bytes.Equal(got, content) {
+			t.Errorf("%d: got=%q, want=%q", i, got, content)
+		}
+	}
+	if _, err := sess.ReadBlob(&Document{ID: 99}, "Content"); err != sql.ErrNoRows {
+		t.Errorf("got=%v, want=%v", err, sql.ErrNoRows)
+	}
+}
+
+func TestWriteExprTsvector(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+
+	mustExec(t, db, `drop table if exists write_expr_documents`)
+	mustExec(t, db, `create table write_expr_documents(id serial primary key, body text not null, search tsvector)`)
+	defer mustExec(t, db, `drop table write_expr_documents`)
+
+	type Document struct {
+		ID     int `sql:"primary key autoincrement"`
+		Body   string
+		Search string
+	}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithTables(TablesConfig{
+			(*Document)(nil): TableConfig{
+				TableName: "write_expr_documents",
+				Columns: ColumnsConfig{
+					"Search": {WriteExpr: "to_tsvector('english', ?)"},
+				},
+			},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+
+	doc := Document{Body: "quick brown foxes", Search: "quick brown foxes"}
+	wantNoError(t, sess.InsertRow(&doc))
+	doc.Search = "lazy dogs jumping"
+	_, err := sess.UpdateRow(&doc)
+	wantNoError(t, err)
+
+	for query, want := range map[string]int{"jump": 1, "fox": 0} {
+		var docs []Document
+		_, err = sess.Select(&docs, `select {} from write_expr_documents where search @@ to_tsquery('english', ?)`, query)
+		wantNoError(t, err)
+		if got := len(docs); got != want {
+			t.Errorf("%s: got=%d, want=%d", query, got, want)
+		}
+	}
+}
+
+func TestInTxSavepointsSQLite(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	type Widget struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	schema := NewSchema(
+		WithDialect(SQLite),
+		WithTables(TablesConfig{
+			(*Widget)(nil): TableConfig{TableName: "tx_widgets"},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	_, err := sess.Exec(`create table tx_widgets(id integer primary key, name text)`)
+	wantNoError(t, err)
+
+	errNested := errors.New("nested failure")
+	err = sess.InTx(func(tx *Session) error {
+		if err := tx.InsertRow(&Widget{ID: 1, Name: "outer"}); err != nil {
+			return err
+		}
+		// nested success is released into the outer transaction
+		if err := tx.InTx(func(tx *Session) error {
+			return tx.InsertRow(&Widget{ID: 2, Name: "nested ok"})
+		}); err != nil {
+			return err
+		}
+		// nested failure rolls back to the savepoint only
+		err := tx.InTx(func(tx *Session) error {
+			if err := tx.InsertRow(&Widget{ID: 3, Name: "nested fail"}); err != nil {
+				return err
+			}
+			return errNested
+		})
+		if err != errNested {
+			t.Errorf("got=%v, want=%v", err, errNested)
+		}
+		return tx.InsertRow(&Widget{ID: 4, Name: "outer again"})
+	})
+	wantNoError(t, err)
+
+	var widgets []Widget
+	_, err = sess.Select(&widgets, `select {} from tx_widgets order by {}`)
+	wantNoError(t, err)
+	if got, want := fmt.Sprint(widgets), "[{1 outer} {2 nested ok} {4 outer again}]"; got != want {
+		t.Errorf("got=%s, want=%s", got, want)
+	}
+
+	// failure in the outer transaction rolls back everything
+	err = sess.InTx(func(tx *Session) error {
+		if err := tx.InTx(func(tx *Session) error {
+			return tx.InsertRow(&Widget{ID: 5, Name: "nested ok"})
+		}); err != nil {
+			return err
+		}
+		return errNested
+	})
+	if err != errNested {
+		t.Errorf("got=%v, want=%v", err, errNested)
+	}
+	widgets = nil
+	_, err = sess.Select(&widgets, `select {} from tx_widgets order by {}`)
+	wantNoError(t, err)
+	if got, want := fmt.Sprint(widgets), "[{1 outer} {2 nested ok} {4 outer again}]"; got != want {
+		t.Errorf("got=%s, want=%s", got, want)
+	}
+}
+
+func TestUpsertRowSQLite(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	testUpsertRow(t, db, SQLite, `create table upsert_widgets(id integer primary key autoincrement, email text not null unique, name text not null, version integer not null)`)
+}
+
+func TestUpsertRowPostgres(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+	mustExec(t, db, `drop table if exists upsert_widgets`)
+	defer mustExec(t, db, `drop table if exists upsert_widgets`)
+	testUpsertRow(t, db, Postgres, `create table upsert_widgets(id serial primary key, email text not null unique, name text not null, version int not null)`)
+}
+
+// testUpsertRow exercises the insert and update branches of an upsert for
+// a table with auto-increment and version columns.
+func testUpsertRow(t *testing.T, db *sql.DB, dialect Dialect, createTable string) {
+	type Widget struct {
+		ID      int64 `sql:"primary key autoincrement"`
+		Email   string
+		Name    string
+		Version int64 `sql:"version"`
+	}
+	schema := NewSchema(
+		WithDialect(dialect),
+		WithTables(TablesConfig{
+			(*Widget)(nil): TableConfig{TableName: "upsert_widgets"},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+	_, err := sess.Exec(createTable)
+	wantNoError(t, err)
+
+	// insert branch
+	first := Widget{Email: "a@example.com", Name: "first"}
+	n, err := sess.UpsertOnConflict(&first, "email", "")
+	wantNoError(t, err)
+	second := Widget{Email: "b@example.com", Name: "second"}
+	_, err = sess.UpsertOnConflict(&second, "email", "")
+	wantNoError(t, err)
+	if got, want := fmt.Sprintf("%d %d/%d %d/%d", n, first.ID, first.Version, second.ID, second.Version), "1 1/1 2/1"; got != want {
+		t.Errorf("insert: got=%q, want=%q", got, want)
+	}
+
+	// update branch
+	updated := Widget{Email: "a@example.com", Name: "updated"}
+	_, err = sess.UpsertOnConflict(&updated, "email", "")
+	wantNoError(t, err)
+	if got, want := fmt.Sprintf("%d/%d", updated.ID, updated.Version), "1/2"; got != want {
+		t.Errorf("update: got=%q, want=%q", got, want)
+	}
+
+	// update branch with the auto-increment primary key as the target
+	byID := Widget{ID: 2, Email: "b@example.com", Name: "second"}
+	_, err = sess.UpsertRow(&byID)
+	wantNoError(t, err)
+	if got, want := fmt.Sprintf("%d/%d", byID.ID, byID.Version), "2/2"; got != want {
+		t.Errorf("update by id: got=%q, want=%q", got, want)
+	}
+
+	var widgets []Widget
+	_, err = sess.Select(&widgets, `select {} from upsert_widgets order by {}`)
+	wantNoError(t, err)
+	want := []Widget{
+		{ID: 1, Email: "a@example.com", Name: "updated", Version: 2},
+		{ID: 2, Email: "b@example.com", Name: "second", Version: 2},
+	}
+	if !reflect.DeepEqual(widgets, want) {
+		t.Errorf("got=%+v\nwant=%+v", widgets, want)
+	}
+}
+
+func TestInsertRowsSQLite(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	testInsertRows(t, db, SQLite, `create table insert_rows_widgets(id integer primary key autoincrement, name text not null)`)
+}
+
+func TestInsertRowsPostgres(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+	mustExec(t, db, `drop table if exists insert_rows_widgets`)
+	defer mustExec(t, db, `drop table if exists insert_rows_widgets`)
+	testInsertRows(t, db, Postgres, `create table insert_rows_widgets(id serial primary key, name text not null)`)
+}
+
+// testInsertRows inserts rows in more than one batch, and checks that the
+// auto-increment fields are set for PostgreSQL, and are not set otherwise.
+func testInsertRows(t *testing.T, db *sql.DB, dialect Dialect, createTable string) {
+	type Widget struct {
+		ID   int64 `sql:"primary key autoincrement"`
+		Name string
+	}
+	defer func(n int) { MaxParamsPerInsert = n }(MaxParamsPerInsert)
+	MaxParamsPerInsert = 2
+
+	schema := NewSchema(
+		WithDialect(dialect),
+		WithTables(TablesConfig{
+			(*Widget)(nil): TableConfig{TableName: "insert_rows_widgets"},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+	_, err := sess.Exec(createTable)
+	wantNoError(t, err)
+	wantNoError(t, sess.InsertRow(&Widget{Name: "first"}))
+
+	rows := []*Widget{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	n, err := sess.InsertRows(rows)
+	wantNoError(t, err)
+	if got, want := n, 3; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	for i, row := range rows {
+		var want int64
+		if isPostgres(dialect) {
+			want = int64(i + 2)
+		}
+		if got := row.ID; got != want {
+			t.Errorf("%d: got id=%d, want=%d", i, got, want)
+		}
+		row.ID = int64(i + 2)
+	}
+
+	var widgets []*Widget
+	_, err = sess.Select(&widgets, `select {} from insert_rows_widgets order by {}`)
+	wantNoError(t, err)
+	want := append([]*Widget{{ID: 1, Name: "first"}}, rows...)
+	if !reflect.DeepEqual(widgets, want) {
+		t.Errorf("got=%+v\nwant=%+v", widgets, want)
+	}
+}
+
+func TestDateRoundTripSQLite(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	testDateRoundTrip(t, db, SQLite)
+}
+
+func TestDateRoundTripPostgres(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+	mustExec(t, db, `drop table if exists date_round_trip`)
+	defer mustExec(t, db, `drop table if exists date_round_trip`)
+	db.SetMaxOpenConns(1)
+	mustExec(t, db, `set time zone 'America/Los_Angeles'`)
+	testDateRoundTrip(t, db, Postgres)
+}
+
+// testDateRoundTrip checks that a date field is stored and scanned back as
+// the same date, even when the time is in a time zone ahead of UTC.
+func testDateRoundTrip(t *testing.T, db *sql.DB, dialect Dialect) {
+	type DateRoundTrip struct {
+		ID   int64      `sql:"primary key"`
+		Born time.Time  `sql:"born date"`
+		Died *time.Time `sql:"died date"`
+	}
+	schema := NewSchema(WithDialect(dialect))
+	createTable, err := schema.TableFor(DateRoundTrip{}).CreateTableSQL()
+	wantNoError(t, err)
+	mustExec(t, db, createTable)
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	local := time.FixedZone("AEST", 10*3600)
+	died := time.Date(2020, 3, 15, 23, 59, 0, 0, time.UTC)
+	rows := []*DateRoundTrip{
+		{ID: 1, Born: time.Date(1970, 1, 2, 0, 30, 0, 0, local)},
+		{ID: 2, Born: time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC), Died: &died},
+	}
+	for _, row := range rows {
+		wantNoError(t, sess.InsertRow(row))
+	}
+
+	var got []*DateRoundTrip
+	_, err = sess.Select(&got, "select {} from date_round_trip order by id")
+	wantNoError(t, err)
+	if len(got) != 2 {
+		t.Fatalf("got=%d rows, want=2", len(got))
+	}
+	if want := time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC); !got[0].Born.Equal(want) || got[0].Died != nil {
+		t.Errorf("got=%v, %v, want=%v, nil", got[0].Born, got[0].Died, want)
+	}
+	if want := time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC); !got[1].Born.Equal(want) {
+		t.Errorf("got=%v, want=%v", got[1].Born, want)
+	}
+	if want := time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC); got[1].Died == nil || !got[1].Died.Equal(want) {
+		t.Errorf("got=%v, want=%v", got[1].Died, want)
+	}
+}
+
+func TestEpochRoundTripSQLite(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	type Event struct {
+		ID        int64      `sql:"primary key"`
+		StartedAt time.Time  `sql:"started_at epoch"`
+		EndedAt   *time.Time `sql:"epoch=ms"`
+	}
+	mustExec(t, db, `create table event(id integer primary key, started_at integer not null, ended_at integer null)`)
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+	defer sess.Close()
+
+	started := time.Date(2020, 3, 15, 10, 30, 45, 0, time.UTC)
+	ended := started.Add(1500 * time.Millisecond)
+	wantNoError(t, sess.InsertRow(&Event{ID: 1, StartedAt: started, EndedAt: &ended}))
+	wantNoError(t, sess.InsertRow(&Event{ID: 2, StartedAt: started}))
+
+	var seconds, millis int64
+	wantNoError(t, db.QueryRow(`select started_at, ended_at from event where id = 1`).Scan(&seconds, &millis))
+	if got, want := seconds, started.Unix(); got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	if got, want := millis, started.Unix()*1000+1500; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+
+	var events []*Event
+	_, err := sess.Select(&events, "select {} from event order by id")
+	wantNoError(t, err)
+	if len(events) != 2 {
+		t.Fatalf("got=%d rows, want=2", len(events))
+	}
+	if !events[0].StartedAt.Equal(started) || events[0].EndedAt == nil || !events[0].EndedAt.Equal(ended) {
+		t.Errorf("got=%v, %v, want=%v, %v", events[0].StartedAt, events[0].EndedAt, started, ended)
+	}
+	if events[1].EndedAt != nil {
+		t.Errorf("got=%v, want=nil", events[1].EndedAt)
+	}
+}
+
+func TestCompositeKeyLoadSQLite(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	type TenantWidget struct {
+		TenantID int64  `sql:"primary key"`
+		ID       string `sql:"primary key"`
+		Name     string
+	}
+	mustExec(t, db, `create table tenant_widget(tenant_id integer not null, id text not null, name text not null, primary key(tenant_id, id))`)
+	mustExec(t, db, `insert into tenant_widget values(1, 'a', 'one-a'), (1, 'b', 'one-b'), (2, 'a', 'two-a')`)
+
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+	defer sess.Close()
+	var load func(tenantID int64, id string) func() (*TenantWidget, error)
+	sess.MakeQuery(&load)
+
+	thunks := []func() (*TenantWidget, error){load(2, "a"), load(1, "b"), load(2, "b")}
+	for i, want := range []string{"two-a", "one-b", ""} {
+		row, err := thunks[i]()
+		wantNoError(t, err)
+		if want == "" {
+			if row != nil {
+				t.Errorf("%d: got=%+v, want=nil", i, row)
+			}
+			continue
+		}
+		if row == nil || row.Name != want {
+			t.Errorf("%d: got=%+v, want=%s", i, row, want)
+		}
+	}
+}
+
+func TestSelectAfterSQLite(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	type Player struct {
+		ID    int64 `sql:"primary key"`
+		Score int
+	}
+	mustExec(t, db, `create table player(id integer primary key, score integer not null)`)
+	mustExec(t, db, `insert into player values(1, 10), (2, 30), (3, 20), (4, 30), (5, 10)`)
+
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+	defer sess.Close()
+
+	tests := []struct {
+		orderFields []string
+		want        []int64
+	}{
+		{orderFields: []string{"Score", "ID"}, want: []int64{1, 5, 3, 2, 4}},
+		{orderFields: []string{"Score desc", "ID desc"}, want: []int64{4, 2, 3, 5, 1}},
+		{orderFields: []string{"Score desc", "ID"}, want: []int64{2, 4, 3, 1, 5}},
+	}
+	for i, tt := range tests {
+		var got []int64
+		var afterValues []interface{}
+		for page := 0; page < 5; page++ {
+			var rows []*Player
+			n, err := sess.SelectAfter(&rows, Player{}, tt.orderFields, afterValues, 2)
+			wantNoError(t, err)
+			if n == 0 {
+				break
+			}
+			for _, row := range rows {
+				got = append(got, row.ID)
+			}
+			last := rows[n-1]
+			afterValues = []interface{}{last.Score, last.ID}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%v, want=%v", i, got, tt.want)
+		}
+	}
+}
+
+func TestSoftDeleteSQLite(t *testing.T) {
+	db := sqliteDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	type Document struct {
+		ID        int64 `sql:"primary key"`
+		Title     string
+		DeletedAt *time.Time `sql:"deleted_at deleted"`
+	}
+	mustExec(t, db, `create table document(id integer primary key, title text not null, deleted_at datetime null)`)
+
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+	defer sess.Close()
+	for i, title := range []string{"one", "two"} {
+		wantNoError(t, sess.InsertRow(&Document{ID: int64(i + 1), Title: title}))
+	}
+
+	doc := &Document{ID: 1}
+	n, err := sess.DeleteRow(doc)
+	wantNoError(t, err)
+	if got, want := n, 1; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	if doc.DeletedAt == nil {
+		t.Error("got=nil, want deleted time")
+	}
+
+	// deleting again has no effect
+	n, err = sess.DeleteRow(&Document{ID: 1})
+	wantNoError(t, err)
+	if got, want := n, 0; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+
+	var get func(id int64) (*Document, error)
+	sess.MakeQuery(&get)
+	row, err := get(1)
+	wantNoError(t, err)
+	if row != nil {
+		t.Errorf("got=%+v, want=nil", row)
+	}
+	found, err := sess.GetRow(&Document{ID: 2})
+	wantNoError(t, err)
+	if !found {
+		t.Error("got=false, want=true")
+	}
+
+	// the row is still in the table
+	var docs []*Document
+	n, err = sess.Select(&docs, "select {} from document where deleted_at is not null")
+	wantNoError(t, err)
+	if got, want := n, 1; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+}
+
+func TestForSharePostgres(t *testing.T) {
+	db := postgresDB(t)
+	defer db.Close()
+
+	type ForShareRow struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	mustExec(t, db, `drop table if exists for_share_row`)
+	defer mustExec(t, db, `drop table if exists for_share_row`)
+	mustExec(t, db, `create table for_share_row(id bigint primary key, name text not null)`)
+	mustExec(t, db, `insert into for_share_row values(1, 'one')`)
+
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+	err := sess.InTx(func(tx *Session) error {
+		var row ForShareRow
+		n, err := tx.Select(&row, "select {} from for_share_row where id = ? {for share}", 1)
+		if err != nil {
+			return err
+		}
+		if n != 1 || row.Name != "one" {
+			t.Errorf("got=%d %+v, want=1 one", n, row)
+		}
+		return nil
+	})
+	wantNoError(t, err)
+}
+
 // mustExec performs an SQL command, which must succeed or the test stops
 func mustExec(t *testing.T, db *sql.DB, query string) {
 	t.Helper()
@@ -0,0 +1,367 @@
+package sqlr
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CreateTableSQL returns a CREATE TABLE statement for the table, based on
+// the columns derived from the row type and the dialect of the schema.
+//
+// The generated SQL is intended as a reasonable starting point, and is
+// not a substitute for a carefully designed database schema. Columns are
+// NOT NULL unless the field is a pointer, is marshaled as JSON, or the
+// zero value is stored as NULL. Columns with permitted values specified
+// by the "enum" keyword in the struct tag include a CHECK constraint.
+//
+// Foreign key constraints are specified by the "references" keyword in the
+// struct tag, optionally followed by the action to take when the referenced
+// row is deleted, eg `sql:"references=users(id) on delete cascade"`.
+//
+// Column comments specified by the "comment" keyword in the struct tag are
+// included inline for MySQL. For Postgres they are included as COMMENT ON COLUMN
+// statements following the CREATE TABLE statement, separated by semicolons.
+func (tbl *Table) CreateTableSQL() (string, error) {
+	dialect := tbl.schema.getDialect()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "create table %s (", dialect.Quote(tbl.tableName))
+
+	// the auto-increment column is declared inline as the primary key
+	// if it is the only primary key column
+	inlinePK := len(tbl.pk) == 1 && tbl.pk[0] == tbl.autoincr
+
+	for i, col := range tbl.storedColumns() {
+		if i > 0 {
+			buf.WriteRune(',')
+		}
+		def, err := tbl.columnDefinition(col, true)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString("\n  ")
+		buf.WriteString(def)
+		if inlinePK && col == tbl.autoincr && dialectName(dialect) != dialectSQLite {
+			// for SQLite, autoincrement can only follow the primary key keyword,
+			// so columnDefinition has already included it
+			buf.WriteString(" primary key")
+		}
+	}
+	if len(tbl.pk) > 0 && !inlinePK {
+		buf.WriteString(",\n  primary key (")
+		for i, col := range tbl.pk {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(dialect.Quote(col.columnName))
+		}
+		buf.WriteRune(')')
+	}
+	for _, col := range tbl.storedColumns() {
+		if col.info.Tag.References == "" {
+			continue
+		}
+		def, err := tbl.foreignKeyDefinition(col)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(",\n  ")
+		buf.WriteString(def)
+	}
+	buf.WriteString("\n)")
+	if dialectName(dialect) == dialectPostgres {
+		for _, col := range tbl.storedColumns() {
+			if comment := col.Comment(); comment != "" {
+				fmt.Fprintf(&buf, ";\ncomment on column %s.%s is %s",
+					dialect.Quote(tbl.tableName), dialect.Quote(col.columnName), quoteString(comment))
+			}
+		}
+	}
+	return buf.String(), nil
+}
+
+// MigrationSQL returns ALTER TABLE statements that apply the column
+// differences to the database table. The differences are usually obtained
+// by calling the Diff method.
+//
+// Added columns are always nullable, so that they can be added to a table
+// that already contains rows. Removed and changed columns can result in the
+// loss of data, so MigrationSQL returns an error unless the AllowDestructive
+// field has been set for these differences.
+func (tbl *Table) MigrationSQL(diff ...ColumnDiff) ([]string, error) {
+	dialect := tbl.schema.getDialect()
+	name := dialectName(dialect)
+	tableName := dialect.Quote(tbl.tableName)
+	var stmts []string
+
+	for _, d := range diff {
+		if d.Kind != ColumnAdded && !d.AllowDestructive {
+			return nil, fmt.Errorf("will not generate SQL for %s column %s without AllowDestructive", d.Kind, d.ColumnName)
+		}
+		switch d.Kind {
+		case ColumnAdded:
+			if d.Column == nil {
+				return nil, fmt.Errorf("missing column for added column %s", d.ColumnName)
+			}
+			def, err := tbl.columnDefinition(d.Column, false)
+			if err != nil {
+				return nil, err
+			}
+			if name == dialectMSSQL {
+				stmts = append(stmts, fmt.Sprintf("alter table %s add %s", tableName, def))
+			} else {
+				stmts = append(stmts, fmt.Sprintf("alter table %s add column %s", tableName, def))
+			}
+		case ColumnRemoved:
+			stmts = append(stmts, fmt.Sprintf("alter table %s drop column %s", tableName, dialect.Quote(d.ColumnName)))
+		case ColumnChanged:
+			if d.Column == nil {
+				return nil, fmt.Errorf("missing column for changed column %s", d.ColumnName)
+			}
+			sqlType, err := sqlTypeFor(d.Column, name)
+			if err != nil {
+				return nil, err
+			}
+			columnName := dialect.Quote(d.ColumnName)
+			switch name {
+			case dialectPostgres:
+				stmts = append(stmts, fmt.Sprintf("alter table %s alter column %s type %s", tableName, columnName, sqlType))
+			case dialectMySQL:
+				stmts = append(stmts, fmt.Sprintf("alter table %s modify column %s %s", tableName, columnName, sqlType))
+			case dialectMSSQL:
+				stmts = append(stmts, fmt.Sprintf("alter table %s alter column %s %s", tableName, columnName, sqlType))
+			case dialectSQLite:
+				return nil, fmt.Errorf("cannot change type of column %s: not supported by SQLite", d.ColumnName)
+			default:
+				stmts = append(stmts, fmt.Sprintf("alter table %s alter column %s set data type %s", tableName, columnName, sqlType))
+			}
+		default:
+			return nil, fmt.Errorf("unknown column difference for column %s: %v", d.ColumnName, d.Kind)
+		}
+	}
+
+	return stmts, nil
+}
+
+// columnDefinition returns the column definition used in CREATE TABLE and
+// ALTER TABLE ADD COLUMN statements. If notNull is false, the column is always
+// nullable.
+func (tbl *Table) columnDefinition(col *Column, notNull bool) (string, error) {
+	dialect := tbl.schema.getDialect()
+	name := dialectName(dialect)
+	sqlType, err := sqlTypeFor(col, name)
+	if err != nil {
+		return "", err
+	}
+	def := dialect.Quote(col.columnName) + " " + sqlType
+	if col.autoIncrement && notNull {
+		switch name {
+		case dialectPostgres:
+			if sqlType == "bigint" {
+				def = dialect.Quote(col.columnName) + " bigserial"
+			} else {
+				def = dialect.Quote(col.columnName) + " serial"
+			}
+		case dialectMySQL:
+			def += " not null auto_increment"
+		case dialectMSSQL:
+			def += " identity(1,1) not null"
+		case dialectSQLite:
+			if len(tbl.pk) == 1 && tbl.pk[0] == col {
+				def += " primary key autoincrement"
+			} else {
+				def += " not null"
+			}
+		default:
+			def += " generated by default as identity"
+		}
+		return def, nil
+	}
+	if notNull && !col.emptyNull && !col.json && col.fieldType().Kind() != reflect.Ptr {
+		def += " not null"
+	}
+	if enum := col.Enum(); len(enum) > 0 {
+		values := make([]string, len(enum))
+		for i, v := range enum {
+			values[i] = quoteString(v)
+		}
+		def += fmt.Sprintf(" check (%s in (%s))", dialect.Quote(col.columnName), strings.Join(values, ", "))
+	}
+	if comment := col.Comment(); comment != "" && name == dialectMySQL {
+		def += " comment " + quoteString(comment)
+	}
+	return def, nil
+}
+
+// foreignKeyDefinition returns the foreign key constraint for a column
+// with the "references" keyword in its struct tag.
+func (tbl *Table) foreignKeyDefinition(col *Column) (string, error) {
+	dialect := tbl.schema.getDialect()
+	ref := col.info.Tag.References
+	open := strings.IndexByte(ref, '(')
+	if open <= 0 || !strings.HasSuffix(ref, ")") {
+		return "", fmt.Errorf("invalid reference %q for column %s: expected table(column)", ref, col.columnName)
+	}
+	refTable, refColumn := ref[:open], ref[open+1:len(ref)-1]
+	if !isReferenceName(refTable) || !isReferenceName(refColumn) || strings.Contains(refColumn, ".") {
+		return "", fmt.Errorf("invalid reference %q for column %s: expected table(column)", ref, col.columnName)
+	}
+	def := fmt.Sprintf("foreign key (%s) references %s (%s)",
+		dialect.Quote(col.columnName), dialect.Quote(refTable), dialect.Quote(refColumn))
+
+	switch onDelete := col.info.Tag.OnDelete; onDelete {
+	case "":
+	case "cascade", "set null", "set default", "no action":
+		def += " on delete " + onDelete
+	case "restrict":
+		if dialectName(dialect) == dialectMSSQL {
+			// SQL Server rejects deletes by default, which is equivalent
+			return "", fmt.Errorf("on delete restrict for column %s is not supported by SQL Server: use no action", col.columnName)
+		}
+		def += " on delete " + onDelete
+	default:
+		return "", fmt.Errorf("invalid on delete action %q for column %s", onDelete, col.columnName)
+	}
+	return def, nil
+}
+
+// isReferenceName reports whether name is a valid table or column name,
+// optionally qualified, in a foreign key reference.
+func isReferenceName(name string) bool {
+	if name == "" || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+		return false
+	}
+	for _, ch := range name {
+		switch {
+		case ch >= 'a' && ch <= 'z',
+			ch >= 'A' && ch <= 'Z',
+			ch >= '0' && ch <= '9',
+			ch == '_', ch == '.':
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// quoteString returns s as an SQL string literal.
+func quoteString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// sqlTypeFor returns the SQL type for the column in the named dialect.
+func sqlTypeFor(col *Column, name string) (string, error) {
+	fieldType := col.fieldType()
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	if (col.json && !col.gzip) || fieldType == reflect.TypeOf(LazyJSON{}) {
+		switch name {
+		case dialectPostgres:
+			return "jsonb", nil
+		case dialectMySQL:
+			return "json", nil
+		case dialectMSSQL:
+			return "nvarchar(max)", nil
+		case dialectOracle:
+			return "clob", nil
+		}
+		return "text", nil
+	}
+
+	if col.hstore {
+		if name != dialectPostgres {
+			return "", fmt.Errorf("hstore column %s requires the postgres dialect", col.columnName)
+		}
+		return "hstore", nil
+	}
+
+	if col.array {
+		if fieldType.Elem().Kind() == reflect.String {
+			return "text[]", nil
+		}
+		return "bigint[]", nil
+	}
+
+	if col.intBool {
+		return "integer", nil
+	}
+
+	if col.date {
+		return "date", nil
+	}
+
+	switch fieldTypeFamily(col) {
+	case familyBool:
+		switch name {
+		case dialectMSSQL:
+			return "bit", nil
+		case dialectSQLite:
+			return "integer", nil
+		case dialectOracle:
+			return "number(1)", nil
+		}
+		return "boolean", nil
+	case familyInteger:
+		if name == dialectSQLite {
+			// required for autoincrement columns
+			return "integer", nil
+		}
+		if name == dialectOracle {
+			return "number(19)", nil
+		}
+		switch fieldType.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Uint8:
+			return "smallint", nil
+		case reflect.Int32, reflect.Uint16:
+			return "integer", nil
+		}
+		return "bigint", nil
+	case familyFloat:
+		if fieldType.Kind() == reflect.Float32 || name == dialectSQLite {
+			return "real", nil
+		}
+		switch name {
+		case dialectMySQL:
+			return "double", nil
+		case dialectMSSQL:
+			return "float", nil
+		}
+		return "double precision", nil
+	case familyText:
+		switch name {
+		case dialectPostgres, dialectSQLite:
+			return "text", nil
+		case dialectMSSQL:
+			return "nvarchar(255)", nil
+		case dialectOracle:
+			return "varchar2(255)", nil
+		}
+		return "varchar(255)", nil
+	case familyBinary:
+		switch name {
+		case dialectPostgres:
+			return "bytea", nil
+		case dialectMySQL:
+			return "longblob", nil
+		case dialectMSSQL:
+			return "varbinary(max)", nil
+		}
+		return "blob", nil
+	case familyTime:
+		switch name {
+		case dialectPostgres:
+			return "timestamp with time zone", nil
+		case dialectMySQL:
+			return "datetime", nil
+		case dialectMSSQL:
+			return "datetime2", nil
+		}
+		return "timestamp", nil
+	}
+
+	return "", fmt.Errorf("cannot determine SQL type for column %s (field %s, type %s)",
+		col.columnName, col.info.FieldNames, col.fieldType())
+}
@@ -0,0 +1,420 @@
+package sqlr
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCreateTableSQL(t *testing.T) {
+	type Row struct {
+		ID      int64 `sql:"primary key autoincrement"`
+		Name    string
+		Amount  float64
+		Active  bool
+		Notes   *string
+		Tags    []string `sql:"json"`
+		Created time.Time
+	}
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{
+			dialect: Postgres,
+			want: "create table \"row\" (\n" +
+				"  \"id\" bigserial primary key,\n" +
+				"  \"name\" text not null,\n" +
+				"  \"amount\" double precision not null,\n" +
+				"  \"active\" boolean not null,\n" +
+				"  \"notes\" text,\n" +
+				"  \"tags\" jsonb,\n" +
+				"  \"created\" timestamp with time zone not null\n" +
+				")",
+		},
+		{
+			dialect: MySQL,
+			want: "create table `row` (\n" +
+				"  `id` bigint not null auto_increment primary key,\n" +
+				"  `name` varchar(255) not null,\n" +
+				"  `amount` double not null,\n" +
+				"  `active` boolean not null,\n" +
+				"  `notes` varchar(255),\n" +
+				"  `tags` json,\n" +
+				"  `created` datetime not null\n" +
+				")",
+		},
+		{
+			dialect: MSSQL,
+			want: "create table [row] (\n" +
+				"  [id] bigint identity(1,1) not null primary key,\n" +
+				"  [name] nvarchar(255) not null,\n" +
+				"  [amount] float not null,\n" +
+				"  [active] bit not null,\n" +
+				"  [notes] nvarchar(255),\n" +
+				"  [tags] nvarchar(max),\n" +
+				"  [created] datetime2 not null\n" +
+				")",
+		},
+		{
+			dialect: SQLite,
+			want: "create table `row` (\n" +
+				"  `id` integer primary key autoincrement,\n" +
+				"  `name` text not null,\n" +
+				"  `amount` real not null,\n" +
+				"  `active` integer not null,\n" +
+				"  `notes` text,\n" +
+				"  `tags` text,\n" +
+				"  `created` timestamp not null\n" +
+				")",
+		},
+		{
+			dialect: Oracle,
+			want: "create table \"ROW\" (\n" +
+				"  \"ID\" number(19) generated by default as identity primary key,\n" +
+				"  \"NAME\" varchar2(255) not null,\n" +
+				"  \"AMOUNT\" double precision not null,\n" +
+				"  \"ACTIVE\" number(1) not null,\n" +
+				"  \"NOTES\" varchar2(255),\n" +
+				"  \"TAGS\" clob,\n" +
+				"  \"CREATED\" timestamp not null\n" +
+				")",
+		},
+		{
+			dialect: ANSISQL,
+			want: "create table \"row\" (\n" +
+				"  \"id\" bigint generated by default as identity primary key,\n" +
+				"  \"name\" varchar(255) not null,\n" +
+				"  \"amount\" double precision not null,\n" +
+				"  \"active\" boolean not null,\n" +
+				"  \"notes\" varchar(255),\n" +
+				"  \"tags\" text,\n" +
+				"  \"created\" timestamp not null\n" +
+				")",
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		got, err := schema.TableFor(Row{}).CreateTableSQL()
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%d: got=\n%s\nwant=\n%s", i, got, tt.want)
+		}
+	}
+}
+
+func TestCreateTableSQLCompositeKey(t *testing.T) {
+	type Row struct {
+		OrderID int32 `sql:"primary key"`
+		LineNo  int16 `sql:"primary key"`
+		Qty     int
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	got, err := schema.TableFor(Row{}).CreateTableSQL()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "create table \"row\" (\n" +
+		"  \"order_id\" integer not null,\n" +
+		"  \"line_no\" smallint not null,\n" +
+		"  \"qty\" bigint not null,\n" +
+		"  primary key (\"order_id\", \"line_no\")\n" +
+		")"
+	if got != want {
+		t.Errorf("got=\n%s\nwant=\n%s", got, want)
+	}
+}
+
+func TestMigrationSQL(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+		Data []byte
+	}
+	tests := []struct {
+		dialect Dialect
+		want    []string
+		wantErr bool
+	}{
+		{
+			dialect: Postgres,
+			want: []string{
+				`alter table "row" add column "name" text`,
+				`alter table "row" drop column "legacy"`,
+				`alter table "row" alter column "data" type bytea`,
+			},
+		},
+		{
+			dialect: MySQL,
+			want: []string{
+				"alter table `row` add column `name` varchar(255)",
+				"alter table `row` drop column `legacy`",
+				"alter table `row` modify column `data` longblob",
+			},
+		},
+		{
+			dialect: MSSQL,
+			want: []string{
+				"alter table [row] add [name] nvarchar(255)",
+				"alter table [row] drop column [legacy]",
+				"alter table [row] alter column [data] varbinary(max)",
+			},
+		},
+		{
+			dialect: ANSISQL,
+			want: []string{
+				`alter table "row" add column "name" varchar(255)`,
+				`alter table "row" drop column "legacy"`,
+				`alter table "row" alter column "data" set data type blob`,
+			},
+		},
+		{
+			dialect: SQLite,
+			wantErr: true,
+		},
+	}
+	for i, tt := range tests {
+		tbl := NewSchema(WithDialect(tt.dialect)).TableFor(Row{})
+		diff := []ColumnDiff{
+			{Kind: ColumnAdded, ColumnName: "name", Column: tbl.cols[1]},
+			{Kind: ColumnRemoved, ColumnName: "legacy", DBType: "text", AllowDestructive: true},
+			{Kind: ColumnChanged, ColumnName: "data", Column: tbl.cols[2], DBType: "text", AllowDestructive: true},
+		}
+		got, err := tbl.MigrationSQL(diff...)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%d: got=nil, want=error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%q, want=%q", i, got, tt.want)
+		}
+	}
+}
+
+func TestMigrationSQLDestructive(t *testing.T) {
+	type Row struct {
+		ID int64 `sql:"primary key"`
+	}
+	tbl := NewSchema(WithDialect(Postgres)).TableFor(Row{})
+	diffs := []ColumnDiff{
+		{Kind: ColumnRemoved, ColumnName: "legacy", DBType: "text"},
+		{Kind: ColumnChanged, ColumnName: "id", Column: tbl.cols[0], DBType: "text"},
+	}
+	for i, d := range diffs {
+		if _, err := tbl.MigrationSQL(d); err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+		}
+	}
+}
+
+func TestCreateTableSQLEnum(t *testing.T) {
+	type Row struct {
+		ID     int64  `sql:"primary key"`
+		Status string `sql:"enum=active|inactive|pending"`
+		Kind   string `sql:"enum=a|'b''s' null"`
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	got, err := schema.TableFor(Row{}).CreateTableSQL()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "create table \"row\" (\n" +
+		"  \"id\" bigint not null,\n" +
+		"  \"status\" text not null check (\"status\" in ('active', 'inactive', 'pending')),\n" +
+		"  \"kind\" text check (\"kind\" in ('a', 'b''s')),\n" +
+		"  primary key (\"id\")\n" +
+		")"
+	if got != want {
+		t.Errorf("got=\n%s\nwant=\n%s", got, want)
+	}
+}
+
+func TestCreateTableSQLComment(t *testing.T) {
+	type Customer struct {
+		ID    int64  `sql:"primary key"`
+		Email string `sql:"comment='Customer''s email address'"`
+		Name  string
+	}
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{
+			dialect: Postgres,
+			want: "create table \"customer\" (\n" +
+				"  \"id\" bigint not null,\n" +
+				"  \"email\" text not null,\n" +
+				"  \"name\" text not null,\n" +
+				"  primary key (\"id\")\n" +
+				");\n" +
+				"comment on column \"customer\".\"email\" is 'Customer''s email address'",
+		},
+		{
+			dialect: MySQL,
+			want: "create table `customer` (\n" +
+				"  `id` bigint not null,\n" +
+				"  `email` varchar(255) not null comment 'Customer''s email address',\n" +
+				"  `name` varchar(255) not null,\n" +
+				"  primary key (`id`)\n" +
+				")",
+		},
+	}
+	for i, tt := range tests {
+		tbl := NewSchema(WithDialect(tt.dialect)).TableFor(Customer{})
+		if got, want := tbl.Columns()[1].Comment(), "Customer's email address"; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if got, want := tbl.Columns()[2].Comment(), ""; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		got, err := tbl.CreateTableSQL()
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%d: got=\n%s\nwant=\n%s", i, got, tt.want)
+		}
+	}
+}
+
+func TestCreateTableSQLReferences(t *testing.T) {
+	type Order struct {
+		ID         int64  `sql:"primary key"`
+		CustomerID int64  `sql:"references=customers(id) on delete cascade"`
+		AgentID    *int64 `sql:"references=app.agents(id)"`
+	}
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{
+			dialect: Postgres,
+			want: "create table \"order\" (\n" +
+				"  \"id\" bigint not null,\n" +
+				"  \"customer_id\" bigint not null,\n" +
+				"  \"agent_id\" bigint,\n" +
+				"  primary key (\"id\"),\n" +
+				"  foreign key (\"customer_id\") references \"customers\" (\"id\") on delete cascade,\n" +
+				"  foreign key (\"agent_id\") references \"app\".\"agents\" (\"id\")\n" +
+				")",
+		},
+		{
+			dialect: MySQL,
+			want: "create table `order` (\n" +
+				"  `id` bigint not null,\n" +
+				"  `customer_id` bigint not null,\n" +
+				"  `agent_id` bigint,\n" +
+				"  primary key (`id`),\n" +
+				"  foreign key (`customer_id`) references `customers` (`id`) on delete cascade,\n" +
+				"  foreign key (`agent_id`) references `app`.`agents` (`id`)\n" +
+				")",
+		},
+	}
+	for i, tt := range tests {
+		tbl := NewSchema(WithDialect(tt.dialect)).TableFor(Order{})
+		got, err := tbl.CreateTableSQL()
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%d: got=\n%s\nwant=\n%s", i, got, tt.want)
+		}
+	}
+}
+
+func TestCreateTableSQLReadExpr(t *testing.T) {
+	type Person struct {
+		ID       int64 `sql:"primary key"`
+		Name     string
+		FullName string `sql:"full_name comment='display name' references=names(name)"`
+	}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithTables(TablesConfig{
+			(*Person)(nil): {
+				Columns: ColumnsConfig{
+					"FullName": {ReadExpr: "upper(name)"},
+				},
+			},
+		}),
+	)
+
+	// the read expression column is not created, so it has no
+	// foreign key or comment
+	got, err := schema.TableFor(Person{}).CreateTableSQL()
+	wantNoError(t, err)
+	want := "create table \"person\" (\n" +
+		"  \"id\" bigint not null,\n" +
+		"  \"name\" text not null,\n" +
+		"  primary key (\"id\")\n" +
+		")"
+	if got != want {
+		t.Errorf("got=\n%s\nwant=\n%s", got, want)
+	}
+}
+
+func TestCreateTableSQLReferencesErrors(t *testing.T) {
+	type BadFormat struct {
+		CustomerID int64 `sql:"references=customers"`
+	}
+	type BadColumn struct {
+		CustomerID int64 `sql:"references=customers(a.id)"`
+	}
+	type BadAction struct {
+		CustomerID int64 `sql:"references=customers(id) on delete explode"`
+	}
+	type Restrict struct {
+		CustomerID int64 `sql:"references=customers(id) on delete restrict"`
+	}
+	tests := []struct {
+		dialect Dialect
+		row     interface{}
+		errText string
+	}{
+		{
+			dialect: Postgres,
+			row:     BadFormat{},
+			errText: `invalid reference "customers" for column customer_id: expected table(column)`,
+		},
+		{
+			dialect: Postgres,
+			row:     BadColumn{},
+			errText: `invalid reference "customers(a.id)" for column customer_id: expected table(column)`,
+		},
+		{
+			dialect: Postgres,
+			row:     BadAction{},
+			errText: `invalid on delete action "explode" for column customer_id`,
+		},
+		{
+			dialect: MSSQL,
+			row:     Restrict{},
+			errText: "on delete restrict for column customer_id is not supported by SQL Server: use no action",
+		},
+		{
+			dialect: MySQL,
+			row:     Restrict{},
+		},
+	}
+	for i, tt := range tests {
+		_, err := NewSchema(WithDialect(tt.dialect)).TableFor(tt.row).CreateTableSQL()
+		var errText string
+		if err != nil {
+			errText = err.Error()
+		}
+		if errText != tt.errText {
+			t.Errorf("%d: got=%q, want=%q", i, errText, tt.errText)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package sqlr
+
+import (
+	"context"
+	"reflect"
+)
+
+// Database error numbers and codes that indicate a deadlock.
+const (
+	mysqlDeadlockNumber      = 1213    // ER_LOCK_DEADLOCK
+	mssqlDeadlockNumber      = 1205    // chosen as deadlock victim
+	postgresDeadlockSQLState = "40P01" // deadlock_detected
+)
+
+// IsDeadlock reports whether err, or the error that caused it, was returned
+// by the database because the transaction was chosen as the victim of a
+// deadlock. Deadlocks are expected in high-concurrency workloads, and the
+// failed transaction can usually be retried successfully.
+//
+// IsDeadlock detects deadlock errors from the MySQL (github.com/go-sql-driver/mysql),
+// SQL Server (github.com/denisenkom/go-mssqldb) and PostgreSQL (github.com/lib/pq)
+// drivers, without this package depending on any of them.
+//
+// Deadlocks are distinct from serialization failures, which occur when a
+// transaction with serializable isolation cannot be committed.
+func IsDeadlock(err error) bool {
+	for err != nil {
+		if isDeadlockError(err) {
+			return true
+		}
+		err = errorCause(err)
+	}
+	return false
+}
+
+// RetryOnDeadlock calls fn, and calls it again if it fails with a deadlock
+// error, up to a maximum of maxAttempts calls in total. The function is always
+// called at least once, even if maxAttempts is less than one. The function should
+// perform the entire transaction, because the database rolls back a transaction
+// that is chosen as a deadlock victim.
+//
+// RetryOnDeadlock returns the error from the last call to fn. It stops retrying
+// if ctx is done, and returns the context's error.
+func RetryOnDeadlock(ctx context.Context, maxAttempts int, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt == 0 || attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+		}
+		if err = fn(ctx); !IsDeadlock(err) {
+			break
+		}
+	}
+	return err
+}
+
+// driverErrorType identifies a driver error type by its package path and name.
+type driverErrorType struct {
+	pkgPath string
+	name    string
+}
+
+// Driver error types that are inspected by field. They are variables
+// so that tests can substitute their own error types.
+var (
+	mysqlErrorType = driverErrorType{pkgPath: "github.com/go-sql-driver/mysql", name: "MySQLError"}
+	pqErrorType    = driverErrorType{pkgPath: "github.com/lib/pq", name: "Error"}
+)
+
+// isDeadlockError reports whether err is a deadlock error from one of
+// the supported database drivers.
+func isDeadlockError(err error) bool {
+	// github.com/denisenkom/go-mssqldb
+	if mssqlErr, ok := err.(interface{ SQLErrorNumber() int32 }); ok {
+		return mssqlErr.SQLErrorNumber() == mssqlDeadlockNumber
+	}
+
+	// github.com/lib/pq (recent versions)
+	if pqErr, ok := err.(interface{ SQLState() string }); ok {
+		return pqErr.SQLState() == postgresDeadlockSQLState
+	}
+
+	// The MySQL driver error, and the PostgreSQL error for older versions
+	// of the lib/pq driver, do not have methods that provide the error
+	// number/code. Inspect the fields instead.
+	v := reflect.ValueOf(err)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	switch (driverErrorType{pkgPath: v.Type().PkgPath(), name: v.Type().Name()}) {
+	case mysqlErrorType:
+		if number := v.FieldByName("Number"); number.IsValid() && number.Kind() == reflect.Uint16 {
+			return number.Uint() == mysqlDeadlockNumber
+		}
+	case pqErrorType:
+		if code := v.FieldByName("Code"); code.IsValid() && code.Kind() == reflect.String {
+			return code.String() == postgresDeadlockSQLState
+		}
+	}
+	return false
+}
+
+// errorCause returns the error that caused err, or nil if there is none.
+func errorCause(err error) error {
+	switch e := err.(type) {
+	case interface{ Cause() error }:
+		return e.Cause()
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	}
+	return nil
+}
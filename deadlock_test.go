@@ -0,0 +1,174 @@
+package sqlr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jjeffery/kv"
+)
+
+// fakeMySQLError has the same fields as the MySQL driver error.
+type fakeMySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *fakeMySQLError) Error() string {
+	return fmt.Sprintf("Error %d: %s", e.Number, e.Message)
+}
+
+// fakePQError has the same fields as the lib/pq driver error.
+type fakePQError struct {
+	Code    string
+	Message string
+}
+
+func (e fakePQError) Error() string {
+	return "pq: " + e.Message
+}
+
+// useFakeDriverErrors substitutes the fake error types for the driver
+// error types that are inspected by field. Call the returned function
+// to restore the driver error types.
+func useFakeDriverErrors() func() {
+	mysqlType, pqType := mysqlErrorType, pqErrorType
+	mysqlErrorType = driverErrorTypeOf(fakeMySQLError{})
+	pqErrorType = driverErrorTypeOf(fakePQError{})
+	return func() {
+		mysqlErrorType, pqErrorType = mysqlType, pqType
+	}
+}
+
+func driverErrorTypeOf(v interface{}) driverErrorType {
+	t := reflect.TypeOf(v)
+	return driverErrorType{pkgPath: t.PkgPath(), name: t.Name()}
+}
+
+// mssqlError has the same method as the SQL Server driver error.
+type mssqlError struct {
+	Number int32
+}
+
+func (e mssqlError) Error() string {
+	return fmt.Sprintf("mssql: error %d", e.Number)
+}
+
+func (e mssqlError) SQLErrorNumber() int32 {
+	return e.Number
+}
+
+func TestIsDeadlock(t *testing.T) {
+	defer useFakeDriverErrors()()
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: errors.New("deadlock"), want: false},
+		{err: &fakeMySQLError{Number: 1213, Message: "Deadlock found"}, want: true},
+		{err: &fakeMySQLError{Number: 1205, Message: "Lock wait timeout"}, want: false},
+		{err: mssqlError{Number: 1205}, want: true},
+		{err: mssqlError{Number: 1213}, want: false},
+		{err: fakePQError{Code: "40P01"}, want: true},
+		{err: fakePQError{Code: "40001"}, want: false},
+		{err: kv.Wrap(&fakeMySQLError{Number: 1213}, "cannot update"), want: true},
+		{err: (*fakeMySQLError)(nil), want: false},
+	}
+	for i, tt := range tests {
+		if got, want := IsDeadlock(tt.err), tt.want; got != want {
+			t.Errorf("%d: %v: got=%v, want=%v", i, tt.err, got, want)
+		}
+	}
+}
+
+func TestIsDeadlockPkgPath(t *testing.T) {
+	// error types with the fields of the driver errors, but declared
+	// in a different package, are not inspected
+	for i, err := range []error{&fakeMySQLError{Number: 1213}, fakePQError{Code: "40P01"}} {
+		if IsDeadlock(err) {
+			t.Errorf("%d: %v: got=true, want=false", i, err)
+		}
+	}
+}
+
+func TestRetryOnDeadlock(t *testing.T) {
+	defer useFakeDriverErrors()()
+	deadlock := &fakeMySQLError{Number: 1213, Message: "Deadlock found"}
+	otherErr := errors.New("other error")
+
+	tests := []struct {
+		errs      []error // error returned by each call
+		attempts  int
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			errs:      []error{nil},
+			attempts:  3,
+			wantCalls: 1,
+		},
+		{
+			errs:      []error{deadlock, deadlock, nil},
+			attempts:  3,
+			wantCalls: 3,
+		},
+		{
+			errs:      []error{deadlock, deadlock, deadlock},
+			attempts:  3,
+			wantCalls: 3,
+			wantErr:   deadlock,
+		},
+		{
+			errs:      []error{deadlock, otherErr, nil},
+			attempts:  3,
+			wantCalls: 2,
+			wantErr:   otherErr,
+		},
+		{
+			// fn is always called
+			errs:      []error{nil},
+			attempts:  0,
+			wantCalls: 1,
+		},
+		{
+			errs:      []error{deadlock, nil},
+			attempts:  -1,
+			wantCalls: 1,
+			wantErr:   deadlock,
+		},
+	}
+	for i, tt := range tests {
+		var calls int
+		err := RetryOnDeadlock(context.Background(), tt.attempts, func(ctx context.Context) error {
+			err := tt.errs[calls]
+			calls++
+			return err
+		})
+		if got, want := calls, tt.wantCalls; got != want {
+			t.Errorf("%d: calls: got=%d, want=%d", i, got, want)
+		}
+		if got, want := err, tt.wantErr; got != want {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+	}
+}
+
+func TestRetryOnDeadlockCanceled(t *testing.T) {
+	defer useFakeDriverErrors()()
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	err := RetryOnDeadlock(ctx, 5, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return &fakeMySQLError{Number: 1213}
+	})
+	if got, want := err, context.Canceled; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := calls, 1; got != want {
+		t.Errorf("calls: got=%d, want=%d", got, want)
+	}
+}
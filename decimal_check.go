@@ -0,0 +1,59 @@
+package sqlr
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var nullFloat64Type = reflect.TypeOf(sql.NullFloat64{})
+
+// checkDecimalOutputs returns an error if any of the result columns has a
+// decimal database type, and the column is scanned into a floating point
+// field. Floating point values cannot represent all decimal values exactly,
+// so precision can be lost without any indication. This check is only made
+// if the schema has the WithDecimalPrecisionCheck option.
+func checkDecimalOutputs(rows *sql.Rows, outputs []*Column) error {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	for i, columnType := range columnTypes {
+		if i >= len(outputs) || outputs[i] == nil || outputs[i].JSON() {
+			continue
+		}
+		if !isDecimalTypeName(columnType.DatabaseTypeName()) {
+			continue
+		}
+		col := outputs[i]
+		if isFloatType(col.fieldType()) {
+			return fmt.Errorf("cannot scan %s column %q into float field %q: precision may be lost, use a decimal type",
+				strings.ToLower(columnType.DatabaseTypeName()), columnType.Name(), col.info.FieldNames)
+		}
+	}
+	return nil
+}
+
+// isDecimalTypeName reports whether the database type name returned by
+// the driver is for an exact decimal type.
+func isDecimalTypeName(name string) bool {
+	switch strings.ToUpper(name) {
+	case "DECIMAL", "NUMERIC", "NEWDECIMAL", "MONEY", "SMALLMONEY":
+		return true
+	}
+	return false
+}
+
+// isFloatType reports whether t is a floating point type, a pointer to a
+// floating point type, or sql.NullFloat64.
+func isFloatType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return true
+	}
+	return t == nullFloat64Type
+}
@@ -0,0 +1,58 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestDecimalPrecisionCheck(t *testing.T) {
+	type FloatRow struct {
+		ID     int64 `sql:"primary key"`
+		Amount float64
+	}
+	type NullFloatRow struct {
+		ID     int64 `sql:"primary key"`
+		Amount sql.NullFloat64
+	}
+	type StringRow struct {
+		ID     int64 `sql:"primary key"`
+		Amount string
+	}
+	db := openRowsDB(&rowsConn{
+		columns: []string{"id", "amount"},
+		types:   []string{"INTEGER", "DECIMAL"},
+		values:  [][]driver.Value{{int64(1), []byte("12.34")}},
+	})
+	defer db.Close()
+
+	// without the option, the decimal is scanned into the float
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+	var rows []FloatRow
+	_, err := sess.Select(&rows, "select {} from amounts")
+	wantNoError(t, err)
+	if len(rows) != 1 || rows[0].Amount != 12.34 {
+		t.Errorf("got=%+v", rows)
+	}
+
+	sess = NewSession(context.Background(), db, NewSchema(WithDialect(Postgres), WithDecimalPrecisionCheck()))
+	defer sess.Close()
+	const wantErr = `cannot scan decimal column "amount" into float field "Amount": precision may be lost, use a decimal type`
+	for _, dest := range []interface{}{&[]FloatRow{}, &FloatRow{}, &[]*NullFloatRow{}} {
+		_, err = sess.Select(dest, "select {} from amounts")
+		if err == nil {
+			t.Errorf("%T: got=nil, want=error", dest)
+		} else if got := err.Error(); got != wantErr {
+			t.Errorf("%T: got=%q, want=%q", dest, got, wantErr)
+		}
+	}
+
+	var stringRows []StringRow
+	_, err = sess.Select(&stringRows, "select {} from amounts")
+	wantNoError(t, err)
+	if len(stringRows) != 1 || stringRows[0].Amount != "12.34" {
+		t.Errorf("got=%+v", stringRows)
+	}
+}
@@ -3,6 +3,7 @@ package sqlr
 import (
 	"database/sql"
 	"database/sql/driver"
+	"strings"
 	"sync"
 
 	"github.com/jjeffery/sqlr/private/dialect"
@@ -29,6 +30,7 @@ var (
 	Postgres Dialect // Quote: "column_name", Placeholders: $1, $2, $3
 	MySQL    Dialect // Quote: `column_name`, Placeholders: ?, ?, ?
 	MSSQL    Dialect // Quote: [column_name], Placeholders: ?, ?, ?
+	Oracle   Dialect // Quote: "COLUMN_NAME", Placeholders: :1, :2, :3
 	SQLite   Dialect // Quote: `column_name`, Placeholders: ?, ?, ?
 	ANSISQL  Dialect // Quote: "column_name", Placeholders: ?, ?, ?
 )
@@ -69,6 +71,8 @@ func DefaultDialect() Dialect {
 				defaultDialect = SQLite
 			case "mssql":
 				defaultDialect = MSSQL
+			case "godror", "oracle":
+				defaultDialect = Oracle
 			}
 		}
 	})
@@ -85,9 +89,10 @@ func init() {
 	Postgres = dialect.Postgres
 	MySQL = dialect.MySQL
 	MSSQL = dialect.MSSQL
+	Oracle = dialect.Oracle
 	SQLite = dialect.SQLite
 	ANSISQL = dialect.ANSI
-	allDialects = []Dialect{Postgres, MySQL, MSSQL, SQLite, ANSISQL}
+	allDialects = []Dialect{Postgres, MySQL, MSSQL, Oracle, SQLite, ANSISQL}
 }
 
 func dialectFor(db *sql.DB) Dialect {
@@ -114,3 +119,57 @@ func isPostgres(dialect Dialect) bool {
 	}
 	return false
 }
+
+// Names of the pre-defined dialects, as returned by dialectName.
+const (
+	dialectPostgres = "postgres"
+	dialectMySQL    = "mysql"
+	dialectMSSQL    = "mssql"
+	dialectOracle   = "oracle"
+	dialectSQLite   = "sqlite"
+	dialectANSI     = "ansi"
+)
+
+// dialectName returns the name of the pre-defined dialect, which is used
+// when generating SQL that is specific to a dialect. Returns an empty string
+// if the dialect is not one of the pre-defined dialects.
+func dialectName(dialect Dialect) string {
+	if isPostgres(dialect) {
+		return dialectPostgres
+	}
+	switch dialect {
+	case MySQL:
+		return dialectMySQL
+	case MSSQL:
+		return dialectMSSQL
+	case Oracle:
+		return dialectOracle
+	case SQLite:
+		return dialectSQLite
+	case ANSISQL:
+		return dialectANSI
+	}
+	return ""
+}
+
+// dialectMatches reports whether the dialect matches one of the
+// names in the list. The names are compared with the pre-defined
+// dialect names. An empty list matches all dialects.
+func dialectMatches(dialect Dialect, names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	name := dialectName(dialect)
+	for _, n := range names {
+		switch n = strings.ToLower(n); n {
+		case "postgresql", "pg":
+			n = dialectPostgres
+		case "sqlite3":
+			n = dialectSQLite
+		}
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -20,6 +20,11 @@ func TestDialect(t *testing.T) {
 			quoted:      `"quoted"`,
 			placeholder: "$1",
 		},
+		{
+			dialect:     Oracle,
+			quoted:      `"quoted"`,
+			placeholder: ":1",
+		},
 	}
 
 	for _, tt := range tests {
@@ -116,6 +116,48 @@ It is an important point to note that this feature is not about writing the SQL
 Rather it is about "filling in the blanks": allowing the programmer to specify as much of the
 SQL query as they want without having to write the tiresome bits.
 
+The text inside the curly braces can modify the list of columns. The default list
+depends on the clause: "{}" in the select list expands to every column, whereas in an
+insert statement it excludes the autoincrement column, and in a where clause it
+expands to the primary key columns only.
+ {alias u}       // prefix each column with the alias "u"
+ {pk}            // primary key columns only
+ {all}           // every column, including the autoincrement column
+ {exclude name}  // every column in the default list except "name"
+The "{all}" token means the same thing in every clause, so "select {all} from users"
+is equivalent to "select {} from users", and "insert into users({all}) values({})"
+includes a value for the autoincrement column.
+
+When a statement needs the value of one field in an unusual position, the "{field Name}"
+token expands to a single placeholder bound to the named field of the row. Fields in
+embedded structs are named using a dotted path, eg "{field Address.Street}".
+ update counters set total = total + {field Delta}, modified_by = ? where {}
+
+Result columns are usually matched with the row's columns by name. Where a query
+returns columns without useful names, such as computed columns or duplicate names,
+the "{ordinal}" token maps the result columns to the row's fields in declaration order.
+The query must return one column for each column of the row.
+ select {ordinal} count(*), max(total), max(total) from orders
+
+The "{table}" token expands to the quoted name of the table for the row type, so that
+the table name does not need to be repeated in the query text. The "{table u}" form is
+followed by the alias "u". The "{table}" token cannot be used for a row type with a
+ShardFunc, because the table name depends on the row.
+ select {alias u} from {table u} where u.parent_id in (select id from {table} where name = ?)
+
+The "{for share}" token locks the selected rows with a shared lock until the end of the
+transaction, so other transactions can read the rows but cannot update or delete them.
+It is rendered as "for share" for PostgreSQL and "lock in share mode" for MySQL, and is
+an error for other dialects. A query with "{for share}" must be run in a transaction.
+ select {} from accounts where id = ? {for share}
+
+The "{limit n}" and "{limit n offset m}" tokens limit the number of rows returned by a
+select query, using the syntax appropriate for the dialect. For SQL Server, a limit with
+no offset in a query without an order by clause, or a limit of zero, is rendered as
+"select top (n)", and otherwise as "offset m rows fetch next n rows only". Oracle always uses the
+"offset m rows fetch next n rows only" form.
+ select {} from users where postcode = ? order by family_name {limit 20 offset 40}
+
 Autoincrement Column Values
 
 When inserting rows, if a column is defined as an autoincrement column, then the generated
@@ -135,7 +177,19 @@ structure will be updated.
  // row.ID will contain the auto-generated value
  fmt.Println(row.ID)
 Autoincrement column values work for all supported databases (PostgreSQL, MySQL,
-Microsoft SQL Server and SQLite).
+Microsoft SQL Server, Oracle and SQLite). For Oracle the value is read back using
+"returning ... into", so the column should be an identity column, or be set from a
+sequence by a trigger.
+
+Columns whose values are generated by the database, such as a column with a default
+of "now()", are marked with the "generated" keyword. These columns are not included
+in insert statements. For PostgreSQL, the values of the auto-increment column and all
+generated columns are read back into the row with a single "returning" clause.
+ type Row {
+   ID        int       `sql:"primary key autoincrement"`
+   Name      string
+   Inserted  time.Time `sql:"inserted generated"`
+ }
 
 Null Columns
 
@@ -160,6 +214,53 @@ Care should be taken, because there are cases where a zero value and a database
 represent the same thing. There are many cases, however, where this feature can be applied,
 and the result is simpler code that is easier to read.
 
+Flags are sometimes stored in integer columns as 0 and 1. A bool field marked with the
+"intbool" keyword, eg `sql:"active intbool"`, is stored as 1 for true and 0 for false, and any
+non-zero value is scanned as true. The WithIntBools schema option does the same for
+every bool field.
+
+A time.Time field marked with the "date" keyword, eg `sql:"born date"`, is stored in a DATE
+column. The date is passed to the database as text in the form "2006-01-02", so that it
+is not shifted by time zone conversions, and it is scanned with the time portion set to
+midnight UTC.
+
+Keywords such as "date", "deleted" and "comment" were once valid column names, so when
+one of these keywords is the first word in a tag, eg `sql:"date null"`, it names the column.
+To use the keyword as an option, give the column name first, as in the examples above.
+Keywords that take a value, such as `sql:"epoch=ms"`, are options in any position.
+
+Some tables store times as integer Unix times. A time.Time field marked with the "epoch"
+keyword, eg `sql:"seen epoch"`, is stored as the number of seconds since the Unix epoch, and
+`sql:"seen epoch=ms"` stores the number of milliseconds. The time is scanned in UTC.
+
+Soft Deletes
+
+A time.Time or *time.Time field marked with the "deleted" keyword, eg `sql:"deleted_at deleted"`,
+identifies a column that records when a row was deleted. The column is null for rows that
+have not been deleted. For these tables DeleteRow sets the column to the current time
+instead of removing the row, and the rows that have been deleted are excluded by GetRow,
+SelectByKeys and the functions created by MakeQuery. The WithSoftDelete(false) schema
+option includes deleted rows in these queries. Queries passed to Select are not modified.
+ type Document struct {
+     ID        int        `sql:"primary key"`
+     Title     string
+     DeletedAt *time.Time `sql:"deleted_at deleted"`
+ }
+
+Struct Columns
+
+A struct field that is not anonymous is mapped to the columns of its fields, and the
+column names are formed by joining the name of the struct field with the names of its
+fields, eg `address_street`. A different prefix can be specified using the "prefix"
+keyword. The "embed" keyword maps the fields of a struct type even if it implements
+sql.Scanner.
+ type Customer struct {
+     ID      int     `sql:"primary key"`
+     Address Address `sql:"address embed prefix=addr_"`
+ }
+In the above example the fields of the Address struct are mapped to the `addr_street`
+and `addr_city` columns.
+
 JSON Columns
 
 It is not uncommon to serialize complex objects as JSON text for storage in an SQL database.
@@ -183,6 +284,47 @@ In the example above the `Cmplx` field will be marshaled as JSON text when
 writing to the database, and unmarshaled into the struct when reading from
 the database.
 
+For PostgreSQL, the "jsonb" keyword, eg `sql:"jsonb"`, casts the value written to
+the column to jsonb (eg "$1::jsonb"), which is needed when the value is compared using
+the jsonb operators or used in an expression index. For other dialects "jsonb" is
+the same as "json".
+
+Large JSON documents can be compressed using the "gzip" keyword, eg `sql:"json gzip"`.
+The JSON text is gzip-compressed when writing to the database and decompressed when
+reading, so the column must be a binary type such as BYTEA or BLOB.
+
+Fields can be stored using an encoding other than JSON, such as gob or msgpack,
+or encrypted, by specifying a Codec for the field in its ColumnConfig:
+ schema := sqlr.NewSchema(
+     sqlr.WithTables(sqlr.TablesConfig{
+         (*Row)(nil): {
+             Columns: sqlr.ColumnsConfig{
+                 "Payload": {Codec: myGobCodec},
+             },
+         },
+     }),
+ )
+A field with a codec is stored in a single binary column, even if it is a struct,
+map or slice.
+
+For PostgreSQL, a field of type map[string]string can be stored in an hstore column
+using the "hstore" keyword, eg `sql:"attrs hstore"`. A NULL hstore is scanned as a nil map.
+
+Dialect-Specific Columns
+
+Occasionally a column only exists in some databases. For example, a table
+might have a full-text search column in Postgres that has no equivalent in
+SQLite. A column can be restricted to one or more dialects using the
+"dialect" keyword in the field's struct tag:
+ type Document struct {
+     ID           int    `sql:"primary key"`
+     Body         string
+     SearchVector string `sql:"dialect=postgres"`
+ }
+In the above example the `search_vector` column is only included in generated
+SQL when the schema dialect is Postgres. Multiple dialects are separated by
+a vertical bar, eg "dialect=postgres|mysql".
+
 WHERE IN Clauses with Multiple Values
 
 While most SQL queries accept a fixed number of parameters, if the SQL query
@@ -204,6 +346,10 @@ In the above example, the number of placeholders ("?") in the query will be incr
 match the number of values in the `ids` slice. The expansion logic can handle any mix of
 slice and scalar arguments.
 
+If a slice is empty, its placeholder is replaced with NULL, so the query is still valid
+SQL and the IN clause matches no rows. To match on a field of a slice of structs, use the
+SliceField function, eg `sqlr.SliceField(customers, "ID")`.
+
 Type-Safe Query Functions
 
 A session can create type-safe query functions. This is a very powerful feature and makes
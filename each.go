@@ -0,0 +1,81 @@
+package sqlr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	rawBytesType = reflect.TypeOf(sql.RawBytes(nil))
+	bytesPtrType = reflect.TypeOf((*[]byte)(nil))
+)
+
+// Each executes a SELECT query and calls fn once for each row returned.
+// The fn argument must be a function with the signature
+//  func(row *Row) error
+// where Row is a struct type. If fn returns an error, no more rows are read
+// and Each returns that error. Each returns the number of rows read.
+//
+// Each is useful for processing large result sets without holding all
+// of the rows in memory at the same time. Fields of type sql.RawBytes are
+// scanned without copying the data from the database driver. This is
+// efficient for large text and blob columns, but the contents of these fields
+// are only valid until fn returns. If fn needs to retain a sql.RawBytes value,
+// it must make a copy.
+//
+// Row handlers registered with HandleRows are not called for rows read by Each.
+func (sess *Session) Each(fn interface{}, query string, args ...interface{}) (int, error) {
+	if fn == nil {
+		return 0, errors.New("nil func")
+	}
+	fnValue := reflect.ValueOf(fn)
+	fnType := fnValue.Type()
+	if fnType.Kind() != reflect.Func ||
+		fnType.NumIn() != 1 ||
+		fnType.In(0).Kind() != reflect.Ptr ||
+		fnType.In(0).Elem().Kind() != reflect.Struct ||
+		fnType.NumOut() != 1 ||
+		fnType.Out(0) != wellKnownTypes.errorType {
+		return 0, fmt.Errorf("expected fn to be func(*Row) error, got %s", fnType)
+	}
+	stmt, err := sess.schema.Prepare(reflect.Zero(fnType.In(0)).Interface(), query)
+	if err != nil {
+		return 0, err
+	}
+	return stmt.scanRows(sess.context, sess.querier, args, scanOptions{zeroCopy: true}, func(rowValuePtr reflect.Value) error {
+		if errValue := fnValue.Call([]reflect.Value{rowValuePtr})[0]; !errValue.IsNil() {
+			return errValue.Interface().(error)
+		}
+		return nil
+	})
+}
+
+// SelectReuse executes a SELECT query and calls fn once for each row
+// returned. Every row is scanned into the same row value, so no memory is
+// allocated for each row. The row value passed to fn is a pointer to a struct
+// of the same type as rowType, which can be a struct or a pointer to a struct.
+// The row is reset to its zero value before each row is scanned.
+//
+// SelectReuse is intended for high-throughput scans where fn copies any
+// field values that it needs. Because the same row value is reused, fn must
+// not retain the row pointer, or any pointer into the row, after it returns.
+// As with Each, fields of type sql.RawBytes are only valid until fn returns.
+//
+// If fn returns an error, no more rows are read and SelectReuse returns
+// that error.
+func (sess *Session) SelectReuse(rowType interface{}, fn func(row interface{}) error, query string, args ...interface{}) error {
+	if fn == nil {
+		return errors.New("nil func")
+	}
+	stmt, err := sess.schema.Prepare(rowType, query)
+	if err != nil {
+		return err
+	}
+	opts := scanOptions{zeroCopy: true, reuseRow: true}
+	_, err = stmt.scanRows(sess.context, sess.querier, args, opts, func(rowValuePtr reflect.Value) error {
+		return fn(rowValuePtr.Interface())
+	})
+	return err
+}
@@ -0,0 +1,316 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEachErrors(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	queryErr := errors.New("query error")
+	sess := NewSession(context.Background(), &FakeDB{queryErr: queryErr}, NewSchema())
+	defer sess.Close()
+
+	tests := []struct {
+		fn      interface{}
+		errText string
+	}{
+		{
+			fn:      nil,
+			errText: "nil func",
+		},
+		{
+			fn:      func(row Row) error { return nil },
+			errText: "expected fn to be func(*Row) error, got func(sqlr.Row) error",
+		},
+		{
+			fn:      func(row *Row) {},
+			errText: "expected fn to be func(*Row) error, got func(*sqlr.Row)",
+		},
+		{
+			fn:      func(row *Row) error { return nil },
+			errText: "query error",
+		},
+	}
+	for i, tt := range tests {
+		_, err := sess.Each(tt.fn, "select {} from rows")
+		if err == nil {
+			t.Errorf("%d: got=nil, want=%q", i, tt.errText)
+			continue
+		}
+		if got, want := err.Error(), tt.errText; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestSelectReuse(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 3)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+	defer sess.Close()
+
+	var rowPtr *Row
+	var ids []int64
+	err := sess.SelectReuse(Row{}, func(row interface{}) error {
+		r := row.(*Row)
+		if rowPtr == nil {
+			rowPtr = r
+		} else if r != rowPtr {
+			t.Error("row was not reused")
+		}
+		ids = append(ids, r.ID)
+		if got, want := r.Name, "name"; got != want {
+			t.Errorf("got=%v, want=%v", got, want)
+		}
+		return nil
+	}, "select {} from rows")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := fmt.Sprint(ids), "[1 2 3]"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	errStop := errors.New("stop")
+	var count int
+	err = sess.SelectReuse(&Row{}, func(row interface{}) error {
+		count++
+		return errStop
+	}, "select {} from rows")
+	if got, want := err, errStop; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := count, 1; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+}
+
+func TestSelectReuseAllocs(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	// extraAllocs returns the number of allocations made reading extraRows
+	// more rows. AllocsPerRun counts the allocations of every goroutine, and
+	// the runtime occasionally allocates during a run, so an allocation for
+	// each row is detected by comparing with the number of rows, not zero.
+	const extraRows = 1000
+	extraAllocs := func(fn func(sess *Session)) int {
+		allocs := func(rowCount int) int {
+			db := rowsDB(t, rowCount)
+			defer db.Close()
+			sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+			defer sess.Close()
+			fn(sess) // warm up the statement cache and connection pool
+			return int(testing.AllocsPerRun(10, func() { fn(sess) }))
+		}
+		return allocs(10+extraRows) - allocs(10)
+	}
+
+	reuse := extraAllocs(func(sess *Session) {
+		err := sess.SelectReuse(Row{}, func(row interface{}) error { return nil }, "select {} from rows")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	if reuse >= extraRows {
+		t.Errorf("SelectReuse: got %d allocations for %d rows, want none per row", reuse, extraRows)
+	}
+
+	each := extraAllocs(func(sess *Session) {
+		_, err := sess.Each(func(row *Row) error { return nil }, "select {} from rows")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	if each < extraRows {
+		t.Errorf("Each: got %d allocations for %d rows, want at least one per row", each, extraRows)
+	}
+}
+
+func BenchmarkSelectReuse(b *testing.B) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(b, 1000)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+	defer sess.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := sess.SelectReuse(Row{}, func(row interface{}) error { return nil }, "select {} from rows")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEach(b *testing.B) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(b, 1000)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+	defer sess.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := sess.Each(func(row *Row) error { return nil }, "select {} from rows")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// rowsDB returns a database that returns rowCount rows with columns
+// "id" and "name" for any query. It is used for testing the scanning of
+// rows without requiring a database server.
+func rowsDB(tb testing.TB, rowCount int) *sql.DB {
+	tb.Helper()
+	db, err := sql.Open(rowsDriverName, strconv.Itoa(rowCount))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+const rowsDriverName = "sqlr-rows"
+
+func init() {
+	sql.Register(rowsDriverName, rowsDriver{})
+}
+
+type rowsDriver struct{}
+
+// rowsDBWithColumns is similar to rowsDB, but the columns returned
+// have the names specified. The first column is the id, and the
+// second column (if any) is the name.
+func rowsDBWithColumns(tb testing.TB, rowCount int, columns ...string) *sql.DB {
+	tb.Helper()
+	db, err := sql.Open(rowsDriverName, fmt.Sprintf("%d;%s", rowCount, strings.Join(columns, ",")))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+func (rowsDriver) Open(name string) (driver.Conn, error) {
+	columns := []string{"id", "name"}
+	if i := strings.IndexByte(name, ';'); i >= 0 {
+		columns = strings.Split(name[i+1:], ",")
+		name = name[:i]
+	}
+	rowCount, err := strconv.Atoi(name)
+	if err != nil {
+		return nil, err
+	}
+	// box the values once, so the driver does not allocate for each row
+	ids := make([]driver.Value, rowCount)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	return &rowsConn{ids: ids, name: "name", columns: columns}, nil
+}
+
+// openRowsDB returns a database that returns the rows described by
+// conn for any query. Set the values field for rows other than the id
+// and name rows returned by rowsDB, and the types field to report the
+// database type name of each column.
+func openRowsDB(conn *rowsConn) *sql.DB {
+	return sql.OpenDB(rowsConnector{conn: conn})
+}
+
+type rowsConnector struct {
+	conn *rowsConn
+}
+
+func (c rowsConnector) Connect(ctx context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c rowsConnector) Driver() driver.Driver                            { return rowsDriver{} }
+
+type rowsConn struct {
+	ids      []driver.Value
+	name     driver.Value
+	columns  []string
+	types    []string         // database type name of each column, if known
+	values   [][]driver.Value // values of each row, instead of ids and name
+	queryErr error            // error returned by every query, if not nil
+}
+
+func (c *rowsConn) Prepare(query string) (driver.Stmt, error) { return &rowsStmt{conn: c}, nil }
+func (c *rowsConn) Close() error                              { return nil }
+func (c *rowsConn) Begin() (driver.Tx, error)                 { return rowsTx{}, nil }
+
+// rowsTx is a transaction that does nothing, as the rows are read-only.
+type rowsTx struct{}
+
+func (rowsTx) Commit() error   { return nil }
+func (rowsTx) Rollback() error { return nil }
+
+type rowsStmt struct {
+	conn *rowsConn
+}
+
+func (s *rowsStmt) Close() error  { return nil }
+func (s *rowsStmt) NumInput() int { return -1 }
+func (s *rowsStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s *rowsStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.conn.queryErr != nil {
+		return nil, s.conn.queryErr
+	}
+	return &rowsRows{conn: s.conn}, nil
+}
+
+type rowsRows struct {
+	conn  *rowsConn
+	index int
+}
+
+func (r *rowsRows) Columns() []string { return r.conn.columns }
+func (r *rowsRows) Close() error      { return nil }
+func (r *rowsRows) Next(dest []driver.Value) error {
+	if r.conn.values != nil {
+		if r.index >= len(r.conn.values) {
+			return io.EOF
+		}
+		copy(dest, r.conn.values[r.index])
+		r.index++
+		return nil
+	}
+	if r.index >= len(r.conn.ids) {
+		return io.EOF
+	}
+	dest[0] = r.conn.ids[r.index]
+	if len(dest) > 1 {
+		dest[1] = r.conn.name
+	}
+	r.index++
+	return nil
+}
+
+func (r *rowsRows) ColumnTypeDatabaseTypeName(index int) string {
+	if index < len(r.conn.types) {
+		return r.conn.types[index]
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package sqlr
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// enumCell is used to scan a column that has a set of permitted values.
+// The value returned by the database is checked against the permitted
+// values before it is scanned into the field, so that a database value
+// unknown to the program (eg a label added to a Postgres enum type) is
+// reported as an error rather than silently stored in the field.
+type enumCell struct {
+	col       *Column
+	cellValue reflect.Value
+	cell      interface{}
+}
+
+func newEnumCell(col *Column, cellValue reflect.Value, cell interface{}) *enumCell {
+	return &enumCell{
+		col:       col,
+		cellValue: cellValue,
+		cell:      cell,
+	}
+}
+
+// Scan implements the sql.Scanner interface.
+func (c *enumCell) Scan(src interface{}) error {
+	var label string
+	switch v := src.(type) {
+	case nil:
+		// NULL is permitted, the database constraint decides
+	case []byte:
+		label = string(v)
+	case string:
+		label = v
+	default:
+		label = fmt.Sprint(v)
+	}
+	if src != nil && !c.col.permitted(label) {
+		return fmt.Errorf("unknown value %q for column %q: must be one of %s",
+			label, c.col.Name(), strings.Join(c.col.Enum(), ", "))
+	}
+	if scanner, ok := c.cell.(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+
+	// The cell is a pointer to the field, which happens when the field is
+	// a pointer. Scan into a new value and point the field at it.
+	if c.cellValue.Kind() != reflect.Ptr {
+		return fmt.Errorf("cannot scan column %q into %s", c.col.Name(), c.cellValue.Type())
+	}
+	if src == nil {
+		c.cellValue.Set(reflect.Zero(c.cellValue.Type()))
+		return nil
+	}
+	newValue := reflect.New(c.cellValue.Type().Elem())
+	scanner, ok := newNullCell(c.col.Name(), newValue.Elem(), newValue.Interface()).(sql.Scanner)
+	if !ok {
+		return fmt.Errorf("cannot scan column %q into %s", c.col.Name(), c.cellValue.Type())
+	}
+	if err := scanner.Scan(src); err != nil {
+		return err
+	}
+	c.cellValue.Set(newValue)
+	return nil
+}
+
+// enumLabel returns the label that is sent to the database for the field
+// value. This is the underlying string for string types, even if the type
+// has a String method, because that is what the driver sends.
+func enumLabel(fieldValue reflect.Value) (string, error) {
+	if valuer, ok := fieldValue.Interface().(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		switch v := v.(type) {
+		case []byte:
+			return string(v), nil
+		case string:
+			return v, nil
+		default:
+			return fmt.Sprint(v), nil
+		}
+	}
+	if fieldValue.Kind() == reflect.String {
+		return fieldValue.String(), nil
+	}
+	return fmt.Sprint(fieldValue.Interface()), nil
+}
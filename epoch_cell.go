@@ -0,0 +1,88 @@
+package sqlr
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// epochCell is used to scan an integer column containing a Unix time into
+// a time.Time field. The unit is time.Second or time.Millisecond, and the
+// field is set to the time in UTC.
+type epochCell struct {
+	colname   string
+	cellValue reflect.Value
+	unit      time.Duration
+}
+
+func newEpochCell(colname string, cellValue reflect.Value, unit time.Duration) *epochCell {
+	return &epochCell{colname: colname, cellValue: cellValue, unit: unit}
+}
+
+// Scan implements the sql.Scanner interface.
+func (c *epochCell) Scan(src interface{}) error {
+	if src == nil {
+		c.cellValue.Set(reflect.Zero(c.cellValue.Type()))
+		return nil
+	}
+	var n int64
+	switch v := src.(type) {
+	case int64:
+		n = v
+	case float64:
+		n = int64(v)
+	case []byte:
+		var err error
+		if n, err = strconv.ParseInt(string(v), 10, 64); err != nil {
+			return fmt.Errorf("cannot scan column %q: %v", c.colname, err)
+		}
+	case string:
+		var err error
+		if n, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return fmt.Errorf("cannot scan column %q: %v", c.colname, err)
+		}
+	default:
+		return fmt.Errorf("cannot scan column %q: unsupported type %T for epoch time", c.colname, src)
+	}
+	t := epochTime(n, c.unit)
+	if c.cellValue.Kind() == reflect.Ptr {
+		c.cellValue.Set(reflect.ValueOf(&t))
+	} else {
+		c.cellValue.Set(reflect.ValueOf(t))
+	}
+	return nil
+}
+
+// epochTime returns the UTC time for n units since the Unix epoch.
+func epochTime(n int64, unit time.Duration) time.Time {
+	perSecond := int64(time.Second / unit)
+	return time.Unix(n/perSecond, (n%perSecond)*int64(unit)).UTC()
+}
+
+// epochArg returns the argument value for a time.Time field that is stored
+// in an integer column as the number of units since the Unix epoch, or nil
+// for a nil pointer. If emptyNull is set, the zero time is stored as NULL.
+func epochArg(fieldValue reflect.Value, emptyNull bool, unit time.Duration) interface{} {
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return nil
+		}
+		fieldValue = fieldValue.Elem()
+	}
+	t := fieldValue.Interface().(time.Time)
+	if emptyNull && t.IsZero() {
+		return nil
+	}
+	perSecond := int64(time.Second / unit)
+	return t.Unix()*perSecond + int64(t.Nanosecond())/int64(unit)
+}
+
+// timeArg returns the argument value for the time t in the column, which is
+// converted to a Unix time integer if the column has the "epoch" keyword.
+func (col *Column) timeArg(t time.Time) interface{} {
+	if col.epoch != 0 {
+		return epochArg(reflect.ValueOf(t), false, col.epoch)
+	}
+	return t
+}
@@ -0,0 +1,144 @@
+package sqlr
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEpochCellScan(t *testing.T) {
+	want := time.Date(2020, 3, 15, 10, 30, 45, 0, time.UTC)
+	wantMillis := want.Add(123 * time.Millisecond)
+	tests := []struct {
+		src  interface{}
+		unit time.Duration
+		want time.Time
+	}{
+		{src: int64(1584268245), unit: time.Second, want: want},
+		{src: float64(1584268245), unit: time.Second, want: want},
+		{src: "1584268245", unit: time.Second, want: want},
+		{src: []byte("1584268245"), unit: time.Second, want: want},
+		{src: int64(1584268245123), unit: time.Millisecond, want: wantMillis},
+		{src: []byte("1584268245123"), unit: time.Millisecond, want: wantMillis},
+		{src: int64(-1500), unit: time.Millisecond, want: time.Unix(-2, 500*int64(time.Millisecond)).UTC()},
+		{src: nil, unit: time.Second, want: time.Time{}},
+	}
+	for i, tt := range tests {
+		var field time.Time
+		var ptrField *time.Time
+		if err := newEpochCell("epoch", reflect.ValueOf(&field).Elem(), tt.unit).Scan(tt.src); err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if err := newEpochCell("epoch", reflect.ValueOf(&ptrField).Elem(), tt.unit).Scan(tt.src); err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if !field.Equal(tt.want) || field.Location() != time.UTC {
+			t.Errorf("%d: got=%v, want=%v", i, field, tt.want)
+		}
+		if tt.src == nil {
+			if ptrField != nil {
+				t.Errorf("%d: got=%v, want=nil", i, *ptrField)
+			}
+		} else if ptrField == nil || !ptrField.Equal(tt.want) {
+			t.Errorf("%d: got=%v, want=%v", i, ptrField, tt.want)
+		}
+	}
+
+	var field time.Time
+	for _, src := range []interface{}{"not a number", time.Now()} {
+		if err := newEpochCell("epoch", reflect.ValueOf(&field).Elem(), time.Second).Scan(src); err == nil {
+			t.Errorf("%v: got=nil, want=error", src)
+		}
+	}
+}
+
+func TestEpochArgs(t *testing.T) {
+	type Row struct {
+		ID       int64      `sql:"primary key"`
+		Seen     time.Time  `sql:"seen epoch"`
+		Expires  *time.Time `sql:"epoch=ms"`
+		Archived time.Time  `sql:"archived epoch null"`
+		Modified time.Time
+	}
+	local := time.FixedZone("AEST", 10*3600)
+	seen := time.Date(2020, 3, 15, 20, 30, 45, 999000000, local)
+	expires := time.Date(2020, 3, 15, 10, 30, 45, 123456789, time.UTC)
+	modified := time.Date(2020, 1, 2, 0, 30, 0, 0, local)
+	db := &FakeDB{rowsAffected: 1}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres), WithLastQuery()))
+	defer sess.Close()
+
+	row := Row{ID: 1, Seen: seen, Expires: &expires, Modified: modified}
+	wantNoError(t, sess.InsertRow(&row))
+	_, args := sess.LastQuery()
+	if want := []interface{}{int64(1), int64(1584268245), int64(1584268245123), nil, modified}; !reflect.DeepEqual(args, want) {
+		t.Errorf("got=%v\nwant=%v", args, want)
+	}
+
+	row = Row{ID: 2}
+	wantNoError(t, sess.InsertRow(&row))
+	_, args = sess.LastQuery()
+	if want := []interface{}{int64(2), time.Time{}.Unix(), nil, nil, time.Time{}}; !reflect.DeepEqual(args, want) {
+		t.Errorf("got=%v\nwant=%v", args, want)
+	}
+
+	for _, dialect := range []Dialect{Postgres, MySQL, SQLite, MSSQL} {
+		schema := NewSchema(WithDialect(dialect))
+		tbl := schema.TableFor(Row{})
+		query, err := tbl.CreateTableSQL()
+		wantNoError(t, err)
+		for _, name := range []string{"seen", "expires", "archived"} {
+			want := dialect.Quote(name) + " bigint"
+			if dialect == SQLite {
+				want = dialect.Quote(name) + " integer"
+			}
+			if !strings.Contains(query, want) {
+				t.Errorf("%s: want %q in %q", dialectName(dialect), want, query)
+			}
+		}
+		if got, want := fieldTypeFamily(tbl.Columns()[1]), familyInteger; got != want {
+			t.Errorf("got=%v, want=%v", got, want)
+		}
+	}
+}
+
+func TestEpochUnknownUnit(t *testing.T) {
+	type Row struct {
+		ID   int64     `sql:"primary key"`
+		Seen time.Time `sql:"epoch=us"`
+	}
+	_, err := NewSchemaE(WithTables(TablesConfig{
+		reflect.TypeOf(Row{}): {},
+	}))
+	if err == nil || !strings.Contains(err.Error(), `unknown epoch unit "us"`) {
+		t.Errorf("got err=%v, want unknown epoch unit", err)
+	}
+
+	// the error is returned by the call that uses the row type
+	sess := NewSession(context.Background(), &FakeDB{rowsAffected: 1}, NewSchema())
+	err = sess.InsertRow(&Row{ID: 1})
+	if err == nil || !strings.Contains(err.Error(), `unknown epoch unit "us"`) {
+		t.Errorf("got err=%v, want unknown epoch unit", err)
+	}
+	var rows []Row
+	_, err = sess.Select(&rows, "select {} from row")
+	if err == nil || !strings.Contains(err.Error(), `unknown epoch unit "us"`) {
+		t.Errorf("got err=%v, want unknown epoch unit", err)
+	}
+	var get func(id int64) (*Row, error)
+	err = sess.makeQueries(&get)
+	if err == nil || !strings.Contains(err.Error(), `unknown epoch unit "us"`) {
+		t.Errorf("got err=%v, want unknown epoch unit", err)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("want panic for unknown epoch unit in TableFor")
+		}
+	}()
+	NewSchema().TableFor(Row{})
+}
@@ -0,0 +1,60 @@
+package sqlr
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jjeffery/sqlr/private/scanner"
+)
+
+// ExecMulti executes a batch of SQL statements separated by semicolons in
+// one call to the database. The args are for any placeholder parameters in
+// the statements, in the order in which they appear in the batch.
+//
+// Unlike Exec, ExecMulti does not expand "{}" column lists or infer the SQL
+// clause for each statement. Placeholders are converted for the schema's
+// dialect, and the batch is otherwise passed to the database driver
+// unchanged. Placeholders must be unnumbered, eg "?", as they are numbered
+// consecutively throughout the batch. A numbered placeholder such as "$1"
+// is an error, because its number would not refer to the same arg once
+// the statements are combined. The result is whatever the database driver
+// returns for the batch, which varies between drivers.
+//
+// Not all database drivers support multiple statements in one call. SQLite
+// does, and MySQL does when the "multiStatements=true" connection parameter
+// is specified. ExecMulti is useful for test setup and simple migrations.
+func (sess *Session) ExecMulti(query string, args ...interface{}) (sql.Result, error) {
+	query, err := convertPlaceholders(sess.schema.getDialect(), query)
+	if err != nil {
+		return nil, err
+	}
+	return sess.querier.ExecContext(sess.context, query, args...)
+}
+
+// convertPlaceholders converts the placeholders in the query to the format
+// required by the dialect. Placeholders are numbered consecutively throughout
+// the query, even if it contains multiple statements. Returns an error if the
+// query contains a numbered placeholder.
+func convertPlaceholders(dialect Dialect, query string) (string, error) {
+	scan := scanner.New(strings.NewReader(query))
+	var buf bytes.Buffer
+	var counter int
+	for scan.Scan() {
+		tok, lit := scan.Token(), scan.Text()
+		if tok == scanner.PLACEHOLDER {
+			if len(lit) > 1 {
+				return "", fmt.Errorf("ExecMulti: numbered placeholder %s not supported, use ? instead", lit)
+			}
+			counter++
+			buf.WriteString(dialect.Placeholder(counter))
+		} else {
+			buf.WriteString(lit)
+		}
+	}
+	if err := scan.Err(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
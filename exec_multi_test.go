@@ -0,0 +1,56 @@
+package sqlr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertPlaceholders(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{
+			dialect: Postgres,
+			query:   "insert into t(a) values(?);\ninsert into t(a) values(?)",
+			want:    "insert into t(a) values($1);\ninsert into t(a) values($2)",
+		},
+		{
+			dialect: MySQL,
+			query:   "update t set a = ? where b = ';';  delete from t where c = ?;",
+			want:    "update t set a = ? where b = ';';  delete from t where c = ?;",
+		},
+		{
+			dialect: Postgres,
+			query:   "create table t(id int); -- comment; with semicolons\ncreate index t_idx on t(id)",
+			want:    "create table t(id int); -- comment; with semicolons\ncreate index t_idx on t(id)",
+		},
+	}
+	for i, tt := range tests {
+		got, err := convertPlaceholders(tt.dialect, tt.query)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%d: got=%q, want=%q", i, got, tt.want)
+		}
+	}
+}
+
+func TestConvertPlaceholdersNumbered(t *testing.T) {
+	tests := []string{
+		"insert into t(a, b) values($2, $1); delete from t where a = $1",
+		"update t set a = $1; update t set b = $1",
+		"insert into t(a) values(?); delete from t where a = $1",
+	}
+	for i, query := range tests {
+		_, err := convertPlaceholders(Postgres, query)
+		if err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+		} else if got, want := err.Error(), "ExecMulti: numbered placeholder"; !strings.HasPrefix(got, want) {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package sqlr
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ExecScan executes an INSERT, UPDATE or DELETE statement that returns rows,
+// and scans the returned rows into dest. This is for statements with a
+// RETURNING clause (Postgres, SQLite) or an OUTPUT clause (SQL Server).
+// Any "{}" in the RETURNING or OUTPUT clause expands to all of the columns.
+//
+// The dest argument can be a pointer to a struct, a pointer to a slice of
+// structs, or a pointer to a slice of struct pointers, as for Select. When dest
+// is a pointer to a struct, it also supplies the values for any columns in the
+// statement, and it is updated with the first row returned:
+//  // postgres
+//  n, err := sess.ExecScan(&row, "insert into users({}) values({}) returning {}")
+//
+//  // sql server
+//  n, err := sess.ExecScan(&row, "insert into users({}) output {alias inserted} values({})")
+// When dest is a pointer to a slice, the statement can only refer to the args:
+//  var deleted []*User
+//  n, err := sess.ExecScan(&deleted, "delete from users where expires < ? returning {}", now)
+//
+// ExecScan returns the number of rows returned by the statement.
+func (sess *Session) ExecScan(dest interface{}, query string, args ...interface{}) (int, error) {
+	if dest == nil {
+		return 0, errors.New("nil pointer")
+	}
+	stmt, err := sess.schema.Prepare(dest, query)
+	if err != nil {
+		return 0, err
+	}
+	tbl := stmt.tbl
+
+	destValue := reflect.ValueOf(dest)
+	row := dest
+	if destValue.Kind() != reflect.Ptr || destValue.Elem().Type() != tbl.rowType {
+		// not a pointer to a row, so the statement cannot refer to the row
+		for _, input := range stmt.inputs {
+			if input.col != nil {
+				return 0, errors.New("cannot refer to row columns in the statement unless dest is a pointer to a struct")
+			}
+		}
+		row = reflect.New(tbl.rowType).Interface()
+	}
+
+	if rc := sess.schema.rowCacheFor(tbl.rowType); rc != nil {
+		if row == dest {
+			defer rc.invalidateRow(tbl, row)
+		} else {
+			defer rc.invalidateAll()
+		}
+	}
+
+	args, err = stmt.getArgs(row, args)
+	if err != nil {
+		return 0, err
+	}
+	return stmt.selectRows(sess.context, sess.querier, dest, args...)
+}
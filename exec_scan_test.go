@@ -0,0 +1,114 @@
+package sqlr
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExecScanPrepare(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	tests := []struct {
+		dialect Dialect
+		sql     string
+		want    string
+	}{
+		{
+			dialect: Postgres,
+			sql:     "insert into rows({}) values({}) returning {}",
+			want:    `insert into rows("id", "name") values($1, $2) returning "id", "name"`,
+		},
+		{
+			dialect: Postgres,
+			sql:     "update rows set {} where {} returning {}",
+			want:    `update rows set "name" = $1 where "id" = $2 returning "id", "name"`,
+		},
+		{
+			dialect: Postgres,
+			sql:     "delete from rows where {} returning {}",
+			want:    `delete from rows where "id" = $1 returning "id", "name"`,
+		},
+		{
+			dialect: MSSQL,
+			sql:     "insert into rows({}) output {alias inserted} values({})",
+			want:    "insert into rows([id], [name]) output inserted.[id], inserted.[name] values(?, ?)",
+		},
+		{
+			dialect: MSSQL,
+			sql:     "delete from rows output {alias deleted} where {}",
+			want:    "delete from rows output deleted.[id], deleted.[name] where [id] = ?",
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		stmt, err := schema.Prepare(Row{}, tt.sql)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got := stmt.String(); got != tt.want {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, tt.want)
+		}
+	}
+}
+
+func TestExecScan(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	tests := []struct {
+		dialect Dialect
+		delete  string
+		update  string
+	}{
+		{
+			dialect: Postgres,
+			delete:  "delete from rows where name = ? returning {}",
+			update:  "update rows set {} where {} returning {}",
+		},
+		{
+			dialect: MSSQL,
+			delete:  "delete from rows output {alias deleted} where name = ?",
+			update:  "update rows set {} output {alias inserted} where {}",
+		},
+	}
+	for _, tt := range tests {
+		dialect := tt.dialect
+		db := rowsDB(t, 3)
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(dialect)))
+
+		var rows []*Row
+		n, err := sess.ExecScan(&rows, tt.delete, "name")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", dialectName(dialect), err)
+		} else if got, want := n, 3; got != want {
+			t.Errorf("%s: got=%d, want=%d", dialectName(dialect), got, want)
+		} else if got, want := rows[2].ID, int64(3); got != want {
+			t.Errorf("%s: got=%d, want=%d", dialectName(dialect), got, want)
+		}
+
+		var row Row
+		if _, err := sess.ExecScan(&rows, "delete from rows where {} returning {}"); err == nil {
+			t.Errorf("%s: expected error", dialectName(dialect))
+		}
+		sess.Close()
+		db.Close()
+
+		// single row returned into a struct
+		db = rowsDB(t, 1)
+		sess = NewSession(context.Background(), db, NewSchema(WithDialect(dialect)))
+		row.ID = 1
+		n, err = sess.ExecScan(&row, tt.update)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", dialectName(dialect), err)
+		} else if n != 1 || row.Name != "name" {
+			t.Errorf("%s: got n=%d, row=%+v", dialectName(dialect), n, row)
+		}
+
+		sess.Close()
+		db.Close()
+	}
+}
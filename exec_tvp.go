@@ -0,0 +1,148 @@
+package sqlr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ExecTVP executes the SQL Server stored procedure proc, passing rows as a
+// table-valued parameter of the user-defined table type tvpTypeName. This is
+// an efficient way to pass many rows to the database in one round trip, for
+// example to a procedure that performs a bulk upsert:
+//  result, err := sess.ExecTVP("dbo.upsert_orders", "dbo.order_type", orders)
+// The rows argument is a slice of structs, a slice of struct pointers, or a
+// pointer to either.
+//
+// The table-valued parameter has one column for each stored column of the
+// row type, in the order that the fields appear in the struct, which must
+// match the order of the columns in the user-defined table type. The values
+// are the same as those passed to an insert statement, so JSON columns are
+// passed as marshaled JSON and columns with the "null" keyword are passed as
+// NULL when the field has its zero value.
+//
+// ExecTVP is only supported by the MSSQL dialect, and requires the schema to
+// be created with the WithTVP option, which creates the table-valued
+// parameter value for the database driver.
+func (sess *Session) ExecTVP(proc string, tvpTypeName string, rows interface{}) (sql.Result, error) {
+	dialect := sess.schema.getDialect()
+	if name := dialectName(dialect); name != dialectMSSQL {
+		return nil, fmt.Errorf("ExecTVP is not supported by dialect %s", name)
+	}
+	if sess.schema.tvp == nil {
+		return nil, errors.New("ExecTVP requires the WithTVP schema option")
+	}
+	rowsValue := reflect.ValueOf(rows)
+	for rowsValue.Kind() == reflect.Ptr {
+		rowsValue = rowsValue.Elem()
+	}
+	if rowsValue.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("ExecTVP expects a slice of rows, got %T", rows)
+	}
+	tbl, err := sess.schema.tableFor(rows)
+	if err != nil {
+		return nil, err
+	}
+	tvpRows, err := tbl.tvpRows(sess.schema, dialect, rowsValue)
+	if err != nil {
+		return nil, err
+	}
+	if rc := sess.schema.rowCacheFor(tbl.rowType); rc != nil {
+		defer rc.invalidateAll()
+	}
+	query := fmt.Sprintf("exec %s %s", proc, dialect.Placeholder(1))
+	return sess.querier.ExecContext(sess.context, query, sess.schema.tvp(tvpTypeName, tvpRows))
+}
+
+// tvpRows returns a slice of structs containing the column values of rows,
+// suitable for passing to the database driver as a table-valued parameter.
+// The struct type is created at run time, with one field for each column,
+// because the driver maps each field of the struct to a column of the
+// user-defined table type by position.
+func (tbl *Table) tvpRows(schema *Schema, dialect Dialect, rowsValue reflect.Value) (interface{}, error) {
+	// a statement with an input for every column, so that the values are
+	// converted in the same way as for an insert statement
+	stmt := &Stmt{
+		schema:    schema,
+		tbl:       tbl,
+		queryType: queryInsert,
+		dialect:   dialect,
+	}
+	columns := tbl.storedColumns()
+	for _, col := range columns {
+		stmt.inputs = append(stmt.inputs, inputSource{col: col})
+	}
+
+	rowArgs := make([][]interface{}, rowsValue.Len())
+	for i := range rowArgs {
+		rowValue := rowsValue.Index(i)
+		if rowValue.Kind() == reflect.Ptr && rowValue.IsNil() {
+			return nil, fmt.Errorf("ExecTVP: row %d is nil", i)
+		}
+		args, err := stmt.getArgs(rowValue.Interface(), nil)
+		if err != nil {
+			return nil, err
+		}
+		rowArgs[i] = args
+	}
+
+	// The type of each field is the type of the values for the column. If
+	// any value is NULL, the field is a pointer (or a byte slice) so that it
+	// can be nil.
+	fields := make([]reflect.StructField, len(columns))
+	fieldNames := make(map[string]bool)
+	for i, col := range columns {
+		var typ reflect.Type
+		var nullable bool
+		for _, args := range rowArgs {
+			if args[i] == nil {
+				nullable = true
+				continue
+			}
+			argType := reflect.TypeOf(args[i])
+			if typ == nil {
+				typ = argType
+			} else if typ != argType {
+				return nil, fmt.Errorf("ExecTVP: column %q has values of type %s and %s", col.Name(), typ, argType)
+			}
+		}
+		if typ == nil {
+			// all values are NULL
+			typ = col.fieldType()
+			for typ.Kind() == reflect.Ptr {
+				typ = typ.Elem()
+			}
+		}
+		if nullable && typ.Kind() != reflect.Ptr && typ.Kind() != reflect.Slice {
+			typ = reflect.PtrTo(typ)
+		}
+		name := col.info.Field.Name
+		if fieldNames[name] {
+			// same field name in different embedded structs
+			name = fmt.Sprintf("%s%d", name, i)
+		}
+		fieldNames[name] = true
+		fields[i] = reflect.StructField{Name: name, Type: typ}
+	}
+
+	structType := reflect.StructOf(fields)
+	tvpRows := reflect.MakeSlice(reflect.SliceOf(structType), len(rowArgs), len(rowArgs))
+	for i, args := range rowArgs {
+		rowValue := tvpRows.Index(i)
+		for j, arg := range args {
+			if arg == nil {
+				continue
+			}
+			fieldValue := rowValue.Field(j)
+			argValue := reflect.ValueOf(arg)
+			if fieldValue.Kind() == reflect.Ptr && argValue.Kind() != reflect.Ptr {
+				ptr := reflect.New(argValue.Type())
+				ptr.Elem().Set(argValue)
+				argValue = ptr
+			}
+			fieldValue.Set(argValue)
+		}
+	}
+	return tvpRows.Interface(), nil
+}
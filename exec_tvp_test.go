@@ -0,0 +1,146 @@
+package sqlr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// testTVP is the value created by the test function passed to WithTVP.
+type testTVP struct {
+	TypeName string
+	Value    interface{}
+}
+
+func TestExecTVP(t *testing.T) {
+	type Document struct {
+		Title string
+	}
+	type Order struct {
+		ID       int64 `sql:"primary key autoincrement"`
+		Customer string
+		Note     string    `sql:"null"`
+		Document *Document `sql:"json"`
+	}
+	var tvp testTVP
+	schema := NewSchema(
+		WithDialect(MSSQL),
+		WithTVP(func(typeName string, rows interface{}) interface{} {
+			tvp = testTVP{TypeName: typeName, Value: rows}
+			return tvp
+		}),
+	)
+	var db FakeDB
+	sess := NewSession(context.Background(), &db, schema)
+	defer sess.Close()
+
+	orders := []*Order{
+		{ID: 1, Customer: "alice", Note: "urgent", Document: &Document{Title: "one"}},
+		{ID: 2, Customer: "bob"},
+	}
+	if _, err := sess.ExecTVP("dbo.upsert_orders", "dbo.order_type", orders); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := db.queries, []string{"exec dbo.upsert_orders ?"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	if got, want := tvp.TypeName, "dbo.order_type"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	rowsValue := reflect.ValueOf(tvp.Value)
+	if got, want := rowsValue.Len(), 2; got != want {
+		t.Fatalf("got=%d, want=%d", got, want)
+	}
+	var fieldNames []string
+	var fieldTypes []reflect.Type
+	rowType := rowsValue.Type().Elem()
+	for i := 0; i < rowType.NumField(); i++ {
+		fieldNames = append(fieldNames, rowType.Field(i).Name)
+		fieldTypes = append(fieldTypes, rowType.Field(i).Type)
+	}
+	if got, want := fieldNames, []string{"ID", "Customer", "Note", "Document"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	wantTypes := []reflect.Type{
+		reflect.TypeOf(int64(0)),
+		reflect.TypeOf(""),
+		reflect.TypeOf((*string)(nil)),
+		reflect.TypeOf([]byte(nil)),
+	}
+	if got, want := fieldTypes, wantTypes; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	row := rowsValue.Index(0)
+	if got, want := row.Field(0).Int(), int64(1); got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := row.Field(2).Elem().String(), "urgent"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := string(row.Field(3).Bytes()), `{"Title":"one"}`; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	row = rowsValue.Index(1)
+	if got, want := row.Field(1).String(), "bob"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if !row.Field(2).IsNil() {
+		t.Errorf("got=%v, want=nil", row.Field(2))
+	}
+	// same as insert: a nil pointer without the "null" keyword is JSON null
+	if got, want := string(row.Field(3).Bytes()), "null"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+}
+
+func TestExecTVPErrors(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	withTVP := WithTVP(func(typeName string, rows interface{}) interface{} {
+		return testTVP{TypeName: typeName, Value: rows}
+	})
+	tests := []struct {
+		schema  *Schema
+		rows    interface{}
+		wantErr string
+	}{
+		{
+			schema:  NewSchema(WithDialect(Postgres), withTVP),
+			rows:    []Row{{ID: 1}},
+			wantErr: "ExecTVP is not supported by dialect postgres",
+		},
+		{
+			schema:  NewSchema(WithDialect(MSSQL)),
+			rows:    []Row{{ID: 1}},
+			wantErr: "ExecTVP requires the WithTVP schema option",
+		},
+		{
+			schema:  NewSchema(WithDialect(MSSQL), withTVP),
+			rows:    &Row{ID: 1},
+			wantErr: "ExecTVP expects a slice of rows, got *sqlr.Row",
+		},
+		{
+			schema:  NewSchema(WithDialect(MSSQL), withTVP),
+			rows:    []*Row{{ID: 1}, nil},
+			wantErr: "ExecTVP: row 1 is nil",
+		},
+	}
+	for i, tt := range tests {
+		var db FakeDB
+		sess := NewSession(context.Background(), &db, tt.schema)
+		_, err := sess.ExecTVP("proc", "type", tt.rows)
+		if err == nil {
+			t.Errorf("%d: got=nil, want=%q", i, tt.wantErr)
+		} else if got, want := err.Error(), tt.wantErr; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if len(db.queries) != 0 {
+			t.Errorf("%d: got=%q, want=no queries", i, db.queries)
+		}
+		sess.Close()
+	}
+}
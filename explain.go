@@ -0,0 +1,106 @@
+package sqlr
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Explain returns the query plan for a query, as reported by the database
+// server. The query is prepared in the same way as for Exec and Select,
+// including the expansion of any slice arguments for "IN (?)" clauses, so
+// the plan is for the exact SQL that the query would execute:
+//  plan, err := sess.Explain("select id from users where status in (?)", statuses)
+// The query plan is returned as text, with one line for each row returned
+// by the database. When the database returns more than one column for each
+// row, as MySQL does, the first line contains the column names and the
+// values in each line are separated by tabs.
+//
+// Explain is supported for PostgreSQL ("explain"), MySQL ("explain") and
+// SQLite ("explain query plan").
+func (sess *Session) Explain(query string, args ...interface{}) (string, error) {
+	return sess.explain(false, query, args)
+}
+
+// ExplainAnalyze is similar to Explain, except that the query is executed
+// and the query plan includes the actual row counts and timings reported by
+// the database server. Because the query is executed, care should be taken
+// when explaining a query that modifies the database.
+//
+// ExplainAnalyze is supported for PostgreSQL and MySQL ("explain analyze").
+func (sess *Session) ExplainAnalyze(query string, args ...interface{}) (string, error) {
+	return sess.explain(true, query, args)
+}
+
+func (sess *Session) explain(analyze bool, query string, args []interface{}) (string, error) {
+	prefix, err := explainPrefix(sess.schema.getDialect(), analyze)
+	if err != nil {
+		return "", err
+	}
+	stmt, err := sess.schema.Prepare(&struct{}{}, query)
+	if err != nil {
+		return "", err
+	}
+	expandedQuery, expandedArgs, err := sess.schema.expand(stmt.query, args)
+	if err != nil {
+		return "", err
+	}
+	rows, err := sess.querier.QueryContext(sess.context, prefix+expandedQuery, expandedArgs...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	return explainText(rows)
+}
+
+// explainPrefix returns the text that is prepended to a query to obtain
+// its query plan for the dialect.
+func explainPrefix(dialect Dialect, analyze bool) (string, error) {
+	name := dialectName(dialect)
+	switch name {
+	case dialectPostgres, dialectMySQL:
+		if analyze {
+			return "explain analyze ", nil
+		}
+		return "explain ", nil
+	case dialectSQLite:
+		if !analyze {
+			return "explain query plan ", nil
+		}
+	}
+	if analyze {
+		return "", fmt.Errorf("ExplainAnalyze is not supported for dialect %s", name)
+	}
+	return "", fmt.Errorf("Explain is not supported for dialect %s", name)
+}
+
+// explainText returns the rows returned by an explain statement as text.
+func explainText(rows *sql.Rows) (string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	if len(columns) > 1 {
+		lines = append(lines, strings.Join(columns, "\t"))
+	}
+	values := make([]sql.NullString, len(columns))
+	scanValues := make([]interface{}, len(columns))
+	for i := range values {
+		scanValues[i] = &values[i]
+	}
+	fields := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(scanValues...); err != nil {
+			return "", err
+		}
+		for i, v := range values {
+			fields[i] = v.String
+		}
+		lines = append(lines, strings.Join(fields, "\t"))
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,101 @@
+package sqlr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExplainPrefix(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		query   string
+		args    []interface{}
+		analyze bool
+		want    string
+		errText string
+	}{
+		{
+			dialect: Postgres,
+			query:   "select id from users where status in (?) and name = ?",
+			args:    []interface{}{[]string{"a", "b", "c"}, "x"},
+			want:    "explain select id from users where status in ($1,$2,$3) and name = $4",
+		},
+		{
+			dialect: Postgres,
+			query:   "select id from users where id = ?",
+			args:    []interface{}{1},
+			analyze: true,
+			want:    "explain analyze select id from users where id = $1",
+		},
+		{
+			dialect: MySQL,
+			query:   "select id from users where id in (?)",
+			args:    []interface{}{[]int{1, 2}},
+			want:    "explain select id from users where id in (?,?)",
+		},
+		{
+			dialect: MySQL,
+			query:   "select id from users",
+			analyze: true,
+			want:    "explain analyze select id from users",
+		},
+		{
+			dialect: SQLite,
+			query:   "select id from users where id = ?",
+			args:    []interface{}{1},
+			want:    "explain query plan select id from users where id = ?",
+		},
+		{
+			dialect: SQLite,
+			query:   "select id from users",
+			analyze: true,
+			errText: "ExplainAnalyze is not supported for dialect sqlite",
+		},
+		{
+			dialect: MSSQL,
+			query:   "select id from users",
+			errText: "Explain is not supported for dialect mssql",
+		},
+	}
+	for i, tt := range tests {
+		db := &FakeDB{queryErr: errors.New("query error")}
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(tt.dialect)))
+		var err error
+		if tt.analyze {
+			_, err = sess.ExplainAnalyze(tt.query, tt.args...)
+		} else {
+			_, err = sess.Explain(tt.query, tt.args...)
+		}
+		if tt.errText != "" {
+			if err == nil || err.Error() != tt.errText {
+				t.Errorf("%d: got=%v, want=%q", i, err, tt.errText)
+			}
+			continue
+		}
+		if got, want := db.queries, []string{tt.want}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, want)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	db := rowsDBWithColumns(t, 3, "QUERY PLAN")
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	plan, err := sess.Explain("select id from users")
+	wantNoError(t, err)
+	if got, want := plan, "1\n2\n3"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	db2 := rowsDBWithColumns(t, 2, "id", "detail")
+	defer db2.Close()
+	sess = NewSession(context.Background(), db2, NewSchema(WithDialect(MySQL)))
+	plan, err = sess.Explain("select id from users")
+	wantNoError(t, err)
+	if got, want := plan, "id\tdetail\n1\tname\n2\tname"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
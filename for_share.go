@@ -0,0 +1,35 @@
+package sqlr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jjeffery/sqlr/private/scanner"
+)
+
+// isForShareToken reports whether the identifier is "{for share}", which
+// locks the selected rows with a shared lock until the end of the transaction.
+func isForShareToken(lit string) bool {
+	if lit[0] != '{' {
+		return false
+	}
+	fields := strings.Fields(scanner.Unquote(lit))
+	return len(fields) == 2 && strings.EqualFold(fields[0], "for") && strings.EqualFold(fields[1], "share")
+}
+
+// forShareSQL returns the clause that locks the selected rows with a shared
+// lock in the dialect. Shared locks allow other transactions to read the rows,
+// but block them from updating or deleting the rows.
+func forShareSQL(dialect Dialect) (string, error) {
+	switch name := dialectName(dialect); name {
+	case dialectPostgres:
+		return "for share", nil
+	case dialectMySQL:
+		return "lock in share mode", nil
+	case "":
+		return "", errors.New("shared row locks are not supported by the dialect")
+	default:
+		return "", fmt.Errorf("shared row locks are not supported for dialect %s", name)
+	}
+}
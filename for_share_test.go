@@ -0,0 +1,89 @@
+package sqlr
+
+import (
+	"context"
+	"testing"
+)
+
+func TestForShare(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	tests := []struct {
+		dialect Dialect
+		query   string
+		want    string
+		wantErr string
+	}{
+		{
+			dialect: Postgres,
+			query:   "select {} from rows where id = ? {for share}",
+			want:    `select "id", "name" from rows where id = $1 for share`,
+		},
+		{
+			dialect: MySQL,
+			query:   "select {} from rows where id = ? {limit 1} {FOR SHARE}",
+			want:    "select `id`, `name` from rows where id = ? limit 1 lock in share mode",
+		},
+		{
+			dialect: MSSQL,
+			query:   "select {} from rows where id = ? {for share}",
+			wantErr: "shared row locks are not supported for dialect mssql at line 1, column 34",
+		},
+		{
+			dialect: SQLite,
+			query:   "select {} from rows where id = ? {for share}",
+			wantErr: "shared row locks are not supported for dialect sqlite at line 1, column 34",
+		},
+		{
+			dialect: Oracle,
+			query:   "select {} from rows where id = ? {for share}",
+			wantErr: "shared row locks are not supported for dialect oracle at line 1, column 34",
+		},
+		{
+			dialect: Postgres,
+			query:   "update rows set {} where {} {for share}",
+			wantErr: `cannot use "{for share}" except in a select query at line 1, column 29`,
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		stmt, err := schema.Prepare(Row{}, tt.query)
+		if tt.wantErr != "" {
+			if err == nil {
+				t.Errorf("%d: got=nil, want=%q", i, tt.wantErr)
+			} else if got := err.Error(); got != tt.wantErr {
+				t.Errorf("%d: got=%q, want=%q", i, got, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if got := stmt.String(); got != tt.want {
+			t.Errorf("%d: got=%q, want=%q", i, got, tt.want)
+		}
+	}
+}
+
+func TestForShareRequiresTx(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 1)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	var rows []*Row
+	_, err := sess.Select(&rows, "select {} from rows {for share}")
+	if err == nil {
+		t.Fatal("got=nil, want error")
+	}
+	if got, want := err.Error(), `"{for share}" requires a transaction`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+package sqlr
+
+import "fmt"
+
+// FullTextMatch returns an SQL predicate that performs a full-text search
+// of column for the words in query, using the full-text search syntax of
+// the schema's dialect. The predicate contains a single placeholder, and
+// args contains the corresponding argument. Column is included in the
+// predicate as-is, so it can be an expression such as "d.body".
+//
+// The predicate is intended to be used in the WHERE clause of a query:
+//  pred, args, err := schema.FullTextMatch("body", "quick fox")
+//  if err != nil {
+//      return err
+//  }
+//  _, err = session.Select(&docs, "select {} from documents where "+pred, args...)
+//
+// For Postgres the predicate is "to_tsvector(column) @@ plainto_tsquery(?)",
+// for MySQL it is "match(column) against (? in natural language mode)", which
+// requires a FULLTEXT index, and for SQLite it is "column match ?", which requires
+// column to belong to an FTS virtual table. An error is returned for other dialects.
+func (s *Schema) FullTextMatch(column, query string) (predicate string, args []interface{}, err error) {
+	switch dialectName(s.getDialect()) {
+	case dialectPostgres:
+		predicate = fmt.Sprintf("to_tsvector(%s) @@ plainto_tsquery(?)", column)
+	case dialectMySQL:
+		predicate = fmt.Sprintf("match(%s) against (? in natural language mode)", column)
+	case dialectSQLite:
+		predicate = fmt.Sprintf("%s match ?", column)
+	default:
+		return "", nil, fmt.Errorf("full-text search is not supported for this dialect")
+	}
+	return predicate, []interface{}{query}, nil
+}
@@ -0,0 +1,64 @@
+package sqlr
+
+import "testing"
+
+func TestFullTextMatch(t *testing.T) {
+	type Document struct {
+		ID   int64 `sql:"primary key"`
+		Body string
+	}
+	tests := []struct {
+		dialect   Dialect
+		predicate string
+		query     string
+		errText   string
+	}{
+		{
+			dialect:   Postgres,
+			predicate: "to_tsvector(body) @@ plainto_tsquery(?)",
+			query:     `select "id", "body" from documents where to_tsvector(body) @@ plainto_tsquery($1)`,
+		},
+		{
+			dialect:   MySQL,
+			predicate: "match(body) against (? in natural language mode)",
+			query:     "select `id`, `body` from documents where match(body) against (? in natural language mode)",
+		},
+		{
+			dialect:   SQLite,
+			predicate: "body match ?",
+			query:     "select `id`, `body` from documents where body match ?",
+		},
+		{
+			dialect: MSSQL,
+			errText: "full-text search is not supported for this dialect",
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		predicate, args, err := schema.FullTextMatch("body", "quick fox")
+		if tt.errText != "" {
+			if err == nil || err.Error() != tt.errText {
+				t.Errorf("%d: got=%v, want=%q", i, err, tt.errText)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := predicate, tt.predicate; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if len(args) != 1 || args[0] != "quick fox" {
+			t.Errorf("%d: got=%v, want=[quick fox]", i, args)
+		}
+		stmt, err := schema.Prepare(Document{}, "select {} from documents where "+predicate)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := stmt.String(), tt.query; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
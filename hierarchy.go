@@ -0,0 +1,243 @@
+package sqlr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SelectHierarchy executes a SELECT query that joins parent rows with their
+// child rows, and assembles the flat results into a slice of parents, each of
+// which owns a slice of its children. This loads a one-to-many relationship
+// in a single query.
+//
+// The parents argument is a pointer to a slice of parent structs, or a pointer
+// to a slice of parent struct pointers. The parentKeyField argument is the name
+// of the parent field that uniquely identifies each parent, and is used to
+// remove duplicate parents from the results. The childField argument is the
+// name of the parent field that will contain the children: it must be a slice
+// of structs, or a slice of struct pointers.
+//
+// The query must return the parent columns and the child columns. Each column
+// in the results is matched first with the parent columns and then with the
+// child columns, so when a column name is common to both (eg "id") the
+// parent column must appear first. Any "{}" in the query expands to the
+// parent columns.
+//  var customers []*Customer
+//  n, err := sess.SelectHierarchy(&customers, "ID", "Orders", `
+//      select {alias c}, o.id, o.customer_id, o.total
+//      from customers c
+//      left join orders o on o.customer_id = c.id
+//      order by c.id, o.id`)
+// When a parent has no children, as can happen with an outer join, every
+// child column is null and no child is added to the parent.
+//
+// SelectHierarchy returns the number of parents in the results.
+func (sess *Session) SelectHierarchy(parents interface{}, parentKeyField, childField string, query string, args ...interface{}) (int, error) {
+	if parents == nil {
+		return 0, errors.New("nil pointer")
+	}
+	destValue := reflect.ValueOf(parents)
+	if destValue.Kind() != reflect.Ptr || destValue.Type().Elem().Kind() != reflect.Slice {
+		return 0, fmt.Errorf("expected parents to be a pointer to a slice, got %s", destValue.Type())
+	}
+	if destValue.IsNil() {
+		return 0, errors.New("nil pointer")
+	}
+	sliceValue := destValue.Elem()
+	parentType := sliceValue.Type().Elem()
+	parentIsPtr := parentType.Kind() == reflect.Ptr
+	if parentIsPtr {
+		parentType = parentType.Elem()
+	}
+	if parentType.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("expected parents to be a pointer to a slice of structs, got %s", destValue.Type())
+	}
+
+	childStructField, ok := parentType.FieldByName(childField)
+	if !ok {
+		return 0, fmt.Errorf("unknown child field %q in %s", childField, parentType)
+	}
+	childSliceType := childStructField.Type
+	if childSliceType.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("expected child field %q to be a slice, got %s", childField, childSliceType)
+	}
+	childType := childSliceType.Elem()
+	childIsPtr := childType.Kind() == reflect.Ptr
+	if childIsPtr {
+		childType = childType.Elem()
+	}
+	if childType.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("expected child field %q to be a slice of structs, got %s", childField, childSliceType)
+	}
+
+	parentRow := reflect.New(parentType).Interface()
+	stmt, err := sess.schema.Prepare(parentRow, query)
+	if err != nil {
+		return 0, err
+	}
+	parentTbl := stmt.tbl
+	childTbl, err := sess.schema.tableFor(reflect.New(childType).Interface())
+	if err != nil {
+		return 0, err
+	}
+	keyCol := stmt.fieldColumn(parentKeyField)
+	if keyCol == nil {
+		return 0, fmt.Errorf("unknown parent key field %q in %s", parentKeyField, parentType)
+	}
+
+	expandedQuery, expandedArgs, err := stmt.schema.expand(stmt.query, args)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := sess.querier.QueryContext(sess.context, expandedQuery, expandedArgs...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	// Match each result column with a parent column, or failing that with
+	// a child column. Each column can only be matched once.
+	parentOutputs := make([]*Column, len(columnNames))
+	childOutputs := make([]*Column, len(columnNames))
+	matchColumn := func(tbl *Table, columnName string, used map[*Column]bool) *Column {
+		for _, col := range tbl.Columns() {
+			if !used[col] && strings.EqualFold(col.Name(), columnName) {
+				used[col] = true
+				return col
+			}
+		}
+		return nil
+	}
+	parentUsed := make(map[*Column]bool)
+	childUsed := make(map[*Column]bool)
+	for i, columnName := range columnNames {
+		if col := matchColumn(parentTbl, columnName, parentUsed); col != nil {
+			parentOutputs[i] = col
+		} else if col := matchColumn(childTbl, columnName, childUsed); col != nil {
+			childOutputs[i] = col
+		} else {
+			return 0, fmt.Errorf("unknown column name=%q", columnName)
+		}
+	}
+	if !parentUsed[keyCol] {
+		return 0, fmt.Errorf("missing parent key column name=%q", keyCol.Name())
+	}
+
+	// index of each parent in the slice, keyed by the parent key
+	parentIndex := make(map[interface{}]int)
+	scanValues := make([]interface{}, len(columnNames))
+	for rows.Next() {
+		parentValue := reflect.New(parentType).Elem()
+		childValue := reflect.New(childType).Elem()
+		var jsonCells []*jsonCell
+		var childCells []*nullCheckCell
+		var childPtrs []reflect.Value
+		for i := range columnNames {
+			var jc *jsonCell
+			if col := parentOutputs[i]; col != nil {
+				scanValues[i], jc = bindCell(col, parentValue, false)
+			} else {
+				scanValues[i], jc = bindCell(childOutputs[i], childValue, false)
+				if scanner, ok := scanValues[i].(sql.Scanner); ok {
+					cell := &nullCheckCell{scanner: scanner}
+					childCells = append(childCells, cell)
+					scanValues[i] = cell
+				} else {
+					// a NULL scanned into a pointer leaves it nil
+					childPtrs = append(childPtrs, reflect.ValueOf(scanValues[i]))
+				}
+			}
+			if jc != nil {
+				jsonCells = append(jsonCells, jc)
+			}
+		}
+		if err := rows.Scan(scanValues...); err != nil {
+			return 0, err
+		}
+		for _, jc := range jsonCells {
+			if err := jc.Unmarshal(); err != nil {
+				return 0, err
+			}
+		}
+
+		key := keyCol.info.Index.ValueRO(parentValue).Interface()
+		index, ok := parentIndex[key]
+		if !ok {
+			index = sliceValue.Len()
+			parentIndex[key] = index
+			sess.schema.callAfterScan(parentValue.Addr())
+			if parentIsPtr {
+				sliceValue.Set(reflect.Append(sliceValue, parentValue.Addr()))
+			} else {
+				sliceValue.Set(reflect.Append(sliceValue, parentValue))
+			}
+		}
+
+		if allNull(childCells, childPtrs) {
+			// no child, eg outer join
+			continue
+		}
+		sess.schema.callAfterScan(childValue.Addr())
+		parent := sliceValue.Index(index)
+		if parentIsPtr {
+			parent = parent.Elem()
+		}
+		children := parent.FieldByIndex(childStructField.Index)
+		if childIsPtr {
+			children.Set(reflect.Append(children, childValue.Addr()))
+		} else {
+			children.Set(reflect.Append(children, childValue))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// If the slice is nil, return an empty slice. This way the returned slice is
+	// always non-nil for a successful call.
+	if sliceValue.IsNil() {
+		sliceValue.Set(reflect.MakeSlice(sliceValue.Type(), 0, 0))
+	}
+	return len(parentIndex), nil
+}
+
+// nullCheckCell records whether the value scanned for a child column is NULL,
+// so that a child row whose columns are all NULL can be distinguished from a
+// child row whose columns contain zero values.
+type nullCheckCell struct {
+	scanner sql.Scanner
+	isNull  bool
+}
+
+func (c *nullCheckCell) Scan(v interface{}) error {
+	c.isNull = v == nil
+	return c.scanner.Scan(v)
+}
+
+// allNull reports whether every child column scanned was NULL.
+func allNull(cells []*nullCheckCell, ptrs []reflect.Value) bool {
+	for _, cell := range cells {
+		if !cell.isNull {
+			return false
+		}
+	}
+	for _, ptr := range ptrs {
+		elem := ptr.Elem()
+		switch elem.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			if !elem.IsNil() {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
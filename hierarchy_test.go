@@ -0,0 +1,113 @@
+package sqlr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSelectHierarchyErrors(t *testing.T) {
+	type Child struct {
+		ID       int64 `sql:"primary key"`
+		ParentID int64
+	}
+	type Parent struct {
+		ID       int64 `sql:"primary key"`
+		Name     string
+		Children []*Child
+		Other    []int
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	sess := NewSession(context.Background(), &FakeDB{}, schema)
+
+	var parents []*Parent
+	var structs []int
+	tests := []struct {
+		parents    interface{}
+		keyField   string
+		childField string
+		want       string
+	}{
+		{
+			parents:    nil,
+			keyField:   "ID",
+			childField: "Children",
+			want:       "nil pointer",
+		},
+		{
+			parents:    parents,
+			keyField:   "ID",
+			childField: "Children",
+			want:       "expected parents to be a pointer to a slice, got []*sqlr.Parent",
+		},
+		{
+			parents:    &structs,
+			keyField:   "ID",
+			childField: "Children",
+			want:       "expected parents to be a pointer to a slice of structs, got *[]int",
+		},
+		{
+			parents:    &parents,
+			keyField:   "ID",
+			childField: "Missing",
+			want:       `unknown child field "Missing" in sqlr.Parent`,
+		},
+		{
+			parents:    &parents,
+			keyField:   "ID",
+			childField: "Name",
+			want:       `expected child field "Name" to be a slice, got string`,
+		},
+		{
+			parents:    &parents,
+			keyField:   "ID",
+			childField: "Other",
+			want:       `expected child field "Other" to be a slice of structs, got []int`,
+		},
+		{
+			parents:    &parents,
+			keyField:   "Missing",
+			childField: "Children",
+			want:       `unknown parent key field "Missing" in sqlr.Parent`,
+		},
+	}
+	for i, tt := range tests {
+		_, err := sess.SelectHierarchy(tt.parents, tt.keyField, tt.childField, "select {} from parents")
+		if err == nil {
+			t.Errorf("%d: got=nil, want=%q", i, tt.want)
+			continue
+		}
+		if got, want := err.Error(), tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestAllNull(t *testing.T) {
+	var n int64
+	var s *string
+	nullCell := func(isNull bool) *nullCheckCell {
+		return &nullCheckCell{isNull: isNull}
+	}
+	str := "x"
+	tests := []struct {
+		cells []*nullCheckCell
+		ptrs  []reflect.Value
+		want  bool
+	}{
+		{want: true},
+		{cells: []*nullCheckCell{nullCell(true), nullCell(true)}, want: true},
+		{cells: []*nullCheckCell{nullCell(true), nullCell(false)}, want: false},
+		{cells: []*nullCheckCell{nullCell(true)}, ptrs: []reflect.Value{reflect.ValueOf(&s)}, want: true},
+		{ptrs: []reflect.Value{reflect.ValueOf(&n)}, want: false},
+	}
+	for i, tt := range tests {
+		if got := allNull(tt.cells, tt.ptrs); got != tt.want {
+			t.Errorf("%d: got=%v, want=%v", i, got, tt.want)
+		}
+	}
+	s = &str
+	if allNull(nil, []reflect.Value{reflect.ValueOf(&s)}) {
+		t.Error("got=true, want=false")
+	}
+}
@@ -0,0 +1,44 @@
+package sqlr
+
+import "strings"
+
+// IdentifierCase specifies how a database folds the case of unquoted
+// identifiers. It is specified for a schema using the WithIdentifierCase
+// option.
+type IdentifierCase int
+
+const (
+	// FoldNone leaves the case of identifiers unchanged. This is the default,
+	// except for the Oracle dialect, where the default is FoldUpper.
+	FoldNone IdentifierCase = iota
+
+	// FoldUpper folds identifiers to upper case, as Oracle does for
+	// unquoted identifiers.
+	FoldUpper
+
+	// FoldLower folds identifiers to lower case, as PostgreSQL does for
+	// unquoted identifiers.
+	FoldLower
+)
+
+// defaultIdentCase returns the identifier case used for the dialect when
+// none is specified using WithIdentifierCase.
+func defaultIdentCase(dialect Dialect) IdentifierCase {
+	if dialectName(dialect) == dialectOracle {
+		// Oracle folds unquoted identifiers to upper case, so by default
+		// the quoted names in generated SQL are upper case.
+		return FoldUpper
+	}
+	return FoldNone
+}
+
+// fold returns the identifier folded to the case.
+func (c IdentifierCase) fold(ident string) string {
+	switch c {
+	case FoldUpper:
+		return strings.ToUpper(ident)
+	case FoldLower:
+		return strings.ToLower(ident)
+	}
+	return ident
+}
@@ -0,0 +1,219 @@
+package sqlr
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// MaxParamsPerInsert is the maximum number of placeholders in one insert
+// statement built by InsertRows. Rows are inserted in batches small enough
+// to stay within the limit. The default is below the limit of every
+// supported database, the lowest of which is SQL Server with 2100.
+var MaxParamsPerInsert = 2000
+
+// InsertRows inserts the rows into the database using multi-row insert
+// statements, which require far fewer round trips to the database than
+// calling InsertRow for each row. The rows argument is a slice of structs,
+// a slice of struct pointers, or a pointer to either. It returns the number
+// of rows inserted.
+//
+// The rows are inserted in batches, so that each insert statement has no
+// more than MaxParamsPerInsert placeholders. If a batch fails, the rows in
+// the earlier batches have already been inserted, so use a transaction if
+// the rows must be inserted atomically.
+//
+// Created at, updated at and version fields are set in the same way as for
+// InsertRow. For PostgreSQL, when the auto-increment column has a sequence,
+// as for serial columns, the values are obtained from the sequence before
+// the rows are inserted, and the auto-increment fields are set. Auto-increment
+// fields are not set for other dialects, because the values that one insert
+// statement allocates to its rows are not guaranteed to be consecutive, eg
+// for MySQL with auto_increment_increment greater than one. Use InsertRow
+// when the values are needed.
+//
+// InsertRows is not supported for Oracle, which does not accept more than
+// one row in the values clause of an insert statement.
+func (sess *Session) InsertRows(rows interface{}) (int, error) {
+	dialect := sess.schema.getDialect()
+	if dialectName(dialect) == dialectOracle {
+		// oracle does not support multi-row values lists
+		return 0, fmt.Errorf("InsertRows is not supported for dialect %s", dialectName(dialect))
+	}
+	rowsValue := reflect.ValueOf(rows)
+	for rowsValue.Kind() == reflect.Ptr {
+		rowsValue = rowsValue.Elem()
+	}
+	if rowsValue.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("InsertRows expects a slice of rows, got %T", rows)
+	}
+	if rowsValue.Len() == 0 {
+		return 0, nil
+	}
+	tbl, err := sess.schema.tableFor(rows)
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := sess.schema.Prepare(tbl.rowType, "insert into t({}) values({})")
+	if err != nil {
+		return 0, err
+	}
+	if rc := sess.schema.rowCacheFor(tbl.rowType); rc != nil {
+		defer rc.invalidateAll()
+	}
+
+	// rows are passed to the statement as pointers, so that fields can be set
+	rowPtrs := make([]interface{}, rowsValue.Len())
+	now := reflect.ValueOf(time.Now())
+	for i := range rowPtrs {
+		rowValue := rowsValue.Index(i)
+		if rowValue.Kind() == reflect.Ptr {
+			if rowValue.IsNil() {
+				return 0, fmt.Errorf("InsertRows: row %d is nil", i)
+			}
+			rowPtrs[i] = rowValue.Interface()
+			rowValue = rowValue.Elem()
+		} else {
+			rowPtrs[i] = rowValue.Addr().Interface()
+		}
+		if sess.schema.zeroPKGuard {
+			if err := tbl.checkZeroPK(fmt.Sprintf("InsertRows: row %d", i), rowPtrs[i]); err != nil {
+				return 0, err
+			}
+		}
+		if tbl.createdAt != nil {
+			tbl.createdAt.info.Index.ValueRW(rowValue).Set(now)
+		}
+		if tbl.updatedAt != nil {
+			tbl.updatedAt.info.Index.ValueRW(rowValue).Set(now)
+		}
+		if tbl.version != nil {
+			tbl.version.info.Index.ValueRW(rowValue).SetInt(1)
+		}
+	}
+
+	cols := newColumns(tbl.Columns())
+	cols.clause = clauseInsertColumns
+	cols.filter = columnFilterInsertable
+	columnNames := cols.String(dialect, nil)
+	paramsPerRow := len(stmt.inputs)
+	if tbl.autoincr != nil && isPostgres(dialect) {
+		// the auto-increment values are passed as well
+		paramsPerRow++
+	}
+	rowsPerBatch := len(rowPtrs)
+	if paramsPerRow > 0 && MaxParamsPerInsert/paramsPerRow < rowsPerBatch {
+		rowsPerBatch = MaxParamsPerInsert / paramsPerRow
+		if rowsPerBatch < 1 {
+			rowsPerBatch = 1
+		}
+	}
+
+	var rowCount int
+	for start := 0; start < len(rowPtrs); {
+		// rows in a batch must be inserted into the same table, which can
+		// differ for sharded tables
+		tableName := tbl.nameFor(rowPtrs[start])
+		end := start + 1
+		for end < len(rowPtrs) && end-start < rowsPerBatch && tbl.nameFor(rowPtrs[end]) == tableName {
+			end++
+		}
+		n, err := sess.insertBatch(tbl, stmt, cols, tableName, columnNames, rowPtrs[start:end])
+		rowCount += n
+		if err != nil {
+			return rowCount, err
+		}
+		start = end
+	}
+	return rowCount, nil
+}
+
+// insertBatch inserts the rows into the table in one insert statement.
+func (sess *Session) insertBatch(tbl *Table, stmt *Stmt, cols columnList, tableName string, columnNames string, rowPtrs []interface{}) (int, error) {
+	dialect := sess.schema.getDialect()
+	var counter int
+	nextCounter := func() int {
+		counter++
+		return counter
+	}
+
+	var ids []int64
+	if tbl.autoincr != nil && isPostgres(dialect) {
+		var err error
+		if ids, err = sess.nextSequenceValues(tbl, tableName, len(rowPtrs)); err != nil {
+			return 0, tbl.wrapRowError(err, rowPtrs[0], "cannot retrieve auto-increment values")
+		}
+		if ids != nil {
+			columnNames = dialect.Quote(tbl.autoincr.Name()) + ", " + columnNames
+		}
+	}
+
+	cols.clause = clauseInsertValues
+	values := make([]string, len(rowPtrs))
+	var args []interface{}
+	for i, row := range rowPtrs {
+		var value string
+		if ids != nil {
+			args = append(args, ids[i])
+			value = dialect.Placeholder(nextCounter()) + ", "
+		}
+		rowArgs, err := stmt.getArgs(row, nil)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, rowArgs...)
+		values[i] = "(" + value + cols.String(dialect, nextCounter) + ")"
+	}
+
+	// The query is not prepared, as the number of rows differs between
+	// batches, and each would be kept in the statement cache.
+	query := fmt.Sprintf("insert into %s(%s) values %s", dialect.Quote(tableName), columnNames, strings.Join(values, ", "))
+	result, err := sess.querier.ExecContext(sess.context, query, args...)
+	if err != nil {
+		return 0, tbl.wrapRowError(err, rowPtrs[0], "cannot insert rows")
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, tbl.wrapRowError(err, rowPtrs[0], "cannot retrieve rows affected")
+	}
+	for i, id := range ids {
+		rowValue := tbl.mustGetRowValue(rowPtrs[i])
+		tbl.autoincr.info.Index.ValueRW(rowValue).SetInt(id)
+	}
+	return int(n), nil
+}
+
+// nextSequenceValues returns count values from the PostgreSQL sequence of
+// the table's auto-increment column. Each value is used by exactly one row,
+// so the values can be assigned to the rows in any order. Returns nil if the
+// column does not have a sequence.
+func (sess *Session) nextSequenceValues(tbl *Table, tableName string, count int) ([]int64, error) {
+	const query = "select nextval(pg_get_serial_sequence($1, $2)) from generate_series(1, $3)"
+	dialect := sess.schema.getDialect()
+	rows, err := sess.querier.QueryContext(sess.context, query, dialect.Quote(tableName), tbl.autoincr.Name(), count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]int64, 0, count)
+	for rows.Next() {
+		var id sql.NullInt64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		if !id.Valid {
+			// the column does not have a sequence
+			return nil, rows.Err()
+		}
+		ids = append(ids, id.Int64)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) != count {
+		return nil, fmt.Errorf("expected %d sequence values, got %d", count, len(ids))
+	}
+	return ids, nil
+}
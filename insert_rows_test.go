@@ -0,0 +1,177 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestInsertRows(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key autoincrement"`
+		Name string
+		Tags []string `sql:"json"`
+	}
+	defer func(n int) { MaxParamsPerInsert = n }(MaxParamsPerInsert)
+	MaxParamsPerInsert = 5
+
+	tests := []struct {
+		dialect Dialect
+		queries []string
+		ids     []int64
+	}{
+		{
+			dialect: SQLite,
+			queries: []string{
+				"insert into `row`(`name`, `tags`) values (?, ?), (?, ?)",
+				"insert into `row`(`name`, `tags`) values (?, ?), (?, ?)",
+				"insert into `row`(`name`, `tags`) values (?, ?)",
+			},
+			// the ids allocated by one statement might not be consecutive
+			ids: []int64{0, 0, 0, 0, 0},
+		},
+		{
+			dialect: MySQL,
+			queries: []string{
+				"insert into `row`(`name`, `tags`) values (?, ?), (?, ?)",
+				"insert into `row`(`name`, `tags`) values (?, ?), (?, ?)",
+				"insert into `row`(`name`, `tags`) values (?, ?)",
+			},
+			ids: []int64{0, 0, 0, 0, 0},
+		},
+		{
+			dialect: MSSQL,
+			queries: []string{
+				"insert into [row]([name], [tags]) values (?, ?), (?, ?)",
+				"insert into [row]([name], [tags]) values (?, ?), (?, ?)",
+				"insert into [row]([name], [tags]) values (?, ?)",
+			},
+			ids: []int64{0, 0, 0, 0, 0},
+		},
+	}
+	for tn, tt := range tests {
+		db := &FakeDB{rowsAffected: 2, lastInsertId: 20}
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(tt.dialect)))
+		rows := []Row{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}
+		n, err := sess.InsertRows(rows)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", tn, err)
+			continue
+		}
+		if got, want := n, 6; got != want {
+			t.Errorf("%d: got n=%d, want=%d", tn, got, want)
+		}
+		if got, want := db.queries, tt.queries; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%q\nwant=%q", tn, got, want)
+		}
+		var ids []int64
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+		if got, want := ids, tt.ids; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%v, want=%v", tn, got, want)
+		}
+		sess.Close()
+	}
+}
+
+// sequenceDB is a querier that returns the rows of a rowsDB for queries,
+// which are the sequence values 1, 2, ...
+type sequenceDB struct {
+	FakeDB
+	rows *sql.DB
+}
+
+func (db *sequenceDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	db.queries = append(db.queries, query)
+	return db.rows.QueryContext(ctx, query, args...)
+}
+
+func TestInsertRowsPostgresSequence(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key autoincrement"`
+		Name string
+	}
+	rowsDB := rowsDBWithColumns(t, 2, "nextval")
+	defer rowsDB.Close()
+	db := &sequenceDB{FakeDB: FakeDB{rowsAffected: 2}, rows: rowsDB}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+	rows := []*Row{{Name: "a"}, {Name: "b"}}
+	n, err := sess.InsertRows(rows)
+	wantNoError(t, err)
+	if got, want := n, 2; got != want {
+		t.Errorf("got n=%d, want=%d", got, want)
+	}
+	want := []string{
+		`select nextval(pg_get_serial_sequence($1, $2)) from generate_series(1, $3)`,
+		`insert into "row"("id", "name") values ($1, $2), ($3, $4)`,
+	}
+	if got := db.queries; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%q\nwant=%q", got, want)
+	}
+	for i, row := range rows {
+		if got, want := row.ID, int64(i+1); got != want {
+			t.Errorf("%d: got id=%d, want=%d", i, got, want)
+		}
+	}
+}
+
+func TestInsertRowsPostgresPlaceholders(t *testing.T) {
+	type Row struct {
+		ID      int64 `sql:"primary key"`
+		Name    string
+		Version int64 `sql:"version"`
+	}
+	db := &FakeDB{rowsAffected: 2}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+	rows := []*Row{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}
+	_, err := sess.InsertRows(&rows)
+	wantNoError(t, err)
+	want := []string{`insert into "row"("id", "name", "version") values ($1, $2, $3), ($4, $5, $6)`}
+	if got := db.queries; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%q\nwant=%q", got, want)
+	}
+	for i, row := range rows {
+		if got, want := row.Version, int64(1); got != want {
+			t.Errorf("%d: got version=%d, want=%d", i, got, want)
+		}
+	}
+}
+
+func TestInsertRowsErrors(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := &FakeDB{}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	if _, err := sess.InsertRows(Row{}); err == nil {
+		t.Error("expected error for a row that is not a slice")
+	}
+	if _, err := sess.InsertRows([]*Row{{ID: 1}, nil}); err == nil {
+		t.Error("expected error for a nil row")
+	}
+	n, err := sess.InsertRows([]Row{})
+	if err != nil || n != 0 {
+		t.Errorf("got n=%d, err=%v", n, err)
+	}
+	if len(db.queries) != 0 {
+		t.Errorf("got queries=%q, want none", db.queries)
+	}
+
+	oracle := NewSession(context.Background(), db, NewSchema(WithDialect(Oracle)))
+	defer oracle.Close()
+	_, err = oracle.InsertRows([]Row{{ID: 1}, {ID: 2}})
+	if got, want := fmt.Sprint(err), "InsertRows is not supported for dialect oracle"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	if len(db.queries) != 0 {
+		t.Errorf("got queries=%q, want none", db.queries)
+	}
+}
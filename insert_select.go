@@ -0,0 +1,125 @@
+package sqlr
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/jjeffery/sqlr/private/scanner"
+)
+
+// InsertSelect inserts the rows returned by selectQuery into the table
+// associated with destRowType, without reading the rows into the program.
+// The SQL executed is
+//  insert into dest(cols) selectQuery
+// where cols are the insertable columns of destRowType, in the order that
+// they appear in the struct. All columns are insertable except the
+// autoincrement column, if there is one. The select query must return
+// matching columns in the same order. Returns the number of rows inserted.
+//
+// This is useful for archiving rows and copying rows between tables:
+//  n, err := session.InsertSelect(&ArchivedOrder{},
+//      "select id, customer, amount from orders where created_at < ?",
+//      cutoff)
+//
+// The select query is passed to the database with its placeholders converted
+// for the dialect and any slice args expanded. Column lists ("{}") are not
+// expanded. If the number of columns in the select list can be determined,
+// it must match the number of insertable columns.
+func (sess *Session) InsertSelect(destRowType interface{}, selectQuery string, args ...interface{}) (int, error) {
+	tbl, err := sess.schema.tableFor(destRowType)
+	if err != nil {
+		return 0, err
+	}
+	if tbl.isSharded() {
+		return 0, fmt.Errorf("InsertSelect does not support %s, which has a ShardFunc", tbl.rowType)
+	}
+	dialect := sess.schema.getDialect()
+
+	var cols []string
+	for _, col := range tbl.Columns() {
+		if columnFilterInsertable(col) {
+			cols = append(cols, dialect.Quote(col.Name()))
+		}
+	}
+	if n, ok := countSelectColumns(selectQuery); ok && n != len(cols) {
+		return 0, fmt.Errorf("select query returns %d columns, but table %s has %d insertable columns",
+			n, tbl.Name(), len(cols))
+	}
+
+	query, args, err := sess.schema.expand(selectQuery, args)
+	if err != nil {
+		return 0, err
+	}
+	query = fmt.Sprintf("insert into %s(%s) %s",
+		dialect.Quote(tbl.Name()), strings.Join(cols, ", "), strings.TrimSpace(query))
+	query, err = convertPlaceholders(dialect, query)
+	if err != nil {
+		return 0, err
+	}
+	result, err := sess.querier.ExecContext(sess.context, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// countSelectColumns returns the number of columns in the select list of
+// a SELECT query. Returns false if the number of columns cannot be
+// determined, which is the case if the query is not a SELECT query, or the
+// select list contains a wildcard.
+func countSelectColumns(query string) (int, bool) {
+	scan := scanner.New(strings.NewReader(query))
+	scan.IgnoreWhiteSpace = true
+	var (
+		inList bool
+		depth  int
+		count  int
+		expr   bytes.Buffer
+	)
+	for scan.Scan() {
+		tok, lit := scan.Token(), scan.Text()
+		if tok == scanner.COMMENT {
+			continue
+		}
+		if !inList {
+			if tok != scanner.IDENT || !strings.EqualFold(lit, "select") {
+				return 0, false
+			}
+			inList = true
+			count = 1
+			continue
+		}
+		switch {
+		case tok == scanner.OP && lit == "(":
+			depth++
+		case tok == scanner.OP && lit == ")":
+			depth--
+		case depth > 0:
+			// inside a function call or sub-query
+		case tok == scanner.OP && lit == "*":
+			if expr.Len() == 0 || strings.HasSuffix(expr.String(), ".") {
+				// wildcard, so cannot tell
+				return 0, false
+			}
+		case tok == scanner.OP && lit == ",":
+			count++
+			expr.Reset()
+			continue
+		case tok == scanner.IDENT && strings.EqualFold(lit, "from"):
+			return count, true
+		case tok == scanner.IDENT && expr.Len() == 0 &&
+			(strings.EqualFold(lit, "distinct") || strings.EqualFold(lit, "all")):
+			continue
+		}
+		expr.WriteString(lit)
+	}
+	if !inList || scan.Err() != nil {
+		return 0, false
+	}
+	return count, true
+}
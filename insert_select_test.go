@@ -0,0 +1,53 @@
+package sqlr
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCountSelectColumns(t *testing.T) {
+	tests := []struct {
+		query string
+		n     int
+		ok    bool
+	}{
+		{query: "select a, b, c from t", n: 3, ok: true},
+		{query: "SELECT a FROM t", n: 1, ok: true},
+		{query: "select distinct a, b from t", n: 2, ok: true},
+		{query: "select coalesce(a, b), count(*), c * 2 from t group by c", n: 3, ok: true},
+		{query: "select (select max(x) from u), b from t", n: 2, ok: true},
+		{query: "select 1, 'two, three'", n: 2, ok: true},
+		{query: "-- comment\nselect a, b from t", n: 2, ok: true},
+		{query: "select * from t", ok: false},
+		{query: "select t.* from t", ok: false},
+		{query: "insert into t values(1)", ok: false},
+		{query: "", ok: false},
+	}
+	for i, tt := range tests {
+		n, ok := countSelectColumns(tt.query)
+		if got, want := ok, tt.ok; got != want {
+			t.Errorf("%d: %s: got=%v, want=%v", i, tt.query, got, want)
+			continue
+		}
+		if got, want := n, tt.n; ok && got != want {
+			t.Errorf("%d: %s: got=%v, want=%v", i, tt.query, got, want)
+		}
+	}
+}
+
+func TestInsertSelectColumnMismatch(t *testing.T) {
+	type Archive struct {
+		ID     int64 `sql:"primary key"`
+		Name   string
+		Amount float64
+	}
+	sess := NewSession(context.Background(), &FakeDB{}, NewSchema())
+	defer sess.Close()
+	_, err := sess.InsertSelect(&Archive{}, "select id, name from orders")
+	if err == nil {
+		t.Fatal("got=nil, want=error")
+	}
+	if got, want := err.Error(), "select query returns 2 columns, but table archive has 3 insertable columns"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
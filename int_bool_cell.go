@@ -0,0 +1,91 @@
+package sqlr
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// intBoolCell is used to scan an integer column into a bool field, where
+// the column stores flags as 0 and 1. Any non-zero value is true.
+type intBoolCell struct {
+	colname   string
+	cellValue reflect.Value
+}
+
+func newIntBoolCell(colname string, cellValue reflect.Value) *intBoolCell {
+	return &intBoolCell{colname: colname, cellValue: cellValue}
+}
+
+// Scan implements the sql.Scanner interface.
+func (c *intBoolCell) Scan(src interface{}) error {
+	if src == nil {
+		c.cellValue.Set(reflect.Zero(c.cellValue.Type()))
+		return nil
+	}
+	var b bool
+	switch v := src.(type) {
+	case bool:
+		b = v
+	case int64:
+		b = v != 0
+	case float64:
+		b = v != 0
+	case []byte:
+		var err error
+		if b, err = parseIntBool(string(v)); err != nil {
+			return fmt.Errorf("cannot scan column %q: %v", c.colname, err)
+		}
+	case string:
+		var err error
+		if b, err = parseIntBool(v); err != nil {
+			return fmt.Errorf("cannot scan column %q: %v", c.colname, err)
+		}
+	default:
+		return fmt.Errorf("cannot scan column %q: unsupported type %T for bool", c.colname, src)
+	}
+	if c.cellValue.Kind() == reflect.Ptr {
+		boolValue := reflect.New(c.cellValue.Type().Elem())
+		boolValue.Elem().SetBool(b)
+		c.cellValue.Set(boolValue)
+	} else {
+		c.cellValue.SetBool(b)
+	}
+	return nil
+}
+
+// parseIntBool parses the text representation of an integer flag. Some
+// drivers return integers as text, and boolean text is also accepted.
+func parseIntBool(s string) (bool, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n != 0, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+// intBoolArg returns the argument value for a bool field that is stored
+// in an integer column: 1 for true and 0 for false, or nil for a nil
+// pointer. If emptyNull is set, false is stored as NULL.
+func intBoolArg(fieldValue reflect.Value, emptyNull bool) interface{} {
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return nil
+		}
+		fieldValue = fieldValue.Elem()
+	}
+	if fieldValue.Bool() {
+		return int64(1)
+	}
+	if emptyNull {
+		return nil
+	}
+	return int64(0)
+}
+
+// isBoolType reports whether t is bool or a pointer to bool.
+func isBoolType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
@@ -0,0 +1,130 @@
+package sqlr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestIntBoolCell(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    bool
+		errText string
+	}{
+		{src: int64(0), want: false},
+		{src: int64(1), want: true},
+		{src: int64(-1), want: true},
+		{src: nil, want: false},
+		{src: true, want: true},
+		{src: float64(1), want: true},
+		{src: []byte("0"), want: false},
+		{src: []byte("1"), want: true},
+		{src: "1", want: true},
+		{src: "true", want: true},
+		{src: "yes", errText: `cannot scan column "Flag": strconv.ParseBool: parsing "yes": invalid syntax`},
+	}
+	for i, tt := range tests {
+		flag := !tt.want
+		cell := newIntBoolCell("Flag", reflect.ValueOf(&flag).Elem())
+		err := cell.Scan(tt.src)
+		if tt.errText != "" {
+			if err == nil || err.Error() != tt.errText {
+				t.Errorf("%d: got=%v, want=%q", i, err, tt.errText)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: got=%v, want=nil", i, err)
+			continue
+		}
+		if got, want := flag, tt.want; got != want {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+	}
+
+	var ptr *bool
+	cell := newIntBoolCell("Flag", reflect.ValueOf(&ptr).Elem())
+	wantNoError(t, cell.Scan(int64(1)))
+	if ptr == nil || !*ptr {
+		t.Errorf("got=%v, want=true", ptr)
+	}
+	wantNoError(t, cell.Scan(nil))
+	if ptr != nil {
+		t.Errorf("got=%v, want=nil", ptr)
+	}
+}
+
+func TestIntBoolArgs(t *testing.T) {
+	type Row struct {
+		ID      int64 `sql:"primary key"`
+		Active  bool  `sql:"active intbool"`
+		Deleted bool  `sql:"deleted intbool null"`
+		Flag    *bool
+	}
+	yes := true
+	tests := []struct {
+		schema *Schema
+		row    *Row
+		want   []interface{}
+	}{
+		{
+			schema: NewSchema(WithDialect(SQLite)),
+			row:    &Row{ID: 1, Active: true, Deleted: false, Flag: &yes},
+			want:   []interface{}{int64(1), int64(1), nil, &yes},
+		},
+		{
+			schema: NewSchema(WithDialect(SQLite)),
+			row:    &Row{ID: 2, Active: false, Deleted: true},
+			want:   []interface{}{int64(2), int64(0), int64(1), (*bool)(nil)},
+		},
+		{
+			schema: NewSchema(WithDialect(SQLite), WithIntBools()),
+			row:    &Row{ID: 3, Flag: &yes},
+			want:   []interface{}{int64(3), int64(0), nil, int64(1)},
+		},
+		{
+			schema: NewSchema(WithDialect(SQLite), WithIntBools()),
+			row:    &Row{ID: 4},
+			want:   []interface{}{int64(4), int64(0), nil, nil},
+		},
+	}
+	for i, tt := range tests {
+		stmt, err := tt.schema.Prepare(tt.row, "insert into rows({}) values({})")
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		args, err := stmt.getArgs(tt.row, nil)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if !reflect.DeepEqual(args, tt.want) {
+			t.Errorf("%d: got=%v, want=%v", i, args, tt.want)
+		}
+	}
+}
+
+func TestWithIntBools(t *testing.T) {
+	type Row struct {
+		Active bool `sql:"id"`
+		Name   string
+	}
+	db := rowsDB(t, 2)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite), WithIntBools()))
+	var rows []Row
+	_, err := sess.Select(&rows, "select {} from rows")
+	wantNoError(t, err)
+	for i, row := range rows {
+		if !row.Active {
+			t.Errorf("%d: got=false, want=true", i)
+		}
+	}
+	if got, want := sess.schema.TableFor(Row{}).Columns()[0].intBool, true; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	// only bool fields are affected
+	if got, want := sess.schema.TableFor(Row{}).Columns()[1].intBool, false; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+}
@@ -1,8 +1,11 @@
 package sqlr
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"reflect"
 )
 
@@ -11,6 +14,8 @@ type jsonCell struct {
 	colname   string
 	cellValue interface{}
 	data      []byte
+	gzip      bool  // data is gzip-compressed JSON
+	codec     Codec // unmarshals data instead of JSON, if not nil
 }
 
 func newJSONCell(colname string, v interface{}) *jsonCell {
@@ -37,9 +42,45 @@ func (jc *jsonCell) Unmarshal() error {
 		val.Set(reflect.Zero(val.Type()))
 		return nil
 	}
-	if err := json.Unmarshal(jc.data, jc.cellValue); err != nil {
+	data := jc.data
+	if jc.gzip {
+		var err error
+		if data, err = gunzipData(data); err != nil {
+			return fmt.Errorf("cannot decompress JSON field %q: %v", jc.colname, err)
+		}
+	}
+	if jc.codec != nil {
+		if err := jc.codec.Unmarshal(data, jc.cellValue); err != nil {
+			return fmt.Errorf("cannot unmarshal field %q: %v", jc.colname, err)
+		}
+		return nil
+	}
+	if err := json.Unmarshal(data, jc.cellValue); err != nil {
 		// TODO(jpj): if Wrap makes it into the stdlib, use it here
 		return fmt.Errorf("cannot unmarshal JSON field %q: %v", jc.colname, err)
 	}
 	return nil
 }
+
+// gzipData returns the gzip-compressed contents of data.
+func gzipData(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gunzipData returns the decompressed contents of gzip-compressed data.
+func gunzipData(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
@@ -1,6 +1,11 @@
 package sqlr
 
-import "testing"
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
 
 func TestJSONCell(t *testing.T) {
 	{
@@ -53,3 +58,141 @@ func TestJSONCell(t *testing.T) {
 		}
 	}
 }
+
+func TestJSONGzip(t *testing.T) {
+	type Document struct {
+		Title string
+		Body  string
+	}
+	type Row struct {
+		ID       int64     `sql:"primary key"`
+		Document *Document `sql:"json gzip"`
+		Plain    string    `sql:"plain gzip"` // no effect unless json
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	tbl := schema.TableFor(Row{})
+	docCol := tbl.Columns()[1]
+	if got, want := docCol.Gzip(), true; got != want {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+	if got, want := tbl.Columns()[2].Gzip(), false; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	doc := &Document{Title: "title", Body: strings.Repeat("lorem ipsum ", 100)}
+	row := Row{ID: 1, Document: doc}
+	stmt, err := schema.Prepare(row, "insert into rows({}) values({})")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := stmt.getArgs(&row, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, ok := args[1].([]byte)
+	if !ok {
+		t.Fatalf("got=%T, want=[]byte", args[1])
+	}
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		t.Fatalf("expected gzip header, got=% x", data[:2])
+	}
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) >= len(jsonData) {
+		t.Errorf("compressed length=%d, want less than %d", len(data), len(jsonData))
+	}
+
+	// round trip
+	var got Row
+	scanValue, jc := bindCell(docCol, reflect.ValueOf(&got).Elem(), false)
+	*(scanValue.(*[]byte)) = data
+	if err := jc.Unmarshal(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Document, doc) {
+		t.Errorf("got=%+v, want=%+v", got.Document, doc)
+	}
+
+	// not compressed
+	jc.data = jsonData
+	if err := jc.Unmarshal(); err == nil {
+		t.Error("got=nil, want=error")
+	}
+
+	if got, want := fieldTypeFamily(docCol), familyBinary; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if typ, _ := sqlTypeFor(docCol, dialectPostgres); typ != "bytea" {
+		t.Errorf("got=%v, want=%v", typ, "bytea")
+	}
+}
+
+func TestJSONB(t *testing.T) {
+	type Document struct {
+		Title string
+	}
+	type Row struct {
+		ID       int64     `sql:"primary key"`
+		Document *Document `sql:"jsonb"`
+	}
+	row := Row{ID: 1, Document: &Document{Title: "title"}}
+
+	tests := []struct {
+		dialect  Dialect
+		query    string
+		wantSQL  string
+		wantText bool
+	}{
+		{
+			dialect:  Postgres,
+			query:    "insert into rows({}) values({})",
+			wantSQL:  `insert into rows("id", "document") values($1, $2::jsonb)`,
+			wantText: true,
+		},
+		{
+			dialect:  Postgres,
+			query:    "update rows set {} where {}",
+			wantSQL:  `update rows set "document" = $1::jsonb where "id" = $2`,
+			wantText: true,
+		},
+		{
+			dialect: MySQL,
+			query:   "insert into rows({}) values({})",
+			wantSQL: "insert into rows(`id`, `document`) values(?, ?)",
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		if got, want := schema.TableFor(Row{}).Columns()[1].JSON(), true; got != want {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+		stmt, err := schema.Prepare(row, tt.query)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if got, want := stmt.String(), tt.wantSQL; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		args, err := stmt.getArgs(&row, nil)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		var docArg interface{}
+		for _, arg := range args {
+			if _, ok := arg.(int64); !ok {
+				docArg = arg
+			}
+		}
+		if tt.wantText {
+			if got, want := docArg, `{"Title":"title"}`; got != want {
+				t.Errorf("%d: got=%v, want=%v", i, got, want)
+			}
+		} else if _, ok := docArg.([]byte); !ok {
+			t.Errorf("%d: got=%T, want=[]byte", i, docArg)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// LastQuery returns the SQL and args of the most recent database call
+// performed by the session. The query is the SQL as sent to the database,
+// after placeholders have been converted for the dialect and any args that
+// are slices have been expanded.
+//
+// The last query is only recorded if the session's schema was created using
+// the WithLastQuery option, otherwise LastQuery returns a blank query and
+// nil args. It is intended for tests and debugging.
+func (sess *Session) LastQuery() (query string, args []interface{}) {
+	if sess.lastQuery == nil {
+		return "", nil
+	}
+	return sess.lastQuery.last()
+}
+
+// lastQueryQuerier is a Querier that records the most recent call to the
+// database. It is safe for concurrent use.
+type lastQueryQuerier struct {
+	querier Querier
+
+	mu    sync.Mutex
+	query string
+	args  []interface{}
+}
+
+// ExecContext implements the Querier interface.
+func (lq *lastQueryQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	lq.record(query, args)
+	return lq.querier.ExecContext(ctx, query, args...)
+}
+
+// QueryContext implements the Querier interface.
+func (lq *lastQueryQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	lq.record(query, args)
+	return lq.querier.QueryContext(ctx, query, args...)
+}
+
+// base returns the querier that performs the database calls.
+func (lq *lastQueryQuerier) base() Querier {
+	return lq.querier
+}
+
+func (lq *lastQueryQuerier) record(query string, args []interface{}) {
+	var argsCopy []interface{}
+	if len(args) > 0 {
+		argsCopy = make([]interface{}, len(args))
+		copy(argsCopy, args)
+	}
+	lq.mu.Lock()
+	lq.query, lq.args = query, argsCopy
+	lq.mu.Unlock()
+}
+
+func (lq *lastQueryQuerier) last() (string, []interface{}) {
+	lq.mu.Lock()
+	defer lq.mu.Unlock()
+	return lq.query, lq.args
+}
+
+// baseQuerier returns the querier that performs the database calls for
+// querier, so that its type can be checked for *sql.Tx or *sql.Conn.
+// Wrappers can be stacked, so each wrapper is unwrapped in turn.
+func baseQuerier(querier Querier) Querier {
+	for {
+		wrapper, ok := querier.(interface{ base() Querier })
+		if !ok {
+			return querier
+		}
+		querier = wrapper.base()
+	}
+}
@@ -0,0 +1,94 @@
+package sqlr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestLastQuery(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 2)
+	defer db.Close()
+
+	// not recorded without the schema option
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	var rows []Row
+	_, err := sess.Select(&rows, "select {} from rows where id in (?)", []int64{1, 2})
+	wantNoError(t, err)
+	if query, args := sess.LastQuery(); query != "" || args != nil {
+		t.Errorf("got query=%q, args=%v, want none", query, args)
+	}
+	sess.Close()
+
+	sess = NewSession(context.Background(), db, NewSchema(WithDialect(Postgres), WithLastQuery()))
+	defer sess.Close()
+	if query, args := sess.LastQuery(); query != "" || args != nil {
+		t.Errorf("got query=%q, args=%v, want none", query, args)
+	}
+
+	_, err = sess.Select(&rows, "select {} from rows where id in (?)", []int64{1, 2})
+	wantNoError(t, err)
+	query, args := sess.LastQuery()
+	if want := `select "id", "name" from rows where id in ($1,$2)`; query != want {
+		t.Errorf("got=%q, want=%q", query, want)
+	}
+	if want := []interface{}{int64(1), int64(2)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("got=%v, want=%v", args, want)
+	}
+
+	var row Row
+	_, err = sess.Select(&row, "select {} from rows where {}", 2)
+	wantNoError(t, err)
+	query, args = sess.LastQuery()
+	if want := `select "id", "name" from rows where "id" = $1`; query != want {
+		t.Errorf("got=%q, want=%q", query, want)
+	}
+	if want := []interface{}{2}; !reflect.DeepEqual(args, want) {
+		t.Errorf("got=%v, want=%v", args, want)
+	}
+
+	r, err := sess.Query("select count(*) from rows")
+	wantNoError(t, err)
+	r.Close()
+	query, args = sess.LastQuery()
+	if want := "select count(*) from rows"; query != want || args != nil {
+		t.Errorf("got query=%q, args=%v, want=%q", query, args, want)
+	}
+}
+
+func TestLastQueryExec(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := &FakeDB{}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres), WithLastQuery()))
+	defer sess.Close()
+
+	_, err := sess.Exec("delete from rows where name = ?", "x")
+	wantNoError(t, err)
+	_, err = sess.UpdateRow(&Row{ID: 1, Name: "one"})
+	wantNoError(t, err)
+	query, args := sess.LastQuery()
+	if want := `update "row" set "name" = $1 where "id" = $2`; query != want {
+		t.Errorf("got=%q, want=%q", query, want)
+	}
+	if want := []interface{}{"one", int64(1)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("got=%v, want=%v", args, want)
+	}
+}
+
+func TestBaseQuerier(t *testing.T) {
+	db := &FakeDB{}
+	inner := &lastQueryQuerier{querier: db}
+	outer := &lastQueryQuerier{querier: inner}
+	for i, querier := range []Querier{db, inner, outer} {
+		if got, want := baseQuerier(querier), Querier(db); got != want {
+			t.Errorf("%d: got=%T, want=%T", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package sqlr
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// LazyJSON holds the contents of a JSON or JSONB column without decoding it.
+// The JSON text is stored when the row is scanned, and is only decoded when
+// the Decode method is called.
+//
+// LazyJSON is useful for columns containing large JSON documents, where the
+// program only needs part of the document some of the time. For example,
+// a column containing a versioned document can be decoded into a small struct
+// to obtain the version, and then decoded into the appropriate type:
+//  var header struct {
+//      SchemaVersion int `json:"schema_version"`
+//  }
+//  if err := row.Doc.Decode(&header); err != nil {
+//      return err
+//  }
+//  switch header.SchemaVersion {
+//      // ... decode into the appropriate type
+//  }
+// LazyJSON implements the sql.Scanner and driver.Valuer interfaces, so the
+// associated struct field does not need the "json" tag. It also implements
+// the json.Marshaler and json.Unmarshaler interfaces, so it works correctly
+// if the field does have the "json" tag.
+type LazyJSON struct {
+	data []byte
+}
+
+// NewLazyJSON returns a LazyJSON containing the JSON encoding of v.
+func NewLazyJSON(v interface{}) (LazyJSON, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return LazyJSON{}, fmt.Errorf("cannot marshal JSON: %v", err)
+	}
+	return LazyJSON{data: data}, nil
+}
+
+// IsNull reports whether the column value is NULL.
+func (lj LazyJSON) IsNull() bool {
+	return len(lj.data) == 0
+}
+
+// Bytes returns the raw JSON text, which is nil if the column value is NULL.
+func (lj LazyJSON) Bytes() []byte {
+	return lj.data
+}
+
+// Decode unmarshals the JSON text into the value pointed to by v.
+// If the column value is NULL, v is not modified.
+func (lj LazyJSON) Decode(v interface{}) error {
+	if lj.IsNull() {
+		return nil
+	}
+	if err := json.Unmarshal(lj.data, v); err != nil {
+		return fmt.Errorf("cannot unmarshal JSON: %v", err)
+	}
+	return nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (lj *LazyJSON) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		lj.data = nil
+	case []byte:
+		// the driver may reuse the memory, so make a copy
+		lj.data = append([]byte(nil), v...)
+	case string:
+		lj.data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into LazyJSON", src)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (lj LazyJSON) Value() (driver.Value, error) {
+	if lj.IsNull() {
+		return nil, nil
+	}
+	return string(lj.data), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (lj LazyJSON) MarshalJSON() ([]byte, error) {
+	if lj.IsNull() {
+		return []byte("null"), nil
+	}
+	return lj.data, nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (lj *LazyJSON) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		lj.data = nil
+		return nil
+	}
+	lj.data = append([]byte(nil), data...)
+	return nil
+}
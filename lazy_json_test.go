@@ -0,0 +1,108 @@
+package sqlr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLazyJSONDecode(t *testing.T) {
+	var lj LazyJSON
+	doc := []byte(`{"schema_version":2,"name":"widget","tags":["a","b"]}`)
+	if err := lj.Scan(doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the driver may reuse the buffer
+	doc[0] = 'x'
+
+	var header struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := lj.Decode(&header); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := header.SchemaVersion, 2; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	var body struct {
+		Name string
+		Tags []string
+	}
+	if err := lj.Decode(&body); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := body.Name, "widget"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := body.Tags, []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+}
+
+func TestLazyJSONNull(t *testing.T) {
+	var lj LazyJSON
+	if err := lj.Scan(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !lj.IsNull() {
+		t.Error("got=false, want=true")
+	}
+	v := 5
+	if err := lj.Decode(&v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := v, 5; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	value, err := lj.Value()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != nil {
+		t.Errorf("got=%v, want=nil", value)
+	}
+}
+
+func TestLazyJSONErrors(t *testing.T) {
+	var lj LazyJSON
+	if err := lj.Scan(`{"schema_version":"two"}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var header struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := lj.Decode(&header); err == nil {
+		t.Error("got=nil, want=error")
+	}
+	if err := lj.Scan(123); err == nil {
+		t.Error("got=nil, want=error")
+	} else if got, want := err.Error(), "cannot scan int into LazyJSON"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestLazyJSONCell(t *testing.T) {
+	var row struct {
+		Doc LazyJSON `sql:"json"`
+	}
+	jc := newJSONCell("doc", &row.Doc)
+	jc.data = []byte(`{"schema_version":3}`)
+	if err := jc.Unmarshal(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := string(row.Doc.Bytes()), `{"schema_version":3}`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	lj, err := NewLazyJSON(map[string]int{"schema_version": 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	value, err := lj.Value()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := value, `{"schema_version":4}`; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package sqlr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jjeffery/sqlr/private/scanner"
+)
+
+// limitSpec is the number of rows specified by a "{limit n}" or
+// "{limit n offset m}" token in a select query.
+type limitSpec struct {
+	limit  int
+	offset int
+}
+
+// parseLimit parses an identifier of the form "{limit n}" or "{limit n offset m}".
+// Returns ok=false if the identifier is not a limit token.
+func parseLimit(lit string) (spec limitSpec, ok bool, err error) {
+	if lit[0] != '{' {
+		return spec, false, nil
+	}
+	fields := strings.Fields(scanner.Unquote(lit))
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "limit") {
+		return spec, false, nil
+	}
+	if len(fields) != 2 && (len(fields) != 4 || !strings.EqualFold(fields[2], "offset")) {
+		return spec, true, fmt.Errorf("expected %q to be {limit n} or {limit n offset m}", lit)
+	}
+	if spec.limit, err = strconv.Atoi(fields[1]); err != nil || spec.limit < 0 {
+		return spec, true, fmt.Errorf("invalid limit %q in %q", fields[1], lit)
+	}
+	if len(fields) == 4 {
+		if spec.offset, err = strconv.Atoi(fields[3]); err != nil || spec.offset < 0 {
+			return spec, true, fmt.Errorf("invalid offset %q in %q", fields[3], lit)
+		}
+	}
+	return spec, true, nil
+}
+
+// useTop reports whether the limit should be rendered as "select top (n)"
+// for SQL Server. The offset/fetch form requires an order by clause, so
+// top is used for the common "first n rows" case where there is no offset
+// and no order by clause. SQL Server rejects "fetch next 0 rows", so top is
+// also used for a limit of zero, where the offset makes no difference.
+func (spec limitSpec) useTop(orderBy bool) bool {
+	return spec.limit == 0 || (spec.offset == 0 && !orderBy)
+}
+
+// SQL returns the SQL for the limit in the dialect. If top is non-blank, it
+// is inserted immediately after the "select" keyword, and clause replaces
+// the limit token.
+func (spec limitSpec) SQL(dialect Dialect, orderBy bool) (top string, clause string) {
+	if dialectName(dialect) == dialectMSSQL {
+		if spec.useTop(orderBy) {
+			return fmt.Sprintf("top (%d)", spec.limit), ""
+		}
+		if !orderBy {
+			// offset/fetch is not valid without an order by clause
+			clause = "order by (select null) "
+		}
+		clause += fmt.Sprintf("offset %d rows fetch next %d rows only", spec.offset, spec.limit)
+		return "", clause
+	}
+	if dialectName(dialect) == dialectOracle {
+		return "", fmt.Sprintf("offset %d rows fetch next %d rows only", spec.offset, spec.limit)
+	}
+	clause = fmt.Sprintf("limit %d", spec.limit)
+	if spec.offset > 0 {
+		clause += fmt.Sprintf(" offset %d", spec.offset)
+	}
+	return "", clause
+}
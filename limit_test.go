@@ -0,0 +1,157 @@
+package sqlr
+
+import "testing"
+
+func TestLimitSQL(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		spec    limitSpec
+		orderBy bool
+		top     string
+		clause  string
+	}{
+		{
+			dialect: MSSQL,
+			spec:    limitSpec{limit: 10},
+			top:     "top (10)",
+		},
+		{
+			dialect: MSSQL,
+			spec:    limitSpec{limit: 10},
+			orderBy: true,
+			clause:  "offset 0 rows fetch next 10 rows only",
+		},
+		{
+			dialect: MSSQL,
+			spec:    limitSpec{limit: 10, offset: 20},
+			orderBy: true,
+			clause:  "offset 20 rows fetch next 10 rows only",
+		},
+		{
+			dialect: MSSQL,
+			spec:    limitSpec{limit: 10, offset: 20},
+			clause:  "order by (select null) offset 20 rows fetch next 10 rows only",
+		},
+		{
+			dialect: MSSQL,
+			spec:    limitSpec{limit: 0, offset: 20},
+			orderBy: true,
+			top:     "top (0)",
+		},
+		{
+			dialect: Oracle,
+			spec:    limitSpec{limit: 0},
+			clause:  "offset 0 rows fetch next 0 rows only",
+		},
+		{
+			dialect: Postgres,
+			spec:    limitSpec{limit: 10},
+			clause:  "limit 10",
+		},
+		{
+			dialect: Oracle,
+			spec:    limitSpec{limit: 10},
+			clause:  "offset 0 rows fetch next 10 rows only",
+		},
+		{
+			dialect: MySQL,
+			spec:    limitSpec{limit: 10, offset: 20},
+			orderBy: true,
+			clause:  "limit 10 offset 20",
+		},
+	}
+	for i, tt := range tests {
+		top, clause := tt.spec.SQL(tt.dialect, tt.orderBy)
+		if got, want := top, tt.top; got != want {
+			t.Errorf("%d: top: got=%q, want=%q", i, got, want)
+		}
+		if got, want := clause, tt.clause; got != want {
+			t.Errorf("%d: clause: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestLimitToken(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	tests := []struct {
+		dialect Dialect
+		sql     string
+		want    string
+	}{
+		{
+			dialect: MSSQL,
+			sql:     "select {} from rows where name like ? {limit 10}",
+			want:    "select top (10) [id], [name] from rows where name like ?",
+		},
+		{
+			dialect: MSSQL,
+			sql:     "select distinct name from rows {limit 5}",
+			want:    "select distinct top (5) name from rows",
+		},
+		{
+			dialect: MSSQL,
+			sql:     "select {} from rows order by name {limit 10}",
+			want:    "select [id], [name] from rows order by name offset 0 rows fetch next 10 rows only",
+		},
+		{
+			dialect: MSSQL,
+			sql:     "select {} from rows order by name {limit 10 offset 30}",
+			want:    "select [id], [name] from rows order by name offset 30 rows fetch next 10 rows only",
+		},
+		{
+			dialect: MSSQL,
+			sql:     "select {} from rows order by name {limit 0 offset 30}",
+			want:    "select top (0) [id], [name] from rows order by name",
+		},
+		{
+			// order by in a window function is not the order by of the query
+			dialect: MSSQL,
+			sql:     "select id, row_number() over (order by name) as n from rows {limit 10}",
+			want:    "select top (10) id, row_number() over (order by name) as n from rows",
+		},
+		{
+			// order by in a subquery is not the order by of the query
+			dialect: MSSQL,
+			sql:     "select id from rows where id in (select top 5 id from rows order by name) {limit 10}",
+			want:    "select top (10) id from rows where id in (select top 5 id from rows order by name)",
+		},
+		{
+			dialect: Postgres,
+			sql:     "select {} from rows order by name {limit 10 offset 30}",
+			want:    `select "id", "name" from rows order by name limit 10 offset 30`,
+		},
+		{
+			dialect: SQLite,
+			sql:     "select {} from rows {limit 1}",
+			want:    "select `id`, `name` from rows limit 1",
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		stmt, err := schema.Prepare(Row{}, tt.sql)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := stmt.String(), tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+
+	errorTests := []string{
+		"select {} from rows {limit}",
+		"select {} from rows {limit x}",
+		"select {} from rows {limit 10 skip 5}",
+		"select {} from rows {limit 10 offset -1}",
+		"delete from rows where {} {limit 10}",
+	}
+	for i, sql := range errorTests {
+		schema := NewSchema(WithDialect(MSSQL))
+		if _, err := schema.Prepare(Row{}, sql); err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+		}
+	}
+}
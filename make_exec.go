@@ -0,0 +1,118 @@
+package sqlr
+
+import (
+	"reflect"
+)
+
+// MakeExec makes one or more functions that can be used to insert rows in a
+// type-safe manner. Each funcPtr is a pointer to a function that will be created
+// by this function.
+//
+// If "Row" is the row type, then the function can be any one of the following
+// signatures:
+//  // Insert a row. If the row has an auto-increment field, it is
+//  // updated with the generated value.
+//  func(row *Row) error
+//
+//  // Insert a row, and return the generated value of the auto-increment
+//  // column. The auto-increment field of the row is also updated.
+//  func(row *Row) (int64, error)
+//
+// If any of the funcPtr arguments are not pointers to a function, or do not fit
+// one of the known function prototypes, then this function will panic.
+func (sess *Session) MakeExec(funcPtr ...interface{}) {
+	for _, fp := range funcPtr {
+		if err := sess.makeExecFunc(fp); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (sess *Session) makeExecFunc(funcPtr interface{}) error {
+	funcPtrValue := reflect.ValueOf(funcPtr)
+	funcPtrType := funcPtrValue.Type()
+	if funcPtrType.Kind() != reflect.Ptr {
+		return newError("expected pointer to function, got %s", funcPtrType.String())
+	}
+	funcValue := funcPtrValue.Elem()
+	funcType := funcValue.Type()
+	if funcType.Kind() != reflect.Func {
+		return newError("expected pointer to function, got %s", funcPtrType.String())
+	}
+	execFuncFactory, err := insertFunc(funcType, sess.schema)
+	if err != nil {
+		return err
+	}
+	funcValue.Set(execFuncFactory(sess))
+	return nil
+}
+
+// insertFunc returns a func implementation for an insert func.
+// input args:
+//   (row *Row)
+// output args alternatives:
+//   (error)
+//   (int64, error)
+func insertFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.Value, error) {
+	const invalidInputsMsg = "MakeExec: expect insert function inputs to be like (row *Row)"
+	if funcType.NumIn() != 1 {
+		return nil, newError(invalidInputsMsg)
+	}
+	rowPtrType := funcType.In(0)
+	if rowPtrType.Kind() != reflect.Ptr || rowPtrType.Elem().Kind() != reflect.Struct {
+		return nil, newError(invalidInputsMsg)
+	}
+	tbl, err := schema.tableFor(rowPtrType.Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	const invalidOutputsMsg = "MakeExec: expect insert function outputs to be like (error) or (int64, error)"
+	if funcType.NumOut() == 0 || funcType.Out(funcType.NumOut()-1) != wellKnownTypes.errorType {
+		return nil, newError(invalidOutputsMsg)
+	}
+	switch funcType.NumOut() {
+	case 1:
+		return makeInsertFunc(funcType, tbl, false), nil
+	case 2:
+		if funcType.Out(0).Kind() != reflect.Int64 {
+			return nil, newError(invalidOutputsMsg)
+		}
+		if tbl.autoincr == nil {
+			return nil, newError("MakeExec: cannot return generated id: %s has no auto-increment column",
+				tbl.RowType().String())
+		}
+		return makeInsertFunc(funcType, tbl, true), nil
+	}
+	return nil, newError(invalidOutputsMsg)
+}
+
+// makeInsertFunc returns a function that inserts a row. If returnID is true, the
+// function returns the value of the auto-increment column after the insert.
+func makeInsertFunc(funcType reflect.Type, tbl *Table, returnID bool) func(*Session) reflect.Value {
+	return func(sess *Session) reflect.Value {
+		return reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
+			rowPtrValue := args[0]
+			var err error
+			if rowPtrValue.IsNil() {
+				err = newError("cannot insert nil %s", rowPtrValue.Type().String())
+			} else {
+				err = sess.InsertRow(rowPtrValue.Interface())
+			}
+			if !returnID {
+				return []reflect.Value{errorValueFor(err)}
+			}
+			idValue := reflect.New(funcType.Out(0)).Elem()
+			if err == nil {
+				fieldValue := tbl.autoincr.info.Index.ValueRO(rowPtrValue.Elem())
+				switch fieldValue.Kind() {
+				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+					idValue.SetInt(fieldValue.Int())
+				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+					idValue.SetInt(int64(fieldValue.Uint()))
+				}
+			}
+			return []reflect.Value{idValue, errorValueFor(err)}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package sqlr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInsertFuncErrors(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key autoincrement"`
+		Name string
+	}
+	type NoAutoIncr struct {
+		Code string `sql:"primary key"`
+	}
+	tests := []struct {
+		fn      interface{}
+		errText string
+	}{
+		{
+			fn: func(*Row) error { return nil },
+		},
+		{
+			fn: func(*Row) (int64, error) { return 0, nil },
+		},
+		{
+			fn: func(*NoAutoIncr) error { return nil },
+		},
+		{
+			fn:      func(*NoAutoIncr) (int64, error) { return 0, nil },
+			errText: "MakeExec: cannot return generated id: sqlr.NoAutoIncr has no auto-increment column",
+		},
+		{
+			fn:      func(Row) error { return nil },
+			errText: "MakeExec: expect insert function inputs to be like (row *Row)",
+		},
+		{
+			fn:      func(*Row) (string, error) { return "", nil },
+			errText: "MakeExec: expect insert function outputs to be like (error) or (int64, error)",
+		},
+		{
+			fn:      func(*Row) int64 { return 0 },
+			errText: "MakeExec: expect insert function outputs to be like (error) or (int64, error)",
+		},
+	}
+	schema := NewSchema()
+	for i, tt := range tests {
+		var errText string
+		if _, err := insertFunc(reflect.TypeOf(tt.fn), schema); err != nil {
+			errText = err.Error()
+		}
+		if got, want := errText, tt.errText; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
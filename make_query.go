@@ -4,34 +4,160 @@ import (
 	"database/sql"
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jjeffery/kv"
-	"github.com/jjeffery/sqlr/dataloader"
+	"github.com/jjeffery/sqlr/private/scanner"
 )
 
 func makeQuery(funcType reflect.Type, schema *Schema) (func(*Session) reflect.Value, error) {
-	if f := schema.funcMap.lookup(funcType); f != nil {
-		return f, nil
+	return makeQueryWithOptions(funcType, schema, queryOptions{})
+}
+
+// makeQueryWithOptions is similar to makeQuery, except that the options
+// configure the function. Only get functions accept options, and functions
+// made with options are not added to the schema's function map, because
+// the map is keyed by function type alone.
+func makeQueryWithOptions(funcType reflect.Type, schema *Schema, opts queryOptions) (func(*Session) reflect.Value, error) {
+	if opts.isZero() {
+		if f := schema.funcMap.lookup(funcType); f != nil {
+			return f, nil
+		}
+	}
+	getOne := func(funcType reflect.Type, schema *Schema) (func(*Session) reflect.Value, error) {
+		return getOneFunc(funcType, schema, opts)
 	}
-	for _, maker := range []func(reflect.Type, *Schema) (func(*Session) reflect.Value, error){
+	getMany := func(funcType reflect.Type, schema *Schema) (func(*Session) reflect.Value, error) {
+		return getManyFunc(funcType, schema, opts)
+	}
+	deleteMany := func(funcType reflect.Type, schema *Schema) (func(*Session) reflect.Value, error) {
+		return deleteManyFunc(funcType, schema, opts)
+	}
+	makers := []func(reflect.Type, *Schema) (func(*Session) reflect.Value, error){
 		selectFunc,
-		getOneFunc,
-		getManyFunc,
+		getOne,
+		deleteMany,
+		getMany,
 		loadOneFunc,
-	} {
+	}
+	if opts.rowType != nil {
+		makers = []func(reflect.Type, *Schema) (func(*Session) reflect.Value, error){
+			deleteMany,
+		}
+	} else if !opts.isZero() {
+		makers = []func(reflect.Type, *Schema) (func(*Session) reflect.Value, error){
+			getOne,
+			getMany,
+		}
+	}
+	for _, maker := range makers {
 		f, err := maker(funcType, schema)
 		if err != nil {
 			return nil, err
 		}
 		if f != nil {
-			f = schema.funcMap.add(funcType, f)
+			if opts.isZero() {
+				f = schema.funcMap.add(funcType, f)
+			}
 			return f, nil
 		}
 	}
 
+	if opts.rowType != nil {
+		return nil, newError("WithRowType is only supported for delete functions: %v", funcType.String())
+	}
+	if !opts.isZero() {
+		return nil, newError("query options are only supported for get functions: %v", funcType.String())
+	}
 	return nil, newError("cannot recognize function: %v", funcType.String())
 }
 
+// A QueryOption configures a function made by Session.MakeQuery.
+type QueryOption func(opts *queryOptions)
+
+// queryOptions contains the options supplied to Session.MakeQuery.
+type queryOptions struct {
+	fieldNames []string     // fields to select, or empty for all fields
+	rowType    reflect.Type // row type for delete functions, or nil
+}
+
+func (opts queryOptions) isZero() bool {
+	return len(opts.fieldNames) == 0 && opts.rowType == nil
+}
+
+// WithColumns creates an option for Session.MakeQuery that restricts the
+// columns selected by get functions to the columns for the named fields.
+// This is useful for avoiding large columns (eg blobs and JSON documents)
+// when they are not needed:
+//  sess.MakeQuery(&dao.GetLite, WithColumns("ID", "Name"))
+// The primary key columns are always selected, and the other fields of each
+// row returned are left as zero values. The name of a struct field selects
+// all of the columns for its fields, and fields of a struct field are named
+// using a period, eg "Address.Street".
+func WithColumns(fieldNames ...string) QueryOption {
+	return func(opts *queryOptions) {
+		opts.fieldNames = append(opts.fieldNames, fieldNames...)
+	}
+}
+
+// WithRowType creates an option for Session.MakeQuery that specifies the row
+// type for delete functions. The prototype of a delete function does not refer
+// to the row type, so the option is required:
+//  sess.MakeQuery(&dao.DeleteWidgets, WithRowType(Widget{}))
+// The row argument can be a struct, a pointer to a struct, or a reflect.Type.
+func WithRowType(row interface{}) QueryOption {
+	return func(opts *queryOptions) {
+		rowType, ok := row.(reflect.Type)
+		if !ok {
+			rowType = reflect.TypeOf(row)
+		}
+		for rowType != nil && rowType.Kind() == reflect.Ptr {
+			rowType = rowType.Elem()
+		}
+		opts.rowType = rowType
+	}
+}
+
+// tableQuery is a select query for a table used by a get function. If the
+// table has been restricted to some of its columns, then the statement is
+// prepared when the function is made, because statements prepared by the
+// schema always include every column.
+type tableQuery struct {
+	query string
+	stmt  *Stmt // prepared for a restricted table, or nil
+}
+
+// newTableQuery returns a table query for tbl. If fieldNames is not empty,
+// the statement is prepared for a copy of tbl restricted to those fields.
+func newTableQuery(tbl *Table, query string, fieldNames []string) (tableQuery, error) {
+	tq := tableQuery{query: query}
+	if len(fieldNames) == 0 {
+		return tq, nil
+	}
+	tbl, err := tbl.withFields(fieldNames)
+	if err != nil {
+		return tableQuery{}, err
+	}
+	query, err = checkSQL(query)
+	if err != nil {
+		return tableQuery{}, err
+	}
+	if tq.stmt, err = newStmt(tbl.schema, tbl, query); err != nil {
+		return tableQuery{}, err
+	}
+	return tq, nil
+}
+
+// selectRows selects rows using the table query.
+func (tq tableQuery) selectRows(sess *Session, rows interface{}, args ...interface{}) (int, error) {
+	if tq.stmt == nil {
+		return sess.Select(rows, tq.query, args...)
+	}
+	return sess.selectWith(sess.context, tq.stmt, rows, args)
+}
+
 // selectFunc returns a func implementation if the func is a select func.
 // input args alternatives:
 //   (query string, args ...interface{})
@@ -40,6 +166,12 @@ func makeQuery(funcType reflect.Type, schema *Schema) (func(*Session) reflect.Va
 //   (*Row, error)
 //   (int, error)
 //   (int64, error)
+//   (string, error)
+//   (bool, error)
+//   (float64, error)
+//   (time.Time, error)
+//   (*string, error)
+//   (sql.NullString, error)
 // Returns nil if not a match, returns error if the function looks like a query
 // but is not quite conformant.
 func selectFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.Value, error) {
@@ -52,6 +184,10 @@ func selectFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.V
 	if funcType.NumIn() == 1 {
 		return nil, nil
 	}
+	if !funcType.IsVariadic() {
+		// might be a get or load func for a composite primary key
+		return nil, nil
+	}
 	const invalidInputsMsg = "expect query function inputs to be like (query string, args ...interface{})"
 	if funcType.NumIn() != 2 {
 		return nil, newError(invalidInputsMsg)
@@ -69,10 +205,12 @@ func selectFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.V
 		return nil, newError(invalidOutputsMsg)
 	}
 	rowType := funcType.Out(0)
-	switch rowType.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return makeSelectIntFunc(funcType), nil
+	if rowType == wellKnownTypes.boolType {
+		// existence query, see makeSelectBoolFunc
+		return makeSelectBoolFunc(funcType), nil
+	}
+	if isScalarType(rowType) || isNullableScalarType(rowType) {
+		return makeSelectScalarFunc(funcType), nil
 	}
 	if rowType.Kind() == reflect.Slice {
 		rowType = rowType.Elem()
@@ -83,7 +221,10 @@ func selectFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.V
 	if rowType.Kind() != reflect.Struct {
 		return nil, newError("expected struct type, got %v", rowType.String())
 	}
-	tbl := schema.TableFor(rowType)
+	tbl, err := schema.tableFor(rowType)
+	if err != nil {
+		return nil, err
+	}
 	rowPtrType := reflect.PtrTo(rowType)
 	if funcType.Out(0) == rowPtrType {
 		return makeSelectRowFunc(funcType, tbl), nil
@@ -96,11 +237,41 @@ func selectFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.V
 	return nil, newError(invalidOutputsMsg)
 }
 
-func makeSelectIntFunc(funcType reflect.Type) func(*Session) reflect.Value {
+// isScalarType reports whether t is a type that can be scanned directly
+// from the first column of a query result, as opposed to a row struct.
+func isScalarType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64,
+		reflect.Bool,
+		reflect.String:
+		return true
+	}
+	return t == timeType
+}
+
+// isNullableScalarType reports whether t is a scalar type that can represent
+// a NULL value. This is either a pointer to a scalar type (eg *string), or
+// a struct that implements sql.Scanner (eg sql.NullString).
+func isNullableScalarType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		return isScalarType(t.Elem())
+	}
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(wellKnownTypes.scannerType)
+}
+
+// makeSelectScalarFunc returns a function that scans the first column of
+// the first row returned by the query into a scalar value. If the query
+// returns no rows, the function returns sql.ErrNoRows, unless the scalar type
+// is nullable, in which case it returns the zero value (eg nil for *string,
+// or an invalid sql.NullString).
+func makeSelectScalarFunc(funcType reflect.Type) func(*Session) reflect.Value {
+	scalarType := funcType.Out(0)
+	nullable := isNullableScalarType(scalarType)
 	return func(sess *Session) reflect.Value {
 		return reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
-			intType := funcType.Out(0)
-			intPtrValue := reflect.New(intType)
+			scalarPtrValue := reflect.New(scalarType)
 			query := args[0].Interface().(string)
 			queryArgs := args[1].Interface().([]interface{})
 			rows, err := sess.Query(query, queryArgs...)
@@ -110,44 +281,163 @@ func makeSelectIntFunc(funcType reflect.Type) func(*Session) reflect.Value {
 					"args", queryArgs,
 				)
 				return []reflect.Value{
-					intPtrValue.Elem(),
+					scalarPtrValue.Elem(),
 					errorValueFor(err),
 				}
 			}
 			defer rows.Close()
 			if !rows.Next() {
+				if err := rows.Err(); err != nil {
+					return []reflect.Value{
+						scalarPtrValue.Elem(),
+						errorValueFor(err),
+					}
+				}
+				if nullable {
+					return []reflect.Value{
+						scalarPtrValue.Elem(),
+						wellKnownTypes.nilErrorValue,
+					}
+				}
 				return []reflect.Value{
-					intPtrValue.Elem(),
+					scalarPtrValue.Elem(),
 					errorValueFor(sql.ErrNoRows),
 				}
 			}
-			if err := rows.Scan(intPtrValue.Interface()); err != nil {
+			dest := scalarPtrValue.Interface()
+			if scalarType.Kind() == reflect.Bool {
+				dest = boolScanner{value: scalarPtrValue.Convert(reflect.PtrTo(wellKnownTypes.boolType)).Interface().(*bool)}
+			}
+			if err := rows.Scan(dest); err != nil {
 				return []reflect.Value{
-					intPtrValue.Elem(),
+					scalarPtrValue.Elem(),
 					errorValueFor(err),
 				}
 			}
 			if err := rows.Err(); err != nil {
 				return []reflect.Value{
-					intPtrValue.Elem(),
+					scalarPtrValue.Elem(),
+					errorValueFor(err),
+				}
+			}
+			return []reflect.Value{
+				scalarPtrValue.Elem(),
+				wellKnownTypes.nilErrorValue,
+			}
+		})
+	}
+}
+
+// makeSelectBoolFunc returns a function for an existence query. If the
+// query returns no rows, the function returns false. For a query like
+// "select exists(select 1 from ...)", or a query whose first column has a
+// boolean database type, the first column of the first row is interpreted
+// as a boolean, including for databases that return the result as a number.
+// For any other query, such as "select id from ...", the function returns
+// true because a row exists, whatever the value of the first column.
+//
+// This takes precedence over the scalar query for the bool type only. A named
+// bool type, *bool and sql.NullBool are scanned by makeSelectScalarFunc.
+func makeSelectBoolFunc(funcType reflect.Type) func(*Session) reflect.Value {
+	return func(sess *Session) reflect.Value {
+		return reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
+			query := args[0].Interface().(string)
+			queryArgs := args[1].Interface().([]interface{})
+			exists, err := selectExists(sess, query, queryArgs)
+			if err != nil {
+				err = kv.Wrap(err, "cannot query").With(
+					"query", query,
+					"args", queryArgs,
+				)
+				return []reflect.Value{
+					reflect.ValueOf(false),
 					errorValueFor(err),
 				}
 			}
 			return []reflect.Value{
-				intPtrValue.Elem(),
+				reflect.ValueOf(exists),
 				wellKnownTypes.nilErrorValue,
 			}
 		})
 	}
 }
 
+// selectExists performs the query for a function created by makeSelectBoolFunc.
+func selectExists(sess *Session, query string, args []interface{}) (bool, error) {
+	rows, err := sess.Query(query, args...)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return false, rows.Err()
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return false, err
+	}
+	if len(columnTypes) == 0 || !(isExistsQuery(query) || isBoolColumn(columnTypes[0])) {
+		// plain row presence
+		return true, rows.Err()
+	}
+	values := make([]interface{}, len(columnTypes))
+	for i := range values {
+		values[i] = new(interface{})
+	}
+	if err := rows.Scan(values...); err != nil {
+		return false, err
+	}
+	v := *values[0].(*interface{})
+	if v == nil {
+		return false, rows.Err()
+	}
+	b, ok := boolValue(v)
+	if !ok {
+		return false, fmt.Errorf("cannot convert %T to bool", v)
+	}
+	return b, rows.Err()
+}
+
+// isExistsQuery reports whether the query has the form "select exists(...)"
+// or "select not exists(...)".
+func isExistsQuery(query string) bool {
+	scan := scanner.New(strings.NewReader(query))
+	var words []string
+	for len(words) < 3 && scan.Scan() {
+		switch scan.Token() {
+		case scanner.WS, scanner.COMMENT:
+			continue
+		}
+		words = append(words, strings.ToLower(scan.Text()))
+	}
+	if len(words) < 2 || words[0] != "select" {
+		return false
+	}
+	if words[1] == "not" && len(words) > 2 {
+		return words[2] == "exists"
+	}
+	return words[1] == "exists"
+}
+
+// isBoolColumn reports whether the database type of the column is boolean.
+func isBoolColumn(columnType *sql.ColumnType) bool {
+	switch strings.ToUpper(columnType.DatabaseTypeName()) {
+	case "BOOL", "BOOLEAN", "BIT":
+		return true
+	}
+	return false
+}
+
 func makeSelectRowsFunc(funcType reflect.Type, tbl *Table) func(*Session) reflect.Value {
 	return func(sess *Session) reflect.Value {
 		return reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
 			rowsPtrValue := reflect.New(reflect.SliceOf(reflect.PtrTo(tbl.RowType())))
 			query := args[0].Interface().(string)
 			queryArgs := args[1].Interface().([]interface{})
-			_, err := sess.Select(rowsPtrValue.Interface(), query, queryArgs...)
+			err := checkQueryArgs(sess, rowsPtrValue.Interface(), query, queryArgs)
+			if err == nil {
+				_, err = sess.Select(rowsPtrValue.Interface(), query, queryArgs...)
+			}
 			if err != nil {
 				err = kv.Wrap(err, "cannot query rows").With(
 					"rowType", tbl.RowType(),
@@ -171,7 +461,11 @@ func makeSelectRowFunc(funcType reflect.Type, tbl *Table) func(*Session) reflect
 			rowPtrValue := reflect.New(tbl.RowType())
 			query := args[0].Interface().(string)
 			queryArgs := args[1].Interface().([]interface{})
-			n, err := sess.Select(rowPtrValue.Interface(), query, queryArgs...)
+			var n int
+			err := checkQueryArgs(sess, rowPtrValue.Interface(), query, queryArgs)
+			if err == nil {
+				n, err = sess.Select(rowPtrValue.Interface(), query, queryArgs...)
+			}
 			if err != nil {
 				err = kv.Wrap(err, "cannot query one row").With(
 					"rowType", tbl.RowType(),
@@ -193,14 +487,29 @@ func makeSelectRowFunc(funcType reflect.Type, tbl *Table) func(*Session) reflect
 	}
 }
 
-func getOneFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.Value, error) {
-	if funcType.NumIn() != 1 {
+// checkQueryArgs returns an error if the number of args does not match the
+// number of placeholders in the query. Select passes the args straight to the
+// database driver, so without this check a mistake in calling a generated
+// query function is reported by the driver, often with a less helpful message.
+func checkQueryArgs(sess *Session, rows interface{}, query string, args []interface{}) error {
+	stmt, err := sess.schema.Prepare(rows, query)
+	if err != nil {
+		return err
+	}
+	if len(args) != stmt.argCount {
+		return fmt.Errorf("wrong number of args: query has %d placeholders, got %d args", stmt.argCount, len(args))
+	}
+	return nil
+}
+
+func getOneFunc(funcType reflect.Type, schema *Schema, opts queryOptions) (func(*Session) reflect.Value, error) {
+	if funcType.NumIn() == 0 || funcType.IsVariadic() {
 		return nil, nil
 	}
 	if funcType.NumOut() != 2 {
 		return nil, nil
 	}
-	if funcType.In(0).Kind() == reflect.Slice {
+	if funcType.NumIn() == 1 && funcType.In(0).Kind() == reflect.Slice {
 		return nil, nil
 	}
 	if funcType.Out(1) != wellKnownTypes.errorType {
@@ -214,35 +523,105 @@ func getOneFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.V
 	if rowType.Kind() != reflect.Struct {
 		return nil, newError("expecting first return arg to be a pointer to struct")
 	}
-	tbl := schema.TableFor(rowType)
-	if len(tbl.PrimaryKey()) == 0 {
-
+	tbl, err := schema.tableFor(rowType)
+	if err != nil {
+		return nil, err
 	}
-
-	pkCol, err := getPKCol(tbl)
+	if err := checkNotSharded(tbl, "get"); err != nil {
+		return nil, err
+	}
+	if err := checkPKArgs(funcType, tbl, "get"); err != nil {
+		return nil, err
+	}
+	if len(tbl.PrimaryKey()) > 1 {
+		query := fmt.Sprintf("select {} from %s where %s", tbl.Name(), pkCondition(tbl))
+		if cond := tbl.notDeleted(""); cond != "" {
+			query += " and " + cond
+		}
+		tq, err := newTableQuery(tbl, query, opts.fieldNames)
+		if err != nil {
+			return nil, err
+		}
+		return makeGetOneCompositeFunc(funcType, tbl, tq), nil
+	}
+	query := fmt.Sprintf("select {} from %s where {}", tbl.Name())
+	if cond := tbl.notDeleted(""); cond != "" {
+		query += " and " + cond
+	}
+	tq, err := newTableQuery(tbl, query, opts.fieldNames)
 	if err != nil {
 		return nil, err
 	}
+	return makeGetOneFunc(funcType, tbl, tq), nil
+}
 
-	inType := funcType.In(0)
-	if inType != pkCol.info.Field.Type {
-		return nil, newError("looks like a get func, but %s has primary key type of %s", tbl.RowType().String(), pkCol.info.Field.Type.String())
+func makeGetOneFunc(funcType reflect.Type, tbl *Table, tq tableQuery) func(*Session) reflect.Value {
+	rc := tbl.schema.rowCacheFor(tbl.RowType())
+	if tq.stmt != nil {
+		// rows with only some columns are not cached
+		rc = nil
+	}
+	return func(sess *Session) reflect.Value {
+		rc := rc
+		if _, ok := baseQuerier(sess.querier).(*sql.Tx); ok {
+			// a transaction can see uncommitted changes, and can see
+			// rows that other sessions cannot, so bypass the cache
+			rc = nil
+		}
+		return reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
+			key := args[0].Interface()
+			var generation int64
+			if rc != nil {
+				if rowPtrValue, ok := rc.get(key); ok {
+					// call the handlers as if the row had been selected
+					sess.callRowHandlers(tbl, rowPtrValue.Interface(), 1)
+					return []reflect.Value{
+						rowPtrValue,
+						wellKnownTypes.nilErrorValue,
+					}
+				}
+				generation = rc.currentGeneration()
+			}
+			rowPtrValue := reflect.New(tbl.RowType())
+			queryArgs := []interface{}{key}
+			n, err := tq.selectRows(sess, rowPtrValue.Interface(), queryArgs...)
+			if err == nil && n > 0 && rc != nil {
+				rc.set(key, rowPtrValue.Elem(), generation)
+			}
+			if err != nil {
+				err = kv.Wrap(err, "cannot get one row").With(
+					"rowType", tbl.RowType(),
+					"query", tq.query,
+					"args", queryArgs,
+				)
+				rowPtrValue = reflect.Zero(reflect.PtrTo(tbl.RowType()))
+			} else if n == 0 {
+				// nothing returned, so zero out the pointer
+				rowPtrValue = reflect.Zero(reflect.PtrTo(tbl.RowType()))
+			}
+			return []reflect.Value{
+				rowPtrValue,
+				errorValueFor(err),
+			}
+		})
 	}
-
-	return makeGetOneFunc(funcType, tbl), nil
 }
 
-func makeGetOneFunc(funcType reflect.Type, tbl *Table) func(*Session) reflect.Value {
+// makeGetOneCompositeFunc makes a get function for a table with a composite
+// primary key, which has one input argument for each primary key column.
+func makeGetOneCompositeFunc(funcType reflect.Type, tbl *Table, tq tableQuery) func(*Session) reflect.Value {
 	return func(sess *Session) reflect.Value {
 		return reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
+			queryArgs := make([]interface{}, len(args))
+			for i, arg := range args {
+				queryArgs[i] = arg.Interface()
+			}
 			rowPtrValue := reflect.New(tbl.RowType())
-			query := fmt.Sprintf("select {} from %s where {}", tbl.Name())
-			queryArgs := []interface{}{args[0].Interface()}
-			n, err := sess.Select(rowPtrValue.Interface(), query, queryArgs...)
+			n, err := tq.selectRows(sess, rowPtrValue.Interface(), queryArgs...)
 			if err != nil {
 				err = kv.Wrap(err, "cannot get one row").With(
 					"rowType", tbl.RowType(),
-					"query", query,
+					"query", tq.query,
 					"args", queryArgs,
 				)
 				rowPtrValue = reflect.Zero(reflect.PtrTo(tbl.RowType()))
@@ -258,7 +637,42 @@ func makeGetOneFunc(funcType reflect.Type, tbl *Table) func(*Session) reflect.Va
 	}
 }
 
-func getManyFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.Value, error) {
+// pkCondition returns the SQL condition that matches the primary key
+// columns of the table with placeholders, eg "`a` = ? and `b` = ?".
+func pkCondition(tbl *Table) string {
+	var conds []string
+	for _, col := range tbl.PrimaryKey() {
+		conds = append(conds, fmt.Sprintf("`%s` = ?", col.Name()))
+	}
+	return strings.Join(conds, " and ")
+}
+
+// pkInCondition returns the SQL condition that matches n composite primary
+// key values with placeholders. Where the dialect supports row values, this is
+// a tuple IN condition, eg "(`a`, `b`) in ((?, ?), (?, ?))". SQL Server does not
+// support row values, so the condition for each key is joined with "or".
+func pkInCondition(tbl *Table, n int) string {
+	if dialectName(tbl.schema.getDialect()) == dialectMSSQL {
+		conds := make([]string, n)
+		for i := range conds {
+			conds[i] = "(" + pkCondition(tbl) + ")"
+		}
+		return strings.Join(conds, " or ")
+	}
+	var names, placeholders []string
+	for _, col := range tbl.PrimaryKey() {
+		names = append(names, fmt.Sprintf("`%s`", col.Name()))
+		placeholders = append(placeholders, "?")
+	}
+	tuple := "(" + strings.Join(placeholders, ", ") + ")"
+	tuples := make([]string, n)
+	for i := range tuples {
+		tuples[i] = tuple
+	}
+	return fmt.Sprintf("(%s) in (%s)", strings.Join(names, ", "), strings.Join(tuples, ", "))
+}
+
+func getManyFunc(funcType reflect.Type, schema *Schema, opts queryOptions) (func(*Session) reflect.Value, error) {
 	if funcType.NumIn() != 1 {
 		return nil, nil
 	}
@@ -283,7 +697,13 @@ func getManyFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.
 	if rowType.Kind() != reflect.Struct {
 		return nil, newError("expecting first return arg to be a slice of pointer to struct")
 	}
-	tbl := schema.TableFor(rowType)
+	tbl, err := schema.tableFor(rowType)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotSharded(tbl, "get"); err != nil {
+		return nil, err
+	}
 	pkCol, err := getPKCol(tbl)
 	if err != nil {
 		return nil, err
@@ -294,24 +714,36 @@ func getManyFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.
 		return nil, newError("looks like a get func, but %s has primary key type of %s", tbl.RowType().String(), pkCol.info.Field.Type.String())
 	}
 
-	return makeGetManyFunc(funcType, tbl), nil
+	tq, err := newTableQuery(tbl, getManyQuery(tbl), opts.fieldNames)
+	if err != nil {
+		return nil, err
+	}
+	return makeGetManyFunc(funcType, tbl, tq), nil
+}
+
+// getManyQuery returns the query for selecting rows by a list of
+// primary key values.
+func getManyQuery(tbl *Table) string {
+	query := fmt.Sprintf("select {} from %s where `%s` in (?)", tbl.Name(), tbl.PrimaryKey()[0].Name())
+	if cond := tbl.notDeleted(""); cond != "" {
+		query += " and " + cond
+	}
+	return query
 }
 
-func makeGetManyFunc(funcType reflect.Type, tbl *Table) func(*Session) reflect.Value {
+func makeGetManyFunc(funcType reflect.Type, tbl *Table, tq tableQuery) func(*Session) reflect.Value {
 	return func(sess *Session) reflect.Value {
 		return reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
 			var err error
 			rowsPtrValue := reflect.New(reflect.SliceOf(reflect.PtrTo(tbl.RowType())))
 			idsValue := args[0]
 			if idsValue.Len() > 0 {
-				pkColName := tbl.PrimaryKey()[0].Name()
-				query := fmt.Sprintf("select {} from %s where `%s` in (?)", tbl.Name(), pkColName)
 				ids := idsValue.Interface()
-				_, err = sess.Select(rowsPtrValue.Interface(), query, ids)
+				_, err = tq.selectRows(sess, rowsPtrValue.Interface(), ids)
 				if err != nil {
 					err = kv.Wrap(err, "cannot get rows").With(
 						"rowType", tbl.RowType(),
-						"query", query,
+						"query", tq.query,
 						"args", ids,
 					)
 				}
@@ -325,14 +757,114 @@ func makeGetManyFunc(funcType reflect.Type, tbl *Table) func(*Session) reflect.V
 	}
 }
 
-func loadOneFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.Value, error) {
+// deleteManyFunc returns a func implementation if the func is a delete func
+// with a slice of primary key values as input, and the number of rows deleted
+// as output:
+//   (ids ...RowID) (int, error)
+//   (ids []RowID) (int, error)
+// Because the function prototype does not refer to the row type, the row type
+// must be specified using the WithRowType option.
+func deleteManyFunc(funcType reflect.Type, schema *Schema, opts queryOptions) (func(*Session) reflect.Value, error) {
 	if funcType.NumIn() != 1 {
 		return nil, nil
 	}
+	if funcType.NumOut() != 2 {
+		return nil, nil
+	}
+	if funcType.In(0).Kind() != reflect.Slice {
+		return nil, nil
+	}
+	if funcType.Out(0) != wellKnownTypes.intType {
+		return nil, nil
+	}
+	if funcType.Out(1) != wellKnownTypes.errorType {
+		return nil, newError("expecting second return arg to be error")
+	}
+	if opts.rowType == nil {
+		return nil, newError("looks like a delete func, but the row type is not specified using WithRowType: %s", funcType.String())
+	}
+	if len(opts.fieldNames) > 0 {
+		return nil, newError("WithColumns is not supported for a delete func: %s", funcType.String())
+	}
+	if opts.rowType.Kind() != reflect.Struct {
+		return nil, newError("expected row type to be a struct, got %s", opts.rowType.String())
+	}
+	tbl, err := schema.tableFor(opts.rowType)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotSharded(tbl, "delete"); err != nil {
+		return nil, err
+	}
+	pkCols := tbl.PrimaryKey()
+	switch len(pkCols) {
+	case 0:
+		return nil, newError("no primary key for a delete func: %s", opts.rowType.String())
+	case 1:
+	default:
+		return nil, newError("composite primary key not supported for a delete func: %s", opts.rowType.String())
+	}
+	keyType := funcType.In(0).Elem()
+	if pkType := pkCols[0].fieldType(); keyType != pkType {
+		return nil, newError("delete func has key type %s, but primary key of %s has type %s",
+			keyType.String(), opts.rowType.String(), pkType.String())
+	}
+	return makeDeleteManyFunc(funcType, tbl), nil
+}
+
+func makeDeleteManyFunc(funcType reflect.Type, tbl *Table) func(*Session) reflect.Value {
+	rc := tbl.schema.rowCacheFor(tbl.RowType())
+	query := fmt.Sprintf("delete from %s where `%s` in (?)", tbl.Name(), tbl.PrimaryKey()[0].Name())
+	if tbl.deletedAt != nil {
+		// soft delete
+		query = fmt.Sprintf("update %s set `%s` = ? where `%s` in (?) and `%s` is null",
+			tbl.Name(), tbl.deletedAt.Name(), tbl.PrimaryKey()[0].Name(), tbl.deletedAt.Name())
+	}
+	return func(sess *Session) reflect.Value {
+		return reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
+			var n int
+			var err error
+			idsValue := args[0]
+			if idsValue.Len() > 0 {
+				if rc != nil {
+					defer rc.invalidateAll()
+				}
+				ids := idsValue.Interface()
+				queryArgs := []interface{}{ids}
+				if tbl.deletedAt != nil {
+					queryArgs = []interface{}{tbl.deletedAt.timeArg(time.Now()), ids}
+				}
+				var result sql.Result
+				result, err = sess.Exec(query, queryArgs...)
+				if err == nil {
+					var count int64
+					count, err = result.RowsAffected()
+					n = int(count)
+				}
+				if err != nil {
+					err = kv.Wrap(err, "cannot delete rows").With(
+						"rowType", tbl.RowType(),
+						"query", query,
+						"args", ids,
+					)
+				}
+			}
+			return []reflect.Value{
+				reflect.ValueOf(n),
+				errorValueFor(err),
+			}
+		})
+	}
+}
+
+func loadOneFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.Value, error) {
+	if funcType.NumIn() == 0 || funcType.IsVariadic() {
+		return nil, nil
+	}
 	if funcType.NumOut() != 1 {
 		return nil, nil
 	}
-	if funcType.In(0).Kind() == reflect.Slice {
+	if funcType.NumIn() == 1 && funcType.In(0).Kind() == reflect.Slice {
 		return nil, nil
 	}
 
@@ -358,28 +890,52 @@ func loadOneFunc(funcType reflect.Type, schema *Schema) (func(*Session) reflect.
 	if rowType.Kind() != reflect.Struct {
 		return nil, invalidOutputsErr
 	}
-	tbl := schema.TableFor(rowType)
-
-	pkCol, err := getPKCol(tbl)
+	tbl, err := schema.tableFor(rowType)
 	if err != nil {
 		return nil, err
 	}
-
-	inType := funcType.In(0)
-	if inType != pkCol.info.Field.Type {
-		return nil, newError("looks like a load func, but %s has primary key type of %s", tbl.RowType().String(), pkCol.info.Field.Type.String())
+	if err := checkNotSharded(tbl, "load"); err != nil {
+		return nil, err
+	}
+	if err := checkPKArgs(funcType, tbl, "load"); err != nil {
+		return nil, err
+	}
+	if len(tbl.PrimaryKey()) > 1 {
+		return makeLoadOneCompositeFunc(funcType, tbl), nil
 	}
-
 	return makeLoadOneFunc(funcType, tbl), nil
 }
 
+// checkPKArgs checks that the input args of a get or load function match
+// the types of the primary key fields, in the order that they are declared.
+func checkPKArgs(funcType reflect.Type, tbl *Table, kind string) error {
+	pkCols := tbl.PrimaryKey()
+	if len(pkCols) == 0 {
+		return newError("no primary key defined for %s (table %s)", tbl.RowType().String(), tbl.Name())
+	}
+	if funcType.NumIn() != len(pkCols) {
+		return newError("looks like a %s func, but %s has %d primary key columns and the func has %d args",
+			kind, tbl.RowType().String(), len(pkCols), funcType.NumIn())
+	}
+	for i, col := range pkCols {
+		if inType := funcType.In(i); inType != col.info.Field.Type {
+			if len(pkCols) == 1 {
+				return newError("looks like a %s func, but %s has primary key type of %s", kind, tbl.RowType().String(), col.info.Field.Type.String())
+			}
+			return newError("looks like a %s func, but arg %d has type %s and primary key field %s of %s has type %s",
+				kind, i+1, inType.String(), col.info.FieldNames, tbl.RowType().String(), col.info.Field.Type.String())
+		}
+	}
+	return nil
+}
+
 func makeLoadOneFunc(funcType reflect.Type, tbl *Table) func(*Session) reflect.Value {
 	return func(sess *Session) reflect.Value {
 		pkCol := tbl.PrimaryKey()[0]
 		queryFuncIn := []reflect.Type{reflect.SliceOf(pkCol.fieldType())}
 		queryFuncOut := []reflect.Type{reflect.SliceOf(reflect.PtrTo(tbl.RowType())), wellKnownTypes.errorType}
 		queryFuncType := reflect.FuncOf(queryFuncIn, queryFuncOut, false)
-		queryFuncValue := makeGetManyFunc(queryFuncType, tbl)(sess)
+		queryFuncValue := makeGetManyFunc(queryFuncType, tbl, tableQuery{query: getManyQuery(tbl)})(sess)
 
 		keyFuncIn := []reflect.Type{reflect.PtrTo(tbl.RowType())}
 		keyFuncOut := []reflect.Type{pkCol.fieldType()}
@@ -392,15 +948,94 @@ func makeLoadOneFunc(funcType reflect.Type, tbl *Table) func(*Session) reflect.V
 		})
 
 		loadFuncPtrValue := reflect.New(funcType)
-		dataloader.Make(loadFuncPtrValue.Interface(), queryFuncValue.Interface(), keyFuncValue.Interface())
+		sess.batchGroup().Make(loadFuncPtrValue.Interface(), queryFuncValue.Interface(), keyFuncValue.Interface())
 		return loadFuncPtrValue.Elem()
 	}
 }
 
+// makeLoadOneCompositeFunc makes a load function for a table with a composite
+// primary key. The dataloader requires a single key, so the primary key values
+// are combined into a struct, which is used as the key of an inner load function.
+func makeLoadOneCompositeFunc(funcType reflect.Type, tbl *Table) func(*Session) reflect.Value {
+	pkCols := tbl.PrimaryKey()
+	keyFields := make([]reflect.StructField, len(pkCols))
+	for i, col := range pkCols {
+		keyFields[i] = reflect.StructField{
+			Name: "K" + strconv.Itoa(i),
+			Type: col.fieldType(),
+		}
+	}
+	keyType := reflect.StructOf(keyFields)
+	rowPtrType := reflect.PtrTo(tbl.RowType())
+
+	return func(sess *Session) reflect.Value {
+		queryFuncType := reflect.FuncOf(
+			[]reflect.Type{reflect.SliceOf(keyType)},
+			[]reflect.Type{reflect.SliceOf(rowPtrType), wellKnownTypes.errorType},
+			false,
+		)
+		queryFuncValue := reflect.MakeFunc(queryFuncType, func(args []reflect.Value) []reflect.Value {
+			var err error
+			rowsPtrValue := reflect.New(reflect.SliceOf(rowPtrType))
+			keysValue := args[0]
+			if keysValue.Len() > 0 {
+				var queryArgs []interface{}
+				for i := 0; i < keysValue.Len(); i++ {
+					keyValue := keysValue.Index(i)
+					for j := range pkCols {
+						queryArgs = append(queryArgs, keyValue.Field(j).Interface())
+					}
+				}
+				cond := pkInCondition(tbl, keysValue.Len())
+				if notDeleted := tbl.notDeleted(""); notDeleted != "" {
+					cond = "(" + cond + ") and " + notDeleted
+				}
+				query := fmt.Sprintf("select {} from %s where %s", tbl.Name(), cond)
+				_, err = sess.Select(rowsPtrValue.Interface(), query, queryArgs...)
+				if err != nil {
+					err = kv.Wrap(err, "cannot get rows").With(
+						"rowType", tbl.RowType(),
+						"query", query,
+						"args", queryArgs,
+					)
+				}
+			}
+			return []reflect.Value{
+				rowsPtrValue.Elem(),
+				errorValueFor(err),
+			}
+		})
+
+		keyFuncType := reflect.FuncOf([]reflect.Type{rowPtrType}, []reflect.Type{keyType}, false)
+		keyFuncValue := reflect.MakeFunc(keyFuncType, func(args []reflect.Value) []reflect.Value {
+			rowValue := args[0].Elem()
+			keyValue := reflect.New(keyType).Elem()
+			for i, col := range pkCols {
+				keyValue.Field(i).Set(rowValue.FieldByIndex([]int(col.fieldIndex())))
+			}
+			return []reflect.Value{keyValue}
+		})
+
+		innerFuncType := reflect.FuncOf([]reflect.Type{keyType}, []reflect.Type{funcType.Out(0)}, false)
+		innerFuncPtrValue := reflect.New(innerFuncType)
+		sess.batchGroup().Make(innerFuncPtrValue.Interface(), queryFuncValue.Interface(), keyFuncValue.Interface())
+		innerFuncValue := innerFuncPtrValue.Elem()
+
+		return reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
+			keyValue := reflect.New(keyType).Elem()
+			for i, arg := range args {
+				keyValue.Field(i).Set(arg)
+			}
+			return innerFuncValue.Call([]reflect.Value{keyValue})
+		})
+	}
+}
+
 func getPKCol(tbl *Table) (*Column, error) {
 	pkCols := tbl.PrimaryKey()
 	if len(pkCols) > 1 {
-		return nil, newError("compositeprimary key not supported: %s", tbl.RowType().String())
+		return nil, newError("composite primary key not supported for a get func with a slice arg, "+
+			"use a get or load func with one arg for each primary key field: %s", tbl.RowType().String())
 	}
 	if len(pkCols) == 0 {
 		return nil, newError("no primary key defined for %s (table %s)", tbl.RowType().String(), tbl.Name())
@@ -410,6 +1045,15 @@ func getPKCol(tbl *Table) (*Column, error) {
 
 type rowFuncError string
 
+// checkNotSharded returns an error if the table has a ShardFunc. Functions
+// that are passed keys instead of rows cannot determine the shard table.
+func checkNotSharded(tbl *Table, kind string) error {
+	if tbl.isSharded() {
+		return newError("%s func not supported for %s, which has a ShardFunc", kind, tbl.RowType().String())
+	}
+	return nil
+}
+
 func newError(format string, args ...interface{}) rowFuncError {
 	msg := fmt.Sprintf(format, args...)
 	return rowFuncError(msg)
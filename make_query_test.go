@@ -0,0 +1,541 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectBoolFunc(t *testing.T) {
+	tests := []struct {
+		rowCount int
+		want     bool
+	}{
+		{rowCount: 0, want: false},
+		{rowCount: 1, want: true},
+		{rowCount: 3, want: true},
+	}
+	for i, tt := range tests {
+		db := rowsDB(t, tt.rowCount)
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+		var exists func(query string, args ...interface{}) (bool, error)
+		sess.MakeQuery(&exists)
+		got, err := exists("select id, name from rows where name = ?", "name")
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		} else if want := tt.want; got != want {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+		sess.Close()
+		db.Close()
+	}
+}
+
+func TestSelectNamedBoolFunc(t *testing.T) {
+	type Flag bool
+	db := rowsDB(t, 0)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+	defer sess.Close()
+	var selectFlag func(query string, args ...interface{}) (Flag, error)
+	var selectNullBool func(query string, args ...interface{}) (*bool, error)
+	sess.MakeQuery(&selectFlag, &selectNullBool)
+
+	// only bool is an existence query, a named bool type is a scalar,
+	// so no rows is an error
+	if _, err := selectFlag("select id from rows"); err != sql.ErrNoRows {
+		t.Errorf("got=%v, want=%v", err, sql.ErrNoRows)
+	}
+	if got, err := selectNullBool("select id from rows"); err != nil || got != nil {
+		t.Errorf("got=%v, %v, want=nil, nil", got, err)
+	}
+}
+
+func TestBoolValue(t *testing.T) {
+	tests := []struct {
+		v      interface{}
+		want   bool
+		wantOK bool
+	}{
+		{v: true, want: true, wantOK: true},
+		{v: int64(0), want: false, wantOK: true},
+		{v: int64(1), want: true, wantOK: true},
+		{v: float64(0), want: false, wantOK: true},
+		{v: float64(1), want: true, wantOK: true},
+		{v: []byte("t"), want: true, wantOK: true},
+		{v: []byte{1}, want: true, wantOK: true},
+		{v: []byte{0}, want: false, wantOK: true},
+		{v: "false", want: false, wantOK: true},
+		{v: "1.0", want: true, wantOK: true},
+		{v: "widget", want: false, wantOK: false},
+		{v: nil, want: false, wantOK: false},
+	}
+	for i, tt := range tests {
+		got, ok := boolValue(tt.v)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%d: got=%v,%v, want=%v,%v", i, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSelectFuncArgCount(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 2)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	var selectRows func(query string, args ...interface{}) ([]*Row, error)
+	var selectRow func(query string, args ...interface{}) (*Row, error)
+	sess.MakeQuery(&selectRows, &selectRow)
+
+	tests := []struct {
+		query   string
+		args    []interface{}
+		errText string
+	}{
+		{
+			query: "select {} from rows where id = ? and name = ?",
+			args:  []interface{}{1, "name"},
+		},
+		{
+			query:   "select {} from rows where id = ? and name = ?",
+			args:    []interface{}{1},
+			errText: "wrong number of args: query has 2 placeholders, got 1 args",
+		},
+		{
+			query:   "select {} from rows where id = $1",
+			args:    []interface{}{1, 2},
+			errText: "wrong number of args: query has 1 placeholders, got 2 args",
+		},
+		{
+			query: "select {} from rows where id in (?)",
+			args:  []interface{}{[]int{1, 2, 3}},
+		},
+	}
+	for i, tt := range tests {
+		_, err1 := selectRows(tt.query, tt.args...)
+		_, err2 := selectRow(tt.query, tt.args...)
+		for _, err := range []error{err1, err2} {
+			if tt.errText == "" {
+				if err != nil {
+					t.Errorf("%d: unexpected error: %v", i, err)
+				}
+				continue
+			}
+			if err == nil {
+				t.Errorf("%d: got=nil, want=error", i)
+				continue
+			}
+			for _, want := range []string{tt.errText, "sqlr.Row", tt.query} {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("%d: got=%q, want it to contain %q", i, err.Error(), want)
+				}
+			}
+		}
+	}
+}
+
+func TestSessionFlush(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	for _, flush := range []bool{false, true} {
+		db := rowsDB(t, 3)
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+		var load func(id int64) func() (*Row, error)
+		sess.MakeQuery(&load)
+		thunks := []func() (*Row, error){load(1), load(3)}
+		if flush {
+			sess.Flush()
+		}
+
+		// the database is no longer available, so only thunks that have
+		// been loaded succeed
+		db.Close()
+		for i, thunk := range thunks {
+			row, err := thunk()
+			if !flush {
+				if err == nil {
+					t.Errorf("%d: got=nil, want=error", i)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%d: unexpected error: %v", i, err)
+			} else if row == nil || row.Name != "name" {
+				t.Errorf("%d: got=%+v", i, row)
+			}
+		}
+		sess.Close()
+	}
+}
+
+func TestSessionCloseFlushes(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 3)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+	var load func(id int64) func() (*Row, error)
+	sess.MakeQuery(&load)
+	thunk := load(2)
+	sess.Close()
+
+	row, err := thunk()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if row == nil || row.ID != 2 {
+		t.Errorf("got=%+v", row)
+	}
+}
+
+func TestCompositeKeyFuncs(t *testing.T) {
+	type Row struct {
+		ID   int64  `sql:"primary key"`
+		Name string `sql:"primary key"`
+	}
+	db := rowsDB(t, 3)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	var get func(id int64, name string) (*Row, error)
+	var load func(id int64, name string) func() (*Row, error)
+	sess.MakeQuery(&get, &load)
+
+	row, err := get(1, "name")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if row == nil || row.ID != 1 || row.Name != "name" {
+		t.Errorf("got=%+v", row)
+	}
+
+	thunks := []func() (*Row, error){load(3, "name"), load(2, "name"), load(4, "name")}
+	for i, want := range []int64{3, 2, 0} {
+		row, err := thunks[i]()
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if want == 0 {
+			if row != nil {
+				t.Errorf("%d: got=%+v, want=nil", i, row)
+			}
+			continue
+		}
+		if row == nil || row.ID != want {
+			t.Errorf("%d: got=%+v, want ID=%d", i, row, want)
+		}
+	}
+}
+
+func TestCompositeKeyFuncErrors(t *testing.T) {
+	type Row struct {
+		ID   int64  `sql:"primary key"`
+		Name string `sql:"primary key"`
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	tests := []struct {
+		fn      interface{}
+		errText string
+	}{
+		{
+			fn:      new(func(id int64) (*Row, error)),
+			errText: "looks like a get func, but sqlr.Row has 2 primary key columns and the func has 1 args",
+		},
+		{
+			fn:      new(func(name string, id int64) (*Row, error)),
+			errText: "looks like a get func, but arg 1 has type string and primary key field ID of sqlr.Row has type int64",
+		},
+		{
+			fn:      new(func(id int64, name int) func() (*Row, error)),
+			errText: "looks like a load func, but arg 2 has type int and primary key field Name of sqlr.Row has type string",
+		},
+		{
+			fn:      new(func(ids []int64) ([]*Row, error)),
+			errText: "composite primary key not supported for a get func with a slice arg, use a get or load func with one arg for each primary key field: sqlr.Row",
+		},
+	}
+	for i, tt := range tests {
+		_, err := makeQuery(reflect.TypeOf(tt.fn).Elem(), schema)
+		if err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+			continue
+		}
+		if got, want := err.Error(), tt.errText; got != want {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, want)
+		}
+	}
+}
+
+func TestCompositeKeyGetSQL(t *testing.T) {
+	type Row struct {
+		ID   int64  `sql:"primary key"`
+		Name string `sql:"primary key"`
+	}
+	tests := []struct {
+		dialect Dialect
+		want    []string
+	}{
+		{
+			dialect: Postgres,
+			want: []string{
+				`select "id", "name" from row where "id" = $1 and "name" = $2`,
+				`select "id", "name" from row where ("id", "name") in (($1, $2), ($3, $4))`,
+			},
+		},
+		{
+			dialect: MSSQL,
+			want: []string{
+				`select [id], [name] from row where [id] = ? and [name] = ?`,
+				`select [id], [name] from row where ([id] = ? and [name] = ?) or ([id] = ? and [name] = ?)`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		db := &FakeDB{queryErr: errors.New("query failed")}
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(tt.dialect)))
+		var get func(id int64, name string) (*Row, error)
+		var load func(id int64, name string) func() (*Row, error)
+		sess.MakeQuery(&get, &load)
+
+		if _, err := get(1, "one"); err == nil {
+			t.Fatal("got=nil, want=error")
+		}
+		thunks := []func() (*Row, error){load(1, "one"), load(2, "two")}
+		if _, err := thunks[0](); err == nil {
+			t.Fatal("got=nil, want=error")
+		}
+		if got := db.queries; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got=%q\nwant=%q", dialectName(tt.dialect), got, tt.want)
+		}
+		sess.Close()
+	}
+}
+
+func TestDeleteManyFunc(t *testing.T) {
+	type WidgetID int64
+	type Widget struct {
+		ID   WidgetID `sql:"primary key"`
+		Name string
+	}
+	type OtherWidget struct {
+		ID   WidgetID `sql:"primary key"`
+		Name string
+	}
+	db := &FakeDB{rowsAffected: 2}
+	schema := NewSchema(WithDialect(Postgres))
+	// another table with the same key type does not affect the delete func
+	schema.TableFor(OtherWidget{})
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	var deleteMany func(ids ...WidgetID) (int, error)
+	var deleteSlice func(ids []WidgetID) (int, error)
+	sess.MakeQuery(&deleteMany, &deleteSlice, WithRowType(&Widget{}))
+
+	n, err := deleteMany(1, 2)
+	wantNoError(t, err)
+	if got, want := n, 2; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	n, err = deleteSlice(nil)
+	wantNoError(t, err)
+	if got, want := n, 0; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	n, err = deleteSlice([]WidgetID{3})
+	wantNoError(t, err)
+	want := []string{
+		`delete from widget where "id" in ($1,$2)`,
+		`delete from widget where "id" in ($1)`,
+	}
+	if got := db.queries; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%q\nwant=%q", got, want)
+	}
+
+	db.execErr = errors.New("exec failed")
+	if _, err := deleteMany(4); err == nil {
+		t.Error("got=nil, want=error")
+	}
+}
+
+func TestDeleteManyFuncErrors(t *testing.T) {
+	type KeyID string
+	type Keyed struct {
+		ID   KeyID `sql:"primary key"`
+		Name string
+	}
+	type PartID int64
+	type Part struct {
+		ID   PartID `sql:"primary key"`
+		Name string `sql:"primary key"`
+	}
+	type NoKey struct {
+		Name string
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	tests := []struct {
+		fn      interface{}
+		opts    []QueryOption
+		errText string
+	}{
+		{
+			fn:      new(func(ids ...KeyID) (int, error)),
+			errText: "looks like a delete func, but the row type is not specified using WithRowType: func(...sqlr.KeyID) (int, error)",
+		},
+		{
+			fn:      new(func(ids ...float64) (int, error)),
+			opts:    []QueryOption{WithRowType(Keyed{})},
+			errText: "delete func has key type float64, but primary key of sqlr.Keyed has type sqlr.KeyID",
+		},
+		{
+			fn:      new(func(ids []PartID) (int, error)),
+			opts:    []QueryOption{WithRowType(Part{})},
+			errText: "composite primary key not supported for a delete func: sqlr.Part",
+		},
+		{
+			fn:      new(func(ids []string) (int, error)),
+			opts:    []QueryOption{WithRowType(NoKey{})},
+			errText: "no primary key for a delete func: sqlr.NoKey",
+		},
+		{
+			fn:      new(func(ids []KeyID) (int, error)),
+			opts:    []QueryOption{WithRowType(Keyed{}), WithColumns("Name")},
+			errText: "WithColumns is not supported for a delete func: func([]sqlr.KeyID) (int, error)",
+		},
+		{
+			fn:      new(func(id KeyID) (*Keyed, error)),
+			opts:    []QueryOption{WithRowType(Keyed{})},
+			errText: "WithRowType is only supported for delete functions: func(sqlr.KeyID) (*sqlr.Keyed, error)",
+		},
+		{
+			fn:      new(func(ids []PartID) (int, string)),
+			errText: "expecting second return arg to be error",
+		},
+	}
+	for i, tt := range tests {
+		var opts queryOptions
+		for _, opt := range tt.opts {
+			opt(&opts)
+		}
+		_, err := makeQueryWithOptions(reflect.TypeOf(tt.fn).Elem(), schema, opts)
+		if err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+			continue
+		}
+		if got, want := err.Error(), tt.errText; got != want {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, want)
+		}
+	}
+}
+
+func TestMakeQueryWithColumns(t *testing.T) {
+	type Row struct {
+		ID      int64 `sql:"primary key"`
+		Name    string
+		Payload []byte
+	}
+	db := &FakeDB{queryErr: errors.New("query failed")}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	var get, getLite func(id int64) (*Row, error)
+	var getManyLite func(ids []int64) ([]*Row, error)
+	sess.MakeQuery(&get)
+	sess.MakeQuery(&getLite, &getManyLite, WithColumns("Name"))
+
+	for _, err := range []error{
+		func() error { _, err := get(1); return err }(),
+		func() error { _, err := getLite(1); return err }(),
+		func() error { _, err := getManyLite([]int64{1, 2}); return err }(),
+	} {
+		if err == nil {
+			t.Error("got=nil, want=error")
+		}
+	}
+	want := []string{
+		`select "id", "name", "payload" from row where "id" = $1`,
+		`select "id", "name" from row where "id" = $1`,
+		`select "id", "name" from row where "id" in ($1,$2)`,
+	}
+	if got := db.queries; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%q\nwant=%q", got, want)
+	}
+}
+
+func TestMakeQueryWithColumnsScan(t *testing.T) {
+	type Row struct {
+		ID      int64 `sql:"primary key"`
+		Name    string
+		Payload []byte
+	}
+	db := rowsDBWithColumns(t, 2, "id", "name")
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	var getLite func(id int64) (*Row, error)
+	var getManyLite func(ids ...int64) ([]*Row, error)
+	sess.MakeQuery(&getLite, &getManyLite, WithColumns("Name"))
+
+	row, err := getLite(1)
+	wantNoError(t, err)
+	if row == nil || row.ID != 1 || row.Name != "name" || row.Payload != nil {
+		t.Errorf("got=%+v", row)
+	}
+	rows, err := getManyLite(1, 2)
+	wantNoError(t, err)
+	if got, want := len(rows), 2; got != want {
+		t.Fatalf("got=%d, want=%d", got, want)
+	}
+	for _, row := range rows {
+		if row.Name != "name" || row.Payload != nil {
+			t.Errorf("got=%+v", row)
+		}
+	}
+}
+
+func TestMakeQueryWithColumnsErrors(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	sess := NewSession(context.Background(), &FakeDB{}, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+	tests := []struct {
+		fn      interface{}
+		errText string
+	}{
+		{
+			fn:      new(func(id int64) (*Row, error)),
+			errText: "unknown field \"Missing\" in sqlr.Row",
+		},
+		{
+			fn:      new(func(query string, args ...interface{}) ([]*Row, error)),
+			errText: "query options are only supported for get functions: func(string, ...interface {}) ([]*sqlr.Row, error)",
+		},
+	}
+	for i, tt := range tests {
+		err := sess.makeQueries(tt.fn, WithColumns("Missing"))
+		if err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+			continue
+		}
+		if got, want := err.Error(), tt.errText; got != want {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, want)
+		}
+	}
+}
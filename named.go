@@ -0,0 +1,75 @@
+package sqlr
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/jjeffery/sqlr/private/scanner"
+)
+
+// SelectNamed executes a SELECT query with named parameters and stores the
+// result in rows. Named parameters are specified in the query as a colon
+// followed by the parameter name, eg ":name", and their values are obtained
+// from args.
+//
+// A named parameter whose value is a slice is expanded into the appropriate
+// number of placeholders, which is useful for WHERE IN clauses:
+//  _, err := session.SelectNamed(&rows,
+//      "select {} from widgets where category = :category and id in (:ids)",
+//      map[string]interface{}{
+//          "category": "tools",
+//          "ids":      []int{1, 2, 3},
+//      })
+// Otherwise SelectNamed behaves the same as Select.
+func (sess *Session) SelectNamed(rows interface{}, query string, args map[string]interface{}) (int, error) {
+	query, argv, err := bindNamed(query, args)
+	if err != nil {
+		return 0, err
+	}
+	return sess.Select(rows, query, argv...)
+}
+
+// bindNamed replaces the named parameters in query with positional
+// placeholders, and returns the positional args in the order in which
+// they appear in the query. A named parameter that appears more than
+// once in the query results in one positional arg for each occurrence.
+// Slice values are not expanded here: that happens when the positional
+// query is executed.
+func bindNamed(query string, args map[string]interface{}) (string, []interface{}, error) {
+	scan := scanner.New(strings.NewReader(query))
+	var buf bytes.Buffer
+	var argv []interface{}
+	var colon bool
+	for scan.Scan() {
+		tok, lit := scan.Token(), scan.Text()
+		if colon {
+			colon = false
+			if tok == scanner.IDENT {
+				arg, ok := args[lit]
+				if !ok {
+					return "", nil, fmt.Errorf("missing value for named parameter %q", lit)
+				}
+				buf.WriteRune('?')
+				argv = append(argv, arg)
+				continue
+			}
+			buf.WriteRune(':')
+		}
+		if tok == scanner.PLACEHOLDER {
+			return "", nil, fmt.Errorf("cannot mix named parameters with placeholder %q", lit)
+		}
+		if tok == scanner.OP && lit == ":" {
+			colon = true
+			continue
+		}
+		buf.WriteString(lit)
+	}
+	if err := scan.Err(); err != nil {
+		return "", nil, err
+	}
+	if colon {
+		buf.WriteRune(':')
+	}
+	return buf.String(), argv, nil
+}
@@ -0,0 +1,89 @@
+package sqlr
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jjeffery/sqlr/private/wherein"
+)
+
+func TestBindNamed(t *testing.T) {
+	type Widget struct {
+		ID       int64 `sql:"primary key"`
+		Category string
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	tests := []struct {
+		query    string
+		args     map[string]interface{}
+		wantSQL  string
+		wantArgs []interface{}
+		errText  string
+	}{
+		{
+			query: "select {} from widgets where category = :category and id in (:ids)",
+			args: map[string]interface{}{
+				"category": "tools",
+				"ids":      []int{1, 2, 3},
+			},
+			wantSQL:  `select "id", "category" from widgets where category = $1 and id in ($2,$3,$4)`,
+			wantArgs: []interface{}{"tools", 1, 2, 3},
+		},
+		{
+			query: "select {} from widgets where id in (:ids) and category = :category and id <> :id",
+			args: map[string]interface{}{
+				"category": "tools",
+				"ids":      []int64{4, 5},
+				"id":       6,
+			},
+			wantSQL:  `select "id", "category" from widgets where id in ($1,$2) and category = $3 and id <> $4`,
+			wantArgs: []interface{}{int64(4), int64(5), "tools", 6},
+		},
+		{
+			query: "select {} from widgets where category::text = :category and category <> ':x'",
+			args: map[string]interface{}{
+				"category": "tools",
+			},
+			wantSQL:  `select "id", "category" from widgets where category::text = $1 and category <> ':x'`,
+			wantArgs: []interface{}{"tools"},
+		},
+		{
+			query:   "select {} from widgets where id = :id",
+			errText: `missing value for named parameter "id"`,
+		},
+		{
+			query:   "select {} from widgets where id = :id and category = ?",
+			args:    map[string]interface{}{"id": 1},
+			errText: `cannot mix named parameters with placeholder "?"`,
+		},
+	}
+	for i, tt := range tests {
+		query, args, err := bindNamed(tt.query, tt.args)
+		if tt.errText != "" {
+			if err == nil || err.Error() != tt.errText {
+				t.Errorf("%d: got=%v, want=%q", i, err, tt.errText)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		stmt, err := schema.Prepare(Widget{}, query)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		gotSQL, gotArgs, err := wherein.Expand(stmt.String(), args)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := gotSQL, tt.wantSQL; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if got, want := gotArgs, tt.wantArgs; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+	}
+}
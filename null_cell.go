@@ -71,6 +71,7 @@ type nullIntCell struct {
 	colname   string
 	cellValue reflect.Value
 	bits      int
+	nullable  sql.NullInt64 // part of the cell to avoid an allocation for each scan
 }
 
 func (nc *nullIntCell) Scan(v interface{}) (err error) {
@@ -80,7 +81,7 @@ func (nc *nullIntCell) Scan(v interface{}) (err error) {
 			err = fmt.Errorf("cannot scan column %q: %v", nc.colname, r)
 		}
 	}()
-	var nullable sql.NullInt64
+	nullable := &nc.nullable
 	if err = nullable.Scan(v); err != nil {
 		return fmt.Errorf("cannot scan column %q: %v", nc.colname, err)
 	}
@@ -95,6 +96,7 @@ func (nc *nullIntCell) Scan(v interface{}) (err error) {
 type nullUintCell struct {
 	colname   string
 	cellValue reflect.Value
+	nullable  sql.NullInt64 // part of the cell to avoid an allocation for each scan
 }
 
 func (nc *nullUintCell) Scan(v interface{}) (err error) {
@@ -104,7 +106,7 @@ func (nc *nullUintCell) Scan(v interface{}) (err error) {
 			err = fmt.Errorf("cannot scan column %q: %v", nc.colname, r)
 		}
 	}()
-	var nullable sql.NullInt64
+	nullable := &nc.nullable
 	if err = nullable.Scan(v); err != nil {
 		return fmt.Errorf("cannot scan column %q: %v", nc.colname, err)
 	}
@@ -120,6 +122,7 @@ type nullFloatCell struct {
 	colname   string
 	cellValue reflect.Value
 	bits      int
+	nullable  sql.NullFloat64 // part of the cell to avoid an allocation for each scan
 }
 
 func (nc *nullFloatCell) Scan(v interface{}) (err error) {
@@ -129,7 +132,7 @@ func (nc *nullFloatCell) Scan(v interface{}) (err error) {
 			err = fmt.Errorf("cannot scan column %q: %v", nc.colname, r)
 		}
 	}()
-	var nullable sql.NullFloat64
+	nullable := &nc.nullable
 	if err := nullable.Scan(v); err != nil {
 		return fmt.Errorf("cannot scan column %q: %v", nc.colname, err)
 	}
@@ -144,10 +147,11 @@ func (nc *nullFloatCell) Scan(v interface{}) (err error) {
 type nullBoolCell struct {
 	colname   string
 	cellValue reflect.Value
+	nullable  sql.NullBool // part of the cell to avoid an allocation for each scan
 }
 
 func (nc *nullBoolCell) Scan(v interface{}) error {
-	var nullable sql.NullBool
+	nullable := &nc.nullable
 	if err := nullable.Scan(v); err != nil {
 		return fmt.Errorf("cannot scan column %q: %v", nc.colname, err)
 	}
@@ -162,10 +166,11 @@ func (nc *nullBoolCell) Scan(v interface{}) error {
 type nullStringCell struct {
 	colname   string
 	cellValue reflect.Value
+	nullable  sql.NullString // part of the cell to avoid an allocation for each scan
 }
 
 func (nc *nullStringCell) Scan(v interface{}) error {
-	var nullable sql.NullString
+	nullable := &nc.nullable
 	if err := nullable.Scan(v); err != nil {
 		return fmt.Errorf("cannot scan column %q: %v", nc.colname, err)
 	}
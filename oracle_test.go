@@ -0,0 +1,81 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// oracleDB is a Querier that sets the value of any output parameter,
+// as the Oracle driver does for "returning ... into".
+type oracleDB struct {
+	FakeDB
+	id int64
+}
+
+func (db *oracleDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	for _, arg := range args {
+		if out, ok := arg.(sql.Out); ok {
+			dest, ok := out.Dest.(*int64)
+			if !ok {
+				return nil, errors.New("expected *int64")
+			}
+			*dest = db.id
+		}
+	}
+	return db.FakeDB.ExecContext(ctx, query, args...)
+}
+
+func TestOracleInsertRow(t *testing.T) {
+	type UserAccount struct {
+		ID   int32 `sql:"primary key autoincrement"`
+		Name string
+	}
+	db := &oracleDB{id: 42}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Oracle)))
+	defer sess.Close()
+
+	row := &UserAccount{Name: "name"}
+	wantNoError(t, sess.InsertRow(row))
+	if got, want := row.ID, int32(42); got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	want := `INSERT INTO "USER_ACCOUNT"("NAME") VALUES(:1) RETURNING "ID" INTO :2`
+	if got := db.queries[0]; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestOracleIdentifierCase(t *testing.T) {
+	type UserAccount struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	tests := []struct {
+		options []SchemaOption
+		want    string
+	}{
+		{
+			want: `SELECT "ID", "NAME" FROM USER_ACCOUNT WHERE "ID" = :1 offset 0 rows fetch next 10 rows only`,
+		},
+		{
+			options: []SchemaOption{WithIdentifierCase(FoldNone)},
+			want:    `select "id", "name" from user_account where "id" = :1 offset 0 rows fetch next 10 rows only`,
+		},
+	}
+	// unquoted identifiers in the query are folded along with the names of
+	// the columns, which includes keywords
+	for i, tt := range tests {
+		options := append([]SchemaOption{WithDialect(Oracle)}, tt.options...)
+		schema := NewSchema(options...)
+		stmt, err := schema.Prepare(UserAccount{}, "select {} from user_account where {} {limit 10}")
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if got := stmt.String(); got != tt.want {
+			t.Errorf("%d: got=%q, want=%q", i, got, tt.want)
+		}
+	}
+}
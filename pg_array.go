@@ -0,0 +1,147 @@
+package sqlr
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// pgArrayCell converts between a slice field and a PostgreSQL array.
+// It is used for scanning the array into the field, and for passing the
+// field as a query argument.
+type pgArrayCell struct {
+	colname   string
+	cellValue reflect.Value
+}
+
+func newPGArrayCell(colname string, cellValue reflect.Value) *pgArrayCell {
+	return &pgArrayCell{
+		colname:   colname,
+		cellValue: cellValue,
+	}
+}
+
+// Scan implements the sql.Scanner interface.
+func (c *pgArrayCell) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		c.cellValue.Set(reflect.Zero(c.cellValue.Type()))
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("cannot scan %T into array field %q", src, c.colname)
+	}
+	elems, err := parsePGArray(text)
+	if err != nil {
+		return fmt.Errorf("cannot scan array field %q: %v", c.colname, err)
+	}
+	sliceValue := reflect.MakeSlice(c.cellValue.Type(), len(elems), len(elems))
+	for i, elem := range elems {
+		if elem == nil {
+			return fmt.Errorf("cannot scan NULL array element into field %q", c.colname)
+		}
+		elemValue := sliceValue.Index(i)
+		if elemValue.Kind() == reflect.String {
+			elemValue.SetString(*elem)
+			continue
+		}
+		n, err := strconv.ParseInt(*elem, 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot scan array field %q: %v", c.colname, err)
+		}
+		elemValue.SetInt(n)
+	}
+	c.cellValue.Set(sliceValue)
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (c *pgArrayCell) Value() (driver.Value, error) {
+	if c.cellValue.IsNil() {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	buf.WriteRune('{')
+	for i := 0; i < c.cellValue.Len(); i++ {
+		if i > 0 {
+			buf.WriteRune(',')
+		}
+		elemValue := c.cellValue.Index(i)
+		if elemValue.Kind() == reflect.String {
+			buf.WriteRune('"')
+			buf.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(elemValue.String()))
+			buf.WriteRune('"')
+		} else {
+			buf.WriteString(strconv.FormatInt(elemValue.Int(), 10))
+		}
+	}
+	buf.WriteRune('}')
+	return buf.String(), nil
+}
+
+// parsePGArray parses the text representation of a one-dimensional
+// PostgreSQL array. NULL elements are returned as nil.
+func parsePGArray(text string) ([]*string, error) {
+	if len(text) < 2 || text[0] != '{' || text[len(text)-1] != '}' {
+		return nil, fmt.Errorf("invalid array %q", text)
+	}
+	body := text[1 : len(text)-1]
+	if body == "" {
+		return []*string{}, nil
+	}
+
+	var elems []*string
+	var buf bytes.Buffer
+	for i := 0; i <= len(body); {
+		buf.Reset()
+		if i < len(body) && body[i] == '{' {
+			return nil, fmt.Errorf("multi-dimensional arrays are not supported")
+		}
+		if i < len(body) && body[i] == '"' {
+			// quoted element
+			i++
+			for {
+				if i >= len(body) {
+					return nil, fmt.Errorf("invalid array %q", text)
+				}
+				ch := body[i]
+				if ch == '"' {
+					i++
+					break
+				}
+				if ch == '\\' && i+1 < len(body) {
+					i++
+					ch = body[i]
+				}
+				buf.WriteByte(ch)
+				i++
+			}
+			elem := buf.String()
+			elems = append(elems, &elem)
+		} else {
+			// unquoted element
+			for i < len(body) && body[i] != ',' {
+				buf.WriteByte(body[i])
+				i++
+			}
+			elem := strings.TrimSpace(buf.String())
+			if strings.EqualFold(elem, "null") {
+				elems = append(elems, nil)
+			} else {
+				elems = append(elems, &elem)
+			}
+		}
+		if i < len(body) && body[i] != ',' {
+			return nil, fmt.Errorf("invalid array %q", text)
+		}
+		i++
+	}
+	return elems, nil
+}
@@ -0,0 +1,148 @@
+package sqlr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPGArrayRoundTrip(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		text  interface{}
+	}{
+		{
+			value: []string{"a", "b c", `d"e`, `f\g`, "", "NULL", "h,i", "{j}"},
+			text:  `{"a","b c","d\"e","f\\g","","NULL","h,i","{j}"}`,
+		},
+		{
+			value: []int{1, -2, 3},
+			text:  "{1,-2,3}",
+		},
+		{
+			value: []int64{9007199254740993, 0},
+			text:  "{9007199254740993,0}",
+		},
+		{
+			value: []string{},
+			text:  "{}",
+		},
+		{
+			value: []int64(nil),
+			text:  nil,
+		},
+	}
+	for i, tt := range tests {
+		value := reflect.ValueOf(tt.value)
+		text, err := newPGArrayCell("col", value).Value()
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := text, tt.text; got != want {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+
+		cellPtr := reflect.New(value.Type())
+		if err := newPGArrayCell("col", cellPtr.Elem()).Scan(text); err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := cellPtr.Elem().Interface(), tt.value; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%#v, want=%#v", i, got, want)
+		}
+	}
+}
+
+func TestPGArrayScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    interface{}
+		errText string
+	}{
+		{
+			src:  []byte(`{abc,"d e", f }`),
+			want: []string{"abc", "d e", "f"},
+		},
+		{
+			src:     "{a,NULL}",
+			want:    []string(nil),
+			errText: `cannot scan NULL array element into field "col"`,
+		},
+		{
+			src:     "{{1,2},{3,4}}",
+			want:    []int(nil),
+			errText: `cannot scan array field "col": multi-dimensional arrays are not supported`,
+		},
+		{
+			src:     "{1,x}",
+			want:    []int(nil),
+			errText: `cannot scan array field "col": strconv.ParseInt: parsing "x": invalid syntax`,
+		},
+		{
+			src:     `{"abc}`,
+			want:    []string(nil),
+			errText: `cannot scan array field "col": invalid array "{\"abc}"`,
+		},
+		{
+			src:     123,
+			want:    []string(nil),
+			errText: `cannot scan int into array field "col"`,
+		},
+	}
+	for i, tt := range tests {
+		cellPtr := reflect.New(reflect.TypeOf(tt.want))
+		err := newPGArrayCell("col", cellPtr.Elem()).Scan(tt.src)
+		var errText string
+		if err != nil {
+			errText = err.Error()
+		}
+		if got, want := errText, tt.errText; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+			continue
+		}
+		if got, want := cellPtr.Elem().Interface(), tt.want; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%#v, want=%#v", i, got, want)
+		}
+	}
+}
+
+func TestPostgresArraysOption(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Tags []string
+		Nums []int
+		Data []string `sql:"json"`
+	}
+	row := Row{ID: 1, Tags: []string{"a"}, Nums: []int{1, 2}, Data: []string{"x"}}
+
+	tests := []struct {
+		opts []SchemaOption
+		want []interface{}
+	}{
+		{
+			opts: []SchemaOption{WithDialect(Postgres), WithPostgresArrays()},
+			want: []interface{}{int64(1), `{"a"}`, "{1,2}", []byte(`["x"]`)},
+		},
+		{
+			// no effect for other dialects
+			opts: []SchemaOption{WithDialect(MySQL), WithPostgresArrays()},
+			want: []interface{}{int64(1), []byte(`["x"]`)},
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(tt.opts...)
+		stmt, err := schema.Prepare(row, "insert into rows")
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		args, err := stmt.getArgs(row, nil)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := args, tt.want; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%#v, want=%#v", i, got, want)
+		}
+	}
+}
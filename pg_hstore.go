@@ -0,0 +1,166 @@
+package sqlr
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// isStringMap reports whether t is a map with string keys and values,
+// which is the type of field that can be stored in an hstore column.
+func isStringMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String
+}
+
+// hstoreCell converts between a map[string]string field and a PostgreSQL
+// hstore value. It is used for scanning the hstore into the field, and for
+// passing the field as a query argument.
+type hstoreCell struct {
+	colname   string
+	cellValue reflect.Value
+}
+
+func newHstoreCell(colname string, cellValue reflect.Value) *hstoreCell {
+	return &hstoreCell{
+		colname:   colname,
+		cellValue: cellValue,
+	}
+}
+
+// Scan implements the sql.Scanner interface.
+func (c *hstoreCell) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		c.cellValue.Set(reflect.Zero(c.cellValue.Type()))
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("cannot scan %T into hstore field %q", src, c.colname)
+	}
+	pairs, err := parseHstore(text)
+	if err != nil {
+		return fmt.Errorf("cannot scan hstore field %q: %v", c.colname, err)
+	}
+	mapType := c.cellValue.Type()
+	mapValue := reflect.MakeMapWithSize(mapType, len(pairs))
+	for key, value := range pairs {
+		if value == nil {
+			return fmt.Errorf("cannot scan NULL hstore value for key %q into field %q", key, c.colname)
+		}
+		// the key and element types can be named string types
+		mapValue.SetMapIndex(reflect.ValueOf(key).Convert(mapType.Key()), reflect.ValueOf(*value).Convert(mapType.Elem()))
+	}
+	c.cellValue.Set(mapValue)
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (c *hstoreCell) Value() (driver.Value, error) {
+	if c.cellValue.IsNil() {
+		return nil, nil
+	}
+	keys := c.cellValue.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+	}
+	var buf bytes.Buffer
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		value := c.cellValue.MapIndex(key)
+		buf.WriteString(quote(key.String()))
+		buf.WriteString("=>")
+		buf.WriteString(quote(value.String()))
+	}
+	return buf.String(), nil
+}
+
+// parseHstore parses the text representation of a PostgreSQL hstore
+// value. NULL values are returned as nil.
+func parseHstore(text string) (map[string]*string, error) {
+	pairs := make(map[string]*string)
+	i := 0
+	skipSpace := func() {
+		for i < len(text) && (text[i] == ' ' || text[i] == '\t' || text[i] == '\n' || text[i] == '\r') {
+			i++
+		}
+	}
+	// scanString scans a quoted or unquoted string, returning the string
+	// and whether it was quoted
+	scanString := func() (string, bool, error) {
+		var buf bytes.Buffer
+		if i < len(text) && text[i] == '"' {
+			i++
+			for {
+				if i >= len(text) {
+					return "", false, fmt.Errorf("invalid hstore %q", text)
+				}
+				ch := text[i]
+				if ch == '"' {
+					i++
+					return buf.String(), true, nil
+				}
+				if ch == '\\' && i+1 < len(text) {
+					i++
+					ch = text[i]
+				}
+				buf.WriteByte(ch)
+				i++
+			}
+		}
+		for i < len(text) && !strings.ContainsRune(" \t\r\n,=", rune(text[i])) {
+			buf.WriteByte(text[i])
+			i++
+		}
+		if buf.Len() == 0 {
+			return "", false, fmt.Errorf("invalid hstore %q", text)
+		}
+		return buf.String(), false, nil
+	}
+
+	for {
+		skipSpace()
+		if i >= len(text) {
+			break
+		}
+		key, _, err := scanString()
+		if err != nil {
+			return nil, err
+		}
+		skipSpace()
+		if !strings.HasPrefix(text[i:], "=>") {
+			return nil, fmt.Errorf("invalid hstore %q", text)
+		}
+		i += 2
+		skipSpace()
+		value, quoted, err := scanString()
+		if err != nil {
+			return nil, err
+		}
+		if !quoted && strings.EqualFold(value, "null") {
+			pairs[key] = nil
+		} else {
+			pairs[key] = &value
+		}
+		skipSpace()
+		if i < len(text) {
+			if text[i] != ',' {
+				return nil, fmt.Errorf("invalid hstore %q", text)
+			}
+			i++
+		}
+	}
+	return pairs, nil
+}
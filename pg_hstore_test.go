@@ -0,0 +1,157 @@
+package sqlr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHstore(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		text    string
+		want    map[string]*string
+		wantErr bool
+	}{
+		{
+			text: ``,
+			want: map[string]*string{},
+		},
+		{
+			text: `"a"=>"1", "b"=>"two words"`,
+			want: map[string]*string{"a": str("1"), "b": str("two words")},
+		},
+		{
+			text: `"quote \" and \\ slash"=>"x", "n"=>NULL, "s"=>"NULL"`,
+			want: map[string]*string{`quote " and \ slash`: str("x"), "n": nil, "s": str("NULL")},
+		},
+		{
+			text: `a=>b,c => d`,
+			want: map[string]*string{"a": str("b"), "c": str("d")},
+		},
+		{
+			text:    `"a"=>`,
+			wantErr: true,
+		},
+		{
+			text:    `"a" "b"`,
+			wantErr: true,
+		},
+		{
+			text:    `"a"=>"b" "c"=>"d"`,
+			wantErr: true,
+		},
+		{
+			text:    `"a=>"b"`,
+			wantErr: true,
+		},
+	}
+	for i, tt := range tests {
+		got, err := parseHstore(tt.text)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%d: got=nil, want=error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%v, want=%v", i, got, tt.want)
+		}
+	}
+}
+
+func TestHstoreCell(t *testing.T) {
+	type Attributes map[string]string
+	tests := []Attributes{
+		nil,
+		{},
+		{"color": "red", "size": "extra large"},
+		{`quote"`: `back\slash`, "empty": ""},
+	}
+	for i, tt := range tests {
+		value, err := newHstoreCell("attrs", reflect.ValueOf(&tt).Elem()).Value()
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		var got Attributes
+		if err := newHstoreCell("attrs", reflect.ValueOf(&got).Elem()).Scan(value); err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt) {
+			t.Errorf("%d: got=%#v, want=%#v", i, got, tt)
+		}
+	}
+
+	// named key and element types
+	type Key string
+	type Value string
+	named := map[Key]Value{"b": "2", "a": "1"}
+	value, err := newHstoreCell("attrs", reflect.ValueOf(&named).Elem()).Value()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `"a"=>"1", "b"=>"2"`; value != want {
+		t.Errorf("got=%#v, want=%#v", value, want)
+	}
+	var gotNamed map[Key]Value
+	if err := newHstoreCell("attrs", reflect.ValueOf(&gotNamed).Elem()).Scan(value); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotNamed, named) {
+		t.Errorf("got=%#v, want=%#v", gotNamed, named)
+	}
+
+	var got map[string]string
+	cell := newHstoreCell("attrs", reflect.ValueOf(&got).Elem())
+	if err := cell.Scan([]byte(`"a"=>NULL`)); err == nil {
+		t.Error("got=nil, want=error")
+	}
+	if err := cell.Scan(42); err == nil {
+		t.Error("got=nil, want=error")
+	}
+}
+
+func TestHstoreArgs(t *testing.T) {
+	type Row struct {
+		ID     int64             `sql:"primary key"`
+		Attrs  map[string]string `sql:"attrs hstore"`
+		Other  map[string]string // ignored without the hstore keyword
+		Extras map[string]string `sql:"json"`
+		Counts map[string]int    `sql:"counts hstore"` // ignored, values are not strings
+	}
+	row := Row{ID: 1, Attrs: map[string]string{"b": "2", "a": "1"}}
+
+	schema := NewSchema(WithDialect(Postgres))
+	stmt, err := schema.Prepare(row, "insert into rows({}) values({})")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := stmt.String(), `insert into rows("id", "attrs", "extras") values($1, $2, $3)`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	args, err := stmt.getArgs(row, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := args[1], `"a"=>"1", "b"=>"2"`; got != want {
+		t.Errorf("got=%#v, want=%#v", got, want)
+	}
+
+	if typ, err := sqlTypeFor(stmt.tbl.Columns()[1], dialectPostgres); err != nil || typ != "hstore" {
+		t.Errorf("got=%q, %v, want=%q", typ, err, "hstore")
+	}
+
+	// hstore is only supported for postgres
+	_, err = NewSchema(WithDialect(MySQL)).Prepare(row, "insert into rows({}) values({})")
+	if err == nil {
+		t.Fatal("got=nil, want=error")
+	}
+	if got, want := err.Error(), `hstore field "Attrs" requires the postgres dialect`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
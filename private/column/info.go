@@ -1,6 +1,7 @@
 package column
 
 import (
+	"bytes"
 	"reflect"
 	"strings"
 
@@ -16,6 +17,7 @@ type Info struct {
 	Path       Path
 	FieldNames string  // one or more field names, joined by periods
 	Tag        TagInfo // meta data from the struct field tag
+	Array      bool    // slice that can only be stored as a database array
 }
 
 func newInfo(field reflect.StructField) *Info {
@@ -26,18 +28,6 @@ func newInfo(field reflect.StructField) *Info {
 	return info
 }
 
-// newScanner returns a scanner for reading the contents of the struct tag.
-// Returns nil if there is no appropriate struct tag to read.
-func newScanner(tag reflect.StructTag) *scanner.Scanner {
-	for _, key := range structTagKeys {
-		str := strings.TrimSpace(tag.Get(key))
-		if str != "" {
-			return newScannerForString(str)
-		}
-	}
-	return nil
-}
-
 func newScannerForString(str string) *scanner.Scanner {
 	scan := scanner.New(strings.NewReader(str))
 	scan.IgnoreWhiteSpace = true
@@ -52,14 +42,61 @@ func newScannerForString(str string) *scanner.Scanner {
 		"version",
 		"json",
 		"jsonb",
+		"gzip",
+		"hstore",
 		"natural",
 		"natural_key",
 		"null",
 		"omitempty",
-		"emptynull")
+		"emptynull",
+		"enum",
+		"dialect",
+		"comment",
+		"references",
+		"generated",
+		"intbool",
+		"date",
+		"deleted",
+		"epoch",
+		"embed",
+		"prefix")
 	return scan
 }
 
+// nameKeywords are keywords that were added after tags were in use, and
+// that could already be naming a column. When one of them is the first
+// token in a tag, eg `sql:"date null"`, it names the column as it always
+// did, unless it is followed by "=", eg `sql:"epoch=ms"`.
+var nameKeywords = map[string]bool{
+	"gzip":       true,
+	"hstore":     true,
+	"enum":       true,
+	"dialect":    true,
+	"comment":    true,
+	"references": true,
+	"generated":  true,
+	"intbool":    true,
+	"date":       true,
+	"deleted":    true,
+	"epoch":      true,
+	"embed":      true,
+	"prefix":     true,
+}
+
+// leadingNameKeyword returns the keyword if it is the first token in the
+// tag value, is one of the nameKeywords, and is not followed by "=".
+func leadingNameKeyword(tagValue string) (string, bool) {
+	scan := newScannerForString(tagValue)
+	if !scan.Scan() || scan.Token() != scanner.KEYWORD {
+		return "", false
+	}
+	lit := scan.Text()
+	if !nameKeywords[strings.ToLower(lit)] || (scan.Scan() && scan.Text() == "=") {
+		return "", false
+	}
+	return lit, true
+}
+
 // TagInfo is information obtained about a column from the
 // struct tags of its corresponding field.
 type TagInfo struct {
@@ -69,21 +106,49 @@ type TagInfo struct {
 	AutoIncrement bool
 	Version       bool
 	JSON          bool
+	JSONB         bool // JSON stored in a PostgreSQL jsonb column
+	Gzip          bool // JSON is gzip-compressed
+	Hstore        bool // map stored as a PostgreSQL hstore
 	NaturalKey    bool
 	EmptyNull     bool
+	Enum          []string // permitted values, if any
+	Dialects      []string // dialects for which the column exists, if restricted
+	Comment       string   // description of the column, for documentation
+	References    string   // foreign key reference, eg "users(id)"
+	OnDelete      string   // referential action for the foreign key, eg "cascade"
+	Generated     bool     // value is generated by the database, eg a column default
+	IntBool       bool     // bool stored in an integer column as 0 or 1
+	Date          bool     // time.Time stored in a DATE column, without a time portion
+	Deleted       bool     // time of a soft delete, null if the row has not been deleted
+	Epoch         string   // time.Time stored as a Unix time integer in seconds ("s") or milliseconds ("ms"); other units are invalid
+	Embed         bool     // struct field is mapped to the columns of its fields
+	Prefix        string   // prefix for the column names of an embedded struct's fields
 }
 
 // ParseTag returns a TagInfo containing information obtained from the
 // StructTag of the field associated with the column.
 func ParseTag(tag reflect.StructTag) TagInfo {
+	for _, key := range structTagKeys {
+		if value := strings.TrimSpace(tag.Get(key)); value != "" {
+			return parseTagValue(value)
+		}
+	}
+	return TagInfo{}
+}
+
+// parseTagValue returns the TagInfo for the value of a single struct tag key.
+func parseTagValue(tagValue string) TagInfo {
 	var tagInfo TagInfo
 
-	scan := newScanner(tag)
-	if scan == nil {
-		return tagInfo
+	scan := newScannerForString(tagValue)
+	if name, ok := leadingNameKeyword(tagValue); ok {
+		tagInfo.Name = name
+		scan.Scan() // skip the name
 	}
 	var hadKeyword bool
-	for scan.Scan() {
+	var rescan bool
+	for rescan || scan.Scan() {
+		rescan = false
 		tok, lit := scan.Token(), scan.Text()
 		switch tok {
 		case scanner.KEYWORD:
@@ -105,8 +170,15 @@ func ParseTag(tag reflect.StructTag) TagInfo {
 				tagInfo.AutoIncrement = true
 			case "version":
 				tagInfo.Version = true
-			case "json", "jsonb":
+			case "json":
+				tagInfo.JSON = true
+			case "jsonb":
 				tagInfo.JSON = true
+				tagInfo.JSONB = true
+			case "gzip":
+				tagInfo.Gzip = true
+			case "hstore":
+				tagInfo.Hstore = true
 			case "natural_key":
 				tagInfo.NaturalKey = true
 			case "natural":
@@ -115,8 +187,42 @@ func ParseTag(tag reflect.StructTag) TagInfo {
 				}
 			case "null", "omitempty", "emptynull":
 				tagInfo.EmptyNull = true
+			case "enum":
+				tagInfo.Enum, rescan = scanValueList(scan)
+			case "dialect":
+				tagInfo.Dialects, rescan = scanValueList(scan)
+			case "comment":
+				tagInfo.Comment, rescan = scanValue(scan)
+			case "references":
+				tagInfo.References, tagInfo.OnDelete, rescan = scanReference(scan)
+			case "generated":
+				tagInfo.Generated = true
+			case "intbool":
+				tagInfo.IntBool = true
+			case "date":
+				tagInfo.Date = true
+			case "deleted":
+				tagInfo.Deleted = true
+			case "epoch":
+				var unit string
+				unit, rescan = scanValue(scan)
+				if tagInfo.Epoch = strings.ToLower(unit); tagInfo.Epoch == "" {
+					tagInfo.Epoch = "s"
+				}
+			case "embed":
+				tagInfo.Embed = true
+			case "prefix":
+				tagInfo.Prefix, rescan = scanValue(scan)
 			}
 		case scanner.IDENT:
+			if strings.ToLower(lit) == "name" {
+				// "name=date" names a column that has the same name as a keyword
+				var name string
+				if name, rescan = scanValue(scan); name != "" {
+					tagInfo.Name = name
+					continue
+				}
+			}
 			if !hadKeyword && tagInfo.Name == "" {
 				tagInfo.Name = scanner.Unquote(lit)
 			}
@@ -134,3 +240,118 @@ func ParseTag(tag reflect.StructTag) TagInfo {
 	}
 	return tagInfo
 }
+
+// scanValueList scans the values following a keyword such as "enum"
+// or "dialect", which are specified as "enum=value1|value2|value3".
+// An unquoted value can contain hyphens, eg "enum=in-progress|done".
+// Other values, such as those containing spaces, must be quoted.
+// Returns true if the scanner has read a token following the values
+// that has not been processed.
+func scanValueList(scan *scanner.Scanner) ([]string, bool) {
+	if !scan.Scan() {
+		return nil, false
+	}
+	if scan.Text() != "=" {
+		return nil, true
+	}
+	var values []string
+	for scan.Scan() {
+		if !isValueToken(scan.Token()) {
+			return values, true
+		}
+		value := scanner.Unquote(scan.Text())
+		if !scan.Scan() {
+			return append(values, value), false
+		}
+		for scan.Text() == "-" {
+			// the scanner reads "in-progress" as three tokens
+			value += "-"
+			if !scan.Scan() {
+				return append(values, value), false
+			}
+			if !isValueToken(scan.Token()) {
+				break
+			}
+			value += scanner.Unquote(scan.Text())
+			if !scan.Scan() {
+				return append(values, value), false
+			}
+		}
+		values = append(values, value)
+		if scan.Text() != "|" {
+			return values, true
+		}
+	}
+	return values, false
+}
+
+func isValueToken(tok scanner.Token) bool {
+	return tok == scanner.IDENT || tok == scanner.LITERAL || tok == scanner.KEYWORD
+}
+
+// scanValue scans the single value following a keyword such as "comment",
+// which is specified as "comment=value". A value containing spaces must be
+// quoted, eg "comment='customer email address'". Returns true if the scanner
+// has read a token following the value that has not been processed.
+func scanValue(scan *scanner.Scanner) (string, bool) {
+	if !scan.Scan() {
+		return "", false
+	}
+	if scan.Text() != "=" {
+		return "", true
+	}
+	if !scan.Scan() {
+		return "", false
+	}
+	switch scan.Token() {
+	case scanner.IDENT, scanner.LITERAL, scanner.KEYWORD:
+		return scanner.Unquote(scan.Text()), false
+	}
+	return "", true
+}
+
+// scanReference scans the foreign key reference following the "references"
+// keyword, which is specified as "references=table(column)", optionally
+// followed by "on delete action". The reference is returned as it appears
+// in the tag without any whitespace, and is validated when it is used.
+// Returns true if the scanner has read a token following the reference
+// that has not been processed.
+func scanReference(scan *scanner.Scanner) (ref string, onDelete string, rescan bool) {
+	if !scan.Scan() {
+		return "", "", false
+	}
+	if scan.Text() != "=" {
+		return "", "", true
+	}
+	var sb bytes.Buffer
+	for scan.Scan() {
+		sb.WriteString(scan.Text())
+		if scan.Text() == ")" {
+			break
+		}
+	}
+	ref = sb.String()
+	if !scan.Scan() {
+		return ref, "", false
+	}
+	if !strings.EqualFold(scan.Text(), "on") {
+		return ref, "", true
+	}
+	if !scan.Scan() || !strings.EqualFold(scan.Text(), "delete") {
+		// something other than "on delete", report it as an invalid action
+		return ref, "on", false
+	}
+	var action []string
+	for scan.Scan() {
+		word := strings.ToLower(scan.Text())
+		switch {
+		case len(action) == 0:
+		case len(action) == 1 && action[0] == "no" && word == "action":
+		case len(action) == 1 && action[0] == "set" && (word == "null" || word == "default"):
+		default:
+			return ref, strings.Join(action, " "), true
+		}
+		action = append(action, word)
+	}
+	return ref, strings.Join(action, " "), false
+}
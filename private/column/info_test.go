@@ -0,0 +1,417 @@
+package column
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTagEnum(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want TagInfo
+	}{
+		{
+			tag: `sql:"enum=active|inactive|pending"`,
+			want: TagInfo{
+				Enum: []string{"active", "inactive", "pending"},
+			},
+		},
+		{
+			tag: `sql:"status enum=active|inactive null"`,
+			want: TagInfo{
+				Name:      "status",
+				Enum:      []string{"active", "inactive"},
+				EmptyNull: true,
+			},
+		},
+		{
+			tag: `sql:"enum = 'in progress' | done"`,
+			want: TagInfo{
+				Enum: []string{"in progress", "done"},
+			},
+		},
+		{
+			tag: `sql:"enum null"`,
+			want: TagInfo{
+				Name:      "enum",
+				EmptyNull: true,
+			},
+		},
+		{
+			tag: `sql:"state enum=in-progress|on-hold-2|done null"`,
+			want: TagInfo{
+				Name:      "state",
+				Enum:      []string{"in-progress", "on-hold-2", "done"},
+				EmptyNull: true,
+			},
+		},
+		{
+			tag: `sql:"enum='x|y'|'a b'"`,
+			want: TagInfo{
+				Enum: []string{"x|y", "a b"},
+			},
+		},
+	}
+	for i, tt := range tests {
+		got := ParseTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagDialect(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want TagInfo
+	}{
+		{
+			tag: `sql:"dialect=postgres"`,
+			want: TagInfo{
+				Dialects: []string{"postgres"},
+			},
+		},
+		{
+			tag: `sql:"search_vector dialect=postgres|mysql null"`,
+			want: TagInfo{
+				Name:      "search_vector",
+				Dialects:  []string{"postgres", "mysql"},
+				EmptyNull: true,
+			},
+		},
+	}
+	for i, tt := range tests {
+		got := ParseTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagComment(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want TagInfo
+	}{
+		{
+			tag: `sql:"comment=email"`,
+			want: TagInfo{
+				Comment: "email",
+			},
+		},
+		{
+			tag: `sql:"email_address comment='Customer''s email address' null"`,
+			want: TagInfo{
+				Name:      "email_address",
+				Comment:   "Customer's email address",
+				EmptyNull: true,
+			},
+		},
+		{
+			tag: `sql:"comment null"`,
+			want: TagInfo{
+				Name:      "comment",
+				EmptyNull: true,
+			},
+		},
+	}
+	for i, tt := range tests {
+		got := ParseTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagGzip(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want TagInfo
+	}{
+		{
+			tag: `sql:"json gzip"`,
+			want: TagInfo{
+				JSON: true,
+				Gzip: true,
+			},
+		},
+		{
+			tag: `sql:"document jsonb gzip null"`,
+			want: TagInfo{
+				Name:      "document",
+				JSON:      true,
+				JSONB:     true,
+				Gzip:      true,
+				EmptyNull: true,
+			},
+		},
+	}
+	for i, tt := range tests {
+		got := ParseTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagReferences(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want TagInfo
+	}{
+		{
+			tag: `sql:"references=users(id)"`,
+			want: TagInfo{
+				References: "users(id)",
+			},
+		},
+		{
+			tag: `sql:"user_id references=users(id) on delete cascade"`,
+			want: TagInfo{
+				Name:       "user_id",
+				References: "users(id)",
+				OnDelete:   "cascade",
+			},
+		},
+		{
+			tag: `sql:"references = users ( id ) on delete set null null"`,
+			want: TagInfo{
+				References: "users(id)",
+				OnDelete:   "set null",
+				EmptyNull:  true,
+			},
+		},
+		{
+			tag: `sql:"references=public.users(id) ON DELETE NO ACTION pk"`,
+			want: TagInfo{
+				References: "public.users(id)",
+				OnDelete:   "no action",
+				PrimaryKey: true,
+			},
+		},
+	}
+	for i, tt := range tests {
+		got := ParseTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagGenerated(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want TagInfo
+	}{
+		{
+			tag: `sql:"status generated"`,
+			want: TagInfo{
+				Name:      "status",
+				Generated: true,
+			},
+		},
+		{
+			tag: `sql:"created_at generated"`,
+			want: TagInfo{
+				Name:      "created_at",
+				Generated: true,
+			},
+		},
+	}
+	for i, tt := range tests {
+		got := ParseTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagIntBool(t *testing.T) {
+	got := ParseTag(`sql:"active intbool null"`)
+	want := TagInfo{
+		Name:      "active",
+		IntBool:   true,
+		EmptyNull: true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%+v, want=%+v", got, want)
+	}
+}
+
+func TestParseTagEmbed(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want TagInfo
+	}{
+		{
+			tag:  `sql:"address embed prefix=addr_"`,
+			want: TagInfo{Name: "address", Embed: true, Prefix: "addr_"},
+		},
+		{
+			tag:  `sql:"prefix='home_' embed"`,
+			want: TagInfo{Embed: true, Prefix: "home_"},
+		},
+		{
+			tag:  `sql:"address embed"`,
+			want: TagInfo{Name: "address", Embed: true},
+		},
+	}
+	for i, tt := range tests {
+		got := ParseTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagDate(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want TagInfo
+	}{
+		{
+			tag:  `sql:"born date"`,
+			want: TagInfo{Name: "born", Date: true},
+		},
+		{
+			tag:  `sql:"birth_date date null"`,
+			want: TagInfo{Name: "birth_date", Date: true, EmptyNull: true},
+		},
+		{
+			tag:  `sql:"'date'"`,
+			want: TagInfo{Name: "date"},
+		},
+	}
+	for i, tt := range tests {
+		got := ParseTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagName(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want TagInfo
+	}{
+		{
+			tag:  `sql:"name=date"`,
+			want: TagInfo{Name: "date"},
+		},
+		{
+			tag:  `sql:"name=date date null"`,
+			want: TagInfo{Name: "date", Date: true, EmptyNull: true},
+		},
+		{
+			tag:  `sql:"name='deleted' deleted"`,
+			want: TagInfo{Name: "deleted", Deleted: true},
+		},
+		{
+			// a column called "name"
+			tag:  `sql:"name"`,
+			want: TagInfo{Name: "name"},
+		},
+		{
+			tag:  `sql:"name null"`,
+			want: TagInfo{Name: "name", EmptyNull: true},
+		},
+	}
+	for i, tt := range tests {
+		got := ParseTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagEpoch(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want TagInfo
+	}{
+		{
+			tag:  `sql:"seen epoch"`,
+			want: TagInfo{Name: "seen", Epoch: "s"},
+		},
+		{
+			tag:  `sql:"epoch=s"`,
+			want: TagInfo{Epoch: "s"},
+		},
+		{
+			tag:  `sql:"created epoch=ms null"`,
+			want: TagInfo{Name: "created", Epoch: "ms", EmptyNull: true},
+		},
+		{
+			tag:  `sql:"epoch null"`,
+			want: TagInfo{Name: "epoch", EmptyNull: true},
+		},
+		{
+			tag:  `sql:"archived epoch null"`,
+			want: TagInfo{Name: "archived", Epoch: "s", EmptyNull: true},
+		},
+		{
+			// unknown unit
+			tag:  `sql:"epoch=us"`,
+			want: TagInfo{Epoch: "us"},
+		},
+	}
+	for i, tt := range tests {
+		got := ParseTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagDeleted(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want TagInfo
+	}{
+		{
+			tag:  `sql:"deleted_at deleted"`,
+			want: TagInfo{Name: "deleted_at", Deleted: true},
+		},
+		{
+			tag:  `sql:"removed_at deleted"`,
+			want: TagInfo{Name: "removed_at", Deleted: true},
+		},
+	}
+	for i, tt := range tests {
+		got := ParseTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagLeadingKeyword(t *testing.T) {
+	// keywords added after tags were in use still name the column when
+	// they are the first token in the tag
+	tests := []struct {
+		tag  reflect.StructTag
+		want TagInfo
+	}{
+		{tag: `sql:"comment"`, want: TagInfo{Name: "comment"}},
+		{tag: `sql:"date"`, want: TagInfo{Name: "date"}},
+		{tag: `sql:"deleted"`, want: TagInfo{Name: "deleted"}},
+		{tag: `sql:"generated"`, want: TagInfo{Name: "generated"}},
+		{tag: `sql:"prefix"`, want: TagInfo{Name: "prefix"}},
+		{tag: `sql:"Epoch"`, want: TagInfo{Name: "Epoch"}},
+		{tag: `sql:" embed "`, want: TagInfo{Name: "embed"}},
+		{tag: `sql:"deleted null"`, want: TagInfo{Name: "deleted", EmptyNull: true}},
+		{tag: `sql:"date null"`, want: TagInfo{Name: "date", EmptyNull: true}},
+		{tag: `sql:"deleted date"`, want: TagInfo{Name: "deleted", Date: true}},
+		{tag: `sql:"epoch = ms null"`, want: TagInfo{Epoch: "ms", EmptyNull: true}},
+		{tag: `sql:"enum=a|b null"`, want: TagInfo{Enum: []string{"a", "b"}, EmptyNull: true}},
+		{tag: `sql:"comment=x"`, want: TagInfo{Comment: "x"}},
+		{tag: `sql:"json"`, want: TagInfo{JSON: true}},
+	}
+	for i, tt := range tests {
+		got := ParseTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, tt.want)
+		}
+	}
+}
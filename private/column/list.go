@@ -15,29 +15,63 @@ var (
 )
 
 // typeMap contains a map of type to column information used
-// to cache results for ListForType.
+// to cache results for ListForType and ListForTypeWithArrays.
 var typeMap = struct {
 	mu sync.RWMutex
-	m  map[reflect.Type][]*Info
+	m  map[reflect.Type]typeLists
 }{
-	m: make(map[reflect.Type][]*Info),
+	m: make(map[reflect.Type]typeLists),
+}
+
+// typeLists contains the column lists for a type, with and
+// without slice fields that can be stored as database arrays.
+type typeLists struct {
+	cols       []*Info
+	withArrays []*Info
 }
 
 // ListForType returns a list of column information
 // associated with the specified type, which must be a struct.
 func ListForType(rowType reflect.Type) []*Info {
+	return listsForType(rowType).cols
+}
+
+// ListForTypeWithArrays returns a list of column information associated
+// with the specified type, which must be a struct. The list includes
+// fields of type []string, []int and []int64, which are not included in
+// the list returned by ListForType. These fields have the Array field set.
+func ListForTypeWithArrays(rowType reflect.Type) []*Info {
+	return listsForType(rowType).withArrays
+}
+
+// ListForTypeSerialized is similar to ListForTypeWithArrays, except that
+// each field whose field names are in serialized is mapped to a single
+// column, in the same way as a field with the "json" keyword in its struct
+// tag. The list is not cached, as it depends on serialized.
+func ListForTypeSerialized(rowType reflect.Type, serialized map[string]bool) []*Info {
+	var list columnList
+	list.addFields(rowType, stateT{serialized: serialized})
+	return list
+}
+
+func listsForType(rowType reflect.Type) typeLists {
 	typeMap.mu.RLock()
-	list, ok := typeMap.m[rowType]
+	lists, ok := typeMap.m[rowType]
 	typeMap.mu.RUnlock()
 	if ok {
-		return list
+		return lists
 	}
 
 	typeMap.mu.Lock()
 	defer typeMap.mu.Unlock()
-	list = newList(rowType)
-	typeMap.m[rowType] = list
-	return list
+	lists.withArrays = newList(rowType)
+	for _, info := range lists.withArrays {
+		if !info.Array {
+			lists.cols = append(lists.cols, info)
+		}
+	}
+	typeMap.m[rowType] = lists
+	return lists
 }
 
 // newList returns a list of column information for the row type.
@@ -49,8 +83,9 @@ func newList(rowType reflect.Type) []*Info {
 }
 
 type stateT struct {
-	index Index
-	path  Path
+	index      Index
+	path       Path
+	serialized map[string]bool // field names of fields mapped to one column
 }
 
 type columnList []*Info
@@ -93,18 +128,39 @@ func (list *columnList) addField(field reflect.StructField, i int, state stateT)
 	// it is necessary to know if the field will be serialized as JSON
 	// in order to decide whether to include the field or not.
 	info := newInfo(field)
+	serialized := info.Tag.JSON
+	if !serialized && len(state.serialized) > 0 {
+		serialized = state.serialized[state.path.Append(field.Name, field.Tag).String()]
+	}
 
-	// Ignore certain types unless they are marked as JSON serialized.
-	if !info.Tag.JSON {
+	// Ignore certain types unless they are marked as serialized.
+	if !serialized {
 		// ignore fields that are arrays, interfaces, maps
 		switch fieldType.Kind() {
-		case reflect.Array, reflect.Interface, reflect.Map:
+		case reflect.Array, reflect.Interface:
 			return
+		case reflect.Map:
+			// a map[string]string can be stored as an hstore
+			if !info.Tag.Hstore || fieldType != field.Type ||
+				fieldType.Key().Kind() != reflect.String ||
+				fieldType.Elem().Kind() != reflect.String {
+				return
+			}
 		}
 
-		// ignore slices that are not byte slices
+		// ignore slices that are not byte slices, unless they can be
+		// stored as a database array
 		if fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() != reflect.Uint8 {
-			return
+			switch fieldType.Elem().Kind() {
+			case reflect.String, reflect.Int, reflect.Int64:
+				if fieldType != field.Type {
+					// pointer to slice
+					return
+				}
+				info.Array = true
+			default:
+				return
+			}
 		}
 	}
 
@@ -123,16 +179,20 @@ func (list *columnList) addField(field reflect.StructField, i int, state stateT)
 	state.path = state.path.Append(field.Name, field.Tag)
 
 	// An embedded structure will not be mapped recursively if it meets
-	// any of the following criteria:
+	// any of the following criteria, unless it is marked as "embed":
 	// * it is time.Time (special case)
 	// * it implements sql.Scan (unlikely)
 	// * its pointer type implements sql.Scan (more likely)
-	// * it is marked as serialize to JSON
+	// * it is marked as serialize to JSON, or is otherwise serialized
+	if fieldType.Kind() == reflect.Struct && info.Tag.Embed && !serialized {
+		list.addFields(fieldType, state)
+		return
+	}
 	if fieldType.Kind() == reflect.Struct &&
 		fieldType != timeType &&
 		!fieldType.Implements(sqlScanType) &&
 		!reflect.PtrTo(fieldType).Implements(sqlScanType) &&
-		!info.Tag.JSON {
+		!serialized {
 		list.addFields(fieldType, state)
 		return
 	}
@@ -276,3 +276,73 @@ func compareInfo(t *testing.T, testCase int, index int, info1, info2 *column.Inf
 	}
 
 }
+
+func TestListForTypeWithArrays(t *testing.T) {
+	type Row struct {
+		ID    int
+		Tags  []string
+		Nums  []int
+		IDs   []int64
+		Other []float64
+		Ptr   *[]string
+	}
+	rowType := reflect.TypeOf(Row{})
+
+	if got, want := len(column.ListForType(rowType)), 1; got != want {
+		t.Fatalf("got=%d, want=%d", got, want)
+	}
+
+	infos := column.ListForTypeWithArrays(rowType)
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.FieldNames)
+		if got, want := info.Array, info.FieldNames != "ID"; got != want {
+			t.Errorf("%s: got=%v, want=%v", info.FieldNames, got, want)
+		}
+	}
+	if got, want := names, []string{"ID", "Tags", "Nums", "IDs"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+}
+
+func TestListForTypeSerialized(t *testing.T) {
+	type Payload struct {
+		A int
+		B string
+	}
+	type Row struct {
+		ID      int
+		Payload *Payload
+		Other   Payload
+		Tags    []string
+		Map     map[string]int
+	}
+	rowType := reflect.TypeOf(Row{})
+	serialized := map[string]bool{
+		"Payload": true,
+		"Tags":    true,
+		"Map":     true,
+	}
+
+	var names []string
+	for _, info := range column.ListForTypeSerialized(rowType, serialized) {
+		names = append(names, info.FieldNames)
+		if info.Array {
+			t.Errorf("%s: got=%v, want=%v", info.FieldNames, info.Array, false)
+		}
+	}
+	want := []string{"ID", "Payload", "Other.A", "Other.B", "Tags", "Map"}
+	if got := names; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	// cached list is unaffected
+	names = nil
+	for _, info := range column.ListForType(rowType) {
+		names = append(names, info.FieldNames)
+	}
+	want = []string{"ID", "Payload.A", "Payload.B", "Other.A", "Other.B"}
+	if got := names; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+}
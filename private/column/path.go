@@ -3,8 +3,8 @@ package column
 import (
 	"reflect"
 	"strings"
-
-	"github.com/jjeffery/sqlr/private/scanner"
+	"unicode"
+	"unicode/utf8"
 )
 
 // A Path contains information about all the StructFields traversed
@@ -52,6 +52,29 @@ func (path Path) Append(fieldName string, fieldTag reflect.StructTag) Path {
 	return clone
 }
 
+// StripPrefix returns a path with prefix removed from the name of the
+// first field in the path. The prefix is only removed if it is followed by
+// the start of another word, ie an upper case letter or a digit, so that
+// the prefix "User" is removed from "UserName", but not from "Username".
+// Otherwise path is returned unchanged.
+func (path Path) StripPrefix(prefix string) Path {
+	if len(path) == 0 || prefix == "" {
+		return path
+	}
+	fieldName := path[0].FieldName
+	if !strings.HasPrefix(fieldName, prefix) {
+		return path
+	}
+	if next, _ := utf8.DecodeRuneInString(fieldName[len(prefix):]); !unicode.IsUpper(next) && !unicode.IsDigit(next) {
+		// not at a word boundary, or the name consists only of prefix
+		return path
+	}
+	stripped := make(Path, len(path))
+	copy(stripped, path)
+	stripped[0].FieldName = fieldName[len(prefix):]
+	return stripped
+}
+
 // Equal returns true if path and other are equal.
 func (path Path) Equal(other Path) bool {
 	if len(path) != len(other) {
@@ -81,6 +104,11 @@ func (path Path) String() string {
 
 // ColumnName returns a column name by applying the naming
 // convention to the contents of the path.
+//
+// If a struct field in the path has a prefix specified in its tag,
+// eg `sql:"address embed prefix=addr_"`, the prefix replaces the name of the
+// struct field, and is prepended to the column name of the remainder
+// of the path without applying the naming convention's join rules.
 func (path Path) ColumnName(nc NamingConvention, key string) string {
 	if len(path) == 1 {
 		// The path almost always has one element in it,
@@ -90,10 +118,19 @@ func (path Path) ColumnName(nc NamingConvention, key string) string {
 	}
 
 	// Less common case where there is more than one item in the path.
-	frags := make([]string, len(path))
-	for i, f := range path {
-		frags[i] = convertField(f.FieldName, f.FieldTag, nc, key)
+	frags := make([]string, 0, len(path))
+	for i, f := range path[:len(path)-1] {
+		if prefix := ParseTag(f.FieldTag).Prefix; prefix != "" {
+			frags = append(frags, prefix+path[i+1:].ColumnName(nc, key))
+			if len(frags) == 1 {
+				return frags[0]
+			}
+			return nc.Join(frags)
+		}
+		frags = append(frags, convertField(f.FieldName, f.FieldTag, nc, key))
 	}
+	last := path[len(path)-1]
+	frags = append(frags, convertField(last.FieldName, last.FieldTag, nc, key))
 	return nc.Join(frags)
 }
 
@@ -146,28 +183,22 @@ func nameFromTagValue(tagValue string) string {
 	if tagValue == "" {
 		return ""
 	}
-	scan := newScannerForString(tagValue)
-	for scan.Scan() {
-		tok, lit := scan.Token(), scan.Text()
-		switch tok {
-		case scanner.KEYWORD:
-			// exit on first keyword, no column specified
-			return ""
-		case scanner.IDENT:
-			// first identifier indicates the column name, and
-			// may be quoted
-			return scanner.Unquote(lit)
-		case scanner.LITERAL:
-			if scanner.IsQuoted(lit) {
-				// a string literal is accepted as the column name
-				return scanner.Unquote(lit)
-			}
-		case scanner.OP:
-			if lit == "-" {
-				// indicates should not be a column
-				return lit
-			}
-			return ""
+	tagInfo := parseTagValue(tagValue)
+	if tagInfo.Ignore {
+		// indicates should not be a column
+		return "-"
+	}
+	return tagInfo.Name
+}
+
+// ProtobufName returns the field name specified in the "protobuf" struct
+// tag of a field in a protoc-generated message struct, eg "user_name" for
+// `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3"`. Returns a
+// blank string if the tag does not specify a name.
+func ProtobufName(tag reflect.StructTag) string {
+	for _, part := range strings.Split(tag.Get("protobuf"), ",") {
+		if strings.HasPrefix(part, "name=") {
+			return strings.TrimPrefix(part, "name=")
 		}
 	}
 	return ""
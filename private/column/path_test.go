@@ -1,6 +1,7 @@
 package column
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -69,3 +70,92 @@ func TestPathEqual(t *testing.T) {
 		}
 	}
 }
+
+func TestPathStripPrefix(t *testing.T) {
+	tests := []struct {
+		path   Path
+		prefix string
+		text   string
+	}{
+		{
+			path:   NewPath("UserName", ""),
+			prefix: "User",
+			text:   "Name",
+		},
+		{
+			path:   NewPath("User", ""),
+			prefix: "User",
+			text:   "User",
+		},
+		{
+			path:   NewPath("Name", ""),
+			prefix: "User",
+			text:   "Name",
+		},
+		{
+			path:   NewPath("UserAddress", "").Append("UserStreet", ""),
+			prefix: "User",
+			text:   "Address.UserStreet",
+		},
+		{
+			path:   NewPath("UserName", ""),
+			prefix: "",
+			text:   "UserName",
+		},
+		{
+			// not at a word boundary
+			path:   NewPath("Username", ""),
+			prefix: "User",
+			text:   "Username",
+		},
+		{
+			path:   NewPath("Users", ""),
+			prefix: "User",
+			text:   "Users",
+		},
+		{
+			path:   NewPath("User2FA", ""),
+			prefix: "User",
+			text:   "2FA",
+		},
+	}
+
+	for i, tt := range tests {
+		original := tt.path.String()
+		if got, want := tt.path.StripPrefix(tt.prefix).String(), tt.text; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if got, want := tt.path.String(), original; got != want {
+			t.Errorf("%d: path modified: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestProtobufName(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want string
+	}{
+		{
+			tag:  `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`,
+			want: "user_name",
+		},
+		{
+			tag:  `protobuf:"varint,1,opt,name=id,proto3"`,
+			want: "id",
+		},
+		{
+			tag:  `protobuf_oneof:"kind"`,
+			want: "",
+		},
+		{
+			tag:  `sql:"name"`,
+			want: "",
+		},
+	}
+	for i, tt := range tests {
+		if got := ProtobufName(tt.tag); got != tt.want {
+			t.Errorf("%d: got=%q, want=%q", i, got, tt.want)
+		}
+	}
+}
@@ -26,6 +26,7 @@ var (
 	ANSI     *Dialect
 	MSSQL    *Dialect
 	MySQL    *Dialect
+	Oracle   *Dialect
 	Postgres *PostgresDialect
 	SQLite   *Dialect
 )
@@ -66,6 +67,11 @@ func init() {
 		quoteFunc:   quoteFunc("`", "`"),
 		driverTypes: []string{"*mysql.MySQLDriver"},
 	}
+	Oracle = &Dialect{
+		quoteFunc:       quoteFunc(`"`, `"`),
+		placeholderFunc: placeholderFunc(":%d"),
+		driverTypes:     []string{"*godror.drv", "*go_ora.OracleDriver"},
+	}
 	SQLite = &Dialect{
 		quoteFunc:   quoteFunc("`", "`"),
 		driverTypes: []string{"*sqlite3.SQLiteDriver"},
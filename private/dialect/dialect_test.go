@@ -32,6 +32,11 @@ func TestNew(t *testing.T) {
 			expectedQuoted:      "[xxx]",
 			expectedPlaceholder: "?",
 		},
+		{
+			dialect:             Oracle,
+			expectedQuoted:      `"xxx"`,
+			expectedPlaceholder: ":2",
+		},
 		{
 			dialect:             ANSI,
 			expectedQuoted:      `"xxx"`,
@@ -58,6 +58,30 @@ const (
 	singleCharOperators = "(),;"
 )
 
+// Position is a position in the scanner input.
+type Position struct {
+	Offset int // byte offset, starting at 0
+	Line   int // line number, starting at 1
+	Column int // column number in characters, starting at 1
+}
+
+// String returns a string representation of the position,
+// eg "line 3, column 10".
+func (pos Position) String() string {
+	return fmt.Sprintf("line %d, column %d", pos.Line, pos.Column)
+}
+
+// Error is an error encountered while scanning input.
+type Error struct {
+	Pos Position // position of the offending token
+	Msg string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at %s", e.Msg, e.Pos)
+}
+
 // Scanner is a simple lexical scanner for SQL statements.
 type Scanner struct {
 	IgnoreWhiteSpace bool
@@ -67,6 +91,9 @@ type Scanner struct {
 	err      error
 	token    Token
 	text     string
+	pos      Position // position of the next character
+	prevPos  Position // position before the last read, for unread
+	tokenPos Position // position of the start of the last token
 }
 
 // New returns a new scanner that takes its input from r.
@@ -74,6 +101,7 @@ func New(r io.Reader) *Scanner {
 	return &Scanner{
 		r:        bufio.NewReader(r),
 		keywords: make(map[string]bool),
+		pos:      Position{Line: 1, Column: 1},
 	}
 }
 
@@ -100,6 +128,11 @@ func (s *Scanner) Text() string {
 	return s.text
 }
 
+// Pos returns the position of the start of the token from the last scan.
+func (s *Scanner) Pos() Position {
+	return s.tokenPos
+}
+
 // Err returns the first non-EOF error that was
 // encountered by the Scanner.
 func (s *Scanner) Err() error {
@@ -108,8 +141,10 @@ func (s *Scanner) Err() error {
 
 // Scan the next SQL token.
 func (s *Scanner) Scan() bool {
+	s.tokenPos = s.pos
 	ch := s.read()
 	for s.IgnoreWhiteSpace && isWhitespace(ch) {
+		s.tokenPos = s.pos
 		ch = s.read()
 	}
 	if ch == eof {
@@ -181,7 +216,10 @@ func (s *Scanner) setToken(tok Token, text string) bool {
 	s.token = tok
 	s.text = text
 	if tok == ILLEGAL {
-		s.err = fmt.Errorf("unrecognised input near %q", text)
+		s.err = &Error{
+			Pos: s.tokenPos,
+			Msg: fmt.Sprintf("unrecognised input near %q", text),
+		}
 		return false
 	}
 	return tok != EOF
@@ -363,13 +401,21 @@ func (s *Scanner) scanPlaceholder(startCh rune) bool {
 }
 
 func (s *Scanner) read() rune {
-	ch, _, err := s.r.ReadRune()
+	ch, size, err := s.r.ReadRune()
 	if err != nil {
 		if err != io.EOF {
 			s.err = err
 		}
 		return eof
 	}
+	s.prevPos = s.pos
+	s.pos.Offset += size
+	if ch == '\n' {
+		s.pos.Line++
+		s.pos.Column = 1
+	} else {
+		s.pos.Column++
+	}
 	return ch
 }
 
@@ -378,7 +424,9 @@ func (s *Scanner) unread(ch rune) {
 		err := s.r.UnreadRune()
 		if err != nil {
 			s.err = err
+			return
 		}
+		s.pos = s.prevPos
 	}
 }
 
@@ -121,7 +121,7 @@ func TestScan(t *testing.T) {
 				{ILLEGAL, "[table_]]name]]"},
 				{EOF, ""},
 			},
-			errText: `unrecognised input near "[table_]]name]]"`,
+			errText: `unrecognised input near "[table_]]name]]" at line 1, column 1`,
 		},
 		{ // placeholders
 			sql: "? ?123 $4 $ ?",
@@ -181,7 +181,7 @@ func TestScan(t *testing.T) {
 				{ILLEGAL, "'missing quote"},
 				{EOF, ""},
 			},
-			errText: `unrecognised input near "'missing quote"`,
+			errText: `unrecognised input near "'missing quote" at line 1, column 1`,
 		},
 		{ // numbers
 			sql: "123,123.456,.123,5",
@@ -202,7 +202,7 @@ func TestScan(t *testing.T) {
 				{ILLEGAL, "\x03"},
 				{EOF, ""},
 			},
-			errText: `unrecognised input near "\x03"`,
+			errText: `unrecognised input near "\x03" at line 1, column 1`,
 		},
 		{ // white space
 			sql: " a  b\r\nc\td \v\t\r\n  e\n\n",
@@ -377,3 +377,44 @@ func TestScan(t *testing.T) {
 		check(scanner, tc.ignoreWhiteSpaceTokens, tc.sql, tc.errText)
 	}
 }
+
+func TestScanPos(t *testing.T) {
+	input := "select a,\n  b -- comment\nfrom ¥t where x = 'unterminated"
+	scan := New(strings.NewReader(input))
+	scan.IgnoreWhiteSpace = true
+
+	type tokenPos struct {
+		text string
+		pos  Position
+	}
+	want := []tokenPos{
+		{"select", Position{Offset: 0, Line: 1, Column: 1}},
+		{"a", Position{Offset: 7, Line: 1, Column: 8}},
+		{",", Position{Offset: 8, Line: 1, Column: 9}},
+		{"b", Position{Offset: 12, Line: 2, Column: 3}},
+		{"-- comment\n", Position{Offset: 14, Line: 2, Column: 5}},
+		{"from", Position{Offset: 25, Line: 3, Column: 1}},
+	}
+	for i, w := range want {
+		if !scan.Scan() {
+			t.Fatalf("%d: unexpected end of input: %v", i, scan.Err())
+		}
+		if got, want := scan.Text(), w.text; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if got, want := scan.Pos(), w.pos; got != want {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, want)
+		}
+	}
+
+	// "¥" is two bytes but one column
+	if scan.Scan() {
+		t.Fatalf("got=%q, want illegal token", scan.Text())
+	}
+	if got, want := scan.Pos(), (Position{Offset: 30, Line: 3, Column: 6}); got != want {
+		t.Errorf("got=%+v, want=%+v", got, want)
+	}
+	if got, want := scan.Err().Error(), `unrecognised input near "¥" at line 3, column 6`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
@@ -6,5 +6,11 @@
 // containing (say) three values, then the SQL would be expanded
 // to
 //  SELECT * FROM table_name where column_name in (?,?,?)
+// An empty list is not valid SQL, so if the slice is empty, the placeholder
+// is replaced with NULL and no argument is passed for it:
+//  SELECT * FROM table_name where column_name in (NULL)
+// A comparison with NULL is never true, so "IN (NULL)" matches no rows.
+// For the same reason "NOT IN (NULL)" would also match no rows, instead of
+// every row, so an empty slice following "NOT IN" is reported as an error.
 // See the example for more details.
 package wherein
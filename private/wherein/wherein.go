@@ -27,34 +27,112 @@ type placeholderInfoT struct {
 }
 
 type argInfoT struct {
-	index  int
-	offset int
-	arg    interface{}
-	slice  reflect.Value
-	len    int
+	index   int
+	offset  int
+	arg     interface{}
+	isSlice bool
+	slice   reflect.Value
+	len     int
 }
 
+// FieldSlice is an argument that is expanded in the same way as a slice,
+// using the values of the named field of each struct in Slice. Slice must
+// be a slice of structs or a slice of pointers to structs. A field of an
+// embedded or nested struct is named using a period, eg "Address.Postcode".
+type FieldSlice struct {
+	Slice interface{}
+	Field string
+}
+
+// values returns the values of the field for each struct in the slice.
+func (fs FieldSlice) values() (reflect.Value, error) {
+	sliceValue := reflect.ValueOf(fs.Slice)
+	if sliceValue.Kind() != reflect.Slice {
+		return reflect.Value{}, fmt.Errorf("expected a slice of structs for field %q, got %T", fs.Field, fs.Slice)
+	}
+	elemType := sliceValue.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("expected a slice of structs for field %q, got %T", fs.Field, fs.Slice)
+	}
+	fieldNames := strings.Split(fs.Field, ".")
+	values := make([]interface{}, sliceValue.Len())
+	for i := range values {
+		v := sliceValue.Index(i)
+		for _, fieldName := range fieldNames {
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, fmt.Errorf("nil pointer at index %d for field %q", i, fs.Field)
+				}
+				v = v.Elem()
+			}
+			if v.Kind() != reflect.Struct {
+				return reflect.Value{}, fmt.Errorf("field %q not found in %s", fs.Field, elemType)
+			}
+			v = v.FieldByName(fieldName)
+			if !v.IsValid() {
+				return reflect.Value{}, fmt.Errorf("field %q not found in %s", fs.Field, elemType)
+			}
+		}
+		if !v.CanInterface() {
+			return reflect.Value{}, fmt.Errorf("field %q is not exported in %s", fs.Field, elemType)
+		}
+		values[i] = v.Interface()
+	}
+	return reflect.ValueOf(values), nil
+}
+
+// Style determines how the placeholder for a slice argument is expanded.
+type Style int
+
+const (
+	// List expands the placeholder to a list of placeholders, one for
+	// each element of the slice, eg "IN (?)" becomes "IN (?,?,?)".
+	List Style = iota
+
+	// Values expands the placeholder to a VALUES list with one row for
+	// each element of the slice, eg "IN (?)" becomes "IN (VALUES (?),(?),(?))".
+	// Some query planners handle a large VALUES list better than a large
+	// IN list.
+	Values
+)
+
 // Expand takes an SQL query and associated arguments and expands out any arguments that
 // are a slice of values. Returns the new, expanded SQL query with arguments that have been
 // flattened into a slice of scalar argument values.
 //
+// If a slice argument is empty, its placeholder is replaced with NULL, and
+// no argument is passed for it. It is an error for an empty slice argument to
+// follow "NOT IN", because "NOT IN (NULL)" matches no rows.
+//
 // If args contains only scalar values, then query and args are returned unchanged.
 func Expand(query string, args []interface{}) (newQuery string, newArgs []interface{}, err error) {
+	return ExpandStyle(query, args, List)
+}
+
+// ExpandStyle is similar to Expand, but the style determines how the
+// placeholder for each slice argument is expanded.
+func ExpandStyle(query string, args []interface{}, style Style) (newQuery string, newArgs []interface{}, err error) {
 	if !hasSlice(args) {
 		// no changes need to be made
 		return query, args, nil
 	}
 
-	return flattenQuery(query, args)
+	return flattenQuery(query, args, style)
 }
 
-func flattenQuery(query string, args []interface{}) (newQuery string, newArgs []interface{}, err error) {
+func flattenQuery(query string, args []interface{}, style Style) (newQuery string, newArgs []interface{}, err error) {
 	placeholderInfos, trailingSQL, err := newPlaceholderInfos(query)
 	if err != nil {
 		return "", nil, err
 	}
 
-	argInfos := newArgInfos(args)
+	argInfos, err := newArgInfos(args)
+	if err != nil {
+		return "", nil, err
+	}
 
 	numericPlaceholders, err := arePlaceholdersNumeric(placeholderInfos)
 	if err != nil {
@@ -72,18 +150,32 @@ func flattenQuery(query string, args []interface{}) (newQuery string, newArgs []
 				return "", nil, fmt.Errorf("not enough arguments for placeholder %s", placeholderInfo.placeholderText)
 			}
 			argInfo := argInfos[argIndex]
+			if argInfo.isSlice && argInfo.len == 0 {
+				if followsNotIn(placeholderInfo.leadingSQL) {
+					return "", nil, fmt.Errorf("empty slice for placeholder %s following NOT IN", placeholderInfo.placeholderText)
+				}
+				// an empty list is not valid SQL, and NULL matches nothing
+				buf.WriteString("NULL")
+				continue
+			}
 			start := placeholderInfo.origNumber + argInfo.offset
 			count := argInfo.len
-			if count == 0 {
+			if !argInfo.isSlice {
 				count = 1
 			}
 			end := start + count
+			if argInfo.isSlice && style == Values {
+				buf.WriteString("VALUES ")
+			}
 			for n := start; n < end; n++ {
 				if n > start {
 					buf.WriteRune(',')
 				}
-				buf.WriteString(placeholderInfo.placeholderPrefix)
-				buf.WriteString(strconv.Itoa(n))
+				placeholder := placeholderInfo.placeholderPrefix + strconv.Itoa(n)
+				if argInfo.isSlice && style == Values {
+					placeholder = "(" + placeholder + ")"
+				}
+				buf.WriteString(placeholder)
 			}
 		}
 	} else {
@@ -93,14 +185,25 @@ func flattenQuery(query string, args []interface{}) (newQuery string, newArgs []
 		for i, placeholderInfo := range placeholderInfos {
 			buf.WriteString(placeholderInfo.leadingSQL)
 			argInfo := argInfos[i]
-			if argInfo.len == 0 {
+			if !argInfo.isSlice {
 				buf.WriteString(placeholderInfo.placeholderText)
+			} else if argInfo.len == 0 {
+				if followsNotIn(placeholderInfo.leadingSQL) {
+					return "", nil, fmt.Errorf("empty slice for placeholder %d following NOT IN", i+1)
+				}
+				// an empty list is not valid SQL, and NULL matches nothing
+				buf.WriteString("NULL")
 			} else {
+				placeholder := placeholderInfo.placeholderText
+				if style == Values {
+					buf.WriteString("VALUES ")
+					placeholder = "(" + placeholder + ")"
+				}
 				for j := 0; j < argInfo.len; j++ {
 					if j > 0 {
 						buf.WriteRune(',')
 					}
-					buf.WriteString(placeholderInfo.placeholderText)
+					buf.WriteString(placeholder)
 				}
 			}
 		}
@@ -113,6 +216,19 @@ func flattenQuery(query string, args []interface{}) (newQuery string, newArgs []
 	return newQuery, newArgs, nil
 }
 
+// followsNotIn reports whether the SQL preceding a placeholder ends with
+// "NOT IN (". An empty slice cannot be expanded to NULL in this case,
+// because "NOT IN (NULL)" matches no rows instead of every row.
+func followsNotIn(leadingSQL string) bool {
+	sql := strings.TrimSpace(leadingSQL)
+	if !strings.HasSuffix(sql, "(") {
+		return false
+	}
+	words := strings.Fields(strings.ToLower(strings.TrimSuffix(sql, "(")))
+	n := len(words)
+	return n >= 2 && words[n-2] == "not" && words[n-1] == "in"
+}
+
 func hasSlice(args []interface{}) bool {
 	for _, arg := range args {
 		switch arg.(type) {
@@ -125,6 +241,8 @@ func hasSlice(args []interface{}) bool {
 			time.Time,
 			driver.Valuer:
 			break
+		case FieldSlice:
+			return true
 		default:
 			if rv := reflect.ValueOf(arg); rv.Kind() == reflect.Slice {
 				return true
@@ -192,33 +310,70 @@ func arePlaceholdersNumeric(placeholderInfos []*placeholderInfoT) (bool, error)
 	return numericPlaceholders, nil
 }
 
-func newArgInfos(args []interface{}) []*argInfoT {
+func newArgInfos(args []interface{}) ([]*argInfoT, error) {
 	argInfos := make([]*argInfoT, 0, len(args))
 	for i, arg := range args {
 		argInfo := &argInfoT{
 			index: i,
 			arg:   arg,
 		}
-		switch arg.(type) {
+		switch v := arg.(type) {
 		case []byte, string,
 			int, uint, int8, byte, int16, uint16, int32, uint32, int64, uint64,
 			float32, float64, driver.Valuer:
 			break
+		case FieldSlice:
+			rv, err := v.values()
+			if err != nil {
+				return nil, fmt.Errorf("arg %d: %v", i+1, err)
+			}
+			argInfo.isSlice = true
+			argInfo.slice = rv
+			argInfo.len = rv.Len()
 		default:
 			if rv := reflect.ValueOf(arg); rv.Kind() == reflect.Slice {
+				argInfo.isSlice = true
 				argInfo.slice = rv
 				argInfo.len = rv.Len()
 			}
 		}
+		if argInfo.isSlice {
+			if err := checkNotNested(argInfo.slice); err != nil {
+				return nil, fmt.Errorf("arg %d: %v", i+1, err)
+			}
+		}
 		argInfos = append(argInfos, argInfo)
 	}
-	return argInfos
+	return argInfos, nil
+}
+
+// checkNotNested returns an error if any element of the slice is itself
+// a slice, as there is no sensible way to expand it. Byte slices are
+// scalar values.
+func checkNotNested(slice reflect.Value) error {
+	for i := 0; i < slice.Len(); i++ {
+		elem := slice.Index(i)
+		if elem.Kind() == reflect.Interface {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Slice || elem.Type().Elem().Kind() == reflect.Uint8 {
+			continue
+		}
+		if _, ok := elem.Interface().(driver.Valuer); ok {
+			continue
+		}
+		return fmt.Errorf("cannot expand nested slice: element %d is %s", i, elem.Type())
+	}
+	return nil
 }
 
 func flattenArgs(argInfos []*argInfoT) []interface{} {
 	var args []interface{}
 	for _, argInfo := range argInfos {
-		if argInfo.len == 0 {
+		if !argInfo.isSlice {
 			// not a slice
 			args = append(args, argInfo.arg)
 		} else {
@@ -234,7 +389,7 @@ func setOffsets(argInfos []*argInfoT) {
 	var offset int
 	for _, argInfo := range argInfos {
 		argInfo.offset = offset
-		if argInfo.len > 0 {
+		if argInfo.isSlice {
 			offset += argInfo.len - 1
 		}
 	}
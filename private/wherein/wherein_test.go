@@ -2,6 +2,7 @@ package wherein
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -115,3 +116,232 @@ func TestFlatten(t *testing.T) {
 		t.Logf("args: %+v", gotArgs)
 	}
 }
+
+func TestExpandStyleValues(t *testing.T) {
+	tests := []struct {
+		sql      string
+		args     []interface{}
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			sql:      "select * from tbl where id in (?) and name = ?",
+			args:     []interface{}{[]int{1, 2, 3}, "zoe"},
+			wantSQL:  "select * from tbl where id in (VALUES (?),(?),(?)) and name = ?",
+			wantArgs: []interface{}{1, 2, 3, "zoe"},
+		},
+		{
+			sql:      "select * from tbl where name = $1 and id in ($2)",
+			args:     []interface{}{"zoe", []int{1, 2}},
+			wantSQL:  "select * from tbl where name = $1 and id in (VALUES ($2),($3))",
+			wantArgs: []interface{}{"zoe", 1, 2},
+		},
+		{
+			sql:      "select * from tbl where id = ?",
+			args:     []interface{}{1},
+			wantSQL:  "select * from tbl where id = ?",
+			wantArgs: []interface{}{1},
+		},
+	}
+	for i, tt := range tests {
+		gotSQL, gotArgs, err := ExpandStyle(tt.sql, tt.args, Values)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := gotSQL, tt.wantSQL; got != want {
+			t.Errorf("%d: got=%q want=%q", i, got, want)
+		}
+		if got, want := gotArgs, tt.wantArgs; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%v want=%v", i, got, want)
+		}
+	}
+}
+
+func TestExpandFieldSlice(t *testing.T) {
+	type Address struct {
+		Postcode string
+	}
+	type Customer struct {
+		ID      int64
+		Name    string
+		Address Address
+		Tags    []string
+	}
+	customers := []Customer{
+		{ID: 1, Name: "a", Address: Address{Postcode: "2000"}},
+		{ID: 2, Name: "b", Address: Address{Postcode: "3000"}},
+	}
+	tests := []struct {
+		sql      string
+		args     []interface{}
+		wantSQL  string
+		wantArgs []interface{}
+		wantErr  string
+	}{
+		{
+			sql:      "select * from orders where customer_id in (?) and status = ?",
+			args:     []interface{}{FieldSlice{Slice: customers, Field: "ID"}, "open"},
+			wantSQL:  "select * from orders where customer_id in (?,?) and status = ?",
+			wantArgs: []interface{}{int64(1), int64(2), "open"},
+		},
+		{
+			sql:      "select * from orders where postcode in ($1) and status = $2",
+			args:     []interface{}{FieldSlice{Slice: []*Customer{&customers[1]}, Field: "Address.Postcode"}, "open"},
+			wantSQL:  "select * from orders where postcode in ($1) and status = $2",
+			wantArgs: []interface{}{"3000", "open"},
+		},
+		{
+			sql:      "select * from orders where customer_id in (?) and status = ?",
+			args:     []interface{}{FieldSlice{Slice: []Customer{}, Field: "ID"}, "open"},
+			wantSQL:  "select * from orders where customer_id in (NULL) and status = ?",
+			wantArgs: []interface{}{"open"},
+		},
+		{
+			sql:      "select * from orders where customer_id in ($1) and status = $2",
+			args:     []interface{}{[]int64{}, "open"},
+			wantSQL:  "select * from orders where customer_id in (NULL) and status = $1",
+			wantArgs: []interface{}{"open"},
+		},
+		{
+			sql:     "select * from orders where customer_id in (?)",
+			args:    []interface{}{FieldSlice{Slice: customers, Field: "Missing"}},
+			wantErr: `arg 1: field "Missing" not found in wherein.Customer`,
+		},
+		{
+			sql:     "select * from orders where customer_id in (?)",
+			args:    []interface{}{FieldSlice{Slice: []int{1}, Field: "ID"}},
+			wantErr: `arg 1: expected a slice of structs for field "ID", got []int`,
+		},
+		{
+			sql:     "select * from orders where customer_id in (?)",
+			args:    []interface{}{FieldSlice{Slice: []*Customer{nil}, Field: "ID"}},
+			wantErr: `arg 1: nil pointer at index 0 for field "ID"`,
+		},
+		{
+			sql:     "select * from orders where tag in (?)",
+			args:    []interface{}{FieldSlice{Slice: customers, Field: "Tags"}},
+			wantErr: "arg 1: cannot expand nested slice: element 0 is []string",
+		},
+		{
+			sql:     "select * from orders where id in (?)",
+			args:    []interface{}{[][]int{{1}, {2}}},
+			wantErr: "arg 1: cannot expand nested slice: element 0 is []int",
+		},
+	}
+	for i, tt := range tests {
+		gotSQL, gotArgs, err := Expand(tt.sql, tt.args)
+		if tt.wantErr != "" {
+			if err == nil {
+				t.Errorf("%d: got=nil, want=%q", i, tt.wantErr)
+			} else if got, want := err.Error(), tt.wantErr; got != want {
+				t.Errorf("%d: got=%q, want=%q", i, got, want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := gotSQL, tt.wantSQL; got != want {
+			t.Errorf("%d: got=%q want=%q", i, got, want)
+		}
+		if got, want := gotArgs, tt.wantArgs; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%v want=%v", i, got, want)
+		}
+	}
+}
+
+func TestExpandEmptySliceNotIn(t *testing.T) {
+	tests := []struct {
+		sql  string
+		args []interface{}
+	}{
+		{
+			sql:  "select * from tbl where id not in (?)",
+			args: []interface{}{[]int{}},
+		},
+		{
+			sql:  "select * from tbl where id NOT IN($1) and name = $2",
+			args: []interface{}{[]int{}, "zoe"},
+		},
+		{
+			sql:  "select * from tbl where id in (?) and code not\n in ( ?)",
+			args: []interface{}{[]int{1}, []string{}},
+		},
+	}
+	for i, tt := range tests {
+		if _, _, err := Expand(tt.sql, tt.args); err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+		}
+	}
+
+	// a non-empty slice is expanded as usual
+	gotSQL, _, err := Expand("select * from tbl where id not in (?)", []interface{}{[]int{1, 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "select * from tbl where id not in (?,?)"; gotSQL != want {
+		t.Errorf("got=%q, want=%q", gotSQL, want)
+	}
+}
+
+func TestExpandEmptySlice(t *testing.T) {
+	tests := []struct {
+		sql      string
+		args     []interface{}
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			sql:      "select * from tbl where id in (?)",
+			args:     []interface{}{[]int{}},
+			wantSQL:  "select * from tbl where id in (NULL)",
+			wantArgs: nil,
+		},
+		{
+			sql:      "select * from tbl where id in (?) and code in (?) and name = ?",
+			args:     []interface{}{[]int{}, []string{"a", "b"}, "zoe"},
+			wantSQL:  "select * from tbl where id in (NULL) and code in (?,?) and name = ?",
+			wantArgs: []interface{}{"a", "b", "zoe"},
+		},
+		{
+			sql:      "select * from tbl where id in ($1) and code in ($2) and name = $3",
+			args:     []interface{}{[]int{1, 2}, []string{}, "zoe"},
+			wantSQL:  "select * from tbl where id in ($1,$2) and code in (NULL) and name = $3",
+			wantArgs: []interface{}{1, 2, "zoe"},
+		},
+		{
+			sql:      "select * from tbl where id in (?) or code in (?)",
+			args:     []interface{}{[]int{}, []string{}},
+			wantSQL:  "select * from tbl where id in (NULL) or code in (NULL)",
+			wantArgs: nil,
+		},
+		{
+			sql:      "select * from tbl where id in ($1) or code in ($2)",
+			args:     []interface{}{[]int{}, []string{}},
+			wantSQL:  "select * from tbl where id in (NULL) or code in (NULL)",
+			wantArgs: nil,
+		},
+	}
+	for i, tt := range tests {
+		for _, style := range []Style{List, Values} {
+			gotSQL, gotArgs, err := ExpandStyle(tt.sql, tt.args, style)
+			if err != nil {
+				t.Errorf("%d: unexpected error: %v", i, err)
+				continue
+			}
+			wantSQL := tt.wantSQL
+			if style == Values {
+				wantSQL = strings.Replace(wantSQL, "(?,?)", "(VALUES (?),(?))", -1)
+				wantSQL = strings.Replace(wantSQL, "($1,$2)", "(VALUES ($1),($2))", -1)
+			}
+			if got, want := gotSQL, wantSQL; got != want {
+				t.Errorf("%d: got=%q want=%q", i, got, want)
+			}
+			if got, want := gotArgs, tt.wantArgs; !reflect.DeepEqual(got, want) {
+				t.Errorf("%d: got=%v want=%v", i, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+// mysqlInvalidConnMessage is the message of the error returned by the
+// MySQL driver (github.com/go-sql-driver/mysql) when the connection has
+// been dropped by the server.
+const mysqlInvalidConnMessage = "invalid connection"
+
+// queryContext performs the query using db. If the statement is a select
+// query, and the schema has been configured using WithReadRetry, the query
+// is retried if it fails with a transient connection error.
+func (stmt *Stmt) queryContext(ctx context.Context, db Querier, query string, args []interface{}) (*sql.Rows, error) {
+	if stmt.sharedLock {
+		if _, ok := baseQuerier(db).(*sql.Tx); !ok {
+			return nil, errors.New(`"{for share}" requires a transaction`)
+		}
+	}
+	retries := 0
+	if stmt.queryType == querySelect && stmt.schema != nil {
+		if _, ok := baseQuerier(db).(*sql.Tx); !ok {
+			retries = stmt.schema.readRetries
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err == nil || attempt >= retries || !isTransientConnError(err) || ctx.Err() != nil {
+			return rows, err
+		}
+	}
+}
+
+// isTransientConnError reports whether err, or the error that caused it,
+// indicates that the connection to the database was dropped, so that a
+// read-only query can safely be performed again.
+func isTransientConnError(err error) bool {
+	for err != nil {
+		if err == driver.ErrBadConn || err.Error() == mysqlInvalidConnMessage {
+			return true
+		}
+		err = errorCause(err)
+	}
+	return false
+}
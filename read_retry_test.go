@@ -0,0 +1,150 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+// flakyDB is a querier that fails the first fails calls with err, and
+// returns the rows of a rowsDB for subsequent queries.
+type flakyDB struct {
+	FakeDB
+	rows  *sql.DB
+	fails int
+	err   error
+	calls int
+}
+
+func (db *flakyDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	db.calls++
+	if db.calls <= db.fails {
+		return nil, db.err
+	}
+	return db.rows.QueryContext(ctx, query, args...)
+}
+
+func (db *flakyDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db.calls++
+	if db.calls <= db.fails {
+		return nil, db.err
+	}
+	return db.FakeDB.ExecContext(ctx, query, args...)
+}
+
+// wrappedError is an error with a cause, which is found using either of
+// the Cause or Unwrap methods.
+type wrappedError struct {
+	msg   string
+	cause error
+}
+
+func (e *wrappedError) Error() string { return e.msg + ": " + e.cause.Error() }
+func (e *wrappedError) Cause() error  { return e.cause }
+func (e *wrappedError) Unwrap() error { return e.cause }
+
+func TestWithReadRetry(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	rows := rowsDB(t, 2)
+	defer rows.Close()
+
+	tests := []struct {
+		retries   int
+		fails     int
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{retries: 0, fails: 1, err: driver.ErrBadConn, wantCalls: 1, wantErr: true},
+		{retries: 1, fails: 1, err: driver.ErrBadConn, wantCalls: 2},
+		{retries: 3, fails: 2, err: driver.ErrBadConn, wantCalls: 3},
+		{retries: 1, fails: 2, err: driver.ErrBadConn, wantCalls: 2, wantErr: true},
+		{retries: 1, fails: 1, err: errors.New(mysqlInvalidConnMessage), wantCalls: 2},
+		{retries: 1, fails: 1, err: &wrappedError{msg: "wrapped", cause: driver.ErrBadConn}, wantCalls: 2},
+		{retries: 3, fails: 1, err: errors.New("syntax error"), wantCalls: 1, wantErr: true},
+	}
+	for tn, tt := range tests {
+		db := &flakyDB{rows: rows, fails: tt.fails, err: tt.err}
+		schema := NewSchema(WithDialect(Postgres), WithReadRetry(tt.retries))
+		sess := NewSession(context.Background(), db, schema)
+		var result []Row
+		_, err := sess.Select(&result, "select {} from rows")
+		if got, want := err != nil, tt.wantErr; got != want {
+			t.Errorf("%d: got err=%v, wantErr=%v", tn, err, want)
+		}
+		if !tt.wantErr && len(result) != 2 {
+			t.Errorf("%d: got %d rows, want 2", tn, len(result))
+		}
+		if got, want := db.calls, tt.wantCalls; got != want {
+			t.Errorf("%d: got calls=%d, want=%d", tn, got, want)
+		}
+		sess.Close()
+	}
+}
+
+func TestWithReadRetryGetAndQuery(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	rows := rowsDB(t, 1)
+	defer rows.Close()
+	schema := NewSchema(WithDialect(Postgres), WithReadRetry(1))
+
+	db := &flakyDB{rows: rows, fails: 1, err: driver.ErrBadConn}
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+	var row Row
+	n, err := sess.Select(&row, "select {} from rows where {}", 1)
+	wantNoError(t, err)
+	if got, want := n, 1; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	if got, want := db.calls, 2; got != want {
+		t.Errorf("get: got calls=%d, want=%d", got, want)
+	}
+
+	db.calls = 0
+	r, err := sess.Query("select id, name from rows")
+	wantNoError(t, err)
+	r.Close()
+	if got, want := db.calls, 2; got != want {
+		t.Errorf("query: got calls=%d, want=%d", got, want)
+	}
+}
+
+func TestWithReadRetryWrites(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	rows := rowsDB(t, 1)
+	defer rows.Close()
+	schema := NewSchema(WithDialect(Postgres), WithReadRetry(3))
+
+	// a query that returns rows, but is not a select
+	db := &flakyDB{rows: rows, fails: 1, err: driver.ErrBadConn}
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+	_, err := sess.Query("insert into rows(id, name) values(1, 'one') returning id")
+	if err != driver.ErrBadConn {
+		t.Errorf("got=%v, want=%v", err, driver.ErrBadConn)
+	}
+	if got, want := db.calls, 1; got != want {
+		t.Errorf("insert returning: got calls=%d, want=%d", got, want)
+	}
+
+	db.calls = 0
+	_, err = sess.Exec("update rows set name = 'two' where id = 1")
+	if err != driver.ErrBadConn {
+		t.Errorf("got=%v, want=%v", err, driver.ErrBadConn)
+	}
+	if got, want := db.calls, 1; got != want {
+		t.Errorf("update: got calls=%d, want=%d", got, want)
+	}
+}
@@ -0,0 +1,195 @@
+package sqlr
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// WithRowCache creates an option that caches rows of the given type in memory
+// for the duration of ttl. The row is usually specified as a nil pointer to
+// the row type, eg (*CountryRow)(nil).
+//
+// Row caching is intended for reference tables that are read frequently and
+// change rarely. Get functions created by MakeQuery, ie functions with a
+// signature like func(id RowID) (*Row, error), consult the cache before
+// querying the database. Rows are removed from the cache when they are
+// inserted or updated using the InsertRow, UpdateRow and Row(row).Exec methods.
+// Changes made to the table using other SQL statements, or by other programs,
+// are not visible until the cached rows expire. Sessions that use a transaction
+// (*sql.Tx) bypass the cache, so that uncommitted rows are never cached.
+//
+// The cache stores a deep copy of each row, and each get returns a new deep
+// copy, so callers can modify the rows returned. Row handlers registered with
+// Session.HandleRows are called for rows read from the cache, but these rows
+// do not count against the session's query budget, as no query is sent.
+//
+// The cache is shared by all sessions that use the schema.
+func WithRowCache(row interface{}, ttl time.Duration) SchemaOption {
+	return func(schema *Schema) error {
+		rowType, err := getRowType(row)
+		if err != nil {
+			return err
+		}
+		if schema.rowCaches == nil {
+			schema.rowCaches = make(map[reflect.Type]*rowCache)
+		}
+		schema.rowCaches[rowType] = newRowCache(ttl)
+		return nil
+	}
+}
+
+// rowCacheFor returns the row cache for the row type, or nil if rows
+// of this type are not cached.
+func (s *Schema) rowCacheFor(rowType reflect.Type) *rowCache {
+	return s.rowCaches[rowType]
+}
+
+// rowCache is an in-memory cache of rows keyed by primary key value.
+type rowCache struct {
+	ttl time.Duration
+	now func() time.Time // for testing
+
+	mu         sync.Mutex
+	entries    map[interface{}]rowCacheEntry
+	generation int64 // incremented whenever an entry is invalidated
+}
+
+type rowCacheEntry struct {
+	rowValue reflect.Value // struct value, not a pointer
+	expires  time.Time
+}
+
+func newRowCache(ttl time.Duration) *rowCache {
+	return &rowCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[interface{}]rowCacheEntry),
+	}
+}
+
+// deepCopy returns a copy of v that does not share any pointers, slices or
+// maps with v, so that a cached row cannot be modified through a row returned
+// to a caller. Unexported struct fields, such as those of time.Time, are
+// copied as they are. Values that refer to themselves are not supported.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if field := c.Field(i); field.CanSet() {
+				field.Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, key := range v.MapKeys() {
+			c.SetMapIndex(key, deepCopy(v.MapIndex(key)))
+		}
+		return c
+	}
+	return v
+}
+
+// get returns a pointer to a deep copy of the cached row for the key.
+func (rc *rowCache) get(key interface{}) (reflect.Value, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	entry, ok := rc.entries[key]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	if !rc.now().Before(entry.expires) {
+		delete(rc.entries, key)
+		return reflect.Value{}, false
+	}
+	rowPtrValue := reflect.New(entry.rowValue.Type())
+	rowPtrValue.Elem().Set(deepCopy(entry.rowValue))
+	return rowPtrValue, true
+}
+
+// currentGeneration returns a value that is passed to set after the row
+// has been read from the database.
+func (rc *rowCache) currentGeneration() int64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.generation
+}
+
+// set stores a deep copy of the row in the cache. The row is not stored if any row
+// has been invalidated since generation was obtained, because the row read from
+// the database might already be out of date.
+func (rc *rowCache) set(key interface{}, rowValue reflect.Value, generation int64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if generation != rc.generation {
+		return
+	}
+	rc.entries[key] = rowCacheEntry{
+		rowValue: deepCopy(rowValue),
+		expires:  rc.now().Add(rc.ttl),
+	}
+}
+
+// invalidateAll removes all rows from the cache.
+func (rc *rowCache) invalidateAll() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.generation++
+	rc.entries = make(map[interface{}]rowCacheEntry)
+}
+
+// invalidateRow removes the row from the cache. If the table does not have a
+// single primary key column then all rows are removed from the cache.
+func (rc *rowCache) invalidateRow(tbl *Table, row interface{}) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.generation++
+	if len(tbl.pk) != 1 {
+		rc.entries = make(map[interface{}]rowCacheEntry)
+		return
+	}
+	rowValue := reflect.ValueOf(row)
+	for rowValue.Kind() == reflect.Ptr {
+		if rowValue.IsNil() {
+			return
+		}
+		rowValue = rowValue.Elem()
+	}
+	key := tbl.pk[0].info.Index.ValueRO(rowValue).Interface()
+	delete(rc.entries, key)
+}
@@ -0,0 +1,237 @@
+package sqlr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRowCache(t *testing.T) {
+	type Country struct {
+		Code string `sql:"primary key"`
+		Name string
+	}
+	schema := NewSchema(WithDialect(Postgres), WithRowCache((*Country)(nil), time.Minute))
+	rc := schema.rowCacheFor(schema.TableFor(Country{}).RowType())
+	if rc == nil {
+		t.Fatal("expected row cache")
+	}
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	rc.now = func() time.Time { return now }
+
+	queryErr := errors.New("query error")
+	db := &FakeDB{queryErr: queryErr, rowsAffected: 1}
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	var get func(code string) (*Country, error)
+	sess.MakeQuery(&get)
+
+	// cache miss queries the database
+	if _, err := get("AU"); err == nil {
+		t.Fatal("got=nil, want=error")
+	}
+
+	// cache hit does not query the database
+	rc.set("AU", reflect.ValueOf(Country{Code: "AU", Name: "Australia"}), rc.currentGeneration())
+	country, err := get("AU")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := country.Name, "Australia"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	// modifying the returned row does not modify the cache
+	country.Name = "Changed"
+	country, err = get("AU")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := country.Name, "Australia"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	// expired rows are not returned
+	now = now.Add(time.Minute)
+	if _, err := get("AU"); err == nil {
+		t.Error("got=nil, want=error")
+	}
+
+	// rows are invalidated on write
+	for _, write := range []func(row *Country) error{
+		func(row *Country) error {
+			_, err := sess.UpdateRow(row)
+			return err
+		},
+		func(row *Country) error {
+			return sess.InsertRow(row)
+		},
+		func(row *Country) error {
+			_, err := sess.Row(row).Exec("update countries set {} where {}")
+			return err
+		},
+	} {
+		rc.set("AU", reflect.ValueOf(Country{Code: "AU", Name: "Australia"}), rc.currentGeneration())
+		rc.set("NZ", reflect.ValueOf(Country{Code: "NZ", Name: "New Zealand"}), rc.currentGeneration())
+		if err := write(&Country{Code: "AU", Name: "Commonwealth of Australia"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := get("AU"); err == nil {
+			t.Error("got=nil, want=error")
+		}
+		if _, err := get("NZ"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestRowCacheDeepCopy(t *testing.T) {
+	type Detail struct {
+		Capital string
+	}
+	type Country struct {
+		Code      string `sql:"primary key"`
+		Languages []string
+		Regions   map[string]int
+		Detail    *Detail
+		Updated   time.Time
+	}
+	schema := NewSchema(WithDialect(Postgres), WithRowCache((*Country)(nil), time.Minute))
+	rc := schema.rowCacheFor(schema.TableFor(Country{}).RowType())
+	db := &FakeDB{queryErr: errors.New("query error")}
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	var get func(code string) (*Country, error)
+	sess.MakeQuery(&get)
+
+	updated := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	newCountry := func() Country {
+		return Country{
+			Code:      "AU",
+			Languages: []string{"en"},
+			Regions:   map[string]int{"NSW": 1},
+			Detail:    &Detail{Capital: "Canberra"},
+			Updated:   updated,
+		}
+	}
+
+	// modifying the row after it is cached does not modify the cache
+	row := newCountry()
+	rc.set("AU", reflect.ValueOf(row), rc.currentGeneration())
+	row.Languages[0] = "changed"
+	row.Regions["NSW"] = 2
+	row.Detail.Capital = "changed"
+
+	// modifying a row returned from the cache does not modify the cache
+	country, err := get("AU")
+	wantNoError(t, err)
+	country.Languages[0] = "changed"
+	country.Regions["VIC"] = 2
+	country.Detail.Capital = "changed"
+
+	country, err = get("AU")
+	wantNoError(t, err)
+	if got, want := *country, newCountry(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%+v, want=%+v", got, want)
+	}
+	if got, want := *country.Detail, *newCountry().Detail; got != want {
+		t.Errorf("got=%+v, want=%+v", got, want)
+	}
+}
+
+func TestRowCacheHandleRows(t *testing.T) {
+	type Country struct {
+		Code string `sql:"primary key"`
+		Name string
+	}
+	schema := NewSchema(WithDialect(Postgres), WithRowCache((*Country)(nil), time.Minute))
+	rc := schema.rowCacheFor(schema.TableFor(Country{}).RowType())
+	db := rowsDBWithColumns(t, 1, "code", "name")
+	defer db.Close()
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	var handled []*Country
+	sess.HandleRows(func(rows []*Country) {
+		handled = append(handled, rows...)
+	})
+	var get func(code string) (*Country, error)
+	sess.MakeQuery(&get)
+
+	// the handlers are called for a cache miss and a cache hit
+	rc.set("AU", reflect.ValueOf(Country{Code: "AU", Name: "Australia"}), rc.currentGeneration())
+	for _, code := range []string{"NZ", "AU"} {
+		handled = nil
+		country, err := get(code)
+		wantNoError(t, err)
+		if len(handled) != 1 || handled[0] != country {
+			t.Errorf("%s: got=%v, want=[%p]", code, handled, country)
+		}
+	}
+}
+
+func TestRowCacheTx(t *testing.T) {
+	type Country struct {
+		Code string `sql:"primary key"`
+		Name string
+	}
+	schema := NewSchema(WithDialect(Postgres), WithRowCache((*Country)(nil), time.Minute))
+	rc := schema.rowCacheFor(schema.TableFor(Country{}).RowType())
+
+	db := rowsDBWithColumns(t, 1, "code", "name")
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	sess := NewSession(context.Background(), tx, schema)
+	defer sess.Close()
+
+	var get func(code string) (*Country, error)
+	sess.MakeQuery(&get)
+
+	// cached rows are not returned in a transaction
+	rc.set("AU", reflect.ValueOf(Country{Code: "AU", Name: "Australia"}), rc.currentGeneration())
+	country, err := get("AU")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := country.Name, "name"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	// rows read in a transaction are not cached
+	if _, err := get("NZ"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := rc.get("NZ"); ok {
+		t.Error("row read in a transaction was cached")
+	}
+}
+
+func TestRowCacheGeneration(t *testing.T) {
+	type Row struct {
+		ID   int `sql:"primary key"`
+		Name string
+	}
+	tbl := NewSchema().TableFor(Row{})
+	rc := newRowCache(time.Minute)
+
+	// row read from the database before an invalidation is not cached
+	generation := rc.currentGeneration()
+	rc.invalidateRow(tbl, &Row{ID: 2})
+	rc.set(1, reflect.ValueOf(Row{ID: 1}), generation)
+	if _, ok := rc.get(1); ok {
+		t.Error("stale row was cached")
+	}
+
+	rc.set(1, reflect.ValueOf(Row{ID: 1}), rc.currentGeneration())
+	if _, ok := rc.get(1); !ok {
+		t.Error("row was not cached")
+	}
+}
@@ -1,18 +1,25 @@
 package sqlr
 
 import (
+	"database/sql"
 	"reflect"
 )
 
 var wellKnownTypes = struct {
 	errorType            reflect.Type
 	stringType           reflect.Type
+	boolType             reflect.Type
+	intType              reflect.Type
 	sliceOfInterfaceType reflect.Type
+	scannerType          reflect.Type
 	nilErrorValue        reflect.Value
 }{
 	errorType:            reflect.TypeOf((*error)(nil)).Elem(),
 	stringType:           reflect.TypeOf((*string)(nil)).Elem(),
+	boolType:             reflect.TypeOf((*bool)(nil)).Elem(),
+	intType:              reflect.TypeOf((*int)(nil)).Elem(),
 	sliceOfInterfaceType: reflect.SliceOf(reflect.TypeOf((*interface{})(nil)).Elem()),
+	scannerType:          reflect.TypeOf((*sql.Scanner)(nil)).Elem(),
 }
 
 func init() {
@@ -0,0 +1,52 @@
+package sqlr
+
+import (
+	"database/sql"
+	"reflect"
+)
+
+// RowScanner is implemented by row types that control how they absorb the
+// contents of a result row, instead of having each column scanned into the
+// corresponding struct field. This allows rows to be read into value objects
+// with unexported fields, and into types with computed fields.
+//
+// When a pointer to the row type implements RowScanner, ScanRow is called once
+// for each row with the column names returned by the query and the column
+// values, as returned by the database driver. The columns are not matched with
+// the struct fields, so unknown or missing columns are not reported as errors,
+// and the query should list the columns explicitly rather than using "{}".
+//  type Money struct {
+//      amount   int64
+//      currency string
+//  }
+//
+//  func (m *Money) ScanRow(columns []string, values []interface{}) error {
+//      ...
+//  }
+//
+//  var prices []Money
+//  n, err := sess.Select(&prices, "select amount, currency from prices")
+type RowScanner interface {
+	ScanRow(columns []string, values []interface{}) error
+}
+
+var rowScannerType = reflect.TypeOf((*RowScanner)(nil)).Elem()
+
+// isRowScanner reports whether a pointer to the row type implements RowScanner.
+func isRowScanner(rowType reflect.Type) bool {
+	return reflect.PtrTo(rowType).Implements(rowScannerType)
+}
+
+// scanRowScanner scans the current row into the row pointed to by
+// rowValuePtr by calling its ScanRow method.
+func scanRowScanner(rows *sql.Rows, columns []string, rowValuePtr reflect.Value) error {
+	values := make([]interface{}, len(columns))
+	scanValues := make([]interface{}, len(columns))
+	for i := range values {
+		scanValues[i] = &values[i]
+	}
+	if err := rows.Scan(scanValues...); err != nil {
+		return err
+	}
+	return rowValuePtr.Interface().(RowScanner).ScanRow(columns, values)
+}
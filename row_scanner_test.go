@@ -0,0 +1,84 @@
+package sqlr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testValueObject has unexported fields, and scans itself.
+type testValueObject struct {
+	id    int64
+	label string // computed from the row
+}
+
+func (v *testValueObject) ScanRow(columns []string, values []interface{}) error {
+	if len(columns) != 2 || columns[0] != "id" || columns[1] != "name" {
+		return fmt.Errorf("unexpected columns %q", columns)
+	}
+	id, ok := values[0].(int64)
+	if !ok {
+		return errors.New("id is not an int64")
+	}
+	v.id = id
+	v.label = fmt.Sprintf("%s-%d", values[1], id)
+	return nil
+}
+
+func TestRowScanner(t *testing.T) {
+	db := rowsDB(t, 3)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+	defer sess.Close()
+
+	var values []testValueObject
+	n, err := sess.Select(&values, "select id, name from rows")
+	wantNoError(t, err)
+	if got, want := n, 3; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	if got, want := fmt.Sprint(values), "[{1 name-1} {2 name-2} {3 name-3}]"; got != want {
+		t.Errorf("got=%s, want=%s", got, want)
+	}
+
+	var ptrs []*testValueObject
+	_, err = sess.Select(&ptrs, "select id, name from rows")
+	wantNoError(t, err)
+	if got, want := ptrs[2].label, "name-3"; got != want {
+		t.Errorf("got=%s, want=%s", got, want)
+	}
+
+	var value testValueObject
+	n, err = sess.Select(&value, "select id, name from rows")
+	wantNoError(t, err)
+	if got, want := n, 3; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	if got, want := value.label, "name-1"; got != want {
+		t.Errorf("got=%s, want=%s", got, want)
+	}
+
+	var labels []string
+	err = sess.SelectReuse(testValueObject{}, func(row interface{}) error {
+		labels = append(labels, row.(*testValueObject).label)
+		return nil
+	}, "select id, name from rows")
+	wantNoError(t, err)
+	if got, want := fmt.Sprint(labels), "[name-1 name-2 name-3]"; got != want {
+		t.Errorf("got=%s, want=%s", got, want)
+	}
+}
+
+func TestRowScannerError(t *testing.T) {
+	db := rowsDBWithColumns(t, 3, "id", "title")
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+	defer sess.Close()
+
+	var values []testValueObject
+	_, err := sess.Select(&values, "select id, title from rows")
+	if got, want := fmt.Sprint(err), `unexpected columns ["id" "title"]`; got != want {
+		t.Errorf("got=%s, want=%s", got, want)
+	}
+}
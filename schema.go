@@ -1,7 +1,14 @@
 package sqlr
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
 	"github.com/jjeffery/sqlr/private/column"
+	"github.com/jjeffery/sqlr/private/wherein"
 )
 
 // Schema contains information known about the database schema and is used
@@ -29,6 +36,76 @@ type Schema struct {
 	tableMap   tableMap
 	key        string
 
+	// store []string, []int and []int64 fields as PostgreSQL arrays
+	postgresArrays bool
+
+	// maximum rows returned by a query, zero for no limit
+	maxRows int
+
+	// number of times a select is retried after a transient connection error
+	readRetries int
+
+	// sessions record the most recent database call
+	lastQuery bool
+
+	// how slice arguments are expanded in queries
+	sliceExpansion SliceExpansion
+
+	// check statements from the cache against freshly prepared statements
+	cacheAudit bool
+
+	// report an error when a decimal column is scanned into a float field
+	decimalCheck bool
+
+	// generated queries include rows that have been soft-deleted
+	includeDeleted bool
+
+	// database queried for the column names of tables on first use
+	introspectDB Querier
+
+	// schema that a schema created by withDialect was derived from, whose
+	// tables supply the introspected column names
+	dialectParent *Schema
+
+	// converts table names derived from type names to plural form
+	pluralizer func(string) string
+
+	// InsertRow reports an error for a zero primary key
+	zeroPKGuard bool
+
+	// creates the driver value for a table-valued parameter in ExecTVP
+	tvp func(typeName string, rows interface{}) interface{}
+
+	// store all bool fields in integer columns as 0 or 1
+	intBools bool
+
+	// case folding of identifiers in generated SQL
+	identCase IdentifierCase
+
+	// identCase was specified by an option, and is not the dialect default
+	identCaseSet bool
+
+	// match result column names to columns case-insensitively
+	caseInsensitive bool
+
+	// name columns of protobuf message structs using their proto field names
+	protobuf bool
+
+	// called for every row scanned, or nil
+	afterScan func(rowType reflect.Type, rowPtr interface{})
+
+	// maps optimistic locking conflicts to the error returned, or nil
+	conflictError func(*OptimisticLockingError) error
+
+	// permitted values for enum types, keyed by type
+	enums map[reflect.Type][]string
+
+	// in-memory row caches, keyed by row type
+	rowCaches map[reflect.Type]*rowCache
+
+	// schemas created by withDialect, keyed by dialect
+	dialectSchemas sync.Map
+
 	init *schemaInit // only used during initialization
 }
 
@@ -75,9 +152,17 @@ func NewSchemaE(opts ...SchemaOption) (*Schema, error) {
 		}
 	}
 
+	if !schema.identCaseSet {
+		schema.identCase = defaultIdentCase(schema.getDialect())
+	}
+	if schema.sliceExpansion == ExpandValues && !supportsValuesList(schema.getDialect()) {
+		return nil, fmt.Errorf("ExpandValues is not supported by dialect %s", dialectName(schema.getDialect()))
+	}
+
 	// configure any tables specified at initialization
 	if schema.init.tablesConfig != nil {
 		for row, cfg := range schema.init.tablesConfig {
+			cfg := cfg // the table keeps a pointer to its config
 			rowType, err := getRowType(row)
 			if err != nil {
 				return nil, err
@@ -99,15 +184,35 @@ func NewSchemaE(opts ...SchemaOption) (*Schema, error) {
 // TableFor returns the table information associated with
 // row, which should be an instance of a struct type
 // or a pointer to a struct type.
-// If row does not refer to a struct type then a panic results.
+// If row does not refer to a struct type, or a struct tag
+// specifies an unknown epoch unit, then a panic results.
+// The other methods that use the row type, such as Session.InsertRow
+// and Session.Select, return these errors instead.
+//
+// If the WithColumnIntrospection option is specified and the database
+// cannot be queried, the table returned has column names determined by
+// the naming convention. The table is not kept, so the database is queried
+// again the next time the row type is used.
 func (s *Schema) TableFor(row interface{}) *Table {
+	tbl, err := s.tableFor(row)
+	if tbl == nil {
+		panic(err)
+	}
+	return tbl
+}
+
+// tableFor returns the table information associated with row. It is
+// similar to TableFor, except that it returns an error instead of panicking.
+// If the database cannot be queried for the columns of the table, the table
+// is returned with the error, and has not been added to the table map.
+func (s *Schema) tableFor(row interface{}) (*Table, error) {
 	rowType, err := getRowType(row)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	tbl := s.tableMap.lookup(rowType)
 	if tbl != nil {
-		return tbl
+		return tbl, nil
 	}
 	// If we get here, then the table/row type mapping was
 	// not supplied when the schema was created. Create the
@@ -115,8 +220,24 @@ func (s *Schema) TableFor(row interface{}) *Table {
 	// that the *Table returned from tableMap.add might be different
 	// to tbl created by this function if another goroutine
 	// has beaten us to creating an entry in the tableMap.
-	tbl = newTable(s, rowType, nil)
-	return s.tableMap.add(rowType, tbl)
+	tbl = newTable(s, rowType, nil, nil)
+	if err := tbl.checkEpochUnits(); err != nil {
+		return nil, err
+	}
+	if s.introspectDB != nil {
+		if err := tbl.introspectColumns(context.Background(), s.introspectDB); err != nil {
+			// not added to the table map, so introspection is tried again
+			return tbl, err
+		}
+	}
+	if s.dialectParent != nil && s.dialectParent.introspectDB != nil {
+		parentTbl, err := s.dialectParent.tableFor(row)
+		if err != nil {
+			return tbl, err
+		}
+		tbl.copyIntrospectedNames(parentTbl)
+	}
+	return s.tableMap.add(rowType, tbl), nil
 }
 
 // columnNamerFunc converts a function into a columnNamer.
@@ -132,8 +253,10 @@ func (f columnNamerFunc) ColumnName(col *column.Info) string {
 // columnNamer returns an object that implements the columnNamer interface
 // for the schema. The column namer returns the column name based on the
 // list of field name/column name mappings for the schema, and the naming
-// convention.
-func (s *Schema) columnNamer() columnNamerFunc {
+// convention. If convention is nil, the schema's naming convention is used.
+// If stripPrefix is not empty, it is removed from the start of field names
+// before the naming convention is applied.
+func (s *Schema) columnNamer(convention NamingConvention, stripPrefix string) columnNamerFunc {
 	return columnNamerFunc(func(col *column.Info) string {
 		if s.fieldMap != nil {
 			if columnName, ok := s.fieldMap.lookup(col.FieldNames); ok {
@@ -145,14 +268,28 @@ func (s *Schema) columnNamer() columnNamerFunc {
 				}
 			}
 		}
-		convention := s.convention
+		if s.protobuf && len(col.Path) == 1 && col.Tag.Name == "" {
+			if name := column.ProtobufName(col.Field.Tag); name != "" {
+				return name
+			}
+		}
+		convention := convention
+		if convention == nil {
+			convention = s.convention
+		}
 		if convention == nil {
 			convention = defaultNamingConvention
 		}
-		return col.Path.ColumnName(convention, s.key)
+		return col.Path.StripPrefix(stripPrefix).ColumnName(convention, s.key)
 	})
 }
 
+// foldIdent returns the identifier folded to the case specified by
+// the WithIdentifierCase option.
+func (s *Schema) foldIdent(ident string) string {
+	return s.identCase.fold(ident)
+}
+
 // renameIdent implements the identRenamer interface.
 func (s *Schema) renameIdent(ident string) (string, bool) {
 	if s.identMap == nil {
@@ -161,6 +298,21 @@ func (s *Schema) renameIdent(ident string) (string, bool) {
 	return s.identMap.lookup(ident)
 }
 
+// usePostgresArrays reports whether slice fields are stored as PostgreSQL arrays.
+func (s *Schema) usePostgresArrays() bool {
+	return s.postgresArrays && isPostgres(s.getDialect())
+}
+
+// expand expands any slice arguments in the query using the schema's
+// slice expansion style.
+func (s *Schema) expand(query string, args []interface{}) (string, []interface{}, error) {
+	style := wherein.List
+	if s.sliceExpansion == ExpandValues {
+		style = wherein.Values
+	}
+	return wherein.ExpandStyle(query, args, style)
+}
+
 // getDialect returns the dialect for the schema. The aim is to make
 // an empty Schema usable, so this method is necessary to ensure that
 // a non-nil dialect is always available.
@@ -178,11 +330,46 @@ func (s *Schema) getDialect() Dialect {
 // Statements are low-level, and most programs do not need to use them
 // directly. This method may be removed in a future version of the API.
 func (s *Schema) Prepare(row interface{}, query string) (*Stmt, error) {
+	return s.prepare(row, query, nil, true)
+}
+
+// PrepareWithConvention is similar to Prepare, except that the column names
+// for the statement are derived using convention instead of the schema's
+// naming convention. This is useful for the occasional query against a legacy
+// table that does not follow the naming convention used by the rest of the
+// database schema. Other statements prepared by the schema are not affected.
+//
+// Any column names specified in struct tags or in the TableConfig for the
+// row type take precedence over the naming convention, as they do for Prepare.
+func (s *Schema) PrepareWithConvention(row interface{}, query string, convention NamingConvention) (*Stmt, error) {
+	if convention == nil {
+		return nil, errors.New("nil naming convention")
+	}
+	return s.prepare(row, query, convention, true)
+}
+
+// prepare creates a statement. If convention is non-nil, it is used instead
+// of the schema's naming convention. If cached is false, the statement is built
+// from scratch and is not added to the schema's statement cache.
+func (s *Schema) prepare(row interface{}, query string, convention NamingConvention, cached bool) (*Stmt, error) {
 	// for queries that do not involve a row, just use an empty struct
 	if row == nil {
 		row = &struct{}{}
 	}
 
+	// queries that do not involve a row have their own cache bucket,
+	// keyed by the original query text
+	rawQuery := query
+	raw := cached && convention == nil && isEmptyRow(row)
+	if raw {
+		if stmt, ok := s.cache.lookupRaw(rawQuery); ok {
+			if s.cacheAudit {
+				s.auditStmt(stmt, row, rawQuery, convention)
+			}
+			return stmt, nil
+		}
+	}
+
 	// determine row type to use for statement
 	rowType, err := getRowType(row)
 	if err != nil {
@@ -194,24 +381,107 @@ func (s *Schema) Prepare(row interface{}, query string) (*Stmt, error) {
 		return nil, err
 	}
 
+	// a naming convention can only be part of the cache key if it is comparable
+	if convention != nil && !reflect.TypeOf(convention).Comparable() {
+		cached = false
+	}
+
+	newTableStmt := func() (*Stmt, error) {
+		tbl, err := s.tableFor(rowType)
+		if err != nil {
+			return nil, err
+		}
+		if convention != nil {
+			tbl = newTable(s, rowType, tbl.cfg, convention)
+		}
+		return newStmt(s, tbl, query)
+	}
+
+	if !cached {
+		return newTableStmt()
+	}
+	if raw {
+		stmt, err := newTableStmt()
+		if err != nil {
+			return nil, err
+		}
+		return s.cache.setRaw(rawQuery, stmt), nil
+	}
+
 	// attempt to get statement from the schema's statement cache
-	stmt, ok := s.cache.lookup(rowType, query)
+	stmt, ok := s.cache.lookup(rowType, query, convention)
 	if !ok {
 		// build statement from scratch
-		tbl := s.TableFor(rowType)
-		stmt, err = newStmt(s, tbl, query)
+		stmt, err = newTableStmt()
 		if err != nil {
 			return nil, err
 		}
 		// add to schema's statement cache, returning the statement in the
 		// cache -- this is just in case another goroutine has beaten us to it
-		stmt = s.cache.set(rowType, query, stmt)
+		stmt = s.cache.set(rowType, query, convention, stmt)
+	} else if s.cacheAudit {
+		s.auditStmt(stmt, row, rawQuery, convention)
 	}
 	return stmt, nil
 }
 
+// auditStmt prepares the query again without using the statement cache,
+// and panics if the SQL does not match the statement found in the cache.
+// A mismatch means that the cache key does not capture everything that
+// affects the generated SQL.
+func (s *Schema) auditStmt(cached *Stmt, row interface{}, query string, convention NamingConvention) {
+	stmt, err := s.prepare(row, query, convention, false)
+	if err != nil {
+		panic(fmt.Sprintf("sqlr: cache audit: cannot prepare cached query %q: %v", query, err))
+	}
+	if stmt.query != cached.query {
+		panic(fmt.Sprintf("sqlr: cache audit: cached statement does not match for query %q:\ncached:   %s\nprepared: %s",
+			query, cached.query, stmt.query))
+	}
+}
+
+// isEmptyRow reports whether row is the anonymous empty struct used for
+// queries that do not involve a row.
+func isEmptyRow(row interface{}) bool {
+	switch row.(type) {
+	case struct{}, *struct{}:
+		return true
+	}
+	return false
+}
+
 // Key returns the key associated with the schema, which is specififed using
 // the WithKey schema option.
 func (s *Schema) Key() string {
 	return s.key
 }
+
+// CacheKeys returns a description of each statement in the schema's
+// statement cache, in sorted order. Each description is the row type
+// followed by the query, or just the query for statements that do not
+// involve a row type, such as those passed to Session.Exec.
+//
+// This is a debugging aid. Statements are cached indefinitely, so a program
+// that builds queries containing embedded literal values, instead of using
+// placeholders, will grow the cache without limit. Near-duplicate keys that
+// differ only by a literal value are a sign of this problem.
+func (s *Schema) CacheKeys() []string {
+	return s.cache.keys()
+}
+
+// callAfterScan calls the function registered using WithAfterScan, if any,
+// for a row that has just been scanned.
+func (s *Schema) callAfterScan(rowValuePtr reflect.Value) {
+	if s.afterScan != nil {
+		s.afterScan(rowValuePtr.Type().Elem(), rowValuePtr.Interface())
+	}
+}
+
+// enumFor returns the permitted values registered for the field type
+// using the WithEnum option, or nil if none have been registered.
+func (s *Schema) enumFor(fieldType reflect.Type) []string {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	return s.enums[fieldType]
+}
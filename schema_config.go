@@ -50,10 +50,34 @@ type TableConfig struct {
 	// table associated with the row type.
 	TableName string
 
+	// StripFieldPrefix optionally specifies a prefix that is removed from
+	// the start of field names before the naming convention is applied to
+	// obtain the column name. For example, if StripFieldPrefix is "User",
+	// then the column name for field UserName is "name". The prefix is only
+	// removed when it is followed by an upper case letter or a digit, so the
+	// column name for field Username is "username". Field names that do not
+	// start with the prefix, and column names specified in the struct tag or
+	// in Columns, are not affected.
+	StripFieldPrefix string
+
 	// Columns is an optional list of column configurations.
 	// Only columns with non-default configuration need to
 	// be included in this list.
 	Columns ColumnsConfig
+
+	// ShardFunc optionally specifies a function that returns the name of the
+	// database table for an individual row, for tables that are sharded
+	// horizontally across multiple tables with the same columns, eg "users_0"
+	// to "users_15". The function is passed the row, and usually computes the
+	// table name from a hash of the primary key.
+	//
+	// The shard table is used by InsertRow, UpdateRow, UpsertRow, UpdateJSON
+	// and GetRow. Queries passed to Select, Exec and similar methods specify
+	// the table name in the query text, and are not affected. Operations that
+	// are not passed a row, such as get, load and delete functions created by
+	// MakeQuery, SelectByKeysVia, SelectAfter and InsertSelect, return an error
+	// for a sharded table.
+	ShardFunc func(row interface{}) string
 }
 
 // ColumnsConfig is a map of individual column configurations, keyed
@@ -118,6 +142,12 @@ type ColumnConfig struct {
 	// storing in the field.
 	JSON bool
 
+	// Codec optionally specifies how the value of the field is marshaled
+	// before storing in the database, and unmarshaled from the value in the
+	// database. It is used instead of JSON encoding, and when the field is
+	// not also marked as JSON, the column is a binary column.
+	Codec Codec
+
 	// NaturalKey optionally indicates that the column forms part of a
 	// natural key for the row. When a column forms part of a natural
 	// key, then the value in that field is included in any error message
@@ -125,6 +155,33 @@ type ColumnConfig struct {
 	// for diagnostics and debugging.
 	NaturalKey bool
 
+	// Generated optionally indicates that the value of the column is
+	// generated by the database, for example by a column default such as
+	// "default now()". The column is not included in insert statements, and
+	// for PostgreSQL the generated value is read back into the field when
+	// the row is inserted.
+	Generated bool
+
+	// WriteExpr optionally specifies an SQL expression for the value written
+	// to the column in generated INSERT and UPDATE statements, instead of a
+	// plain placeholder. The expression must contain exactly one "?"
+	// placeholder, which is bound to the value of the field, eg
+	// "ST_GeomFromText(?)" or "to_tsvector('english', ?)". Because the
+	// expression is scanned for placeholders, it cannot contain a "?"
+	// operator, such as the PostgreSQL jsonb operator.
+	WriteExpr string
+
+	// ReadExpr optionally specifies an SQL expression that populates the
+	// field when rows are selected, instead of a database column. This
+	// allows a field to be derived from other columns without a database
+	// view, eg "first_name || ' ' || last_name". The expression is included
+	// in the select column list, aliased to the column name. It is included
+	// as is, and is not qualified with any alias in "{alias t}", so it should
+	// qualify column names itself when the query joins tables. The column is
+	// not included in insert and update statements, or in CREATE TABLE
+	// statements.
+	ReadExpr string
+
 	// OverrideStructTag optionally specifies that the configuration
 	// in this struct should override all configuration present in
 	// the field's struct tag. This would only be used in unusual
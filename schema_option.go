@@ -1,6 +1,11 @@
 package sqlr
 
-import "database/sql"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
 
 // A SchemaOption provides optional configuration and is supplied when
 // creating a new Schema.
@@ -24,6 +29,364 @@ func WithDialect(dialect Dialect) SchemaOption {
 	}
 }
 
+// WithPostgresArrays creates an option that stores fields of type []string,
+// []int and []int64 as native PostgreSQL arrays. This option has no effect
+// unless the schema uses the PostgreSQL dialect.
+//
+// Fields with the "json" struct tag are always stored as JSON, and slices
+// passed as query arguments are still expanded for "IN (?)" clauses.
+func WithPostgresArrays() SchemaOption {
+	return func(schema *Schema) error {
+		schema.postgresArrays = true
+		schema.cache.clear()
+		return nil
+	}
+}
+
+// WithIntBools creates an option that stores every bool field in an integer
+// column, with 1 for true and 0 for false. When scanning, any non-zero value
+// is true. This is common for SQLite and MySQL schemas, where flags are often
+// stored in INTEGER or TINYINT columns, and scanning an integer into a bool
+// fails for some drivers.
+//
+// Individual fields can be stored this way without this option by using the
+// "intbool" keyword in the struct tag:
+//  Active bool `sql:"active intbool"`
+func WithIntBools() SchemaOption {
+	return func(schema *Schema) error {
+		schema.intBools = true
+		schema.cache.clear()
+		return nil
+	}
+}
+
+// WithIdentifierCase creates an option that folds the case of identifiers in
+// generated SQL, for databases that fold unquoted identifiers to upper case
+// (eg Oracle) or lower case (eg PostgreSQL). Table and column names derived
+// from the row struct are folded, so that the quoted names in generated SQL
+// match the names of tables and columns that were created with unquoted
+// identifiers. Unquoted identifiers in the query text are also folded, so
+// the generated SQL is consistent. Quoted identifiers in the query text are
+// not changed.
+func WithIdentifierCase(fold IdentifierCase) SchemaOption {
+	return func(schema *Schema) error {
+		schema.identCase = fold
+		schema.identCaseSet = true
+		schema.cache.clear()
+		return nil
+	}
+}
+
+// WithCaseInsensitiveColumns creates an option that matches the column names
+// returned by a query with the columns of the row struct without regard to
+// case. This is useful for databases that return upper-case column names,
+// such as Oracle, where the result column "USER_NAME" matches the column
+// "user_name".
+//
+// Without this option, column names are matched case-sensitively, and only
+// if that fails is a second, case-insensitive match attempted. With this option
+// the lower-case column names are determined once for each table, and every
+// result column is matched the same way. It is an error for a query to return
+// a column that matches more than one column of the row struct.
+func WithCaseInsensitiveColumns() SchemaOption {
+	return func(schema *Schema) error {
+		schema.caseInsensitive = true
+		schema.cache.clear()
+		return nil
+	}
+}
+
+// WithProtobuf creates an option for scanning query results into structs
+// generated by protoc-gen-go, so that a service using protobuf messages as
+// its model can select directly into them:
+//  var users []*pb.User
+//  n, err := sess.Select(&users, "select {} from users where org_id = ?", orgID)
+// Each field is mapped to the column with the field name given in its
+// "protobuf" struct tag, which is conventionally snake case. A column name
+// specified in an "sql" or "sqlr" struct tag takes precedence, as does any
+// name set with WithField. Exported fields whose names start with "XXX_",
+// which hold the internal state of messages generated by older versions of
+// protoc-gen-go, are ignored. Structs without "protobuf" struct tags are not
+// affected by this option.
+func WithProtobuf() SchemaOption {
+	return func(schema *Schema) error {
+		schema.protobuf = true
+		schema.cache.clear()
+		return nil
+	}
+}
+
+// ErrTooManyRows is returned when a query returns more rows than the
+// limit specified by the WithMaxRows schema option.
+var ErrTooManyRows = errors.New("query returned too many rows")
+
+// WithMaxRows creates an option that limits the number of rows that can be
+// returned by a query that selects into a slice. If a query returns more than
+// n rows, reading stops and ErrTooManyRows is returned. This is a safety net
+// against queries that would otherwise read a huge number of rows into
+// memory, such as a query with a missing WHERE clause.
+//
+// The limit applies when rows are read into a slice or a map by Select,
+// SelectUncached, SelectAfter, SelectExec and ExecScan, and by query
+// functions made by MakeQuery that return a slice. It applies whether or not
+// the package rewrites the query. It does not apply to Query, which returns
+// the rows to the caller, or to Each and SelectReuse, which do not keep the
+// rows in memory. If n is zero or negative, there is no limit.
+func WithMaxRows(n int) SchemaOption {
+	return func(schema *Schema) error {
+		schema.maxRows = n
+		return nil
+	}
+}
+
+// WithReadRetry creates an option that retries a select query up to n times
+// if it fails with a transient connection error, such as driver.ErrBadConn.
+// It applies to queries performed by Select, Query and the get methods.
+//
+// Only queries that are recognized as selects are retried: inserts, updates
+// and deletes are never retried, because the statement may have been applied
+// before the connection failed. Queries performed within a transaction are
+// not retried either, because the transaction cannot continue on a new
+// connection. This includes queries made using the session passed to the
+// function called by Session.InTx, even though that session uses the same
+// schema. If n is zero or negative, queries are not retried.
+func WithReadRetry(n int) SchemaOption {
+	return func(schema *Schema) error {
+		schema.readRetries = n
+		return nil
+	}
+}
+
+// WithLastQuery creates an option that records the SQL and args of the most
+// recent database call performed by each session, which are returned by the
+// session's LastQuery method. This is useful in tests and for debugging, but
+// has a small overhead for every database call.
+func WithLastQuery() SchemaOption {
+	return func(schema *Schema) error {
+		schema.lastQuery = true
+		return nil
+	}
+}
+
+// WithCacheAudit creates an option that checks the schema's statement cache
+// for correctness. Every time a statement is found in the cache, the query is
+// prepared again from scratch and the generated SQL is compared with the SQL
+// of the cached statement. If they differ the program panics, as this indicates
+// a bug where the cache key does not capture all of the inputs that affect the
+// generated SQL.
+//
+// This option is intended for development and testing only, as it removes any
+// performance benefit of the statement cache.
+func WithCacheAudit() SchemaOption {
+	return func(schema *Schema) error {
+		schema.cacheAudit = true
+		return nil
+	}
+}
+
+// WithSoftDelete creates an option that controls whether the queries
+// generated for tables with a deleted column exclude rows that have been
+// soft-deleted. A deleted column is a time.Time or *time.Time field with the
+// "deleted" keyword in its struct tag, eg `sql:"deleted_at deleted"`. The
+// filtering is enabled by default, so this option is only needed to turn
+// it off.
+//
+// The filtering applies to GetRow, SelectByKeys, and the functions created
+// by MakeQuery. Queries supplied to Select are never modified.
+func WithSoftDelete(enabled bool) SchemaOption {
+	return func(schema *Schema) error {
+		schema.includeDeleted = !enabled
+		return nil
+	}
+}
+
+// WithColumnIntrospection creates an option that resolves the column names
+// of a table by querying db for the columns of the database table the first
+// time the row type is used. A field whose column name, as determined by the
+// naming convention, does not match a database column is mapped to the
+// database column with the same normalized name, where names are compared
+// ignoring case and underscores. For example, a field named "UserID" maps to a
+// column named "userid", "USER_ID" or "user_id".
+//
+// Fields with a column name in the struct tag or in a ColumnConfig are not
+// affected. The columns of row types supplied by WithTables are resolved when
+// the schema is created, and NewSchemaE returns an error if the database
+// cannot be queried. For other row types, an error querying the database is
+// returned by the call that first uses the row type, and the database is
+// queried again by the next call. If the database table does not exist, the
+// column names are determined by the naming convention.
+func WithColumnIntrospection(db Querier) SchemaOption {
+	return func(schema *Schema) error {
+		schema.introspectDB = db
+		return nil
+	}
+}
+
+// WithZeroPKGuard creates an option that causes InsertRow, InsertRows,
+// UpsertRow and UpsertOnConflict to return an error if a row has a primary
+// key field with its zero value, and the table does not have an
+// autoincrement column. This catches the common mistake of
+// forgetting to assign the ID of a row before inserting it, which would
+// otherwise insert a row with an ID of zero (or an empty string).
+//
+// Primary key columns that are generated by the database are not checked.
+func WithZeroPKGuard() SchemaOption {
+	return func(schema *Schema) error {
+		schema.zeroPKGuard = true
+		return nil
+	}
+}
+
+// WithTVP creates an option that sets the function used by ExecTVP to create
+// a table-valued parameter for SQL Server. The sqlr package does not depend
+// on any database driver, so the function creates the driver's value from the
+// type name and rows. For the github.com/denisenkom/go-mssqldb driver:
+//  schema := sqlr.NewSchema(
+//      sqlr.WithDialect(sqlr.MSSQL),
+//      sqlr.WithTVP(func(typeName string, rows interface{}) interface{} {
+//          return mssql.TVP{TypeName: typeName, Value: rows}
+//      }),
+//  )
+func WithTVP(fn func(typeName string, rows interface{}) interface{}) SchemaOption {
+	return func(schema *Schema) error {
+		schema.tvp = fn
+		return nil
+	}
+}
+
+// WithDecimalPrecisionCheck creates an option that reports an error when
+// a query returns a DECIMAL or NUMERIC column that would be scanned into a
+// float32 or float64 field. Floating point values cannot represent every
+// decimal value exactly, so precision can be lost without any indication,
+// which is a particular problem for financial data. Fields for decimal
+// columns should use a decimal type that implements sql.Scanner.
+//
+// The check relies on the database type name reported by the driver for
+// each column, and has no effect for drivers that do not report it.
+func WithDecimalPrecisionCheck() SchemaOption {
+	return func(schema *Schema) error {
+		schema.decimalCheck = true
+		return nil
+	}
+}
+
+// SliceExpansion determines how a placeholder for a slice argument
+// is expanded in an SQL query.
+type SliceExpansion int
+
+const (
+	// ExpandList expands the placeholder to a list of placeholders,
+	// one for each element of the slice. This is the default.
+	//  "where id in (?)" becomes "where id in (?,?,?)"
+	ExpandList SliceExpansion = iota
+
+	// ExpandValues expands the placeholder to a VALUES list, with one
+	// row for each element of the slice. Some databases (notably PostgreSQL)
+	// produce better query plans for a large VALUES list than for a
+	// large IN list. MySQL, SQL Server and Oracle do not accept a VALUES
+	// list in this position, and a schema for these dialects cannot be
+	// created with ExpandValues.
+	//  "where id in (?)" becomes "where id in (VALUES (?),(?),(?))"
+	ExpandValues
+)
+
+// WithSliceExpansion creates an option that determines how slice arguments
+// are expanded in queries. The default is ExpandList.
+func WithSliceExpansion(expansion SliceExpansion) SchemaOption {
+	return func(schema *Schema) error {
+		switch expansion {
+		case ExpandList, ExpandValues:
+			schema.sliceExpansion = expansion
+			return nil
+		}
+		return fmt.Errorf("invalid slice expansion: %d", expansion)
+	}
+}
+
+// supportsValuesList reports whether the dialect accepts a VALUES list
+// in place of an IN list, as generated by ExpandValues.
+func supportsValuesList(dialect Dialect) bool {
+	switch dialectName(dialect) {
+	case dialectMySQL, dialectMSSQL, dialectOracle:
+		return false
+	}
+	return true
+}
+
+// WithAfterScan creates an option that registers a function that is called
+// for every row scanned from the database, after any JSON and null handling.
+// The rowType argument is the type of the row struct, and rowPtr is a pointer
+// to the row. The function is called for all queries that scan rows into
+// structs, including rows selected using Select and functions created by
+// MakeQuery.
+//
+// The function is global to the schema, so it is suitable for cross-cutting
+// post-processing that applies to rows of any type, such as trimming the
+// trailing spaces from CHAR columns or converting times to UTC.
+func WithAfterScan(fn func(rowType reflect.Type, rowPtr interface{})) SchemaOption {
+	return func(schema *Schema) error {
+		schema.afterScan = fn
+		return nil
+	}
+}
+
+// WithConflictError creates an option that maps the error returned when
+// updating a row with a version field fails because of an optimistic locking
+// conflict. The mapper is called with the *OptimisticLockingError, and the
+// error it returns is returned by UpdateRow instead. This allows an application
+// to return its own error type for conflicts, such as an error that results in
+// an HTTP 409 response, without every caller translating the error.
+//
+// If the mapper returns nil, the *OptimisticLockingError is returned.
+func WithConflictError(mapper func(*OptimisticLockingError) error) SchemaOption {
+	return func(schema *Schema) error {
+		schema.conflictError = mapper
+		return nil
+	}
+}
+
+// WithEnum creates an option that registers the permitted values for a Go
+// type that is stored in a database enum column, such as a PostgreSQL native
+// enum type. The value argument is any value of the type, typically one of
+// its constants.
+//  type Status string
+//
+//  const (
+//      StatusActive   Status = "active"
+//      StatusInactive Status = "inactive"
+//  )
+//
+//  schema := NewSchema(
+//      WithEnum(StatusActive, "active", "inactive"),
+//  )
+//
+// Every column whose field has the type (or a pointer to the type) is checked
+// in both directions. Inserting or updating a row with a value that is not one
+// of the labels is an error, and so is reading a row where the database returns
+// a label that is not one of the values: this catches a database schema that has
+// drifted from the program. The value sent to the database is compared exactly,
+// so a type with a String method is checked using its underlying value, or the
+// result of its Value method if it implements driver.Valuer.
+//
+// Permitted values specified using the "enum" keyword in a struct tag take
+// precedence over values registered with this option.
+func WithEnum(value interface{}, labels ...string) SchemaOption {
+	return func(schema *Schema) error {
+		if value == nil {
+			return errors.New("WithEnum: nil value")
+		}
+		if len(labels) == 0 {
+			return fmt.Errorf("WithEnum: no labels for %T", value)
+		}
+		if schema.enums == nil {
+			schema.enums = make(map[reflect.Type][]string)
+		}
+		schema.enums[reflect.TypeOf(value)] = labels
+		schema.cache.clear()
+		return nil
+	}
+}
+
 // WithNamingConvention creates and option that sets the schema's naming convention.
 func WithNamingConvention(convention NamingConvention) SchemaOption {
 	return func(schema *Schema) error {
@@ -33,6 +396,23 @@ func WithNamingConvention(convention NamingConvention) SchemaOption {
 	}
 }
 
+// WithPluralizer creates an option that sets a function for converting
+// table names to plural form. When the table name for a row type is derived
+// from the name of the type, the name returned by the naming convention is
+// passed to the pluralizer, so that with the default naming convention a type
+// named "Category" can be mapped to a table named "categories", and a type
+// named "Person" to a table named "people".
+//
+// The pluralizer is not used for table names specified in a TableConfig or
+// using the "table" struct tag.
+func WithPluralizer(pluralize func(name string) string) SchemaOption {
+	return func(schema *Schema) error {
+		schema.pluralizer = pluralize
+		schema.cache.clear()
+		return nil
+	}
+}
+
 // WithField creates an option that maps a Go field name to a
 // database column name.
 //
@@ -83,6 +463,10 @@ func WithField(fieldName string, columnName string) SchemaOption {
 // an SQL Server database where a table is named "[User]", but the
 // same table is named "users" in the Postgres schema.
 //
+// Either identifier can be schema-qualified, eg "dbo.[User]", and quoted
+// using any of the common quoting styles. Quoted parts of the replacement
+// identifier are re-quoted to suit the schema's dialect.
+//
 // Deprecated: Use (TODO) instead. This is a confusing API, and another,
 // clearer option will be provided.
 func WithIdentifier(identifier string, meaning string) SchemaOption {
@@ -90,7 +474,8 @@ func WithIdentifier(identifier string, meaning string) SchemaOption {
 		if schema.identMap == nil {
 			schema.identMap = newIdentMap(schema.identMap)
 		}
-		schema.identMap.add(meaning, identifier)
+		// identifiers in queries are looked up without any quotes
+		schema.identMap.add(unquoteIdent(meaning), identifier)
 		return nil
 	}
 }
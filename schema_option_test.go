@@ -1,6 +1,11 @@
 package sqlr
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -111,3 +116,601 @@ func TestWithKey(t *testing.T) {
 		t.Errorf("got=%q want=%q", got, want)
 	}
 }
+
+func TestWithPluralizer(t *testing.T) {
+	type Category struct {
+		ID int `sql:"primary key"`
+	}
+	type Person struct {
+		ID int `sql:"primary key"`
+	}
+	type OrderLine struct {
+		ID int `sql:"primary key"`
+	}
+	type Tagged struct {
+		ID int `sql:"primary key" table:"tagged_things"`
+	}
+	type Configured struct {
+		ID int `sql:"primary key"`
+	}
+	irregular := map[string]string{
+		"person": "people",
+	}
+	pluralize := func(name string) string {
+		if plural, ok := irregular[name]; ok {
+			return plural
+		}
+		if strings.HasSuffix(name, "y") {
+			return strings.TrimSuffix(name, "y") + "ies"
+		}
+		return name + "s"
+	}
+	schema := NewSchema(
+		WithPluralizer(pluralize),
+		WithTables(TablesConfig{
+			reflect.TypeOf(Configured{}): {TableName: "config_table"},
+		}),
+	)
+	tests := []struct {
+		row  interface{}
+		want string
+	}{
+		{row: Category{}, want: "categories"},
+		{row: &Person{}, want: "people"},
+		{row: OrderLine{}, want: "order_lines"},
+		{row: Tagged{}, want: "tagged_things"},
+		{row: Configured{}, want: "config_table"},
+	}
+	for _, tt := range tests {
+		if got := schema.TableFor(tt.row).Name(); got != tt.want {
+			t.Errorf("got=%q, want=%q", got, tt.want)
+		}
+	}
+
+	// same case naming convention
+	schema = NewSchema(WithNamingConvention(SameCase), WithPluralizer(pluralize))
+	if got, want := schema.TableFor(Category{}).Name(), "Categories"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestWithZeroPKGuard(t *testing.T) {
+	type Widget struct {
+		ID   string `sql:"primary key"`
+		Name string
+	}
+	type Line struct {
+		OrderID int64 `sql:"primary key"`
+		LineNo  int   `sql:"primary key"`
+	}
+	type Counter struct {
+		ID   int64 `sql:"primary key autoincrement"`
+		Name string
+	}
+	type Generated struct {
+		ID   string `sql:"primary key generated"`
+		Name string
+	}
+	tests := []struct {
+		row     interface{}
+		guard   bool
+		wantErr string
+	}{
+		{row: &Widget{Name: "zero id"}},
+		{row: &Widget{Name: "zero id"}, guard: true, wantErr: "InsertRow: primary key field ID of sqlr.Widget has zero value"},
+		{row: &Widget{ID: "w1"}, guard: true},
+		{row: &Line{OrderID: 1}, guard: true, wantErr: "InsertRow: primary key field LineNo of sqlr.Line has zero value"},
+		{row: &Line{OrderID: 1, LineNo: 1}, guard: true},
+		{row: &Counter{Name: "autoincrement"}, guard: true},
+		{row: &Generated{Name: "generated"}, guard: true},
+	}
+	for i, tt := range tests {
+		options := []SchemaOption{WithDialect(MySQL)}
+		if tt.guard {
+			options = append(options, WithZeroPKGuard())
+		}
+		db := &FakeDB{rowsAffected: 1, lastInsertId: 1}
+		sess := NewSession(context.Background(), db, NewSchema(options...))
+		err := sess.InsertRow(tt.row)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%d: %v", i, err)
+			}
+		} else if err == nil {
+			t.Errorf("%d: got=nil, want=%q", i, tt.wantErr)
+		} else if got := err.Error(); got != tt.wantErr {
+			t.Errorf("%d: got=%q, want=%q", i, got, tt.wantErr)
+		}
+		if tt.wantErr != "" && len(db.queries) > 0 {
+			t.Errorf("%d: got=%q, want no queries", i, db.queries)
+		}
+		sess.Close()
+	}
+}
+
+func TestWithZeroPKGuardRows(t *testing.T) {
+	type Widget struct {
+		ID   string `sql:"primary key"`
+		Name string
+	}
+	db := &FakeDB{rowsAffected: 1}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite), WithZeroPKGuard()))
+	defer sess.Close()
+
+	tests := []struct {
+		fn      func() error
+		wantErr string
+	}{
+		{
+			fn: func() error {
+				_, err := sess.InsertRows([]Widget{{ID: "w1"}, {Name: "zero id"}})
+				return err
+			},
+			wantErr: "InsertRows: row 1: primary key field ID of sqlr.Widget has zero value",
+		},
+		{
+			fn: func() error {
+				_, err := sess.UpsertRow(&Widget{Name: "zero id"})
+				return err
+			},
+			wantErr: "UpsertRow: primary key field ID of sqlr.Widget has zero value",
+		},
+		{
+			fn: func() error {
+				_, err := sess.UpsertOnConflict(&Widget{Name: "zero id"}, "name", "")
+				return err
+			},
+			wantErr: "UpsertOnConflict: primary key field ID of sqlr.Widget has zero value",
+		},
+	}
+	for i, tt := range tests {
+		err := tt.fn()
+		if err == nil {
+			t.Errorf("%d: got=nil, want=%q", i, tt.wantErr)
+		} else if got := err.Error(); got != tt.wantErr {
+			t.Errorf("%d: got=%q, want=%q", i, got, tt.wantErr)
+		}
+	}
+	if len(db.queries) > 0 {
+		t.Errorf("got=%q, want no queries", db.queries)
+	}
+
+	n, err := sess.InsertRows([]Widget{{ID: "w1"}, {ID: "w2"}})
+	wantNoError(t, err)
+	if got, want := n, 1; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+}
+
+func TestWithAfterScan(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 3)
+	defer db.Close()
+
+	var calls int
+	schema := NewSchema(
+		WithDialect(SQLite),
+		WithAfterScan(func(rowType reflect.Type, rowPtr interface{}) {
+			calls++
+			if got, want := rowType, reflect.TypeOf(Row{}); got != want {
+				t.Errorf("got=%v, want=%v", got, want)
+			}
+			row := rowPtr.(*Row)
+			row.Name = strings.ToUpper(row.Name)
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	var rows []Row
+	n, err := sess.Select(&rows, "select {} from rows")
+	wantNoError(t, err)
+	if got, want := calls, n; got != want {
+		t.Errorf("slice: got=%v calls, want=%v", got, want)
+	}
+	for _, row := range rows {
+		if got, want := row.Name, "NAME"; got != want {
+			t.Errorf("got=%v, want=%v", got, want)
+		}
+	}
+
+	calls = 0
+	var row Row
+	_, err = sess.Select(&row, "select {} from rows")
+	wantNoError(t, err)
+	if got, want := calls, 1; got != want {
+		t.Errorf("single row: got=%v calls, want=%v", got, want)
+	}
+	if got, want := row.Name, "NAME"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	calls = 0
+	var selectRows func(query string, args ...interface{}) ([]*Row, error)
+	sess.MakeQuery(&selectRows)
+	ptrs, err := selectRows("select {} from rows where name = ?", "name")
+	wantNoError(t, err)
+	if got, want := calls, len(ptrs); got != want || got == 0 {
+		t.Errorf("query func: got=%v calls, want=%v", got, want)
+	}
+}
+
+type testStatus string
+
+// String is deliberately different from the label stored in the database.
+func (s testStatus) String() string {
+	return strings.ToUpper(string(s))
+}
+
+func TestWithEnum(t *testing.T) {
+	type Row struct {
+		ID     int64 `sql:"primary key"`
+		Status testStatus
+		Prev   *testStatus
+	}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithEnum(testStatus(""), "active", "inactive"),
+	)
+	tbl := schema.TableFor(Row{})
+	if got, want := strings.Join(tbl.Columns()[1].Enum(), ","), "active,inactive"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	inactive := testStatus("inactive")
+	deleted := testStatus("deleted")
+	tests := []struct {
+		row     Row
+		errText string
+	}{
+		{
+			row: Row{ID: 1, Status: "active"},
+		},
+		{
+			row: Row{ID: 1, Status: "active", Prev: &inactive},
+		},
+		{
+			// String method is not used for the comparison
+			row:     Row{ID: 1, Status: "ACTIVE"},
+			errText: `invalid value "ACTIVE" for field "Status": must be one of active, inactive`,
+		},
+		{
+			row:     Row{ID: 1, Status: "active", Prev: &deleted},
+			errText: `invalid value "deleted" for field "Prev": must be one of active, inactive`,
+		},
+	}
+	for i, tt := range tests {
+		stmt, err := schema.Prepare(tt.row, "insert into rows")
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		_, err = stmt.getArgs(&tt.row, nil)
+		var errText string
+		if err != nil {
+			errText = err.Error()
+		}
+		if got, want := errText, tt.errText; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+
+	if _, err := NewSchemaE(WithEnum(testStatus(""))); err == nil {
+		t.Error("expected error for no labels")
+	}
+}
+
+func TestWithEnumScan(t *testing.T) {
+	// the rows database returns "name" in the name column
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name *testStatus
+	}
+	tests := []struct {
+		labels  []string
+		errText string
+	}{
+		{
+			labels: []string{"id", "name"},
+		},
+		{
+			labels:  []string{"active", "inactive"},
+			errText: `unknown value "name" for column "name": must be one of active, inactive`,
+		},
+	}
+	for i, tt := range tests {
+		db := rowsDB(t, 2)
+		schema := NewSchema(WithDialect(SQLite), WithEnum(testStatus(""), tt.labels...))
+		sess := NewSession(context.Background(), db, schema)
+		var rows []Row
+		_, err := sess.Select(&rows, "select {} from rows")
+		var errText string
+		if err != nil {
+			errText = err.Error()
+		} else if rows[1].Name == nil || *rows[1].Name != "name" {
+			t.Errorf("%d: got=%+v", i, rows[1])
+		}
+		if !strings.Contains(errText, tt.errText) || (tt.errText == "") != (errText == "") {
+			t.Errorf("%d: got=%q, want=%q", i, errText, tt.errText)
+		}
+		sess.Close()
+		db.Close()
+	}
+}
+
+func TestWithCaseInsensitiveColumns(t *testing.T) {
+	type Row struct {
+		ID       int64 `sql:"primary key"`
+		UserName string
+	}
+	type Ambiguous struct {
+		ID    int64  `sql:"id"`
+		Code  string `sql:"code"`
+		Code2 string `sql:"CODE"`
+	}
+	tests := []struct {
+		row     interface{}
+		columns []string
+		errText string
+	}{
+		{
+			row:     &Row{},
+			columns: []string{"ID", "USER_NAME"},
+		},
+		{
+			row:     &Row{},
+			columns: []string{"Id", "User_Name"},
+		},
+		{
+			row:     &Row{},
+			columns: []string{"ID", "NAME"},
+			errText: `unknown column name="NAME"`,
+		},
+		{
+			row:     &Row{},
+			columns: []string{"ID", "ID"},
+			errText: `unknown column name="ID"`,
+		},
+		{
+			row:     &Ambiguous{},
+			columns: []string{"ID", "CODE"},
+			errText: `ambiguous column name="CODE"`,
+		},
+	}
+	for i, tt := range tests {
+		db := rowsDBWithColumns(t, 2, tt.columns[0], tt.columns[1])
+		schema := NewSchema(WithDialect(SQLite), WithCaseInsensitiveColumns())
+		sess := NewSession(context.Background(), db, schema)
+		_, err := sess.Select(tt.row, "select {} from rows")
+		var errText string
+		if err != nil {
+			errText = err.Error()
+		} else if row := tt.row.(*Row); row.ID != 1 || row.UserName != "name" {
+			t.Errorf("%d: got=%+v", i, row)
+		}
+		if errText != tt.errText {
+			t.Errorf("%d: got=%q, want=%q", i, errText, tt.errText)
+		}
+		sess.Close()
+		db.Close()
+	}
+}
+
+// testProtoUser has the layout of a struct generated by protoc-gen-go.
+type testProtoUser struct {
+	state         struct{}
+	sizeCache     int32
+	unknownFields []byte
+
+	Id       int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserName string `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+}
+
+// testLegacyProtoUser has the layout of a struct generated by older
+// versions of protoc-gen-go.
+type testLegacyProtoUser struct {
+	Id                   int64    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	DisplayName          string   `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Email                string   `protobuf:"bytes,3,opt,name=email,proto3" sql:"email_address"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func TestWithProtobuf(t *testing.T) {
+	schema := NewSchema(WithDialect(SQLite), WithProtobuf())
+	tests := []struct {
+		row     interface{}
+		columns []string
+	}{
+		{
+			row:     &testProtoUser{},
+			columns: []string{"id", "user_name"},
+		},
+		{
+			row:     &testLegacyProtoUser{},
+			columns: []string{"id", "display_name", "email_address"},
+		},
+	}
+	for i, tt := range tests {
+		var columns []string
+		for _, col := range schema.TableFor(tt.row).Columns() {
+			columns = append(columns, col.Name())
+		}
+		if got, want := strings.Join(columns, ","), strings.Join(tt.columns, ","); got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+
+	db := rowsDBWithColumns(t, 3, "id", "user_name")
+	sess := NewSession(context.Background(), db, schema)
+	var users []*testProtoUser
+	n, err := sess.Select(&users, "select {} from users order by id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n, 3; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	for i, user := range users {
+		if got, want := user.Id, int64(i+1); got != want {
+			t.Errorf("%d: got=%d, want=%d", i, got, want)
+		}
+		if got, want := user.UserName, "name"; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+
+	// without the option, the column names follow the naming convention
+	schema = NewSchema(WithDialect(SQLite))
+	if got, want := schema.TableFor(&testLegacyProtoUser{}).Columns()[1].Name(), "display_name"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	if got, want := len(schema.TableFor(&testLegacyProtoUser{}).Columns()), 5; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+}
+
+// conflictDB is a querier that updates no rows, and returns the rows of
+// a rowsDB for every query.
+type conflictDB struct {
+	FakeDB
+	rows *sql.DB
+}
+
+func (db *conflictDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	db.queries = append(db.queries, query)
+	return db.rows.QueryContext(ctx, query, args...)
+}
+
+type testConflictError struct {
+	status  int
+	version int64
+}
+
+func (e *testConflictError) Error() string {
+	return fmt.Sprintf("status %d: version %d", e.status, e.version)
+}
+
+func TestWithConflictError(t *testing.T) {
+	type Row struct {
+		ID      int64 `sql:"primary key"`
+		Name    string
+		Version int64 `sql:"version"`
+	}
+	var called int
+	mapper := func(err *OptimisticLockingError) error {
+		called++
+		if err.ExpectedVersion == 5 {
+			// use the default error
+			return nil
+		}
+		return &testConflictError{status: 409, version: err.ActualVersion}
+	}
+
+	db := &conflictDB{rows: rowsDBWithColumns(t, 1, "version")}
+	defer db.rows.Close()
+	for _, schema := range []*Schema{
+		NewSchema(WithDialect(Postgres)),
+		NewSchema(WithDialect(Postgres), WithConflictError(mapper)),
+	} {
+		sess := NewSession(context.Background(), db, schema)
+		_, err := sess.UpdateRow(&Row{ID: 1, Name: "one", Version: 2})
+		if schema.conflictError == nil {
+			if _, ok := err.(*OptimisticLockingError); !ok {
+				t.Errorf("got=%v, want=*OptimisticLockingError", err)
+			}
+			continue
+		}
+		conflictErr, ok := err.(*testConflictError)
+		if !ok {
+			t.Fatalf("got=%v, want=*testConflictError", err)
+		}
+		if got, want := *conflictErr, (testConflictError{status: 409, version: 1}); got != want {
+			t.Errorf("got=%+v, want=%+v", got, want)
+		}
+		_, err = sess.UpdateRowExpecting(&Row{ID: 1, Name: "one", Version: 5}, 5)
+		if _, ok := err.(*OptimisticLockingError); !ok {
+			t.Errorf("got=%v, want=*OptimisticLockingError", err)
+		}
+	}
+	if got, want := called, 2; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+}
+
+func TestWithIdentifierCase(t *testing.T) {
+	type User struct {
+		ID       int64 `sql:"primary key"`
+		UserName string
+		Email    string `sql:"EmailAddress"`
+	}
+	tests := []struct {
+		schema *Schema
+		query  string
+		want   string
+	}{
+		{
+			schema: NewSchema(WithDialect(Postgres), WithIdentifierCase(FoldUpper)),
+			query:  "select {} from users where {}",
+			want:   `SELECT "ID", "USER_NAME", "EMAILADDRESS" FROM USERS WHERE "ID" = $1`,
+		},
+		{
+			schema: NewSchema(WithDialect(Postgres), WithIdentifierCase(FoldUpper)),
+			query:  `select {alias u} from "Users" u where u.user_name = ?`,
+			want:   `SELECT U."ID", U."USER_NAME", U."EMAILADDRESS" FROM "Users" U WHERE U.USER_NAME = $1`,
+		},
+		{
+			schema: NewSchema(WithDialect(Postgres), WithIdentifierCase(FoldUpper)),
+			query:  "insert into users({}) values({})",
+			want:   `INSERT INTO USERS("ID", "USER_NAME", "EMAILADDRESS") VALUES($1, $2, $3)`,
+		},
+		{
+			schema: NewSchema(WithDialect(Postgres), WithIdentifierCase(FoldUpper), WithIdentifier("app_users", "users")),
+			query:  "update users set {} where {}",
+			want:   `UPDATE APP_USERS SET "USER_NAME" = $1, "EMAILADDRESS" = $2 WHERE "ID" = $3`,
+		},
+		{
+			schema: NewSchema(WithDialect(Postgres), WithIdentifierCase(FoldLower)),
+			query:  "SELECT {} FROM Users WHERE {}",
+			want:   `select "id", "user_name", "emailaddress" from users where "id" = $1`,
+		},
+		{
+			schema: NewSchema(WithDialect(Postgres)),
+			query:  "SELECT {} FROM Users WHERE {}",
+			want:   `SELECT "id", "user_name", "EmailAddress" FROM Users WHERE "id" = $1`,
+		},
+	}
+	for i, tt := range tests {
+		stmt, err := tt.schema.Prepare(User{}, tt.query)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if got := stmt.String(); got != tt.want {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, tt.want)
+		}
+	}
+
+	// upper-case result columns match exactly
+	db := rowsDBWithColumns(t, 2, "ID", "USER_NAME")
+	defer db.Close()
+	schema := NewSchema(WithDialect(Postgres), WithIdentifierCase(FoldUpper))
+	sess := NewSession(context.Background(), db, schema)
+	type Row struct {
+		ID       int64 `sql:"primary key"`
+		UserName string
+	}
+	var rows []Row
+	_, err := sess.Select(&rows, "select id, user_name from users")
+	wantNoError(t, err)
+	if got, want := fmt.Sprint(rows), "[{1 name} {2 name}]"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	if got, want := schema.TableFor(User{}).Name(), "USER"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
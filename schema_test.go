@@ -1,6 +1,10 @@
 package sqlr
 
-import "testing"
+import (
+	"context"
+	"strings"
+	"testing"
+)
 
 func TestTableFor(t *testing.T) {
 	type Row struct {
@@ -25,3 +29,417 @@ func TestTableFor(t *testing.T) {
 		t.Errorf("got=%v, want=%v", got, want)
 	}
 }
+
+func TestLeadingKeywordColumnName(t *testing.T) {
+	// tags written before these keywords existed still name the column
+	type Note struct {
+		ID        int64  `sql:"pk"`
+		Body      string `sql:"comment"`
+		IsDeleted bool   `sql:"deleted"`
+		Day       string `sql:"date null"`
+		Kind      string `sql:"name=enum null"`
+	}
+
+	tbl := NewSchema().TableFor(&Note{})
+	var got []string
+	for _, col := range tbl.Columns() {
+		got = append(got, col.Name())
+	}
+	want := []string{"id", "comment", "deleted", "date", "enum"}
+	if len(got) != len(want) {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%d: got=%v, want=%v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStripFieldPrefix(t *testing.T) {
+	type User struct {
+		UserID      int64 `sql:"primary key"`
+		UserName    string
+		UserAddress struct {
+			Street string
+		}
+		User      string
+		EmailAddr string `sql:"user_email"`
+		UserPhone string
+		Username  string // not at a word boundary
+	}
+
+	schema := NewSchema(WithTables(TablesConfig{
+		(*User)(nil): {
+			StripFieldPrefix: "User",
+			Columns: ColumnsConfig{
+				"UserPhone": {ColumnName: "phone_number"},
+			},
+		},
+	}))
+	tbl := schema.TableFor(&User{})
+
+	var got []string
+	for _, col := range tbl.Columns() {
+		got = append(got, col.Name())
+	}
+	want := []string{"id", "name", "address_street", "user", "user_email", "phone_number", "username"}
+	if len(got) != len(want) {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%d: got=%v, want=%v", i, got[i], want[i])
+		}
+	}
+
+	// other tables are not affected
+	type Other struct {
+		UserName string
+	}
+	if got, want := schema.TableFor(&Other{}).Columns()[0].Name(), "user_name"; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+}
+
+func TestDialectColumns(t *testing.T) {
+	type Document struct {
+		ID           int64 `sql:"primary key"`
+		Body         string
+		SearchVector string `sql:"dialect=postgres"`
+	}
+
+	tests := []struct {
+		dialect Dialect
+		insert  string
+		query   string
+	}{
+		{
+			dialect: Postgres,
+			insert:  `insert into documents("id", "body", "search_vector") values($1, $2, $3)`,
+			query:   `select "id", "body", "search_vector" from documents where "id" = $1`,
+		},
+		{
+			dialect: SQLite,
+			insert:  "insert into documents(`id`, `body`) values(?, ?)",
+			query:   "select `id`, `body` from documents where `id` = ?",
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		for _, q := range []struct{ sql, want string }{
+			{sql: "insert documents", want: tt.insert},
+			{sql: "select {} from documents where {}", want: tt.query},
+		} {
+			stmt, err := schema.Prepare(Document{}, q.sql)
+			if err != nil {
+				t.Errorf("%d: unexpected error: %v", i, err)
+				continue
+			}
+			if got, want := stmt.String(), q.want; got != want {
+				t.Errorf("%d: got=%q, want=%q", i, got, want)
+			}
+		}
+	}
+}
+
+func TestPrepareWithConvention(t *testing.T) {
+	type Customer struct {
+		CustomerID int64 `sql:"primary key"`
+		FirstName  string
+		LastName   string `sql:"surname"`
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	const query = "select {} from customers where {}"
+
+	stmt, err := schema.PrepareWithConvention(Customer{}, query, SameCase)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := stmt.String(), `select "CustomerID", "FirstName", "surname" from customers where "CustomerID" = $1`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	// statement is cached for the convention
+	stmt2, err := schema.PrepareWithConvention(Customer{}, query, SameCase)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stmt2 != stmt {
+		t.Error("expected statement to be cached")
+	}
+
+	// other statements are not affected
+	stmt, err = schema.Prepare(Customer{}, query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := stmt.String(), `select "customer_id", "first_name", "surname" from customers where "customer_id" = $1`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	if got, want := schema.TableFor(Customer{}).Columns()[0].Name(), "customer_id"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	if _, err := schema.PrepareWithConvention(Customer{}, query, nil); err == nil {
+		t.Error("got=nil, want=error")
+	}
+}
+
+func TestWriteExpr(t *testing.T) {
+	type Place struct {
+		ID       int64 `sql:"primary key autoincrement"`
+		Name     string
+		Location string `sql:"geom"`
+		Search   string
+	}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithTables(TablesConfig{
+			(*Place)(nil): {
+				Columns: ColumnsConfig{
+					"Location": {WriteExpr: "ST_GeomFromText(?, 4326)"},
+					"Search":   {WriteExpr: "to_tsvector('english', ?)"},
+				},
+			},
+		}),
+	)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "insert into places({}) values({})",
+			want:  `insert into places("name", "geom", "search") values($1, ST_GeomFromText($2, 4326), to_tsvector('english', $3))`,
+		},
+		{
+			query: "update places set {} where {}",
+			want:  `update places set "name" = $1, "geom" = ST_GeomFromText($2, 4326), "search" = to_tsvector('english', $3) where "id" = $4`,
+		},
+		{
+			// the expression is only used for writing
+			query: "select {} from places where {}",
+			want:  `select "id", "name", "geom", "search" from places where "id" = $1`,
+		},
+	}
+	for i, tt := range tests {
+		stmt, err := schema.Prepare(Place{}, tt.query)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if got := stmt.String(); got != tt.want {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, tt.want)
+		}
+	}
+
+	// the field value is bound to the placeholder in the expression
+	stmt, err := schema.Prepare(Place{}, "insert into places({}) values({})")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := stmt.getArgs(&Place{Name: "x", Location: "POINT(1 2)", Search: "words"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(args), 3; got != want || args[1] != "POINT(1 2)" {
+		t.Errorf("got=%v, want=%d args", args, want)
+	}
+
+	for _, expr := range []string{"lower(name)", "concat(?, ?)"} {
+		_, err := NewSchemaE(WithTables(TablesConfig{
+			(*Place)(nil): {
+				Columns: ColumnsConfig{
+					"Name": {WriteExpr: expr},
+				},
+			},
+		}))
+		want := `sqlr.Place: write expression for field Name must contain one placeholder: "` + expr + `"`
+		if err == nil || err.Error() != want {
+			t.Errorf("got=%v, want=%q", err, want)
+		}
+	}
+}
+
+func TestReadExpr(t *testing.T) {
+	type Person struct {
+		ID        int64 `sql:"primary key"`
+		FirstName string
+		LastName  string
+		FullName  string
+	}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithTables(TablesConfig{
+			(*Person)(nil): {
+				TableName: "people",
+				Columns: ColumnsConfig{
+					"FullName": {ReadExpr: "first_name || ' ' || last_name"},
+				},
+			},
+		}),
+	)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "select {} from people where {}",
+			want:  `select "id", "first_name", "last_name", (first_name || ' ' || last_name) as "full_name" from people where "id" = $1`,
+		},
+		{
+			query: "select {alias p} from people p order by {alias p all}",
+			want:  `select p."id", p."first_name", p."last_name", (first_name || ' ' || last_name) as "full_name" from people p order by p."id", p."first_name", p."last_name", "full_name"`,
+		},
+		{
+			query: "insert into people({}) values({})",
+			want:  `insert into people("id", "first_name", "last_name") values($1, $2, $3)`,
+		},
+		{
+			query: "insert into people({all}) values({})",
+			want:  `insert into people("id", "first_name", "last_name") values($1, $2, $3)`,
+		},
+		{
+			query: "update people set {all} where {}",
+			want:  `update people set "id" = $1, "first_name" = $2, "last_name" = $3 where "id" = $4`,
+		},
+		{
+			query: "update people set {} where {} returning {}",
+			want:  `update people set "first_name" = $1, "last_name" = $2 where "id" = $3 returning "id", "first_name", "last_name", (first_name || ' ' || last_name) as "full_name"`,
+		},
+	}
+	for i, tt := range tests {
+		stmt, err := schema.Prepare(Person{}, tt.query)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if got := stmt.String(); got != tt.want {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, tt.want)
+		}
+	}
+
+	ddl, err := schema.TableFor(Person{}).CreateTableSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"full_name"`; strings.Contains(ddl, want) {
+		t.Errorf("got=%q, want no %s", ddl, want)
+	}
+
+	_, err = NewSchemaE(WithTables(TablesConfig{
+		(*Person)(nil): {
+			Columns: ColumnsConfig{
+				"FullName": {ReadExpr: "first_name || ?"},
+			},
+		},
+	}))
+	if want := `sqlr.Person: read expression for field FullName cannot contain placeholders: "first_name || ?"`; err == nil || err.Error() != want {
+		t.Errorf("got=%v, want=%q", err, want)
+	}
+
+	// the expression column is scanned by name
+	db := rowsDBWithColumns(t, 1, "id", "full_name")
+	defer db.Close()
+	type Name struct {
+		ID       int64 `sql:"primary key"`
+		FullName string
+	}
+	schema = NewSchema(
+		WithDialect(Postgres),
+		WithTables(TablesConfig{
+			(*Name)(nil): {
+				Columns: ColumnsConfig{
+					"FullName": {ReadExpr: "first_name || ' ' || last_name"},
+				},
+			},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+	var names []Name
+	_, err = sess.Select(&names, "select {} from people")
+	wantNoError(t, err)
+	if got, want := names[0].FullName, "name"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestEmbedPrefix(t *testing.T) {
+	type Address struct {
+		Street string
+		City   string
+	}
+	type Customer struct {
+		ID      int64 `sql:"primary key"`
+		Name    string
+		Address Address `sql:"address embed prefix=addr_"`
+		Billing Address
+	}
+	type Site struct {
+		Address Address `sql:"address embed prefix=addr_"`
+	}
+	type Location struct {
+		ID      int64 `sql:"primary key"`
+		Address struct {
+			Street string
+		} `sql:"address embed prefix=addr_"`
+	}
+	type Office struct {
+		ID int64 `sql:"primary key"`
+		HQ Site  `sql:"hq embed prefix=hq_"`
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	tests := []struct {
+		row   interface{}
+		query string
+		want  string
+	}{
+		{
+			row:   Customer{},
+			query: "select {} from customers where {}",
+			want:  `select "id", "name", "addr_street", "addr_city", "billing_street", "billing_city" from customers where "id" = $1`,
+		},
+		{
+			row:   Customer{},
+			query: "update customers set {} where {}",
+			want:  `update customers set "name" = $1, "addr_street" = $2, "addr_city" = $3, "billing_street" = $4, "billing_city" = $5 where "id" = $6`,
+		},
+		{
+			row:   Office{},
+			query: "select {} from offices",
+			want:  `select "id", "hq_addr_street", "hq_addr_city" from offices`,
+		},
+	}
+	for i, tt := range tests {
+		stmt, err := schema.Prepare(tt.row, tt.query)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if got, want := stmt.String(), tt.want; got != want {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, want)
+		}
+	}
+
+	// scan routes the prefixed columns into the nested struct
+	db := rowsDBWithColumns(t, 2, "id", "addr_street")
+	defer db.Close()
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+	var locations []Location
+	_, err := sess.Select(&locations, "select {} from locations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(locations), 2; got != want {
+		t.Fatalf("got=%d, want=%d", got, want)
+	}
+	for i, c := range locations {
+		if got, want := c.ID, int64(i+1); got != want {
+			t.Errorf("%d: got=%d, want=%d", i, got, want)
+		}
+		if got, want := c.Address.Street, "name"; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package sqlr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SetSearchPath sets the PostgreSQL search_path for the session, so that
+// unqualified table names in subsequent queries resolve to the tables in
+// the named schemas. This is useful for multi-tenant databases that have a
+// separate schema for each tenant:
+//  sess, err := sqlr.NewConnSession(ctx, db, schema)
+//  if err != nil {
+//      return err
+//  }
+//  defer sess.Close()
+//  if err := sess.SetSearchPath("tenant_42", "public"); err != nil {
+//      return err
+//  }
+// The search path is a property of the database connection, so the session
+// must be pinned to a single connection, either because it was created using
+// NewConnSession, or because its querier is a transaction. For a transaction,
+// the search path only applies until the transaction ends. For a pinned
+// connection, the search path remains in effect when the connection is returned
+// to the pool, so it is good practice to set it at the start of every session.
+//
+// Row caches created with the WithRowCache option are keyed by row type, and
+// are not aware of the search path. Do not use row caches for tables that
+// exist in more than one tenant schema.
+func (sess *Session) SetSearchPath(schemas ...string) error {
+	query, err := searchPathSQL(sess.schema.getDialect(), sess.querier, schemas)
+	if err != nil {
+		return err
+	}
+	if _, err := sess.querier.ExecContext(sess.context, query); err != nil {
+		return fmt.Errorf("cannot set search path: %v", err)
+	}
+	return nil
+}
+
+// searchPathSQL returns the SQL statement for setting the search path.
+func searchPathSQL(dialect Dialect, querier Querier, schemas []string) (string, error) {
+	if !isPostgres(dialect) {
+		return "", fmt.Errorf("SetSearchPath is not supported for dialect %s", dialectName(dialect))
+	}
+	var set string
+	switch baseQuerier(querier).(type) {
+	case *sql.Conn:
+		set = "set"
+	case *sql.Tx:
+		set = "set local"
+	default:
+		return "", errors.New("SetSearchPath requires a session created by NewConnSession, or a transaction")
+	}
+	if len(schemas) == 0 {
+		return "", errors.New("SetSearchPath requires at least one schema")
+	}
+	quoted := make([]string, len(schemas))
+	for i, name := range schemas {
+		if name == "" || strings.ContainsAny(name, "\"`[]. \t\r\n;") {
+			return "", fmt.Errorf("invalid schema name %q", name)
+		}
+		quoted[i] = dialect.Quote(name)
+	}
+	return fmt.Sprintf("%s search_path to %s", set, strings.Join(quoted, ", ")), nil
+}
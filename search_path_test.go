@@ -0,0 +1,66 @@
+package sqlr
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestSearchPathSQL(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		querier Querier
+		schemas []string
+		want    string
+		errText string
+	}{
+		{
+			dialect: Postgres,
+			querier: &sql.Conn{},
+			schemas: []string{"tenant_42", "public"},
+			want:    `set search_path to "tenant_42", "public"`,
+		},
+		{
+			dialect: Postgres,
+			querier: &sql.Tx{},
+			schemas: []string{"$user"},
+			want:    `set local search_path to "$user"`,
+		},
+		{
+			dialect: Postgres,
+			querier: &sql.DB{},
+			schemas: []string{"tenant_42"},
+			errText: "SetSearchPath requires a session created by NewConnSession, or a transaction",
+		},
+		{
+			dialect: MySQL,
+			querier: &sql.Conn{},
+			schemas: []string{"tenant_42"},
+			errText: "SetSearchPath is not supported for dialect mysql",
+		},
+		{
+			dialect: Postgres,
+			querier: &sql.Conn{},
+			errText: "SetSearchPath requires at least one schema",
+		},
+		{
+			dialect: Postgres,
+			querier: &sql.Conn{},
+			schemas: []string{"public; drop table users"},
+			errText: `invalid schema name "public; drop table users"`,
+		},
+	}
+	for i, tt := range tests {
+		got, err := searchPathSQL(tt.dialect, tt.querier, tt.schemas)
+		var errText string
+		if err != nil {
+			errText = err.Error()
+		}
+		if errText != tt.errText {
+			t.Errorf("%d: got error %q, want %q", i, errText, tt.errText)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%d: got=%q, want=%q", i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package sqlr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SelectAfter selects the next page of rows using keyset pagination. Rows
+// are ordered by orderFields, and only the rows that sort after afterValues
+// are returned, up to a maximum of limit rows. Unlike pagination using an
+// offset, the database can use an index to find the first row of the page,
+// so fetching a deep page is as efficient as fetching the first page.
+//
+// Each order field is the name of a field in rowType, optionally followed by
+// "asc" or "desc". There must be one value in afterValues for each order
+// field, which is usually the field values of the last row of the previous
+// page. If afterValues is empty, the first page is returned.
+//  var users []*User
+//  n, err := sess.SelectAfter(&users, User{}, []string{"FamilyName", "ID"}, nil, 20)
+//  ...
+//  last := users[n-1]
+//  n, err = sess.SelectAfter(&users, User{}, []string{"FamilyName", "ID"},
+//      []interface{}{last.FamilyName, last.ID}, 20)
+// For the ordering to be stable from one page to the next, the order fields
+// taken together must be unique, which is easiest to achieve by making the
+// primary key the last order field. The order fields should not be nullable.
+// Soft-deleted rows are not returned, unless the schema includes them.
+//
+// When every order field has the same direction, the condition is a single
+// tuple comparison such as "(family_name, id) > (?, ?)". Otherwise, and
+// for SQL Server and Oracle, which do not support tuple comparisons, the
+// condition is expanded into the equivalent "or" terms.
+func (sess *Session) SelectAfter(rows interface{}, rowType interface{}, orderFields []string, afterValues []interface{}, limit int) (int, error) {
+	if rowType == nil {
+		return 0, errors.New("nil row type")
+	}
+	if _, err := getRowType(rowType); err != nil {
+		return 0, err
+	}
+	if limit <= 0 {
+		return 0, fmt.Errorf("invalid limit %d", limit)
+	}
+	tbl, err := sess.schema.tableFor(rowType)
+	if err != nil {
+		return 0, err
+	}
+	if tbl.isSharded() {
+		return 0, fmt.Errorf("SelectAfter does not support %s, which has a ShardFunc", tbl.rowType)
+	}
+	keys, err := parseOrderFields(tbl, orderFields)
+	if err != nil {
+		return 0, err
+	}
+	if len(afterValues) > 0 && len(afterValues) != len(keys) {
+		return 0, fmt.Errorf("expected %d after values for order fields, got %d", len(keys), len(afterValues))
+	}
+
+	var query bytes.Buffer
+	var args []interface{}
+	query.WriteString("select {} from {table}")
+	var conds []string
+	if len(afterValues) > 0 {
+		var cond string
+		name := dialectName(sess.schema.getDialect())
+		cond, args = keysetCondition(keys, afterValues, name != dialectMSSQL && name != dialectOracle)
+		conds = append(conds, cond)
+	}
+	if cond := tbl.notDeleted(""); cond != "" {
+		conds = append(conds, cond)
+	}
+	if len(conds) > 0 {
+		query.WriteString(" where " + strings.Join(conds, " and "))
+	}
+	query.WriteString(" order by ")
+	for i, key := range keys {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString(key.name)
+		if key.desc {
+			query.WriteString(" desc")
+		}
+	}
+	fmt.Fprintf(&query, " {limit %d}", limit)
+
+	stmt, err := sess.schema.Prepare(rowType, query.String())
+	if err != nil {
+		return 0, err
+	}
+	return sess.selectWith(sess.context, stmt, rows, args)
+}
+
+// orderKey is a column in the order by clause of a keyset query.
+type orderKey struct {
+	name string // backtick-quoted column name
+	desc bool
+}
+
+// parseOrderFields returns the order keys for the order fields, each of
+// which is a field name optionally followed by "asc" or "desc".
+func parseOrderFields(tbl *Table, orderFields []string) ([]orderKey, error) {
+	if len(orderFields) == 0 {
+		return nil, errors.New("no order fields specified")
+	}
+	keys := make([]orderKey, 0, len(orderFields))
+	for _, orderField := range orderFields {
+		words := strings.Fields(orderField)
+		if len(words) == 0 || len(words) > 2 {
+			return nil, fmt.Errorf("invalid order field %q", orderField)
+		}
+		var key orderKey
+		if len(words) == 2 {
+			switch strings.ToLower(words[1]) {
+			case "asc":
+			case "desc":
+				key.desc = true
+			default:
+				return nil, fmt.Errorf("invalid direction in order field %q", orderField)
+			}
+		}
+		for _, col := range tbl.Columns() {
+			if col.info.FieldNames == words[0] {
+				key.name = fmt.Sprintf("`%s`", col.Name())
+				break
+			}
+		}
+		if key.name == "" {
+			return nil, fmt.Errorf("unknown field %q in order fields for %s", words[0], tbl.RowType())
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// keysetCondition returns the condition that selects the rows ordered
+// after values, and the args for the condition's placeholders. If tuple is
+// true and every key has the same direction, the condition is a single
+// tuple comparison.
+func keysetCondition(keys []orderKey, values []interface{}, tuple bool) (string, []interface{}) {
+	op := func(key orderKey) string {
+		if key.desc {
+			return "<"
+		}
+		return ">"
+	}
+	for _, key := range keys {
+		if key.desc != keys[0].desc {
+			tuple = false
+			break
+		}
+	}
+	if len(keys) == 1 {
+		return fmt.Sprintf("%s %s ?", keys[0].name, op(keys[0])), values
+	}
+	if tuple {
+		var names, placeholders []string
+		for _, key := range keys {
+			names = append(names, key.name)
+			placeholders = append(placeholders, "?")
+		}
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(names, ", "), op(keys[0]),
+			strings.Join(placeholders, ", ")), values
+	}
+
+	// (a > ?) or (a = ? and b > ?) or (a = ? and b = ? and c > ?)
+	var terms []string
+	var args []interface{}
+	for i, key := range keys {
+		var conds []string
+		for j := 0; j < i; j++ {
+			conds = append(conds, keys[j].name+" = ?")
+			args = append(args, values[j])
+		}
+		conds = append(conds, fmt.Sprintf("%s %s ?", key.name, op(key)))
+		args = append(args, values[i])
+		terms = append(terms, "("+strings.Join(conds, " and ")+")")
+	}
+	return "(" + strings.Join(terms, " or ") + ")", args
+}
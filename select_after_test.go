@@ -0,0 +1,191 @@
+package sqlr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectAfter(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 2)
+	defer db.Close()
+
+	tests := []struct {
+		dialect     Dialect
+		orderFields []string
+		afterValues []interface{}
+		wantQuery   string
+		wantArgs    []interface{}
+	}{
+		{
+			dialect:     Postgres,
+			orderFields: []string{"Name", "ID"},
+			wantQuery:   `select "id", "name" from "row" order by "name", "id" limit 2`,
+		},
+		{
+			dialect:     Postgres,
+			orderFields: []string{"ID"},
+			afterValues: []interface{}{10},
+			wantQuery:   `select "id", "name" from "row" where "id" > $1 order by "id" limit 2`,
+			wantArgs:    []interface{}{10},
+		},
+		{
+			dialect:     Postgres,
+			orderFields: []string{"Name desc", "ID DESC"},
+			afterValues: []interface{}{"x", 10},
+			wantQuery:   `select "id", "name" from "row" where ("name", "id") < ($1, $2) order by "name" desc, "id" desc limit 2`,
+			wantArgs:    []interface{}{"x", 10},
+		},
+		{
+			dialect:     SQLite,
+			orderFields: []string{"Name asc", "ID desc"},
+			afterValues: []interface{}{"x", 10},
+			wantQuery:   "select `id`, `name` from `row` where ((`name` > ?) or (`name` = ? and `id` < ?)) order by `name`, `id` desc limit 2",
+			wantArgs:    []interface{}{"x", "x", 10},
+		},
+		{
+			dialect:     MSSQL,
+			orderFields: []string{"Name", "ID"},
+			afterValues: []interface{}{"x", 10},
+			wantQuery:   "select [id], [name] from [row] where (([name] > ?) or ([name] = ? and [id] > ?)) order by [name], [id] offset 0 rows fetch next 2 rows only",
+			wantArgs:    []interface{}{"x", "x", 10},
+		},
+		{
+			dialect:     Oracle,
+			orderFields: []string{"Name", "ID"},
+			afterValues: []interface{}{"x", 10},
+			wantQuery:   `SELECT "ID", "NAME" FROM "ROW" WHERE (("NAME" > :1) OR ("NAME" = :2 AND "ID" > :3)) ORDER BY "NAME", "ID" offset 0 rows fetch next 2 rows only`,
+			wantArgs:    []interface{}{"x", "x", 10},
+		},
+	}
+	for i, tt := range tests {
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(tt.dialect), WithLastQuery()))
+		var rows []*Row
+		n, err := sess.SelectAfter(&rows, Row{}, tt.orderFields, tt.afterValues, 2)
+		wantNoError(t, err)
+		if got, want := n, 2; got != want {
+			t.Errorf("%d: got=%d, want=%d", i, got, want)
+		}
+		query, args := sess.LastQuery()
+		if query != tt.wantQuery {
+			t.Errorf("%d: got=%q, want=%q", i, query, tt.wantQuery)
+		}
+		if !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("%d: got=%v, want=%v", i, args, tt.wantArgs)
+		}
+		sess.Close()
+	}
+}
+
+func TestSelectAfterSoftDelete(t *testing.T) {
+	type Row struct {
+		ID        int64 `sql:"primary key"`
+		Name      string
+		DeletedAt *time.Time `sql:"deleted_at deleted"`
+	}
+	db := rowsDBWithColumns(t, 2, "id", "name", "deleted_at")
+	defer db.Close()
+
+	tests := []struct {
+		afterValues []interface{}
+		wantQuery   string
+	}{
+		{
+			wantQuery: `select "id", "name", "deleted_at" from "row" where "deleted_at" is null order by "id" limit 2`,
+		},
+		{
+			afterValues: []interface{}{10},
+			wantQuery:   `select "id", "name", "deleted_at" from "row" where "id" > $1 and "deleted_at" is null order by "id" limit 2`,
+		},
+	}
+	for i, tt := range tests {
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres), WithLastQuery()))
+		var rows []*Row
+		_, err := sess.SelectAfter(&rows, Row{}, []string{"ID"}, tt.afterValues, 2)
+		wantNoError(t, err)
+		if query, _ := sess.LastQuery(); query != tt.wantQuery {
+			t.Errorf("%d: got=%q, want=%q", i, query, tt.wantQuery)
+		}
+		sess.Close()
+	}
+}
+
+func TestSelectAfterErrors(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	type Other struct {
+		ID int64 `sql:"primary key"`
+	}
+	db := rowsDB(t, 1)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	tests := []struct {
+		rowType     interface{}
+		orderFields []string
+		afterValues []interface{}
+		limit       int
+		want        string
+	}{
+		{
+			rowType: nil,
+			limit:   10,
+			want:    "nil row type",
+		},
+		{
+			rowType:     Row{},
+			orderFields: []string{"ID"},
+			limit:       0,
+			want:        "invalid limit 0",
+		},
+		{
+			rowType: Row{},
+			limit:   10,
+			want:    "no order fields specified",
+		},
+		{
+			rowType:     Row{},
+			orderFields: []string{"Missing"},
+			limit:       10,
+			want:        `unknown field "Missing" in order fields for sqlr.Row`,
+		},
+		{
+			rowType:     Row{},
+			orderFields: []string{"ID sideways"},
+			limit:       10,
+			want:        `invalid direction in order field "ID sideways"`,
+		},
+		{
+			rowType:     Row{},
+			orderFields: []string{"Name", "ID"},
+			afterValues: []interface{}{"x"},
+			limit:       10,
+			want:        "expected 2 after values for order fields, got 1",
+		},
+		{
+			rowType:     Other{},
+			orderFields: []string{"ID"},
+			limit:       10,
+			want:        "expected rows to be *[]github.com/jjeffery/sqlr.Other, *[]*github.com/jjeffery/sqlr.Other, or *github.com/jjeffery/sqlr.Other",
+		},
+	}
+	for i, tt := range tests {
+		var rows []*Row
+		_, err := sess.SelectAfter(&rows, tt.rowType, tt.orderFields, tt.afterValues, tt.limit)
+		if err == nil {
+			t.Errorf("%d: got=nil, want=%q", i, tt.want)
+			continue
+		}
+		if got := err.Error(); got != tt.want {
+			t.Errorf("%d: got=%q, want=%q", i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package sqlr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// KeyStrategy determines how SelectByKeysVia selects rows for a list of keys.
+type KeyStrategy int
+
+const (
+	// KeysInChunks selects the rows using "where id in (...)" queries, with
+	// up to KeysPerChunk keys in each query. It works with any database.
+	KeysInChunks KeyStrategy = iota
+
+	// KeysTempTable inserts the keys into a temporary table, and selects the
+	// rows by joining with the temporary table in a single query. This can be
+	// much faster for very large numbers of keys. It requires a session created
+	// by NewConnSession, or a transaction, because the temporary table is only
+	// visible on one database connection. It is supported for PostgreSQL,
+	// MySQL and SQLite.
+	KeysTempTable
+)
+
+// KeysPerChunk is the maximum number of keys included in one query or one
+// insert statement by SelectByKeysVia. It is well below the limit on the
+// number of placeholders in a statement for the common databases.
+var KeysPerChunk = 1000
+
+// keysTempTablePrefix is the prefix of the name of the temporary table
+// used by the KeysTempTable strategy. A number is appended so that each
+// call creates a new table, and never refers to an existing table.
+const keysTempTablePrefix = "sqlr_keys_"
+
+// keysTempTableCount is incremented to name each temporary table.
+var keysTempTableCount uint64
+
+// SelectByKeysVia selects the rows whose primary key is one of the keys. The
+// rows argument is a pointer to a slice of structs, or a pointer to a slice of
+// struct pointers, and the selected rows are appended to the slice. The row type
+// must have a single primary key column, and keys must be a slice of values of the
+// primary key type. A key that appears more than once selects its row once.
+// The order of the selected rows is not specified.
+//
+// Where Select with an "in (?)" clause expands every key into a placeholder in
+// one query, which fails when the number of keys exceeds the database limit on
+// placeholders, SelectByKeysVia can handle any number of keys. The strategy
+// determines how the rows are selected:
+//  var rows []*Widget
+//  n, err := sess.SelectByKeysVia(&rows, ids, sqlr.KeysTempTable)
+// SelectByKeysVia returns the number of rows selected.
+func (sess *Session) SelectByKeysVia(rows interface{}, keys interface{}, strategy KeyStrategy) (int, error) {
+	tbl, err := sess.schema.tableFor(rows)
+	if err != nil {
+		return 0, err
+	}
+	if tbl.isSharded() {
+		return 0, fmt.Errorf("SelectByKeysVia does not support %s, which has a ShardFunc", tbl.rowType)
+	}
+	keysValue := reflect.ValueOf(keys)
+	if keysValue.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("expected keys to be a slice, got %T", keys)
+	}
+	pkCols := tbl.PrimaryKey()
+	if len(pkCols) != 1 {
+		return 0, fmt.Errorf("SelectByKeysVia requires a single primary key column in %s", tbl.rowType)
+	}
+	pkCol := pkCols[0]
+
+	switch strategy {
+	case KeysInChunks:
+		return sess.selectByKeysInChunks(rows, tbl, pkCol, keysValue)
+	case KeysTempTable:
+		return sess.selectByKeysTempTable(rows, tbl, pkCol, keysValue)
+	}
+	return 0, fmt.Errorf("unknown key strategy %d", strategy)
+}
+
+func (sess *Session) selectByKeysInChunks(rows interface{}, tbl *Table, pkCol *Column, keysValue reflect.Value) (int, error) {
+	dialect := sess.schema.getDialect()
+	query := fmt.Sprintf("select {} from %s where %s in (?)",
+		dialect.Quote(tbl.Name()), dialect.Quote(pkCol.Name()))
+	if cond := tbl.notDeleted(""); cond != "" {
+		query += " and " + cond
+	}
+	// Duplicate keys are removed, otherwise a row would be selected
+	// once for each chunk that contains its key.
+	keys := uniqueKeys(keysValue)
+	var rowCount int
+	for start := 0; start < len(keys); start += KeysPerChunk {
+		end := start + KeysPerChunk
+		if end > len(keys) {
+			end = len(keys)
+		}
+		n, err := sess.Select(rows, query, keys[start:end])
+		rowCount += n
+		if err != nil {
+			return rowCount, err
+		}
+	}
+	return rowCount, nil
+}
+
+func (sess *Session) selectByKeysTempTable(rows interface{}, tbl *Table, pkCol *Column, keysValue reflect.Value) (int, error) {
+	dialect := sess.schema.getDialect()
+	name := dialectName(dialect)
+	switch name {
+	case dialectPostgres, dialectMySQL, dialectSQLite:
+	default:
+		return 0, fmt.Errorf("temporary table strategy is not supported for dialect %s", name)
+	}
+	switch baseQuerier(sess.querier).(type) {
+	case *sql.Conn, *sql.Tx:
+	default:
+		return 0, errors.New("temporary table strategy requires a session created by NewConnSession, or a transaction")
+	}
+	keyType, err := sqlTypeFor(pkCol, name)
+	if err != nil {
+		return 0, err
+	}
+
+	tempName := fmt.Sprint(keysTempTablePrefix, atomic.AddUint64(&keysTempTableCount, 1))
+	tempTable := dialect.Quote(tempName)
+	keyColumn := dialect.Quote("key_value")
+	if _, err := sess.Exec(fmt.Sprintf("create temporary table %s (%s %s not null)", tempTable, keyColumn, keyType)); err != nil {
+		return 0, err
+	}
+	// The drop statement only ever refers to a temporary table.
+	switch name {
+	case dialectPostgres:
+		defer sess.Exec(fmt.Sprintf("drop table pg_temp.%s", tempTable))
+	case dialectMySQL:
+		defer sess.Exec(fmt.Sprintf("drop temporary table %s", tempTable))
+	case dialectSQLite:
+		defer sess.Exec(fmt.Sprintf("drop table temp.%s", tempTable))
+	}
+
+	// Duplicate keys are inserted once, otherwise the join would
+	// select the same row more than once.
+	keys := uniqueKeys(keysValue)
+	for start := 0; start < len(keys); start += KeysPerChunk {
+		end := start + KeysPerChunk
+		if end > len(keys) {
+			end = len(keys)
+		}
+		// The query is not prepared, as the number of placeholders differs
+		// for the last chunk, and each would be kept in the statement cache.
+		args := make([]interface{}, 0, end-start)
+		values := make([]string, 0, end-start)
+		for _, key := range keys[start:end] {
+			args = append(args, key)
+			values = append(values, "("+dialect.Placeholder(len(args))+")")
+		}
+		query := fmt.Sprintf("insert into %s(%s) values %s", tempTable, keyColumn, strings.Join(values, ", "))
+		if _, err := sess.querier.ExecContext(sess.context, query, args...); err != nil {
+			return 0, err
+		}
+	}
+
+	query := fmt.Sprintf("select {alias t} from %s t join %s k on k.%s = t.%s",
+		dialect.Quote(tbl.Name()), tempTable, keyColumn, dialect.Quote(pkCol.Name()))
+	if cond := tbl.notDeleted("t"); cond != "" {
+		query += " where " + cond
+	}
+	return sess.Select(rows, query)
+}
+
+// uniqueKeys returns the keys in the slice, in order, with any duplicates
+// removed. Keys of a type that cannot be compared are returned unchanged.
+func uniqueKeys(keysValue reflect.Value) []interface{} {
+	keys := make([]interface{}, 0, keysValue.Len())
+	if !keysValue.Type().Elem().Comparable() {
+		for i := 0; i < keysValue.Len(); i++ {
+			keys = append(keys, keysValue.Index(i).Interface())
+		}
+		return keys
+	}
+	seen := make(map[interface{}]bool, keysValue.Len())
+	for i := 0; i < keysValue.Len(); i++ {
+		key := keysValue.Index(i).Interface()
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,101 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestSelectByKeysViaErrors(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	type Composite struct {
+		A int64 `sql:"primary key"`
+		B int64 `sql:"primary key"`
+	}
+	tests := []struct {
+		dialect  Dialect
+		querier  Querier
+		rows     interface{}
+		keys     interface{}
+		strategy KeyStrategy
+		errText  string
+	}{
+		{
+			dialect: Postgres,
+			rows:    &[]Row{},
+			keys:    int64(1),
+			errText: "expected keys to be a slice, got int64",
+		},
+		{
+			dialect: Postgres,
+			rows:    &[]Composite{},
+			keys:    []int64{1},
+			errText: "SelectByKeysVia requires a single primary key column in sqlr.Composite",
+		},
+		{
+			dialect:  Postgres,
+			rows:     &[]Row{},
+			keys:     []int64{1},
+			strategy: KeyStrategy(99),
+			errText:  "unknown key strategy 99",
+		},
+		{
+			dialect:  Postgres,
+			rows:     &[]*Row{},
+			keys:     []int64{1},
+			strategy: KeysTempTable,
+			errText:  "temporary table strategy requires a session created by NewConnSession, or a transaction",
+		},
+		{
+			dialect:  MSSQL,
+			querier:  &sql.Conn{},
+			rows:     &[]*Row{},
+			keys:     []int64{1},
+			strategy: KeysTempTable,
+			errText:  "temporary table strategy is not supported for dialect mssql",
+		},
+	}
+	for i, tt := range tests {
+		querier := tt.querier
+		if querier == nil {
+			querier = &FakeDB{}
+		}
+		sess := NewSession(context.Background(), querier, NewSchema(WithDialect(tt.dialect)))
+		_, err := sess.SelectByKeysVia(tt.rows, tt.keys, tt.strategy)
+		var errText string
+		if err != nil {
+			errText = err.Error()
+		}
+		if errText != tt.errText {
+			t.Errorf("%d: got=%q, want=%q", i, errText, tt.errText)
+		}
+	}
+}
+
+func TestSelectByKeysInChunks(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	defer func(n int) { KeysPerChunk = n }(KeysPerChunk)
+	KeysPerChunk = 2
+
+	// the rows database returns the same 3 rows for every query
+	db := rowsDB(t, 3)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(SQLite)))
+	defer sess.Close()
+
+	var rows []*Row
+	n, err := sess.SelectByKeysVia(&rows, []int64{1, 2, 3, 4, 5}, KeysInChunks)
+	wantNoError(t, err)
+	if got, want := n, 9; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	if got, want := len(rows), 9; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package sqlr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrMultipleRows is returned by SelectExactlyOne when the query returns
+// more than one row.
+var ErrMultipleRows = errors.New("query returned more than one row")
+
+// SelectExactlyOne executes a SELECT query that is expected to return exactly
+// one row, and stores the row in dest, which must be a pointer to a row struct.
+// It is the safe form of Select for lookups that are expected to be unique:
+//  var user User
+//  err := sess.SelectExactlyOne(&user, "select {} from users where email = ?", email)
+// If the query returns no rows, SelectExactlyOne returns sql.ErrNoRows. If the
+// query returns more than one row, SelectExactlyOne returns ErrMultipleRows.
+// In both cases dest is left unchanged.
+func (sess *Session) SelectExactlyOne(dest interface{}, query string, args ...interface{}) error {
+	stmt, err := sess.schema.Prepare(dest, query)
+	if err != nil {
+		return err
+	}
+	destValue := reflect.ValueOf(dest)
+	rowType := stmt.tbl.RowType()
+	if destValue.Kind() != reflect.Ptr || destValue.Type().Elem() != rowType {
+		return fmt.Errorf("expected dest to be *%s", stmt.expectedTypeName())
+	}
+	if destValue.IsNil() {
+		return errors.New("nil pointer")
+	}
+
+	// scan into a new row, so that dest is only modified when
+	// exactly one row is returned
+	rowPtr := reflect.New(rowType)
+	n, err := stmt.selectRows(sess.context, sess.querier, rowPtr.Interface(), args...)
+	if err != nil {
+		return err
+	}
+	switch {
+	case n == 0:
+		return sql.ErrNoRows
+	case n > 1:
+		return ErrMultipleRows
+	}
+	destValue.Elem().Set(rowPtr.Elem())
+	sess.callRowHandlers(stmt.tbl, dest, n)
+	return nil
+}
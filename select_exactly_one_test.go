@@ -0,0 +1,106 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestSelectExactlyOne(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	tests := []struct {
+		rowCount int
+		wantErr  error
+		wantRow  Row
+	}{
+		{
+			rowCount: 0,
+			wantErr:  sql.ErrNoRows,
+			wantRow:  Row{ID: 99, Name: "unchanged"},
+		},
+		{
+			rowCount: 1,
+			wantRow:  Row{ID: 1, Name: "name"},
+		},
+		{
+			rowCount: 3,
+			wantErr:  ErrMultipleRows,
+			wantRow:  Row{ID: 99, Name: "unchanged"},
+		},
+	}
+	for i, tt := range tests {
+		db := rowsDB(t, tt.rowCount)
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+		row := Row{ID: 99, Name: "unchanged"}
+		err := sess.SelectExactlyOne(&row, "select {} from rows where name = ?", "name")
+		if got, want := err, tt.wantErr; got != want {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+		if got, want := row, tt.wantRow; got != want {
+			t.Errorf("%d: got=%+v, want=%+v", i, got, want)
+		}
+		sess.Close()
+		db.Close()
+	}
+}
+
+func TestSelectExactlyOneHandleRows(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	for _, rowCount := range []int{0, 1, 3} {
+		db := rowsDB(t, rowCount)
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+		var handled []*Row
+		sess.HandleRows(func(rows []*Row) {
+			handled = append(handled, rows...)
+		})
+		var row Row
+		err := sess.SelectExactlyOne(&row, "select {} from rows")
+
+		// the handlers are only called with dest, and only when
+		// exactly one row is returned
+		if rowCount == 1 {
+			wantNoError(t, err)
+			if len(handled) != 1 || handled[0] != &row {
+				t.Errorf("%d: got=%v, want=[%p]", rowCount, handled, &row)
+			}
+		} else if len(handled) != 0 {
+			t.Errorf("%d: got=%v, want none", rowCount, handled)
+		}
+		sess.Close()
+		db.Close()
+	}
+}
+
+func TestSelectExactlyOneErrors(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 1)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	var rows []*Row
+	err := sess.SelectExactlyOne(&rows, "select {} from rows")
+	if err == nil {
+		t.Fatal("got=nil, want=error")
+	}
+	if got, want := err.Error(), "expected dest to be *github.com/jjeffery/sqlr.Row"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	var nilRow *Row
+	err = sess.SelectExactlyOne(nilRow, "select {} from rows")
+	if err == nil {
+		t.Fatal("got=nil, want=error")
+	}
+	if got, want := err.Error(), "nil pointer"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+package sqlr
+
+import "fmt"
+
+// SelectExec executes an INSERT, UPDATE or DELETE statement that returns
+// rows, and stores the rows returned in rows, in the same way as Select.
+// This makes it possible to modify rows and obtain the rows that were
+// modified in one round trip:
+//  var deleted []*Order
+//  n, err := sess.SelectExec(&deleted,
+//      "delete from orders where created_at < ? returning {}", cutoff)
+// For PostgreSQL and SQLite the statement should have a RETURNING clause,
+// and for SQL Server the statement should have an OUTPUT clause. MySQL
+// does not support statements that return modified rows, and Oracle only
+// returns them into output parameters, so SelectExec returns an error for
+// the MySQL and Oracle dialects.
+//
+// Unlike Select, the statement is never retried after a transient
+// connection error, because the statement modifies the database. If the
+// schema has the WithMaxRows option and the statement returns more rows
+// than the limit, ErrTooManyRows is returned, but the rows have still been
+// modified.
+//
+// SelectExec returns the number of rows returned by the statement.
+func (sess *Session) SelectExec(rows interface{}, query string, args ...interface{}) (int, error) {
+	if name := dialectName(sess.schema.getDialect()); name == dialectMySQL || name == dialectOracle {
+		return 0, fmt.Errorf("SelectExec is not supported by dialect %s", name)
+	}
+	stmt, err := sess.schema.Prepare(rows, query)
+	if err != nil {
+		return 0, err
+	}
+	if stmt.queryType == querySelect {
+		return 0, fmt.Errorf("SelectExec requires an insert, update or delete statement, use Select instead")
+	}
+	if rc := sess.schema.rowCacheFor(stmt.tbl.rowType); rc != nil {
+		defer rc.invalidateAll()
+	}
+	return sess.selectWith(sess.context, stmt, rows, args)
+}
@@ -0,0 +1,85 @@
+package sqlr
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSelectExec(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 2)
+	defer db.Close()
+
+	tests := []struct {
+		dialect   Dialect
+		query     string
+		args      []interface{}
+		wantQuery string
+	}{
+		{
+			dialect:   Postgres,
+			query:     "delete from rows where id in (?) returning {}",
+			args:      []interface{}{[]int64{1, 2}},
+			wantQuery: `delete from rows where id in ($1,$2) returning "id", "name"`,
+		},
+		{
+			dialect:   Postgres,
+			query:     "update rows set name = ? where id in (?) returning {}",
+			args:      []interface{}{"name", []int64{1, 2}},
+			wantQuery: `update rows set name = $1 where id in ($2,$3) returning "id", "name"`,
+		},
+		{
+			dialect:   SQLite,
+			query:     "delete from rows where id in (?) returning {}",
+			args:      []interface{}{[]int64{1, 2}},
+			wantQuery: "delete from rows where id in (?,?) returning `id`, `name`",
+		},
+	}
+	for _, tt := range tests {
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(tt.dialect), WithLastQuery()))
+		var rows []*Row
+		n, err := sess.SelectExec(&rows, tt.query, tt.args...)
+		wantNoError(t, err)
+		if got, want := n, 2; got != want {
+			t.Errorf("got=%d, want=%d", got, want)
+		}
+		if got, want := len(rows), 2; got != want {
+			t.Errorf("got=%d, want=%d", got, want)
+		}
+		if query, _ := sess.LastQuery(); query != tt.wantQuery {
+			t.Errorf("got=%q, want=%q", query, tt.wantQuery)
+		}
+		sess.Close()
+	}
+}
+
+func TestSelectExecErrors(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 1)
+	defer db.Close()
+	var rows []*Row
+
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(MySQL)))
+	defer sess.Close()
+	if _, err := sess.SelectExec(&rows, "delete from rows where id = ?", 1); err == nil {
+		t.Error("want error for MySQL dialect, got nil")
+	}
+
+	sess = NewSession(context.Background(), db, NewSchema(WithDialect(Oracle)))
+	defer sess.Close()
+	if _, err := sess.SelectExec(&rows, "delete from rows where id = ?", 1); err == nil {
+		t.Error("want error for Oracle dialect, got nil")
+	}
+
+	sess = NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+	if _, err := sess.SelectExec(&rows, "select {} from rows"); err == nil {
+		t.Error("want error for select statement, got nil")
+	}
+}
@@ -10,7 +10,7 @@ import (
 	"time"
 
 	"github.com/jjeffery/kv"
-	"github.com/jjeffery/sqlr/private/wherein"
+	"github.com/jjeffery/sqlr/dataloader"
 )
 
 // A Session is a request-scoped database session. It can execute
@@ -20,12 +20,22 @@ type Session struct {
 	cancel  func()
 	querier Querier
 	schema  *Schema
+	conn    *sql.Conn // pinned connection closed by Close, or nil
 
 	// cache of query functions for this session
 	queryFuncs map[reflect.Type]reflect.Value
 
 	// map of row handler callback functions
 	rowHandlers map[reflect.Type][]func(reflect.Value)
+
+	// batch group for load functions created by MakeQuery, or nil
+	loaders *dataloader.BatchGroup
+
+	// depth of nested savepoints created by InTx
+	savepoints int
+
+	// records the most recent database call, or nil
+	lastQuery *lastQueryQuerier
 }
 
 // NewSession returns a new, request-scoped session.
@@ -43,12 +53,46 @@ func NewSession(ctx context.Context, querier Querier, schema *Schema) *Session {
 		panic("schema cannot be nil")
 	}
 	ctx, cancel := context.WithCancel(ctx)
-	return &Session{
+	sess := &Session{
 		context: ctx,
 		cancel:  cancel,
 		querier: querier,
 		schema:  schema,
 	}
+	if schema.lastQuery {
+		sess.lastQuery = &lastQueryQuerier{querier: querier}
+		sess.querier = sess.lastQuery
+	}
+	return sess
+}
+
+// NewConnSession returns a new, request-scoped session that is pinned to
+// a single connection acquired from the db connection pool. All queries
+// performed by the session run on the same physical connection, which is
+// necessary for features such as temporary tables and session variables.
+//
+// The connection is returned to the pool when the session's Close method
+// is called, so it is important to always close the session. A pinned
+// connection is unavailable to other goroutines for the lifetime of the
+// session, so many long-lived sessions can exhaust the connection pool
+// and cause other queries to block until a connection becomes available.
+func NewConnSession(ctx context.Context, db *sql.DB, schema *Schema) (*Session, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if db == nil {
+		return nil, errors.New("db cannot be nil")
+	}
+	if schema == nil {
+		return nil, errors.New("schema cannot be nil")
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sess := NewSession(ctx, conn, schema)
+	sess.conn = conn
+	return sess, nil
 }
 
 // Close releases resources associated with the session. Any attempt to
@@ -59,13 +103,51 @@ func NewSession(ctx context.Context, querier Querier, schema *Schema) *Session {
 // of a request is an effective way to release resources associated with
 // the session and to ensure that it can no longer be used.
 //
-// Close implements the io.Closer interface. It always returns nil.
+// If the session was created using NewConnSession, Close returns the
+// pinned connection to the pool.
+//
+// Close calls Flush before releasing resources, so that any queries for
+// pending load function thunks are performed.
+//
+// Close implements the io.Closer interface. It returns nil unless there
+// is an error closing the pinned connection.
 func (sess *Session) Close() error {
+	sess.Flush()
 	sess.cancel()
 	sess.queryFuncs = nil
+	if sess.conn != nil {
+		conn := sess.conn
+		sess.conn = nil
+		return conn.Close()
+	}
 	return nil
 }
 
+// Flush performs the queries for any pending thunks returned by load
+// functions created using MakeQuery. Ordinarily these queries are performed
+// when a pending thunk is first called, so a thunk that is never called does
+// not result in a query. Calling Flush ensures that the results for every
+// thunk have been loaded, for example before the results are handed to code
+// that runs after the request has completed.
+//
+// The load functions for a session belong to the same batch group, so
+// calling any pending thunk also performs the queries for the pending thunks
+// of the other load functions. Any errors are returned by the thunks.
+func (sess *Session) Flush() {
+	if sess.loaders != nil {
+		sess.loaders.Dispatch()
+	}
+}
+
+// batchGroup returns the batch group for load functions created by
+// MakeQuery, creating it if necessary.
+func (sess *Session) batchGroup() *dataloader.BatchGroup {
+	if sess.loaders == nil {
+		sess.loaders = dataloader.NewBatchGroup()
+	}
+	return sess.loaders
+}
+
 // Exec executes a query without returning any rows. The args are for any placeholder parameters in the query.
 func (sess *Session) Exec(query string, args ...interface{}) (sql.Result, error) {
 	return sess.execForRow(&struct{}{}, query, args...)
@@ -76,22 +158,46 @@ func (sess *Session) Exec(query string, args ...interface{}) (sql.Result, error)
 // Exec is a general-purpose row-based query function. For simple insert and update operations, consider
 // using the InsertRow and UpdateRow methods respectively.
 func (sess *Session) execForRow(row interface{}, query string, args ...interface{}) (sql.Result, error) {
+	return sess.execContext(sess.context, row, query, args)
+}
+
+// execContext is similar to execForRow, except that the query uses ctx.
+func (sess *Session) execContext(ctx context.Context, row interface{}, query string, args []interface{}) (sql.Result, error) {
 	stmt, err := sess.schema.Prepare(row, query)
 	if err != nil {
 		return nil, err
 	}
-	return stmt.exec(sess.context, sess.querier, row, args...)
+	if rc := sess.schema.rowCacheFor(stmt.tbl.rowType); rc != nil {
+		defer rc.invalidateRow(stmt.tbl, row)
+	}
+	return stmt.exec(ctx, sess.querier, row, args...)
 }
 
 // InsertRow inserts one row into the database.
 //
 // If the row has an auto-increment field, then that field is updated
-// with the value of the auto-increment column.
+// with the value of the auto-increment column. For Oracle, the value is
+// returned using "returning ... into", so the column should be an identity
+// column or be set from a sequence by a trigger. For PostgreSQL, fields
+// with columns generated by the database are also updated, using the
+// same statement.
 func (sess *Session) InsertRow(row interface{}) error {
-	tbl := sess.schema.TableFor(row)
+	tbl, err := sess.schema.tableFor(row)
+	if err != nil {
+		return err
+	}
+	if sess.schema.zeroPKGuard {
+		if err := tbl.checkZeroPK("InsertRow", row); err != nil {
+			return err
+		}
+	}
+	if rc := sess.schema.rowCacheFor(tbl.rowType); rc != nil {
+		defer rc.invalidateRow(tbl, row)
+	}
 
 	// if we are going to update any fields, make sure we have a pointer
-	if tbl.createdAt != nil || tbl.updatedAt != nil || tbl.version != nil || tbl.autoincr != nil {
+	returning := isPostgres(sess.schema.dialect) && (tbl.autoincr != nil || len(tbl.generated) > 0)
+	if tbl.createdAt != nil || tbl.updatedAt != nil || tbl.version != nil || tbl.autoincr != nil || returning {
 		// We will want to modify row, so check that it can be modified.
 		// Unfortunately this is a runtime check and cannot be determined at compile time.
 		// TODO(jpj): considered creating MakeInsert and MakeUpdate functions similar to
@@ -103,6 +209,11 @@ func (sess *Session) InsertRow(row interface{}) error {
 			if tbl.autoincr != nil {
 				names = append(names, tbl.autoincr.info.FieldNames)
 			}
+			if returning {
+				for _, col := range tbl.generated {
+					names = append(names, col.info.FieldNames)
+				}
+			}
 			if tbl.createdAt != nil {
 				names = append(names, tbl.createdAt.info.FieldNames)
 			}
@@ -141,25 +252,28 @@ func (sess *Session) InsertRow(row interface{}) error {
 			versionValue.SetInt(1)
 		}
 
+		if returning {
+			if err := sess.postgresInsertRow(row, tbl, rowValue); err != nil {
+				return err
+			}
+			// success = true
+			return nil
+		}
 		if tbl.autoincr != nil {
-			if isPostgres(sess.schema.dialect) {
-				if err := sess.postgresInsertRow(row, tbl, rowValue); err != nil {
-					return err
-				}
-				// success = true
-				return nil
-			} else {
-				if err := sess.autoincrInsertRow(row, tbl, rowValue); err != nil {
-					return err
-				}
-				// success = true
-				return nil
+			insertRow := sess.autoincrInsertRow
+			if dialectName(sess.schema.getDialect()) == dialectOracle {
+				insertRow = sess.oracleInsertRow
+			}
+			if err := insertRow(row, tbl, rowValue); err != nil {
+				return err
 			}
+			// success = true
+			return nil
 		}
 	}
 
 	// no autoincr column, so just a standard insert
-	query := fmt.Sprintf("insert into %s({}) values({})", sess.schema.dialect.Quote(tbl.tableName))
+	query := fmt.Sprintf("insert into %s({}) values({})", sess.schema.dialect.Quote(tbl.nameFor(row)))
 	stmt, err := sess.schema.Prepare(row, query)
 	if err != nil {
 		return err
@@ -173,7 +287,7 @@ func (sess *Session) InsertRow(row interface{}) error {
 }
 
 func (sess *Session) autoincrInsertRow(row interface{}, tbl *Table, rowValue reflect.Value) error {
-	query := fmt.Sprintf("insert into %s({}) values({})", sess.schema.dialect.Quote(tbl.tableName))
+	query := fmt.Sprintf("insert into %s({}) values({})", sess.schema.dialect.Quote(tbl.nameFor(row)))
 	stmt, err := sess.schema.Prepare(row, query)
 	if err != nil {
 		return err
@@ -192,35 +306,145 @@ func (sess *Session) autoincrInsertRow(row interface{}, tbl *Table, rowValue ref
 	return nil
 }
 
-func (sess *Session) postgresInsertRow(row interface{}, tbl *Table, rowValue reflect.Value) error {
-	query := fmt.Sprintf(
-		"insert into %s({}) values({}) returning %s",
-		sess.schema.dialect.Quote(tbl.tableName),
-		sess.schema.dialect.Quote(tbl.autoincr.columnName),
-	)
+// oracleInsertRow inserts the row and reads back the value of the
+// auto-increment column, which is usually an identity column or is set
+// by a trigger from a sequence. Oracle does not support LastInsertId, so
+// the value is returned in an output parameter using "returning ... into".
+func (sess *Session) oracleInsertRow(row interface{}, tbl *Table, rowValue reflect.Value) error {
+	dialect := sess.schema.dialect
+	query := fmt.Sprintf("insert into %s({}) values({}) returning %s into ?",
+		dialect.Quote(tbl.nameFor(row)), dialect.Quote(tbl.autoincr.columnName))
 	stmt, err := sess.schema.Prepare(row, query)
 	if err != nil {
 		return err
 	}
-	args, err := stmt.getArgs(row, nil)
+	var id int64
+	if _, err := stmt.exec(sess.context, sess.querier, row, sql.Out{Dest: &id}); err != nil {
+		return tbl.wrapRowError(err, row, "cannot insert row")
+	}
+	// already checked previously that this field can be set
+	field := tbl.autoincr.info.Index.ValueRW(rowValue)
+	field.SetInt(id)
+	return nil
+}
+
+// postgresInsertRow inserts the row and reads back the values of the
+// auto-increment column and any generated columns in one round trip.
+func (sess *Session) postgresInsertRow(row interface{}, tbl *Table, rowValue reflect.Value) error {
+	query, returnCols := postgresInsertSQL(sess.schema.dialect, tbl, row)
+	found, err := sess.queryReturned(row, tbl, rowValue, query, returnCols, "cannot insert row")
 	if err != nil {
 		return err
 	}
+	if !found {
+		return tbl.wrapRowError(sql.ErrNoRows, row, "cannot retrieve generated values")
+	}
+	return nil
+}
+
+// queryReturned performs the query, which returns at most one row, and sets
+// the fields of the row for cols from the columns of the returned row. It
+// returns false if no row is returned. The msg describes the query in any
+// error returned.
+func (sess *Session) queryReturned(row interface{}, tbl *Table, rowValue reflect.Value, query string, cols []*Column, msg string) (bool, error) {
+	stmt, err := sess.schema.Prepare(row, query)
+	if err != nil {
+		return false, err
+	}
+	args, err := stmt.getArgs(row, nil)
+	if err != nil {
+		return false, err
+	}
 	rows, err := sess.querier.QueryContext(sess.context, stmt.String(), args...)
 	if err != nil {
-		return tbl.wrapRowError(err, row, "cannot insert row")
+		return false, tbl.wrapRowError(err, row, msg)
 	}
 	defer rows.Close()
-	// expecting one row, one column
-	rows.Next()
-	var lastInsertID int64
-	if err := rows.Scan(&lastInsertID); err != nil {
-		return tbl.wrapRowError(err, row, "cannot retrieve last insert id")
+	// expecting at most one row, one column for each returned column
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return false, tbl.wrapRowError(err, row, msg)
+		}
+		return false, nil
 	}
-	// already checked previously that this field can be set
-	field := tbl.autoincr.info.Index.ValueRW(rowValue)
-	field.SetInt(lastInsertID)
-	return nil
+	// already checked previously that these fields can be set
+	scanValues := make([]interface{}, len(cols))
+	var jsonCells []*jsonCell
+	for i, col := range cols {
+		var jc *jsonCell
+		scanValues[i], jc = bindCell(col, rowValue, false)
+		if jc != nil {
+			jsonCells = append(jsonCells, jc)
+		}
+	}
+	if err := rows.Scan(scanValues...); err != nil {
+		return false, tbl.wrapRowError(err, row, "cannot retrieve returned values")
+	}
+	for _, jc := range jsonCells {
+		if err := jc.Unmarshal(); err != nil {
+			return false, tbl.wrapRowError(err, row, "cannot retrieve returned values")
+		}
+	}
+	return true, rows.Err()
+}
+
+// postgresInsertSQL returns the SQL for inserting the row with a returning
+// clause, and the columns listed in the returning clause: the auto-increment
+// column (if any) followed by the generated columns.
+func postgresInsertSQL(dialect Dialect, tbl *Table, row interface{}) (string, []*Column) {
+	var returnCols []*Column
+	if tbl.autoincr != nil {
+		returnCols = append(returnCols, tbl.autoincr)
+	}
+	for _, col := range tbl.generated {
+		if col != tbl.autoincr {
+			returnCols = append(returnCols, col)
+		}
+	}
+	names := make([]string, len(returnCols))
+	for i, col := range returnCols {
+		names[i] = dialect.Quote(col.columnName)
+	}
+	query := fmt.Sprintf(
+		"insert into %s({}) values({}) returning %s",
+		dialect.Quote(tbl.nameFor(row)),
+		strings.Join(names, ", "),
+	)
+	return query, returnCols
+}
+
+// GetRow reads one row from the database, using the values of the primary
+// key fields of row to identify the database row. The row must be a pointer
+// to a struct, and the remaining fields are set from the database row. It
+// returns false if there is no database row with the primary key.
+//
+// GetRow is useful for tables with a ShardFunc specified in their TableConfig,
+// because the row is read from the shard table for the primary key.
+func (sess *Session) GetRow(row interface{}) (bool, error) {
+	tbl, err := sess.schema.tableFor(row)
+	if err != nil {
+		return false, err
+	}
+	if len(tbl.pk) == 0 {
+		return false, fmt.Errorf("GetRow requires a primary key in %s", tbl.rowType)
+	}
+	query := fmt.Sprintf("select {} from %s where {}", sess.schema.getDialect().Quote(tbl.nameFor(row)))
+	if cond := tbl.notDeleted(""); cond != "" {
+		query += " and " + cond
+	}
+	stmt, err := sess.schema.Prepare(row, query)
+	if err != nil {
+		return false, err
+	}
+	args, err := stmt.getArgs(row, nil)
+	if err != nil {
+		return false, err
+	}
+	n, err := stmt.selectRows(sess.context, sess.querier, row, args...)
+	if err != nil {
+		return false, tbl.wrapRowError(err, row, "cannot get row")
+	}
+	return n > 0, nil
 }
 
 // UpdateRow updates one row in the database. It returns the number
@@ -229,9 +453,103 @@ func (sess *Session) postgresInsertRow(row interface{}, tbl *Table, rowValue ref
 // If the row has a version field, then that field is incremented
 // during the update. If the row being updated does not match the
 // original value of the version field, then an OptimisticLockingError
-// will be returned.
+// will be returned, unless it is mapped to another error with the
+// WithConflictError schema option.
 func (sess *Session) UpdateRow(row interface{}) (int, error) {
-	tbl := sess.schema.TableFor(row)
+	return sess.updateRow("UpdateRow", row, nil)
+}
+
+// UpdateRowExpecting updates one row in the database, but only if the
+// version of the row in the database is equal to expectedVersion. The row
+// must have a version field, which is set to expectedVersion + 1 if the
+// update is successful. If the version of the row in the database does
+// not match, then an OptimisticLockingError is returned and the version
+// field is unchanged.
+//
+// This is useful for optimistic concurrency control where the expected
+// version is supplied by the client, for example in an HTTP If-Match
+// header, rather than being the version of the row when it was read.
+func (sess *Session) UpdateRowExpecting(row interface{}, expectedVersion int64) (int, error) {
+	tbl, err := sess.schema.tableFor(row)
+	if err != nil {
+		return 0, err
+	}
+	if tbl.version == nil {
+		return 0, fmt.Errorf("UpdateRowExpecting requires a version field in %s", tbl.rowType)
+	}
+	return sess.updateRow("UpdateRowExpecting", row, &expectedVersion)
+}
+
+// DeleteRow deletes one row from the database, using the values of the
+// primary key fields of row to identify the database row. It returns the
+// number of rows deleted, which should be zero or one.
+//
+// If the row has a deleted field, identified by the "deleted" keyword in its
+// struct tag, the row is soft-deleted: instead of removing the row from the
+// table, the deleted column is set to the current time. Rows that have been
+// soft-deleted are excluded from GetRow and the queries created by MakeQuery,
+// unless the WithSoftDelete(false) schema option is specified. If row is a
+// pointer, its deleted field is set to the time of the delete.
+func (sess *Session) DeleteRow(row interface{}) (int, error) {
+	tbl, err := sess.schema.tableFor(row)
+	if err != nil {
+		return 0, err
+	}
+	if len(tbl.pk) == 0 {
+		return 0, fmt.Errorf("DeleteRow requires a primary key in %s", tbl.rowType)
+	}
+	if rc := sess.schema.rowCacheFor(tbl.rowType); rc != nil {
+		defer rc.invalidateRow(tbl, row)
+	}
+
+	dialect := sess.schema.getDialect()
+	query := fmt.Sprintf("delete from %s where {}", dialect.Quote(tbl.nameFor(row)))
+	var args []interface{}
+	now := time.Now()
+	if tbl.deletedAt != nil {
+		query = fmt.Sprintf("update %s set %s = ? where {} and %s is null",
+			dialect.Quote(tbl.nameFor(row)),
+			dialect.Quote(tbl.deletedAt.Name()),
+			dialect.Quote(tbl.deletedAt.Name()),
+		)
+		args = append(args, tbl.deletedAt.timeArg(now))
+	}
+	stmt, err := sess.schema.Prepare(row, query)
+	if err != nil {
+		return 0, err
+	}
+	result, err := stmt.exec(sess.context, sess.querier, row, args...)
+	if err != nil {
+		return 0, tbl.wrapRowError(err, row, "cannot delete row")
+	}
+	rowsDeleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, tbl.wrapRowError(err, row, "cannot retrieve rows deleted")
+	}
+	if tbl.deletedAt != nil && rowsDeleted > 0 {
+		if rowValue := tbl.mustGetRowValue(row); rowValue.CanAddr() {
+			deletedValue := tbl.deletedAt.info.Index.ValueRW(rowValue)
+			if deletedValue.Kind() == reflect.Ptr {
+				deletedValue.Set(reflect.ValueOf(&now))
+			} else {
+				deletedValue.Set(reflect.ValueOf(now))
+			}
+		}
+	}
+	return int(rowsDeleted), nil
+}
+
+// updateRow updates one row in the database. If expectedVersion is nil,
+// the expected version is the value of the row's version field (if any).
+// The method is the name of the calling method, for error messages.
+func (sess *Session) updateRow(method string, row interface{}, expectedVersion *int64) (int, error) {
+	tbl, err := sess.schema.tableFor(row)
+	if err != nil {
+		return 0, err
+	}
+	if rc := sess.schema.rowCacheFor(tbl.rowType); rc != nil {
+		defer rc.invalidateRow(tbl, row)
+	}
 
 	// if we are going to update any fields, make sure we have a pointer
 	if tbl.updatedAt != nil || tbl.version != nil {
@@ -251,9 +569,9 @@ func (sess *Session) UpdateRow(row interface{}) (int, error) {
 			}
 			var msg string
 			if len(names) == 1 {
-				msg = fmt.Sprintf("UpdateRow requires *%s to update field %s", tbl.rowType, names[0])
+				msg = fmt.Sprintf("%s requires *%s to update field %s", method, tbl.rowType, names[0])
 			} else {
-				msg = fmt.Sprintf("UpdateRow requires *%s to update fields %s", tbl.rowType, strings.Join(names, ", "))
+				msg = fmt.Sprintf("%s requires *%s to update fields %s", method, tbl.rowType, strings.Join(names, ", "))
 			}
 			return 0, errors.New(msg)
 		}
@@ -268,7 +586,11 @@ func (sess *Session) UpdateRow(row interface{}) (int, error) {
 		}
 
 		if tbl.version != nil {
-			n, err := sess.updateRowVersioned(row, tbl, rowValue)
+			version := tbl.version.info.Index.ValueRO(rowValue).Int()
+			if expectedVersion != nil {
+				version = *expectedVersion
+			}
+			n, err := sess.updateRowVersioned(row, tbl, rowValue, version)
 			if err != nil {
 				return 0, err
 			}
@@ -278,7 +600,7 @@ func (sess *Session) UpdateRow(row interface{}) (int, error) {
 	}
 
 	// no version column, so just a standard update
-	query := fmt.Sprintf("update %s set {} where {}", sess.schema.dialect.Quote(tbl.tableName))
+	query := fmt.Sprintf("update %s set {} where {}", sess.schema.dialect.Quote(tbl.nameFor(row)))
 	stmt, err := sess.schema.Prepare(row, query)
 	if err != nil {
 		return 0, err
@@ -295,10 +617,13 @@ func (sess *Session) UpdateRow(row interface{}) (int, error) {
 	return int(rowsUpdated), nil
 }
 
-func (sess *Session) updateRowVersioned(row interface{}, tbl *Table, rowValue reflect.Value) (int, error) {
+// updateRowVersioned updates a row that has a version field. The update
+// only succeeds if the version of the row in the database is expectedVersion,
+// in which case the version is set to expectedVersion + 1.
+func (sess *Session) updateRowVersioned(row interface{}, tbl *Table, rowValue reflect.Value, expectedVersion int64) (int, error) {
 	versionValue := tbl.version.info.Index.ValueRW(rowValue)
 	oldVersion := versionValue.Int()
-	newVersion := oldVersion + 1
+	newVersion := expectedVersion + 1
 	versionValue.SetInt(newVersion)
 	var success bool
 
@@ -312,7 +637,7 @@ func (sess *Session) updateRowVersioned(row interface{}, tbl *Table, rowValue re
 	dialect := sess.schema.dialect
 	query := fmt.Sprintf(
 		"update %s set {} where {} and %s = ?",
-		dialect.Quote(tbl.tableName),
+		dialect.Quote(tbl.nameFor(row)),
 		dialect.Quote(tbl.version.columnName),
 	)
 	stmt, err := sess.schema.Prepare(row, query)
@@ -320,7 +645,7 @@ func (sess *Session) updateRowVersioned(row interface{}, tbl *Table, rowValue re
 		return 0, err
 	}
 
-	result, err := stmt.exec(sess.context, sess.querier, row, oldVersion)
+	result, err := stmt.exec(sess.context, sess.querier, row, expectedVersion)
 	if err != nil {
 		return 0, tbl.wrapRowError(err, row, "cannot update row")
 	}
@@ -345,7 +670,7 @@ func (sess *Session) updateRowVersioned(row interface{}, tbl *Table, rowValue re
 	query = fmt.Sprintf(
 		"select %s from %s",
 		dialect.Quote(tbl.version.columnName),
-		dialect.Quote(tbl.tableName),
+		dialect.Quote(tbl.nameFor(row)),
 	)
 	var args []interface{}
 	for i, pkcol := range tbl.pk {
@@ -361,18 +686,25 @@ func (sess *Session) updateRowVersioned(row interface{}, tbl *Table, rowValue re
 	if err != nil {
 		return 0, tbl.wrapRowError(err, row, "cannot obtain version")
 	}
+	defer rows.Close()
 	var currentVersion int64
 	rows.Next()
 	if err := rows.Scan(&currentVersion); err != nil {
 		return 0, tbl.wrapRowError(err, row, "cannot scan version")
 	}
 
-	return 0, &OptimisticLockingError{
+	lockErr := &OptimisticLockingError{
 		Table:           tbl,
 		Row:             row,
-		ExpectedVersion: oldVersion,
+		ExpectedVersion: expectedVersion,
 		ActualVersion:   currentVersion,
 	}
+	if mapper := sess.schema.conflictError; mapper != nil {
+		if err := mapper(lockErr); err != nil {
+			return 0, err
+		}
+	}
+	return 0, lockErr
 }
 
 // OptimisticLockingError is an error generated during an Update
@@ -404,10 +736,16 @@ func (e *OptimisticLockingError) Error() string {
 // The argument passed to rows can be one of the following:
 //  A pointer to an array of structs; or
 //  a pointer to an array of struct pointers; or
+//  a pointer to a map of struct pointers keyed by primary key; or
 //  a pointer to a struct.
 // When rows is a pointer to an array it is populated with
 // one item for each row returned by the SELECT query.
 //
+// When rows is a pointer to a map, the map key type must be the type of
+// the primary key field, and each row returned by the SELECT query is
+// added to the map using its primary key value. Tables with a composite
+// primary key are not supported. If the map is nil, a new map is created.
+//
 // When rows is a pointer to a struct, it is populated with
 // the first row returned from the query. This is a good
 // option when the query will only return one row.
@@ -415,19 +753,42 @@ func (e *OptimisticLockingError) Error() string {
 // Select returns the number of rows returned by the SELECT
 // query.
 func (sess *Session) Select(rows interface{}, query string, args ...interface{}) (int, error) {
+	return sess.selectContext(sess.context, rows, query, args)
+}
+
+// selectContext is similar to Select, except that the query uses ctx.
+func (sess *Session) selectContext(ctx context.Context, rows interface{}, query string, args []interface{}) (int, error) {
 	stmt, err := sess.schema.Prepare(rows, query)
 	if err != nil {
 		return 0, err
 	}
-	n, err := stmt.selectRows(sess.context, sess.querier, rows, args...)
+	return sess.selectWith(ctx, stmt, rows, args)
+}
+
+// selectWith selects rows using a prepared statement, and calls the
+// row handlers for any rows returned.
+func (sess *Session) selectWith(ctx context.Context, stmt *Stmt, rows interface{}, args []interface{}) (int, error) {
+	n, err := stmt.selectRows(ctx, sess.querier, rows, args...)
 	if err != nil {
 		return n, err
 	}
 	if n > 0 {
-		// TODO: perform notifications
 		sess.callRowHandlers(stmt.tbl, rows, n)
 	}
-	return n, err
+	return n, nil
+}
+
+// SelectUncached is identical to Select, except that the prepared statement
+// is not stored in the schema's statement cache. It is intended for programs
+// that construct many unique queries, for example queries containing embedded
+// literal values, where caching every statement would result in unbounded
+// memory growth.
+func (sess *Session) SelectUncached(rows interface{}, query string, args ...interface{}) (int, error) {
+	stmt, err := sess.schema.prepare(rows, query, nil, false)
+	if err != nil {
+		return 0, err
+	}
+	return sess.selectWith(sess.context, stmt, rows, args)
 }
 
 // Querier returns the database querier associated with this session.
@@ -461,10 +822,21 @@ func (sess *Session) Schema() *Schema {
 //  func(ids []RowID) ([]*Row, error)
 //  func(ids ...RowID) ([]*Row, error)
 //
+//  // Delete multiple rows given multiple IDs, returning the number of
+//  // rows deleted. The prototype does not refer to Row, so the row type
+//  // is specified with an option: sess.MakeQuery(&fn, WithRowType(Row{}))
+//  func(ids []RowID) (int, error)
+//  func(ids ...RowID) (int, error)
+//
 //  // Get one row returning a thunk: batches multiple requests into
 //  // one query using the dataloader pattern
 //  func(id RowID) func() (*Row, error)
 //
+//  // Get one row for a composite primary key, with one arg for each
+//  // primary key field in the order that the fields are declared
+//  func(tenantID TenantID, id RowID) (*Row, error)
+//  func(tenantID TenantID, id RowID) func() (*Row, error)
+//
 //  // Execute a query that will return multiple Row objects
 //  func(query string, args ...interface{}) ([]*Row, error)
 //
@@ -476,6 +848,30 @@ func (sess *Session) Schema() *Schema {
 //  func(query string, args ...interface{}) (int, error)
 //  func(query string, args ...interface{}) (int64, error)
 //
+//  // Execute a query that will return a single scalar value from
+//  // the first column of the first row.
+//  func(query string, args ...interface{}) (string, error)
+//  func(query string, args ...interface{}) (float64, error)
+//  func(query string, args ...interface{}) (time.Time, error)
+//
+//  // Execute a query that reports whether a row exists, such as
+//  // "select exists(select 1 from ...)". Returns false if the query
+//  // returns no rows, otherwise the first column is interpreted as a
+//  // boolean, or true if it is not a boolean value.
+//  func(query string, args ...interface{}) (bool, error)
+//
+//  // Execute a query that will return a single scalar value that
+//  // might be NULL. If the query returns NULL or no rows, then the
+//  // zero value is returned (nil or an invalid sql.NullString).
+//  func(query string, args ...interface{}) (*string, error)
+//  func(query string, args ...interface{}) (sql.NullString, error)
+//
+// Options of type QueryOption can be supplied along with the function pointers,
+// and they apply to every function made in the same call. WithColumns applies
+// to get functions, and WithRowType applies to delete functions:
+//  sess.MakeQuery(&dao.GetLite, WithColumns("ID", "Name"))
+//  sess.MakeQuery(&dao.DeleteWidgets, WithRowType(Widget{}))
+//
 // If any of the funcPtr arguments are not pointers to a function, or do not fit
 // one of the known function prototypes, then this function will panic.
 func (sess *Session) MakeQuery(funcPtr ...interface{}) {
@@ -485,15 +881,24 @@ func (sess *Session) MakeQuery(funcPtr ...interface{}) {
 }
 
 func (sess *Session) makeQueries(funcPtr ...interface{}) error {
+	var opts queryOptions
+	var funcPtrs []interface{}
 	for _, fp := range funcPtr {
-		if err := sess.makeQueryFunc(fp); err != nil {
+		if opt, ok := fp.(QueryOption); ok {
+			opt(&opts)
+			continue
+		}
+		funcPtrs = append(funcPtrs, fp)
+	}
+	for _, fp := range funcPtrs {
+		if err := sess.makeQueryFunc(fp, opts); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (sess *Session) makeQueryFunc(funcPtr interface{}) error {
+func (sess *Session) makeQueryFunc(funcPtr interface{}, opts queryOptions) error {
 	funcPtrValue := reflect.ValueOf(funcPtr)
 	funcPtrType := funcPtrValue.Type()
 	if funcPtrType.Kind() != reflect.Ptr {
@@ -505,6 +910,17 @@ func (sess *Session) makeQueryFunc(funcPtr interface{}) error {
 		return newError("expected pointer to function, got %s", funcPtrType.String())
 	}
 
+	if !opts.isZero() {
+		// functions made with options are not cached, because
+		// the cache is keyed by function type alone
+		queryFuncFactory, err := makeQueryWithOptions(funcType, sess.schema, opts)
+		if err != nil {
+			return err
+		}
+		funcValue.Set(queryFuncFactory(sess))
+		return nil
+	}
+
 	// lookup the cache, and if a miss then create func and add to cache
 	queryFunc, ok := sess.queryFuncs[funcType]
 	if !ok {
@@ -526,6 +942,11 @@ func (sess *Session) makeQueryFunc(funcPtr interface{}) error {
 // are converted to the format suitable for the SQL dialect, and any
 // args that are slices are expanded (eg for WHERE IN (...) clauses).
 func (sess *Session) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return sess.queryContext(sess.context, query, args)
+}
+
+// queryContext is similar to Query, except that the query uses ctx.
+func (sess *Session) queryContext(ctx context.Context, query string, args []interface{}) (*sql.Rows, error) {
 	var row struct{}
 	stmt, err := sess.schema.Prepare(row, query)
 	if err != nil {
@@ -535,11 +956,11 @@ func (sess *Session) Query(query string, args ...interface{}) (*sql.Rows, error)
 	if err != nil {
 		return nil, err
 	}
-	expandedQuery, expandedArgs, err := wherein.Expand(stmt.query, args)
+	expandedQuery, expandedArgs, err := sess.schema.expand(stmt.query, args)
 	if err != nil {
 		return nil, err
 	}
-	return sess.querier.QueryContext(sess.context, expandedQuery, expandedArgs...)
+	return stmt.queryContext(ctx, sess.querier, expandedQuery, expandedArgs)
 }
 
 // callRowHandlers calls the appropriate row handlers
@@ -567,6 +988,17 @@ func (sess *Session) callRowHandlers(tbl *Table, rows interface{}, rowCount int)
 		return
 	}
 
+	if rowsValue.Kind() == reflect.Map {
+		sliceValue := reflect.MakeSlice(reflect.SliceOf(rowsValue.Type().Elem()), 0, rowsValue.Len())
+		for _, key := range rowsValue.MapKeys() {
+			sliceValue = reflect.Append(sliceValue, rowsValue.MapIndex(key))
+		}
+		for _, callback := range handlers {
+			callback(sliceValue)
+		}
+		return
+	}
+
 	if rowsValue.Kind() == reflect.Struct {
 		// A single row has been returned
 		sliceValue := reflect.MakeSlice(reflect.SliceOf(rowsPtrValue.Type()), 1, 1)
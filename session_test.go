@@ -0,0 +1,188 @@
+package sqlr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInsertRowGenerated(t *testing.T) {
+	type Widget struct {
+		ID     int64 `sql:"primary key autoincrement"`
+		Name   string
+		Status string `sql:"status generated"`
+		Code   string
+	}
+	type Gadget struct {
+		Code   string `sql:"primary key"`
+		Status string `sql:"status generated"`
+	}
+	tests := []struct {
+		dialect Dialect
+		row     interface{}
+		want    string
+	}{
+		{
+			dialect: Postgres,
+			row:     &Widget{Name: "one"},
+			want:    `insert into "widget"("name", "code") values($1, $2) returning "id", "status"`,
+		},
+		{
+			dialect: Postgres,
+			row:     &Gadget{Code: "one"},
+			want:    `insert into "gadget"("code") values($1) returning "status"`,
+		},
+		{
+			dialect: SQLite,
+			row:     &Gadget{Code: "one"},
+			want:    "insert into `gadget`(`code`) values(?)",
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		db := &FakeDB{rowsAffected: 1, queryErr: errors.New("query error")}
+		sess := NewSession(context.Background(), db, schema)
+		sess.InsertRow(tt.row)
+		if got, want := db.queries, []string{tt.want}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, want)
+		}
+	}
+
+	// generated values are read back into the row
+	schema := NewSchema(WithDialect(Postgres), WithTables(TablesConfig{
+		(*Widget)(nil): TableConfig{
+			Columns: map[string]ColumnConfig{
+				"Name":   {ColumnName: "status", Generated: true},
+				"Status": {Ignore: true},
+			},
+		},
+	}))
+	db := rowsDBWithColumns(t, 1, "id", "status")
+	sess := NewSession(context.Background(), db, schema)
+	row := &Widget{Code: "x"}
+	wantNoError(t, sess.InsertRow(row))
+	if got, want := row.ID, int64(1); got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	if got, want := row.Name, "name"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	// a non-pointer row cannot be updated
+	err := sess.InsertRow(Gadget{Code: "one"})
+	if err == nil {
+		t.Fatal("got=nil, want=error")
+	}
+	if got, want := err.Error(), "InsertRow requires *sqlr.Gadget to update field Status"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestUpdateRowNotPointer(t *testing.T) {
+	type Document struct {
+		ID        int64 `sql:"primary key"`
+		Version   int64 `sql:"version"`
+		UpdatedAt time.Time
+	}
+	db := &FakeDB{rowsAffected: 1}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	// the error names the method that was called
+	_, err := sess.UpdateRow(Document{ID: 1})
+	if got, want := fmt.Sprint(err), "UpdateRow requires *sqlr.Document to update fields Version, UpdatedAt"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	_, err = sess.UpdateRowExpecting(Document{ID: 1}, 1)
+	if got, want := fmt.Sprint(err), "UpdateRowExpecting requires *sqlr.Document to update fields Version, UpdatedAt"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestSelectMap(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 3)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	var handled int
+	sess.HandleRows(func(rows []*Row) {
+		handled += len(rows)
+	})
+
+	var rows map[int64]*Row
+	n, err := sess.Select(&rows, "select {} from rows")
+	wantNoError(t, err)
+	if got, want := n, 3; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	if got, want := len(rows), 3; got != want {
+		t.Fatalf("got=%d, want=%d", got, want)
+	}
+	for id, row := range rows {
+		if row.ID != id {
+			t.Errorf("got=%d, want=%d", row.ID, id)
+		}
+	}
+	if got, want := handled, 3; got != want {
+		t.Errorf("handled: got=%d, want=%d", got, want)
+	}
+
+	// existing map entries are retained
+	rows = map[int64]*Row{99: {ID: 99}}
+	_, err = sess.Select(&rows, "select {} from rows")
+	wantNoError(t, err)
+	if got, want := len(rows), 4; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+}
+
+func TestSelectMapErrors(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	type CompositeRow struct {
+		ID   int64  `sql:"primary key"`
+		Name string `sql:"primary key"`
+	}
+	db := rowsDB(t, 3)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	tests := []struct {
+		rows    interface{}
+		errText string
+	}{
+		{
+			rows:    &map[string]*Row{},
+			errText: "cannot select into map: key type is string, but sqlr.Row has primary key type of int64",
+		},
+		{
+			rows:    &map[int64]Row{},
+			errText: "expected rows to be *map[KeyType]*github.com/jjeffery/sqlr.Row",
+		},
+		{
+			rows:    &map[int64]*CompositeRow{},
+			errText: "cannot select into map: composite primary key not supported for sqlr.CompositeRow",
+		},
+	}
+	for i, tt := range tests {
+		_, err := sess.Select(tt.rows, "select {} from rows")
+		if err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+			continue
+		}
+		if got, want := err.Error(), tt.errText; got != want {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, want)
+		}
+	}
+}
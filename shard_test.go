@@ -0,0 +1,79 @@
+package sqlr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestShardFunc(t *testing.T) {
+	type User struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithTables(TablesConfig{
+			(*User)(nil): TableConfig{
+				TableName: "users",
+				ShardFunc: func(row interface{}) string {
+					return fmt.Sprintf("users_%d", row.(*User).ID%2)
+				},
+			},
+		}),
+	)
+	db := &FakeDB{rowsAffected: 1, queryErr: errors.New("query error")}
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	wantNoError(t, sess.InsertRow(&User{ID: 1, Name: "one"}))
+	wantNoError(t, sess.InsertRow(&User{ID: 2, Name: "two"}))
+	_, err := sess.UpdateRow(&User{ID: 3, Name: "three"})
+	wantNoError(t, err)
+	if _, err := sess.GetRow(&User{ID: 4}); err == nil {
+		t.Error("got=nil, want=error")
+	}
+	// queries that name the table are not affected
+	_, err = sess.Exec("delete from users_0 where id = ?", 2)
+	wantNoError(t, err)
+
+	want := []string{
+		`insert into "users_1"("id", "name") values($1, $2)`,
+		`insert into "users_0"("id", "name") values($1, $2)`,
+		`update "users_1" set "name" = $1 where "id" = $2`,
+		`select "id", "name" from "users_0" where "id" = $1`,
+		`delete from users_0 where id = $1`,
+	}
+	if got := db.queries; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%q\nwant=%q", got, want)
+	}
+	if got, want := schema.TableFor(User{}).Name(), "users"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	// operations that are not passed a row cannot determine the shard table
+	var get func(id int64) (*User, error)
+	var getMany func(ids []int64) ([]*User, error)
+	var load func(id int64) func() (*User, error)
+	var deleteMany func(ids []int64) (int, error)
+	for i, funcPtr := range []interface{}{&get, &getMany, &load} {
+		if err := sess.makeQueries(funcPtr); err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+		}
+	}
+	if _, err := makeQueryWithOptions(reflect.TypeOf(deleteMany), schema, queryOptions{rowType: reflect.TypeOf(User{})}); err == nil {
+		t.Error("delete: got=nil, want=error")
+	}
+	var users []*User
+	if _, err := sess.SelectByKeysVia(&users, []int64{1, 2}, KeysInChunks); err == nil {
+		t.Error("SelectByKeysVia: got=nil, want=error")
+	}
+	if _, err := sess.SelectAfter(&users, User{}, []string{"ID"}, nil, 10); err == nil {
+		t.Error("SelectAfter: got=nil, want=error")
+	}
+	if _, err := sess.InsertSelect(User{}, "select id, name from old_users"); err == nil {
+		t.Error("InsertSelect: got=nil, want=error")
+	}
+}
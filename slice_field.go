@@ -0,0 +1,17 @@
+package sqlr
+
+import "github.com/jjeffery/sqlr/private/wherein"
+
+// SliceField returns a query argument that is expanded in the same way as
+// a slice for "IN (?)" clauses, using the value of the named field of each
+// struct in slice. This saves building a slice of IDs from a slice of rows:
+//  n, err := sess.Select(&orders, "select {} from orders where customer_id in (?)",
+//      sqlr.SliceField(customers, "ID"))
+// The slice must be a slice of structs or a slice of pointers to structs.
+// A field of a nested struct is named using a period, eg "Address.Postcode".
+//
+// If the slice is empty, the placeholder is replaced with NULL, which
+// matches no rows.
+func SliceField(slice interface{}, fieldName string) interface{} {
+	return wherein.FieldSlice{Slice: slice, Field: fieldName}
+}
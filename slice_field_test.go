@@ -0,0 +1,37 @@
+package sqlr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSliceField(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 2)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres), WithLastQuery()))
+	defer sess.Close()
+
+	customers := []*Row{{ID: 7}, {ID: 8}}
+	var rows []Row
+	_, err := sess.Select(&rows, "select {} from rows where id in (?)", SliceField(customers, "ID"))
+	wantNoError(t, err)
+	query, args := sess.LastQuery()
+	if want := `select "id", "name" from rows where id in ($1,$2)`; query != want {
+		t.Errorf("got=%q, want=%q", query, want)
+	}
+	if want := []interface{}{int64(7), int64(8)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("got=%v, want=%v", args, want)
+	}
+
+	_, err = sess.Select(&rows, "select {} from rows where id in (?)", SliceField([]*Row{}, "ID"))
+	wantNoError(t, err)
+	query, _ = sess.LastQuery()
+	if want := `select "id", "name" from rows where id in (NULL)`; query != want {
+		t.Errorf("got=%q, want=%q", query, want)
+	}
+}
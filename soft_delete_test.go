@@ -0,0 +1,154 @@
+package sqlr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDeleteRow(t *testing.T) {
+	type Hard struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	type Soft struct {
+		ID        int64 `sql:"primary key"`
+		Name      string
+		DeletedAt *time.Time `sql:"deleted_at deleted"`
+	}
+	type SoftValue struct {
+		ID        int64 `sql:"primary key"`
+		Name      string
+		DeletedAt time.Time `sql:"deleted_at deleted"`
+	}
+
+	db := &FakeDB{rowsAffected: 1}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+
+	hard := &Hard{ID: 1}
+	n, err := sess.DeleteRow(hard)
+	wantNoError(t, err)
+	if got, want := n, 1; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+
+	soft := &Soft{ID: 2}
+	_, err = sess.DeleteRow(soft)
+	wantNoError(t, err)
+	if soft.DeletedAt == nil || soft.DeletedAt.IsZero() {
+		t.Errorf("got=%v, want deleted time", soft.DeletedAt)
+	}
+
+	softValue := &SoftValue{ID: 3}
+	_, err = sess.DeleteRow(softValue)
+	wantNoError(t, err)
+	if softValue.DeletedAt.IsZero() {
+		t.Error("got zero time, want deleted time")
+	}
+
+	// not a pointer, so the field cannot be set, but the row is deleted
+	_, err = sess.DeleteRow(Soft{ID: 4})
+	wantNoError(t, err)
+
+	wantQueries := []string{
+		`delete from "hard" where "id" = $1`,
+		`update "soft" set "deleted_at" = $1 where "id" = $2 and "deleted_at" is null`,
+		`update "soft_value" set "deleted_at" = $1 where "id" = $2 and "deleted_at" is null`,
+		`update "soft" set "deleted_at" = $1 where "id" = $2 and "deleted_at" is null`,
+	}
+	if !reflect.DeepEqual(db.queries, wantQueries) {
+		t.Errorf("got=%q\nwant=%q", db.queries, wantQueries)
+	}
+
+	type NoPK struct {
+		Name string
+	}
+	if _, err := sess.DeleteRow(&NoPK{}); err == nil {
+		t.Error("want error for row without primary key, got nil")
+	}
+}
+
+func TestSoftDeleteQueries(t *testing.T) {
+	type Doc struct {
+		ID        int64 `sql:"primary key"`
+		Title     string
+		DeletedAt *time.Time `sql:"deleted_at deleted"`
+	}
+	tests := []struct {
+		options []SchemaOption
+		want    []string
+	}{
+		{
+			want: []string{
+				`select "id", "title", "deleted_at" from "doc" where "id" = $1 and "deleted_at" is null`,
+				`select "id", "title", "deleted_at" from doc where "id" = $1 and "deleted_at" is null`,
+				`select "id", "title", "deleted_at" from doc where "id" in ($1,$2) and "deleted_at" is null`,
+			},
+		},
+		{
+			options: []SchemaOption{WithSoftDelete(false)},
+			want: []string{
+				`select "id", "title", "deleted_at" from "doc" where "id" = $1`,
+				`select "id", "title", "deleted_at" from doc where "id" = $1`,
+				`select "id", "title", "deleted_at" from doc where "id" in ($1,$2)`,
+			},
+		},
+	}
+	for i, tt := range tests {
+		db := &FakeDB{queryErr: errors.New("query error")}
+		options := append([]SchemaOption{WithDialect(Postgres)}, tt.options...)
+		sess := NewSession(context.Background(), db, NewSchema(options...))
+		var get func(id int64) (*Doc, error)
+		var getMany func(ids []int64) ([]*Doc, error)
+		sess.MakeQuery(&get, &getMany)
+
+		sess.GetRow(&Doc{ID: 1})
+		get(1)
+		getMany([]int64{1, 2})
+		if !reflect.DeepEqual(db.queries, tt.want) {
+			t.Errorf("%d: got=%q\nwant=%q", i, db.queries, tt.want)
+		}
+		sess.Close()
+	}
+}
+
+func TestSoftDeleteMany(t *testing.T) {
+	type DocID int64
+	type Doc struct {
+		ID        DocID `sql:"primary key"`
+		Title     string
+		DeletedAt time.Time `sql:"deleted_at deleted"`
+	}
+	db := &FakeDB{rowsAffected: 2}
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	defer sess.Close()
+	var deleteMany func(ids []DocID) (int, error)
+	sess.MakeQuery(&deleteMany, WithRowType(Doc{}))
+
+	n, err := deleteMany([]DocID{1, 2})
+	wantNoError(t, err)
+	if got, want := n, 2; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	want := []string{`update doc set "deleted_at" = $1 where "id" in ($2,$3) and "deleted_at" is null`}
+	if !reflect.DeepEqual(db.queries, want) {
+		t.Errorf("got=%q\nwant=%q", db.queries, want)
+	}
+}
+
+func TestMultipleDeletedColumns(t *testing.T) {
+	type Doc struct {
+		ID        int64      `sql:"primary key"`
+		DeletedAt *time.Time `sql:"deleted_at deleted"`
+		RemovedAt *time.Time `sql:"removed_at deleted"`
+	}
+	_, err := NewSchemaE(WithTables(TablesConfig{
+		reflect.TypeOf(Doc{}): {},
+	}))
+	if err == nil {
+		t.Error("want error for multiple deleted columns, got nil")
+	}
+}
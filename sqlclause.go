@@ -25,6 +25,9 @@ const (
 	clauseUpdateWhere
 	clauseDeleteFrom
 	clauseDeleteWhere
+	clauseInsertReturning
+	clauseUpdateReturning
+	clauseDeleteReturning
 )
 
 // queryType deduces the type of query based on the SQL clause.
@@ -32,11 +35,11 @@ func (c sqlClause) queryType() queryType {
 	switch c {
 	case clauseSelectColumns, clauseSelectFrom, clauseSelectWhere, clauseSelectOrderBy:
 		return querySelect
-	case clauseInsertColumns, clauseInsertValues:
+	case clauseInsertColumns, clauseInsertValues, clauseInsertReturning:
 		return queryInsert
-	case clauseUpdateTable, clauseUpdateSet, clauseUpdateWhere:
+	case clauseUpdateTable, clauseUpdateSet, clauseUpdateWhere, clauseUpdateReturning:
 		return queryUpdate
-	case clauseDeleteFrom, clauseDeleteWhere:
+	case clauseDeleteFrom, clauseDeleteWhere, clauseDeleteReturning:
 		return queryDelete
 	}
 	return queryUnknown
@@ -68,6 +71,12 @@ func (c sqlClause) String() string {
 		return "delete from"
 	case clauseDeleteWhere:
 		return "delete where"
+	case clauseInsertReturning:
+		return "insert returning"
+	case clauseUpdateReturning:
+		return "update returning"
+	case clauseDeleteReturning:
+		return "delete returning"
 	}
 	return fmt.Sprintf("Unknown %d", c)
 }
@@ -84,7 +93,11 @@ func (c sqlClause) isInput() bool {
 }
 
 func (c sqlClause) isOutput() bool {
-	return c == clauseSelectColumns
+	return c.matchAny(
+		clauseSelectColumns,
+		clauseInsertReturning,
+		clauseUpdateReturning,
+		clauseDeleteReturning)
 }
 
 func (c sqlClause) acceptsColumns() bool {
@@ -115,6 +128,17 @@ func (c sqlClause) defaultFilter() func(col *Column) bool {
 	return columnFilterAll
 }
 
+// allFilter returns the filter for a column list with the "all" keyword.
+// Columns with a read expression are excluded from the clauses that write
+// to the table.
+func (c sqlClause) allFilter() func(col *Column) bool {
+	switch c {
+	case clauseInsertColumns, clauseInsertValues, clauseUpdateSet:
+		return columnFilterWritable
+	}
+	return columnFilterAll
+}
+
 // nextClause operates an extremely simple state transition
 // keeping track of which part of an SQL clause we are in.
 func (c sqlClause) nextClause(keyword string) sqlClause {
@@ -146,18 +170,38 @@ func (c sqlClause) nextClause(keyword string) sqlClause {
 		return clauseUpdateTable
 	case "values":
 		switch c {
-		case clauseInsertColumns:
+		case clauseInsertColumns, clauseInsertReturning:
 			return clauseInsertValues
 		}
 	case "where":
 		switch c {
 		case clauseSelectFrom, clauseSelectColumns:
 			return clauseSelectWhere
-		case clauseDeleteFrom:
+		case clauseDeleteFrom, clauseDeleteReturning:
 			return clauseDeleteWhere
-		case clauseUpdateSet, clauseUpdateTable:
+		case clauseUpdateSet, clauseUpdateTable, clauseUpdateReturning:
 			return clauseUpdateWhere
 		}
+	case "returning":
+		// postgres, sqlite: returning clause at the end of the statement
+		switch c {
+		case clauseInsertColumns, clauseInsertValues:
+			return clauseInsertReturning
+		case clauseUpdateSet, clauseUpdateWhere:
+			return clauseUpdateReturning
+		case clauseDeleteFrom, clauseDeleteWhere:
+			return clauseDeleteReturning
+		}
+	case "output":
+		// mssql: output clause before the values or where clause
+		switch c {
+		case clauseInsertColumns:
+			return clauseInsertReturning
+		case clauseUpdateSet:
+			return clauseUpdateReturning
+		case clauseDeleteFrom:
+			return clauseDeleteReturning
+		}
 	}
 
 	return c
@@ -172,3 +216,17 @@ const (
 	queryDelete
 	querySelect
 )
+
+func (qt queryType) String() string {
+	switch qt {
+	case querySelect:
+		return "select"
+	case queryInsert:
+		return "insert"
+	case queryUpdate:
+		return "update"
+	case queryDelete:
+		return "delete"
+	}
+	return "unknown"
+}
@@ -54,6 +54,18 @@ func TestSqlClauseString(t *testing.T) {
 			clause: clauseDeleteWhere,
 			text:   "delete where",
 		},
+		{
+			clause: clauseInsertReturning,
+			text:   "insert returning",
+		},
+		{
+			clause: clauseUpdateReturning,
+			text:   "update returning",
+		},
+		{
+			clause: clauseDeleteReturning,
+			text:   "delete returning",
+		},
 		{
 			clause: sqlClause(999),
 			text:   "Unknown 999",
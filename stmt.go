@@ -4,15 +4,14 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/jjeffery/sqlr/private/scanner"
-	"github.com/jjeffery/sqlr/private/wherein"
 )
 
 // Stmt is a prepared statement. A Stmt is safe for concurrent use by multiple goroutines.
@@ -33,6 +32,8 @@ type Stmt struct {
 		columns []*Column
 	}
 	autoIncrColumn *Column
+	ordinal        bool // result columns are mapped to columns by position
+	sharedLock     bool // rows are locked by "{for share}", which requires a transaction
 }
 
 // inputSource describes where to source the input to an SQL query. (There is
@@ -59,7 +60,18 @@ func newStmt(schema *Schema, tbl *Table, sql string) (*Stmt, error) {
 		return nil, err
 	}
 
+	if !isPostgres(stmt.dialect) {
+		for _, col := range tbl.Columns() {
+			if col.hstore {
+				return nil, fmt.Errorf("hstore field %q requires the postgres dialect", col.info.FieldNames)
+			}
+		}
+	}
+
 	if stmt.queryType == queryInsert {
+		if err := checkInsertCounts(stmt.query); err != nil {
+			return nil, err
+		}
 		for _, col := range tbl.Columns() {
 			if col.AutoIncrement() {
 				stmt.autoIncrColumn = col
@@ -89,12 +101,20 @@ func (stmt *Stmt) String() string {
 	return stmt.query
 }
 
+// QueryType returns the type of SQL query associated with the statement,
+// which is one of "select", "insert", "update", "delete" or "unknown".
+// It is useful for routing queries to read-only replicas and for
+// tagging metrics.
+func (stmt *Stmt) QueryType() string {
+	return stmt.queryType.String()
+}
+
 func (stmt *Stmt) exec(ctx context.Context, db Querier, row interface{}, args ...interface{}) (sql.Result, error) {
 	args, err := stmt.getArgs(row, args)
 	if err != nil {
 		return nil, err
 	}
-	expandedQuery, expandedArgs, err := wherein.Expand(stmt.query, args)
+	expandedQuery, expandedArgs, err := stmt.schema.expand(stmt.query, args)
 	if err != nil {
 		return nil, err
 	}
@@ -139,6 +159,9 @@ func (stmt *Stmt) selectRows(ctx context.Context, db Querier, rows interface{},
 		// pointer to row struct, so only fetch one row
 		return stmt.selectOne(ctx, db, rows, destValue, args)
 	}
+	if destType.Kind() == reflect.Map {
+		return stmt.selectMap(ctx, db, destValue, args)
+	}
 
 	// if not a pointer to a struct, should be a pointer to a
 	// slice of structs or a pointer to a slice of struct pointers
@@ -156,39 +179,157 @@ func (stmt *Stmt) selectRows(ctx context.Context, db Querier, rows interface{},
 		return 0, errorPtrType()
 	}
 
-	expandedQuery, expandedArgs, err := wherein.Expand(stmt.query, args)
+	maxRows := stmt.schema.maxRows
+	var appended int
+	rowCount, err := stmt.scanRows(ctx, db, args, scanOptions{}, func(rowValuePtr reflect.Value) error {
+		if maxRows > 0 && appended >= maxRows {
+			return ErrTooManyRows
+		}
+		appended++
+		if isPtr {
+			sliceValue.Set(reflect.Append(sliceValue, rowValuePtr))
+		} else {
+			sliceValue.Set(reflect.Append(sliceValue, rowValuePtr.Elem()))
+		}
+		return nil
+	})
+	if err == ErrTooManyRows {
+		return maxRows, err
+	}
+	if err != nil {
+		return rowCount, err
+	}
+
+	// If the slice is nil, return an empty slice. This way the returned slice is
+	// always non-nil for a successful call.
+	if sliceValue.IsNil() {
+		if isPtr {
+			sliceValue.Set(reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(rowType)), 0, 0))
+		} else {
+			sliceValue.Set(reflect.MakeSlice(reflect.SliceOf(rowType), 0, 0))
+		}
+	}
+
+	return rowCount, nil
+}
+
+// selectMap selects rows into mapValue, which is a map of row struct pointers
+// keyed by primary key value. The rows are scanned into a slice, and then
+// added to the map.
+func (stmt *Stmt) selectMap(ctx context.Context, db Querier, mapValue reflect.Value, args []interface{}) (int, error) {
+	rowPtrType := reflect.PtrTo(stmt.tbl.RowType())
+	mapType := mapValue.Type()
+	if mapType.Elem() != rowPtrType {
+		expectedTypeName := stmt.expectedTypeName()
+		return 0, fmt.Errorf("expected rows to be *map[KeyType]*%s", expectedTypeName)
+	}
+	pkCols := stmt.tbl.PrimaryKey()
+	if len(pkCols) == 0 {
+		return 0, fmt.Errorf("cannot select into map: no primary key defined for %s", stmt.tbl.RowType())
+	}
+	if len(pkCols) > 1 {
+		return 0, fmt.Errorf("cannot select into map: composite primary key not supported for %s", stmt.tbl.RowType())
+	}
+	pkCol := pkCols[0]
+	if mapType.Key() != pkCol.fieldType() {
+		return 0, fmt.Errorf("cannot select into map: key type is %s, but %s has primary key type of %s",
+			mapType.Key(), stmt.tbl.RowType(), pkCol.fieldType())
+	}
+
+	sliceValue := reflect.New(reflect.SliceOf(rowPtrType))
+	rowCount, err := stmt.selectRows(ctx, db, sliceValue.Interface(), args...)
+	if err != nil && err != ErrTooManyRows {
+		return rowCount, err
+	}
+	if mapValue.IsNil() {
+		mapValue.Set(reflect.MakeMapWithSize(mapType, sliceValue.Elem().Len()))
+	}
+	for i := 0; i < sliceValue.Elem().Len(); i++ {
+		rowPtrValue := sliceValue.Elem().Index(i)
+		keyValue := rowPtrValue.Elem().FieldByIndex([]int(pkCol.fieldIndex()))
+		mapValue.SetMapIndex(keyValue, rowPtrValue)
+	}
+	return rowCount, err
+}
+
+// scanOptions control how rows are scanned by scanRows.
+type scanOptions struct {
+	// zeroCopy means that fields of type sql.RawBytes refer to memory owned
+	// by the database driver, and are only valid until the callback returns.
+	// Otherwise these fields are copied.
+	zeroCopy bool
+
+	// reuseRow means that every row is scanned into the same row value,
+	// which is reset before each row is scanned.
+	reuseRow bool
+}
+
+// scanRows executes the query and calls fn with a pointer to the row
+// value for each row returned. Unless opts.reuseRow is set, a new row value
+// is allocated for each row. Returns the number of rows scanned.
+func (stmt *Stmt) scanRows(ctx context.Context, db Querier, args []interface{}, opts scanOptions, fn func(rowValuePtr reflect.Value) error) (int, error) {
+	expandedQuery, expandedArgs, err := stmt.schema.expand(stmt.query, args)
 	if err != nil {
 		return 0, err
 	}
-	sqlRows, err := db.QueryContext(ctx, expandedQuery, expandedArgs...)
+	sqlRows, err := stmt.queryContext(ctx, db, expandedQuery, expandedArgs)
 	if err != nil {
 		return 0, err
 	}
 	defer sqlRows.Close()
+	rowType := stmt.tbl.RowType()
+	if isRowScanner(rowType) {
+		return stmt.scanRowScanners(sqlRows, opts, fn)
+	}
 	outputs, err := stmt.getOutputs(sqlRows)
 	if err != nil {
 		return 0, err
 	}
+	if stmt.schema.decimalCheck {
+		if err := checkDecimalOutputs(sqlRows, outputs); err != nil {
+			return 0, err
+		}
+	}
 
 	var rowCount = 0
-	scanValues := make([]interface{}, len(stmt.tbl.Columns()))
+	scanValues := make([]interface{}, len(outputs))
 
-	for sqlRows.Next() {
-		rowCount++
-		rowValuePtr := reflect.New(rowType)
-		rowValue := reflect.Indirect(rowValuePtr)
+	// bind sets the scan values to refer to the fields in rowValue
+	bind := func(rowValue reflect.Value) []*jsonCell {
 		var jsonCells []*jsonCell
 		for i, col := range outputs {
-			cellValue := col.info.Index.ValueRW(rowValue)
-			cellPtr := cellValue.Addr().Interface()
-			if col.JSON() {
-				jc := newJSONCell(col.info.Field.Name, cellPtr)
+			var jc *jsonCell
+			scanValues[i], jc = bindCell(col, rowValue, opts.zeroCopy)
+			if jc != nil {
 				jsonCells = append(jsonCells, jc)
-				scanValues[i] = jc.ScanValue()
-			} else {
-				scanValues[i] = newNullCell(col.info.Field.Name, cellValue, cellPtr)
 			}
 		}
+		return jsonCells
+	}
+
+	var (
+		rowValuePtr reflect.Value
+		jsonCells   []*jsonCell
+		template    reflect.Value
+	)
+	if opts.reuseRow {
+		// Bind once only. Binding allocates any nil pointers to embedded
+		// structs, so keep a copy of the bound row, which is used to reset
+		// the row without losing the pointers that the scan values refer to.
+		rowValuePtr = reflect.New(rowType)
+		jsonCells = bind(rowValuePtr.Elem())
+		template = reflect.New(rowType).Elem()
+		template.Set(rowValuePtr.Elem())
+	}
+
+	for sqlRows.Next() {
+		rowCount++
+		if opts.reuseRow {
+			rowValuePtr.Elem().Set(template)
+		} else {
+			rowValuePtr = reflect.New(rowType)
+			jsonCells = bind(rowValuePtr.Elem())
+		}
 		err = sqlRows.Scan(scanValues...)
 		if err != nil {
 			return 0, err
@@ -198,46 +339,121 @@ func (stmt *Stmt) selectRows(ctx context.Context, db Querier, rows interface{},
 				return rowCount, err
 			}
 		}
-		if isPtr {
-			sliceValue.Set(reflect.Append(sliceValue, rowValuePtr))
-		} else {
-			sliceValue.Set(reflect.Append(sliceValue, rowValue))
+		stmt.schema.callAfterScan(rowValuePtr)
+		if err := fn(rowValuePtr); err != nil {
+			return rowCount, err
 		}
 	}
 
 	if err := sqlRows.Err(); err != nil {
 		return 0, err
 	}
+	return rowCount, nil
+}
 
-	// If the slice is nil, return an empty slice. This way the returned slice is
-	// always non-nil for a successful call.
-	if sliceValue.IsNil() {
-		if isPtr {
-			sliceValue.Set(reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(rowType)), 0, 0))
+// scanRowScanners is the equivalent of scanRows for a row type that
+// implements RowScanner. It deliberately does not call getOutputs: the
+// row type decides how to absorb the columns, and it often has no exported
+// fields to match them with, so getOutputs would report every column as
+// unknown. For the same reason the decimal check does not apply.
+func (stmt *Stmt) scanRowScanners(sqlRows *sql.Rows, opts scanOptions, fn func(rowValuePtr reflect.Value) error) (int, error) {
+	columns, err := sqlRows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	rowType := stmt.tbl.RowType()
+	var rowCount int
+	var rowValuePtr reflect.Value
+	for sqlRows.Next() {
+		rowCount++
+		if opts.reuseRow && rowValuePtr.IsValid() {
+			rowValuePtr.Elem().Set(reflect.Zero(rowType))
 		} else {
-			sliceValue.Set(reflect.MakeSlice(reflect.SliceOf(rowType), 0, 0))
+			rowValuePtr = reflect.New(rowType)
+		}
+		if err := scanRowScanner(sqlRows, columns, rowValuePtr); err != nil {
+			return rowCount, err
+		}
+		stmt.schema.callAfterScan(rowValuePtr)
+		if err := fn(rowValuePtr); err != nil {
+			return rowCount, err
 		}
 	}
-
+	if err := sqlRows.Err(); err != nil {
+		return 0, err
+	}
 	return rowCount, nil
 }
 
+// bindCell returns the scan value for the column's field in rowValue.
+// If the column is serialized as JSON or by a codec, the JSON cell is also
+// returned, and it needs to be unmarshaled after each row is scanned.
+func bindCell(col *Column, rowValue reflect.Value, zeroCopy bool) (interface{}, *jsonCell) {
+	cellValue := col.info.Index.ValueRW(rowValue)
+	cellPtr := cellValue.Addr().Interface()
+	if col.JSON() || col.codec != nil {
+		jc := newJSONCell(col.info.Field.Name, cellPtr)
+		jc.gzip = col.gzip
+		jc.codec = col.codec
+		return jc.ScanValue(), jc
+	}
+	if col.array {
+		return newPGArrayCell(col.info.Field.Name, cellValue), nil
+	}
+	if col.hstore {
+		return newHstoreCell(col.info.Field.Name, cellValue), nil
+	}
+	if col.intBool {
+		return newIntBoolCell(col.info.Field.Name, cellValue), nil
+	}
+	if col.date {
+		return newDateCell(col.info.Field.Name, cellValue), nil
+	}
+	if col.epoch != 0 {
+		return newEpochCell(col.info.Field.Name, cellValue, col.epoch), nil
+	}
+	if cellValue.Type() == rawBytesType && !zeroCopy {
+		return copyRawBytesPtr(cellValue), nil
+	}
+	cell := newNullCell(col.info.Field.Name, cellValue, cellPtr)
+	if len(col.enum) > 0 {
+		return newEnumCell(col, cellValue, cell), nil
+	}
+	return cell, nil
+}
+
+// copyRawBytesPtr returns a pointer to a sql.RawBytes cell that has been
+// converted to a *[]byte, so that the database driver copies the value
+// when it is scanned. Scanning directly into a *sql.RawBytes does not copy,
+// and the contents are only valid until the next row is read.
+func copyRawBytesPtr(cellValue reflect.Value) interface{} {
+	return cellValue.Addr().Convert(bytesPtrType).Interface()
+}
+
 // TODO(jpj): need to merge the common code in Select and selectOne
 
 func (stmt *Stmt) selectOne(ctx context.Context, db Querier, dest interface{}, rowValue reflect.Value, args []interface{}) (int, error) {
-	expandedQuery, expandedArgs, err := wherein.Expand(stmt.query, args)
+	expandedQuery, expandedArgs, err := stmt.schema.expand(stmt.query, args)
 	if err != nil {
 		return 0, err
 	}
-	rows, err := db.QueryContext(ctx, expandedQuery, expandedArgs...)
+	rows, err := stmt.queryContext(ctx, db, expandedQuery, expandedArgs)
 	if err != nil {
 		return 0, err
 	}
 	defer rows.Close()
+	if isRowScanner(stmt.tbl.RowType()) {
+		return stmt.selectOneRowScanner(rows, rowValue)
+	}
 	outputs, err := stmt.getOutputs(rows)
 	if err != nil {
 		return 0, err
 	}
+	if stmt.schema.decimalCheck {
+		if err := checkDecimalOutputs(rows, outputs); err != nil {
+			return 0, err
+		}
+	}
 
 	scanValues := make([]interface{}, len(outputs))
 	var jsonCells []*jsonCell
@@ -251,14 +467,10 @@ func (stmt *Stmt) selectOne(ctx context.Context, db Querier, dest interface{}, r
 	rowCount := 1
 
 	for i, col := range outputs {
-		cellValue := col.info.Index.ValueRW(rowValue)
-		cellPtr := cellValue.Addr().Interface()
-		if col.JSON() {
-			jc := newJSONCell(col.info.Field.Name, cellPtr)
+		var jc *jsonCell
+		scanValues[i], jc = bindCell(col, rowValue, false)
+		if jc != nil {
 			jsonCells = append(jsonCells, jc)
-			scanValues[i] = jc.ScanValue()
-		} else {
-			scanValues[i] = newNullCell(col.info.Field.Name, cellValue, cellPtr)
 		}
 	}
 	err = rows.Scan(scanValues...)
@@ -270,6 +482,7 @@ func (stmt *Stmt) selectOne(ctx context.Context, db Querier, dest interface{}, r
 			return rowCount, err
 		}
 	}
+	stmt.schema.callAfterScan(rowValue.Addr())
 
 	// count any additional rows
 	for rows.Next() {
@@ -279,6 +492,31 @@ func (stmt *Stmt) selectOne(ctx context.Context, db Querier, dest interface{}, r
 	return rowCount, nil
 }
 
+// selectOneRowScanner is the equivalent of selectOne for a row type that
+// implements RowScanner. Like scanRowScanners, it passes the column names
+// to ScanRow instead of matching them with getOutputs.
+func (stmt *Stmt) selectOneRowScanner(rows *sql.Rows, rowValue reflect.Value) (int, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		// no rows returned
+		return 0, nil
+	}
+	rowCount := 1
+	if err := scanRowScanner(rows, columns, rowValue.Addr()); err != nil {
+		return rowCount, err
+	}
+	stmt.schema.callAfterScan(rowValue.Addr())
+
+	// count any additional rows
+	for rows.Next() {
+		rowCount++
+	}
+	return rowCount, rows.Err()
+}
+
 func (stmt *Stmt) getOutputs(rows *sql.Rows) ([]*Column, error) {
 	stmt.output.mutex.RLock()
 	outputs := stmt.output.columns
@@ -304,6 +542,25 @@ func (stmt *Stmt) getOutputs(rows *sql.Rows) ([]*Column, error) {
 		return nil, err
 	}
 
+	if stmt.ordinal {
+		// map by position, regardless of the column names
+		cols := stmt.tbl.Columns()
+		if len(columnNames) != len(cols) {
+			return nil, fmt.Errorf("query returned %d columns, expected %d columns for ordinal mapping", len(columnNames), len(cols))
+		}
+		stmt.output.columns = cols
+		return stmt.output.columns, nil
+	}
+
+	if stmt.tbl.lowerColumns != nil {
+		outputs, err = stmt.getOutputsFold(columnNames)
+		if err != nil {
+			return nil, err
+		}
+		stmt.output.columns = outputs
+		return stmt.output.columns, nil
+	}
+
 	outputs = make([]*Column, len(columnNames))
 	var columnNotFound = false
 	for i, columnName := range columnNames {
@@ -326,6 +583,18 @@ func (stmt *Stmt) getOutputs(rows *sql.Rows) ([]*Column, error) {
 		for k, v := range columnMap {
 			lowerColumnMap[strings.ToLower(k)] = v
 		}
+
+		// Result structs for ad-hoc queries, such as aggregate queries, often
+		// name the result column in the struct tag (eg `sql:"cnt"`). The column
+		// name can differ from the tag name if the field is in an embedded struct,
+		// or if the column has been renamed in the schema, so the tag name is
+		// used as a last resort.
+		tagColumnMap := make(map[string]*Column)
+		for _, col := range columnMap {
+			if tagName := col.info.Tag.Name; tagName != "" {
+				tagColumnMap[strings.ToLower(tagName)] = col
+			}
+		}
 		for i, columnName := range columnNames {
 			if outputs[i] != nil {
 				continue
@@ -333,11 +602,14 @@ func (stmt *Stmt) getOutputs(rows *sql.Rows) ([]*Column, error) {
 			columnNameLower := strings.ToLower(columnName)
 			col := lowerColumnMap[columnNameLower]
 			if col == nil {
+				col = tagColumnMap[columnNameLower]
+			}
+			if col == nil || columnMap[col.Name()] == nil {
 				unknownColumnNames = append(unknownColumnNames, columnName)
 				continue
 			}
 			outputs[i] = col
-			delete(lowerColumnMap, columnNameLower)
+			delete(lowerColumnMap, strings.ToLower(col.Name()))
 			delete(columnMap, col.Name())
 		}
 
@@ -363,39 +635,185 @@ func (stmt *Stmt) getOutputs(rows *sql.Rows) ([]*Column, error) {
 	return stmt.output.columns, nil
 }
 
+// getOutputsFold matches the column names without regard to case, using
+// the lower-case column map that was built when the table was created.
+func (stmt *Stmt) getOutputsFold(columnNames []string) ([]*Column, error) {
+	outputs := make([]*Column, len(columnNames))
+	used := make(map[*Column]bool, len(columnNames))
+	var unknownColumnNames []string
+	for i, columnName := range columnNames {
+		col, ok := stmt.tbl.lowerColumns[strings.ToLower(columnName)]
+		if ok && col == nil {
+			return nil, fmt.Errorf("ambiguous column name=%q", columnName)
+		}
+		if col == nil || used[col] {
+			unknownColumnNames = append(unknownColumnNames, columnName)
+			continue
+		}
+		outputs[i] = col
+		used[col] = true
+	}
+	if len(unknownColumnNames) == 1 {
+		return nil, fmt.Errorf("unknown column name=%q", unknownColumnNames[0])
+	}
+	if len(unknownColumnNames) > 0 {
+		return nil, fmt.Errorf("unknown columns names=%q", strings.Join(unknownColumnNames, ","))
+	}
+	var missingColumnNames []string
+	for _, col := range stmt.tbl.Columns() {
+		if !used[col] {
+			missingColumnNames = append(missingColumnNames, col.Name())
+		}
+	}
+	if len(missingColumnNames) == 1 {
+		return nil, fmt.Errorf("missing column name=%q", missingColumnNames[0])
+	}
+	if len(missingColumnNames) > 0 {
+		return nil, fmt.Errorf("missing columns names=%s", strings.Join(missingColumnNames, ","))
+	}
+	return outputs, nil
+}
+
+// scanSQL scans the query and builds the statement's SQL, inputs and query type.
+// An error includes the position in the query of the token that caused it.
 func (stmt *Stmt) scanSQL(query string) error {
-	query = strings.TrimSpace(query)
 	scan := scanner.New(strings.NewReader(query))
+	if err := stmt.scanTokens(scan, query); err != nil {
+		if _, ok := err.(*scanner.Error); ok {
+			// already includes the position
+			return err
+		}
+		return fmt.Errorf("%v at %s", err, scan.Pos())
+	}
+	return nil
+}
+
+func (stmt *Stmt) scanTokens(scan *scanner.Scanner, query string) error {
 	columns := newColumns(stmt.tbl.Columns())
 	var counter int
 	counterNext := func() int { counter++; return counter }
 	var insertColumns *columnList
 	var clause sqlClause
 	var buf bytes.Buffer
-	rename := func(name string) string {
-		if newName, ok := stmt.schema.renameIdent(name); ok {
-			return newName
-		}
-		return name
-	}
+
+	// A schema-qualified identifier such as "dbo.[User]" is scanned as
+	// multiple tokens. Keep track of the parts scanned so far, so that the
+	// qualified name can be renamed as a whole.
+	var identParts []string
+	var identStart int
+	var afterDot bool
+
+	// counts of placeholders in the query, which can be numbered or unnumbered
+	var unnumberedArgs int
+	var numberedArgs bool
+
+	// position after the "select" keyword, where "top (n)" is inserted
+	// for a limit in SQL Server, and whether the outer select has an order
+	// by clause, as opposed to a subquery or a window function
+	selectEnd := -1
+	var orderBy bool
+	var top string
+	var parenDepth int
 
 	for scan.Scan() {
 		tok, lit := scan.Token(), scan.Text()
+		if tok == scanner.OP && lit == "." && len(identParts) > 0 && !afterDot {
+			afterDot = true
+			buf.WriteString(lit)
+			continue
+		}
+		if tok != scanner.IDENT || !afterDot {
+			identParts = identParts[:0]
+		}
+		afterDot = false
+
 		switch tok {
 		case scanner.WS:
 			buf.WriteRune(' ')
 		case scanner.COMMENT:
 			// strip comment
 		case scanner.LITERAL, scanner.OP:
+			if tok == scanner.OP {
+				// an operator can contain more than one paren, eg "()"
+				parenDepth += strings.Count(lit, "(") - strings.Count(lit, ")")
+			}
 			buf.WriteString(lit)
 		case scanner.PLACEHOLDER:
-			// TODO(jpj): should parse the placeholder in case it is positional
-			// instead of just allocating it a number assuming it is not positional
+			// A numbered placeholder such as "$2" refers to a specific arg,
+			// otherwise placeholders refer to args in the order they appear.
+			// Either way, the placeholders in the prepared query are numbered
+			// in order, with one input for each placeholder.
+			argIndex := unnumberedArgs
+			if n, err := strconv.Atoi(lit[1:]); err == nil && n > 0 {
+				argIndex = n - 1
+				numberedArgs = true
+			} else {
+				unnumberedArgs++
+			}
+			if numberedArgs && unnumberedArgs > 0 {
+				return fmt.Errorf("cannot mix numbered and unnumbered placeholders in %q", query)
+			}
 			buf.WriteString(stmt.dialect.Placeholder(counterNext()))
-			stmt.inputs = append(stmt.inputs, inputSource{argIndex: stmt.argCount})
-			stmt.argCount++
+			stmt.inputs = append(stmt.inputs, inputSource{argIndex: argIndex})
+			if argIndex >= stmt.argCount {
+				stmt.argCount = argIndex + 1
+			}
 		case scanner.IDENT:
-			if lit[0] == '{' {
+			if fieldName, ok := parseFieldRef(lit); ok {
+				// a single placeholder bound to a specific field of the row
+				if stmt.queryType == querySelect {
+					return fmt.Errorf("cannot use %q in a select query", lit)
+				}
+				col := stmt.fieldColumn(fieldName)
+				if col == nil {
+					return fmt.Errorf("unknown field %q in %q", fieldName, lit)
+				}
+				buf.WriteString(stmt.dialect.Placeholder(counterNext()))
+				stmt.inputs = append(stmt.inputs, inputSource{col: col})
+			} else if isOrdinalToken(lit) {
+				// map result columns by position, the token is not part of the SQL
+				stmt.ordinal = true
+				if n := buf.Len(); n > 0 && buf.Bytes()[n-1] == ' ' {
+					buf.Truncate(n - 1)
+				}
+			} else if alias, ok, err := parseTableToken(lit); ok {
+				if err != nil {
+					return err
+				}
+				if stmt.tbl.isSharded() {
+					// the table name depends on the row
+					return fmt.Errorf("cannot expand %q for %s, which has a ShardFunc", lit, stmt.tbl.rowType)
+				}
+				buf.WriteString(stmt.dialect.Quote(stmt.tbl.Name()))
+				if alias != "" {
+					if scanner.IsQuoted(alias) {
+						alias = stmt.dialect.Quote(scanner.Unquote(alias))
+					} else {
+						alias = stmt.schema.foldIdent(alias)
+					}
+					buf.WriteString(" " + alias)
+				}
+			} else if isForShareToken(lit) {
+				if stmt.queryType != querySelect {
+					return fmt.Errorf("cannot use %q except in a select query", lit)
+				}
+				forShare, err := forShareSQL(stmt.dialect)
+				if err != nil {
+					return err
+				}
+				buf.WriteString(forShare)
+				stmt.sharedLock = true
+			} else if spec, ok, err := parseLimit(lit); ok {
+				if err != nil {
+					return err
+				}
+				if stmt.queryType != querySelect || selectEnd < 0 {
+					return fmt.Errorf("cannot use %q except in a select query", lit)
+				}
+				var limitClause string
+				top, limitClause = spec.SQL(stmt.dialect, orderBy)
+				buf.WriteString(limitClause)
+			} else if lit[0] == '{' {
 				if !clause.acceptsColumns() {
 					// invalid place to insert columns
 					return fmt.Errorf("cannot expand %q in %q clause", lit, clause)
@@ -421,32 +839,232 @@ func (stmt *Stmt) scanSQL(query string) error {
 					if err != nil {
 						return fmt.Errorf("cannot expand %q in %q clause: %v", lit, clause, err)
 					}
+					if !scanner.IsQuoted(cols.alias) {
+						cols.alias = stmt.schema.foldIdent(cols.alias)
+					}
 					buf.WriteString(cols.String(stmt.dialect, counterNext))
 					stmt.addInputColumns(cols)
 					if clause == clauseInsertColumns {
 						insertColumns = &cols
 					}
 				}
-			} else if scanner.IsQuoted(lit) {
-				lit = rename(scanner.Unquote(lit))
-				buf.WriteString(stmt.dialect.Quote(lit))
 			} else {
-				lit = rename(lit)
+				quoted := scanner.IsQuoted(lit)
+				name := lit
+				if quoted {
+					name = scanner.Unquote(lit)
+				}
+				if len(identParts) == 0 {
+					identStart = buf.Len()
+				}
+				identParts = append(identParts, name)
+				if newName, ok := stmt.schema.renameIdent(strings.Join(identParts, ".")); ok && len(identParts) > 1 {
+					// replace the entire qualified name, quoted as per the replacement
+					buf.Truncate(identStart)
+					buf.WriteString(stmt.quoteIdent(newName, false))
+					continue
+				}
+				if newName, ok := stmt.schema.renameIdent(name); ok {
+					lit = stmt.quoteIdent(newName, quoted)
+				} else if quoted {
+					lit = stmt.dialect.Quote(name)
+				} else {
+					// an unquoted identifier is folded as per the database
+					lit = stmt.schema.foldIdent(lit)
+				}
 				buf.WriteString(lit)
+				if quoted {
+					continue
+				}
 
 				// An unquoted identifer might be an SQL keyword.
 				// Attempt to infer the SQL clause and query type.
-				clause = clause.nextClause(lit)
+				// The output clause is specific to SQL Server, and "output"
+				// is a valid column name in other dialects.
+				prevClause := clause
+				if !strings.EqualFold(lit, "output") || dialectName(stmt.dialect) == dialectMSSQL {
+					clause = clause.nextClause(lit)
+				}
 				if stmt.queryType == queryUnknown {
 					stmt.queryType = clause.queryType()
 				}
+				if clause == clauseSelectColumns {
+					if selectEnd < 0 && strings.EqualFold(lit, "select") {
+						selectEnd = buf.Len()
+					} else if selectEnd >= 0 && strings.EqualFold(lit, "distinct") &&
+						strings.TrimSpace(buf.String()[selectEnd:identStart]) == "" {
+						// "top" follows "select distinct"
+						selectEnd = buf.Len()
+					}
+				}
+				if clause == clauseSelectOrderBy && prevClause != clause && parenDepth == 0 {
+					orderBy = true
+				}
 			}
 		}
 	}
-	stmt.query = strings.TrimSpace(buf.String())
+	if err := scan.Err(); err != nil {
+		return err
+	}
+	query = buf.String()
+	if top != "" {
+		query = query[:selectEnd] + " " + top + query[selectEnd:]
+	}
+	stmt.query = strings.TrimSpace(query)
+	return nil
+}
+
+// parseFieldRef parses an identifier of the form "{field Name}", which
+// refers to the field of the row called Name. Fields in embedded structs
+// are referred to using a dotted path, eg "{field Address.Street}".
+func parseFieldRef(lit string) (string, bool) {
+	if lit[0] != '{' {
+		return "", false
+	}
+	fields := strings.Fields(scanner.Unquote(lit))
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "field") {
+		return "", false
+	}
+	return fields[1], true
+}
+
+// parseTableToken parses an identifier of the form "{table}" or
+// "{table alias}", which expands to the table name for the row type,
+// optionally followed by an alias.
+func parseTableToken(lit string) (alias string, ok bool, err error) {
+	if lit[0] != '{' {
+		return "", false, nil
+	}
+	fields := strings.Fields(scanner.Unquote(lit))
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "table") {
+		return "", false, nil
+	}
+	if len(fields) > 2 {
+		return "", true, fmt.Errorf("invalid table token %q", lit)
+	}
+	if len(fields) == 2 {
+		alias = fields[1]
+	}
+	return alias, true, nil
+}
+
+// isOrdinalToken reports whether the identifier is "{ordinal}", which
+// indicates that result columns are mapped to columns by position.
+func isOrdinalToken(lit string) bool {
+	return lit[0] == '{' && strings.EqualFold(strings.TrimSpace(scanner.Unquote(lit)), "ordinal")
+}
+
+// fieldColumn returns the column for the named field, or nil if there
+// is no column associated with the field.
+func (stmt *Stmt) fieldColumn(fieldName string) *Column {
+	for _, col := range stmt.tbl.Columns() {
+		if col.info.FieldNames == fieldName {
+			return col
+		}
+	}
 	return nil
 }
 
+// checkInsertCounts checks that the number of columns in the column list
+// of an INSERT statement matches the number of values in each row of the
+// VALUES clause. This catches a mismatch between the expanded column list
+// and any placeholders added to the VALUES clause, which would otherwise
+// result in a confusing error from the database driver. INSERT statements
+// without a column list or a VALUES clause are not checked.
+func checkInsertCounts(query string) error {
+	scan := scanner.New(strings.NewReader(query))
+	scan.IgnoreWhiteSpace = true
+
+	// countList counts the items in a parenthesized list, assuming
+	// that the opening parenthesis has already been scanned.
+	countList := func() int {
+		var depth, count int
+		for scan.Scan() {
+			tok, lit := scan.Token(), scan.Text()
+			if tok == scanner.COMMENT {
+				continue
+			}
+			if count == 0 && !(tok == scanner.OP && lit == ")" && depth == 0) {
+				count = 1
+			}
+			if tok == scanner.OP {
+				switch lit {
+				case "(":
+					depth++
+				case ")":
+					if depth == 0 {
+						return count
+					}
+					depth--
+				case ",":
+					if depth == 0 {
+						count++
+					}
+				}
+			}
+		}
+		return count
+	}
+
+	// find the column list, which is the first parenthesized list
+	var columnCount int
+	for {
+		if !scan.Scan() {
+			return nil
+		}
+		tok, lit := scan.Token(), scan.Text()
+		if tok == scanner.OP && lit == "(" {
+			columnCount = countList()
+			break
+		}
+		if tok == scanner.IDENT && (strings.EqualFold(lit, "values") || strings.EqualFold(lit, "select")) {
+			// no column list
+			return nil
+		}
+	}
+
+	if !scan.Scan() || scan.Token() != scanner.IDENT || !strings.EqualFold(scan.Text(), "values") {
+		// not a VALUES clause, eg INSERT ... SELECT
+		return nil
+	}
+	for row := 1; scan.Scan(); row++ {
+		if scan.Token() != scanner.OP || scan.Text() != "(" {
+			break
+		}
+		if valueCount := countList(); valueCount != columnCount {
+			return fmt.Errorf("insert has %d columns but %d values in row %d of the values clause",
+				columnCount, valueCount, row)
+		}
+		if !scan.Scan() || scan.Text() != "," {
+			break
+		}
+	}
+	return nil
+}
+
+// quoteIdent returns the replacement for a renamed identifier, quoted
+// for the statement's dialect. Each part of a schema-qualified replacement
+// that is quoted, using any of the quoting styles, is re-quoted for the
+// dialect. If quoteAll is true, the identifier was quoted in the query, so
+// every part of the replacement is quoted.
+func (stmt *Stmt) quoteIdent(ident string, quoteAll bool) string {
+	scan := scanner.New(strings.NewReader(ident))
+	var buf bytes.Buffer
+	for scan.Scan() {
+		tok, lit := scan.Token(), scan.Text()
+		if tok == scanner.IDENT && scanner.IsQuoted(lit) {
+			buf.WriteString(stmt.dialect.Quote(scanner.Unquote(lit)))
+		} else if tok == scanner.IDENT && quoteAll {
+			buf.WriteString(stmt.dialect.Quote(stmt.schema.foldIdent(lit)))
+		} else if tok == scanner.IDENT {
+			buf.WriteString(stmt.schema.foldIdent(lit))
+		} else {
+			buf.WriteString(lit)
+		}
+	}
+	return buf.String()
+}
+
 func (stmt *Stmt) addInputColumns(cols columnList) {
 	if cols.clause.isInput() {
 		for _, col := range cols.filtered() {
@@ -478,22 +1096,62 @@ func (stmt *Stmt) getArgs(row interface{}, argv []interface{}) ([]interface{}, e
 	for _, input := range stmt.inputs {
 		if input.col != nil {
 			colVal := input.col.info.Index.ValueRO(rowVal)
-			if input.col.JSON() {
-				// marshal field contents into JSON and pass as a byte array
+			if stmt.queryType == queryInsert || stmt.queryType == queryUpdate {
+				// check before sending to the database, as this gives
+				// a clearer error than a constraint violation
+				if err := input.col.checkEnum(colVal); err != nil {
+					return nil, err
+				}
+			}
+			if input.col.JSON() || input.col.codec != nil {
+				// marshal field contents into JSON (or using the column's
+				// codec) and pass as a byte array
 				valueRO := colVal.Interface()
 				if input.col.EmptyNull() && reflect.DeepEqual(valueRO, input.col.zeroValue) {
 					args = append(args, nil)
 				} else if valueRO == nil {
 					args = append(args, nil)
+				} else if input.col.codec != nil && colVal.Kind() == reflect.Ptr && colVal.IsNil() {
+					// a nil pointer is stored as NULL, rather than
+					// passing it to the codec
+					args = append(args, nil)
 				} else {
-					data, err := json.Marshal(valueRO)
+					data, err := input.col.marshal(valueRO)
 					if err != nil {
 						// TODO(jpj): if errors.Wrap makes it into the stdlib, use it here
 						err = fmt.Errorf("cannot marshal field %q: %v", input.col.info.Field.Name, err)
 						return nil, err
 					}
-					args = append(args, data)
+					if input.col.gzip {
+						if data, err = gzipData(data); err != nil {
+							return nil, fmt.Errorf("cannot compress field %q: %v", input.col.info.Field.Name, err)
+						}
+					}
+					if input.col.jsonb && isPostgres(stmt.dialect) {
+						// text, so the value can be cast to jsonb
+						args = append(args, string(data))
+					} else {
+						args = append(args, data)
+					}
+				}
+			} else if input.col.array {
+				value, err := newPGArrayCell(input.col.info.Field.Name, colVal).Value()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, value)
+			} else if input.col.hstore {
+				value, err := newHstoreCell(input.col.info.Field.Name, colVal).Value()
+				if err != nil {
+					return nil, err
 				}
+				args = append(args, value)
+			} else if input.col.intBool {
+				args = append(args, intBoolArg(colVal, input.col.EmptyNull()))
+			} else if input.col.date {
+				args = append(args, dateArg(colVal, input.col.EmptyNull()))
+			} else if input.col.epoch != 0 {
+				args = append(args, epochArg(colVal, input.col.EmptyNull(), input.col.epoch))
 			} else if input.col.EmptyNull() {
 				// TODO: store zero value with the column
 				zero := reflect.Zero(colVal.Type()).Interface()
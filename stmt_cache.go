@@ -1,7 +1,9 @@
 package sqlr
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 )
 
@@ -9,27 +11,67 @@ import (
 type stmtCache struct {
 	mu    sync.RWMutex
 	stmts map[stmtKey]*Stmt
+
+	// Statements for queries that do not involve a row, such as those
+	// passed to Session.Exec and Session.Query, keyed by the query text.
+	// These are very common, so they are looked up without needing to
+	// determine the row type or convert the query first.
+	raw map[string]*Stmt
 }
 
 // stmtKey is the unique key used to identify statements within
 // a statement cache. Note that two statements with the same key might
 // be different for different schemas, as  the dialects and/or naming conventions
-// could be different.
+// could be different. The convention is only set for statements prepared with
+// an alternate naming convention.
 type stmtKey struct {
-	rowType reflect.Type
-	query   string
+	rowType    reflect.Type
+	query      string
+	convention NamingConvention
 }
 
 func (c *stmtCache) clear() {
 	c.mu.Lock()
 	c.stmts = nil
+	c.raw = nil
 	c.mu.Unlock()
 }
 
-func (c *stmtCache) lookup(rowType reflect.Type, query string) (*Stmt, bool) {
+// len returns the number of statements in the cache.
+func (c *stmtCache) len() int {
+	c.mu.RLock()
+	n := len(c.stmts) + len(c.raw)
+	c.mu.RUnlock()
+	return n
+}
+
+// keys returns a description of the key of each statement in the cache,
+// in sorted order. Statements for a row type are described as the row type
+// followed by the query, and statements that do not involve a row are
+// described by the query alone.
+func (c *stmtCache) keys() []string {
+	c.mu.RLock()
+	keys := make([]string, 0, len(c.stmts)+len(c.raw))
+	for key := range c.stmts {
+		s := fmt.Sprintf("%s: %s", key.rowType, key.query)
+		if key.convention != nil {
+			s += fmt.Sprintf(" (convention %T)", key.convention)
+		}
+		keys = append(keys, s)
+	}
+	for query := range c.raw {
+		keys = append(keys, query)
+	}
+	c.mu.RUnlock()
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *stmtCache) lookup(rowType reflect.Type, query string, convention NamingConvention) (*Stmt, bool) {
 	key := stmtKey{
-		rowType: rowType,
-		query:   query,
+		rowType:    rowType,
+		query:      query,
+		convention: convention,
 	}
 	c.mu.RLock()
 	stmt, ok := c.stmts[key]
@@ -40,10 +82,11 @@ func (c *stmtCache) lookup(rowType reflect.Type, query string) (*Stmt, bool) {
 // set the statement for the given rowType and query string. Returns the statement,
 // which could be different from the input statement if another goroutine has already
 // set a statement for the same row type and query.
-func (c *stmtCache) set(rowType reflect.Type, query string, stmt *Stmt) *Stmt {
+func (c *stmtCache) set(rowType reflect.Type, query string, convention NamingConvention, stmt *Stmt) *Stmt {
 	key := stmtKey{
-		rowType: rowType,
-		query:   query,
+		rowType:    rowType,
+		query:      query,
+		convention: convention,
 	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -58,3 +101,28 @@ func (c *stmtCache) set(rowType reflect.Type, query string, stmt *Stmt) *Stmt {
 	}
 	return stmt
 }
+
+// lookupRaw returns the statement for a query that does not involve a row.
+func (c *stmtCache) lookupRaw(query string) (*Stmt, bool) {
+	c.mu.RLock()
+	stmt, ok := c.raw[query]
+	c.mu.RUnlock()
+	return stmt, ok
+}
+
+// setRaw sets the statement for a query that does not involve a row. Like set,
+// it returns the statement in the cache, which might have been set by another
+// goroutine.
+func (c *stmtCache) setRaw(query string, stmt *Stmt) *Stmt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.raw == nil {
+		c.raw = make(map[string]*Stmt)
+	}
+	if existing, ok := c.raw[query]; ok {
+		stmt = existing
+	} else {
+		c.raw[query] = stmt
+	}
+	return stmt
+}
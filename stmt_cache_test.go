@@ -0,0 +1,206 @@
+package sqlr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSelectUncached(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	db := &FakeDB{queryErr: errors.New("query error")}
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	for i := 0; i < 10; i++ {
+		var rows []*Row
+		query := fmt.Sprintf("select {} from rows where id = %d", i)
+		if _, err := sess.SelectUncached(&rows, query); err == nil {
+			t.Fatal("got=nil, want=error")
+		}
+	}
+	if got, want := schema.cache.len(), 0; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+
+	var rows []*Row
+	if _, err := sess.Select(&rows, "select {} from rows where id = 1"); err == nil {
+		t.Fatal("got=nil, want=error")
+	}
+	if got, want := schema.cache.len(), 1; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+}
+
+func TestPrepareUncached(t *testing.T) {
+	type Row struct {
+		ID int64 `sql:"primary key"`
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	stmt1, err := schema.prepare(Row{}, "select {} from rows", nil, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stmt2, err := schema.Prepare(Row{}, "select {} from rows")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stmt1 == stmt2 {
+		t.Error("uncached statement should not be shared")
+	}
+	if got, want := stmt1.String(), stmt2.String(); got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestRawQueryCache(t *testing.T) {
+	schema := NewSchema(WithDialect(Postgres))
+	db := &FakeDB{}
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := sess.Exec("delete from rows where id = ?", 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got, want := schema.cache.len(), 1; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+
+	// Exec and Query use the same statement for the same query text
+	stmt1, err := schema.Prepare(&struct{}{}, "delete from rows where id = ?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stmt2, err := schema.Prepare(struct{}{}, "delete from rows where id = ?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stmt3, err := schema.Prepare(nil, "delete from rows where id = ?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stmt1 != stmt2 || stmt1 != stmt3 {
+		t.Error("expected raw query statements to be shared")
+	}
+	if got, want := stmt1.String(), "delete from rows where id = $1"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	// different query text is a different statement
+	stmt4, err := schema.Prepare(nil, "delete from rows where id = $1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stmt4 == stmt1 {
+		t.Error("expected different statements for different queries")
+	}
+
+	// a named empty struct does not share the raw query bucket
+	type Empty struct{}
+	stmt5, err := schema.Prepare(Empty{}, "delete from rows where id = ?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stmt5 == stmt1 {
+		t.Error("expected different statements for different row types")
+	}
+	if got, want := schema.cache.len(), 3; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+
+	schema.cache.clear()
+	if got, want := schema.cache.len(), 0; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+}
+
+func TestCacheKeys(t *testing.T) {
+	type Row struct {
+		ID int64 `sql:"primary key"`
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	db := &FakeDB{}
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	if got := schema.CacheKeys(); len(got) != 0 {
+		t.Errorf("got=%q, want empty", got)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := sess.Exec(fmt.Sprintf("delete from rows where id = %d", i)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := schema.Prepare(Row{}, "select {} from rows"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := schema.Prepare(&Row{}, "select {} from rows"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := schema.prepare(Row{}, "select {} from rows where {}", nil, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"delete from rows where id = 0",
+		"delete from rows where id = 1",
+		"sqlr.Row: select {} from rows",
+	}
+	if got := schema.CacheKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%q\nwant=%q", got, want)
+	}
+}
+
+func TestCacheAudit(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	schema := NewSchema(WithDialect(Postgres), WithCacheAudit())
+	queries := []struct {
+		row        interface{}
+		query      string
+		convention NamingConvention
+	}{
+		{row: Row{}, query: "select {} from rows where {}"},
+		{row: &Row{}, query: "update rows set {} where {}"},
+		{row: Row{}, query: "select {} from rows", convention: SameCase},
+		{query: "select count(*) from rows where id = ?"},
+	}
+	for i := 0; i < 2; i++ {
+		for _, q := range queries {
+			var err error
+			if q.convention != nil {
+				_, err = schema.PrepareWithConvention(q.row, q.query, q.convention)
+			} else {
+				_, err = schema.Prepare(q.row, q.query)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	// corrupt the cache to check that the audit detects a mismatch
+	other := NewSchema(WithDialect(MySQL))
+	stmt, err := other.Prepare(Row{}, "select {} from rows where {}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	schema.cache.clear()
+	schema.cache.set(reflect.TypeOf(Row{}), "select {} from rows where {}", nil, stmt)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("got=nil, want=panic")
+		}
+	}()
+	schema.Prepare(Row{}, "select {} from rows where {}")
+}
@@ -1,6 +1,8 @@
 package sqlr
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 	"testing"
 )
@@ -197,6 +199,57 @@ func TestPrepare(t *testing.T) {
 				"postgres": `select "id", "hash", "name", "count" from "xxx" where "id" = $1 and "hash" = $2`,
 			},
 		},
+		{
+			row: struct {
+				ID      int64 `sql:"primary key autoincrement"`
+				Version int   `sql:"version"`
+				Name    string
+			}{},
+			sql: "select {all} from tbl where {pk}",
+			queries: map[string]string{
+				"mysql":    "select `id`, `version`, `name` from tbl where `id` = ?",
+				"postgres": `select "id", "version", "name" from tbl where "id" = $1`,
+			},
+		},
+		{
+			row: struct {
+				ID      int64 `sql:"primary key autoincrement"`
+				Version int   `sql:"version"`
+				Name    string
+			}{},
+			sql: "select {all, alias t} from tbl t order by {alias t}",
+			queries: map[string]string{
+				"mysql":    "select t.`id`, t.`version`, t.`name` from tbl t order by t.`id`",
+				"postgres": `select t."id", t."version", t."name" from tbl t order by t."id"`,
+			},
+		},
+		{
+			sql: "select 1",
+			queries: map[string]string{
+				"mysql":    "select 1",
+				"postgres": "select 1",
+			},
+		},
+		{
+			sql: "select now()",
+			queries: map[string]string{
+				"mysql":    "select now()",
+				"postgres": "select now()",
+			},
+		},
+		{
+			sql: "select current_timestamp",
+			queries: map[string]string{
+				"mysql":    "select current_timestamp",
+				"postgres": "select current_timestamp",
+			},
+		},
+		{
+			sql: "select nextval('seq')",
+			queries: map[string]string{
+				"postgres": "select nextval('seq')",
+			},
+		},
 	}
 
 	for i, tt := range tests {
@@ -214,3 +267,571 @@ func TestPrepare(t *testing.T) {
 		}
 	}
 }
+
+func TestEnumArgs(t *testing.T) {
+	type Row struct {
+		ID     int64  `sql:"primary key"`
+		Status string `sql:"enum=active|inactive|pending"`
+		Kind   string `sql:"enum=a|b null"`
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	tests := []struct {
+		sql     string
+		row     Row
+		errText string
+	}{
+		{
+			sql: "insert into rows",
+			row: Row{ID: 1, Status: "active", Kind: "a"},
+		},
+		{
+			sql: "update rows",
+			row: Row{ID: 1, Status: "pending"},
+		},
+		{
+			sql:     "insert into rows",
+			row:     Row{ID: 1, Status: "deleted"},
+			errText: `invalid value "deleted" for field "Status": must be one of active, inactive, pending`,
+		},
+		{
+			sql:     "update rows",
+			row:     Row{ID: 1, Status: "active", Kind: "c"},
+			errText: `invalid value "c" for field "Kind": must be one of a, b`,
+		},
+		{
+			// only checked for insert and update
+			sql: "delete from rows where {}",
+			row: Row{ID: 1, Status: "deleted"},
+		},
+	}
+	for i, tt := range tests {
+		stmt, err := schema.Prepare(tt.row, tt.sql)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		_, err = stmt.getArgs(&tt.row, nil)
+		var errText string
+		if err != nil {
+			errText = err.Error()
+		}
+		if got, want := errText, tt.errText; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestStmtQueryType(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{sql: "select {} from rows where {}", want: "select"},
+		{sql: "select rows", want: "select"},
+		{sql: "insert into rows({}) values({})", want: "insert"},
+		{sql: "insert rows", want: "insert"},
+		{sql: "update rows set {} where {}", want: "update"},
+		{sql: "update rows", want: "update"},
+		{sql: "delete from rows where {}", want: "delete"},
+		{sql: "create table xyz(id int)", want: "unknown"},
+	}
+	for i, tt := range tests {
+		stmt, err := schema.Prepare(Row{}, tt.sql)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := stmt.QueryType(), tt.want; got != want {
+			t.Errorf("%d: %s: got=%q, want=%q", i, tt.sql, got, want)
+		}
+	}
+}
+
+func TestRenameIdent(t *testing.T) {
+	type User struct {
+		UserID int `sql:"primary key"`
+		Name   string
+	}
+	tests := []struct {
+		opts  []SchemaOption
+		query string
+		want  string
+	}{
+		{
+			// replacement quoted for a different dialect is re-quoted
+			opts:  []SchemaOption{WithDialect(Postgres), WithIdentifier("[User]", "users")},
+			query: "select {} from users where {}",
+			want:  `select "user_id", "name" from "User" where "user_id" = $1`,
+		},
+		{
+			opts:  []SchemaOption{WithDialect(MySQL), WithIdentifier(`"User"`, "users")},
+			query: "select {} from users where {}",
+			want:  "select `user_id`, `name` from `User` where `user_id` = ?",
+		},
+		{
+			opts:  []SchemaOption{WithDialect(MSSQL), WithIdentifier("[User]", "users")},
+			query: "select {} from users where {}",
+			want:  "select [user_id], [name] from [User] where [user_id] = ?",
+		},
+		{
+			// quoted identifier in the query is renamed and quoted once
+			opts:  []SchemaOption{WithDialect(MSSQL), WithIdentifier("[User]", "users")},
+			query: `select {} from "users" where {}`,
+			want:  "select [user_id], [name] from [User] where [user_id] = ?",
+		},
+		{
+			// quoted identifier with an unquoted replacement
+			opts:  []SchemaOption{WithDialect(Postgres), WithIdentifier("User", "users")},
+			query: "select {} from `users` where {}",
+			want:  `select "user_id", "name" from "User" where "user_id" = $1`,
+		},
+		{
+			// schema-qualified replacement
+			opts:  []SchemaOption{WithDialect(MSSQL), WithIdentifier("dbo.[User]", "users")},
+			query: "select {} from users where {}",
+			want:  "select [user_id], [name] from dbo.[User] where [user_id] = ?",
+		},
+		{
+			// schema-qualified identifier in the query
+			opts:  []SchemaOption{WithDialect(Postgres), WithIdentifier("users", "dbo.[User]")},
+			query: "select {} from dbo.[User] where {}",
+			want:  `select "user_id", "name" from users where "user_id" = $1`,
+		},
+		{
+			// schema-qualified identifier in the query with a qualified replacement
+			opts:  []SchemaOption{WithDialect(Postgres), WithIdentifier("app.users", "dbo.User")},
+			query: "select {} from dbo.User u where {}",
+			want:  `select "user_id", "name" from app.users u where "user_id" = $1`,
+		},
+		{
+			// only the table part of a qualified name is renamed
+			opts:  []SchemaOption{WithDialect(Postgres), WithIdentifier("users", "User")},
+			query: "select {} from app.User where {}",
+			want:  `select "user_id", "name" from app.users where "user_id" = $1`,
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(tt.opts...)
+		stmt, err := schema.Prepare(User{}, tt.query)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := stmt.String(), tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestInsertCountMismatch(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+		Age  int
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	tests := []struct {
+		sql     string
+		errText string
+	}{
+		{
+			sql: "insert into rows({}) values({})",
+		},
+		{
+			sql: "insert into rows({exclude age}) values({})",
+		},
+		{
+			sql: "insert into rows({}, extra) values({}, ?)",
+		},
+		{
+			sql: "insert into rows(id, name) values(?, coalesce(?, 'x')), (?, ?)",
+		},
+		{
+			sql: "insert into rows(id, name) select id, name from other_rows",
+		},
+		{
+			sql:     "insert into rows({}) values(?, ?)",
+			errText: "insert has 3 columns but 2 values in row 1 of the values clause",
+		},
+		{
+			sql:     "insert into rows({exclude age}) values({}, ?)",
+			errText: "insert has 2 columns but 3 values in row 1 of the values clause",
+		},
+		{
+			sql:     "insert into rows(id, {exclude id}) values({})",
+			errText: "insert has 3 columns but 2 values in row 1 of the values clause",
+		},
+		{
+			sql:     "insert into rows(id, name) values(?, ?), (?)",
+			errText: "insert has 2 columns but 1 values in row 2 of the values clause",
+		},
+	}
+	for i, tt := range tests {
+		_, err := schema.Prepare(Row{}, tt.sql)
+		var errText string
+		if err != nil {
+			errText = err.Error()
+		}
+		if got, want := errText, tt.errText; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestInterleavedArgs(t *testing.T) {
+	type Row struct {
+		ID       int64 `sql:"primary key"`
+		TenantID int64 `sql:"primary key"`
+		Name     string
+		Age      int
+	}
+	row := Row{ID: 1, TenantID: 2, Name: "name", Age: 42}
+	tests := []struct {
+		dialect Dialect
+		sql     string
+		argv    []interface{}
+		want    string
+		args    []interface{}
+	}{
+		{
+			dialect: Postgres,
+			sql:     "update rows set {}, modified_by = ? where {} and age < ? and name <> ?",
+			argv:    []interface{}{"user", 100, "x"},
+			want:    `update rows set "name" = $1, "age" = $2, modified_by = $3 where "id" = $4 and "tenant_id" = $5 and age < $6 and name <> $7`,
+			args:    []interface{}{"name", 42, "user", int64(1), int64(2), 100, "x"},
+		},
+		{
+			dialect: MySQL,
+			sql:     "update rows set modified_by = ?, {} where age < ? and {} and name <> ?",
+			argv:    []interface{}{"user", 100, "x"},
+			want:    "update rows set modified_by = ?, `name` = ?, `age` = ? where age < ? and `id` = ? and `tenant_id` = ? and name <> ?",
+			args:    []interface{}{"user", "name", 42, 100, int64(1), int64(2), "x"},
+		},
+		{
+			dialect: Postgres,
+			sql:     "update rows set {exclude age}, modified_by = $2 where {} and age < $1 and modified_by <> $2",
+			argv:    []interface{}{100, "user"},
+			want:    `update rows set "name" = $1, modified_by = $2 where "id" = $3 and "tenant_id" = $4 and age < $5 and modified_by <> $6`,
+			args:    []interface{}{"name", "user", int64(1), int64(2), 100, "user"},
+		},
+		{
+			dialect: MSSQL,
+			sql:     "update rows set {} where {alias r} and age between ? and ?",
+			argv:    []interface{}{10, 20},
+			want:    "update rows set [name] = ?, [age] = ? where r.[id] = ? and r.[tenant_id] = ? and age between ? and ?",
+			args:    []interface{}{"name", 42, int64(1), int64(2), 10, 20},
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		stmt, err := schema.Prepare(row, tt.sql)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := stmt.String(), tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		args, err := stmt.getArgs(&row, tt.argv)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := args, tt.args; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+	}
+
+	// cannot mix numbered and unnumbered placeholders
+	_, err := NewSchema(WithDialect(Postgres)).Prepare(row, "update rows set {} where {} and age < $1 and name <> ?")
+	if err == nil {
+		t.Error("got=nil, want=error")
+	}
+}
+
+func TestFieldPlaceholder(t *testing.T) {
+	type Address struct {
+		Street string
+	}
+	type Row struct {
+		ID      int64 `sql:"primary key"`
+		A       int
+		Delta   int
+		Address Address
+	}
+	row := Row{ID: 7, A: 11, Delta: 3, Address: Address{Street: "Main St"}}
+	tests := []struct {
+		dialect Dialect
+		sql     string
+		argv    []interface{}
+		want    string
+		args    []interface{}
+	}{
+		{
+			dialect: Postgres,
+			sql:     "update t set a = {field A}, b = a + {field Delta} where {}",
+			want:    `update t set a = $1, b = a + $2 where "id" = $3`,
+			args:    []interface{}{11, 3, int64(7)},
+		},
+		{
+			dialect: MySQL,
+			sql:     "update t set b = b - {field Delta}, street = {field Address.Street} where id = {field ID} and b > ?",
+			argv:    []interface{}{0},
+			want:    "update t set b = b - ?, street = ? where id = ? and b > ?",
+			args:    []interface{}{3, "Main St", int64(7), 0},
+		},
+		{
+			dialect: Postgres,
+			sql:     "update t set a = $1 + {FIELD A} where {}",
+			argv:    []interface{}{100},
+			want:    `update t set a = $1 + $2 where "id" = $3`,
+			args:    []interface{}{100, 11, int64(7)},
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		stmt, err := schema.Prepare(row, tt.sql)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := stmt.String(), tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		args, err := stmt.getArgs(&row, tt.argv)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got, want := args, tt.args; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+	}
+
+	errorTests := []struct {
+		sql  string
+		want string
+	}{
+		{
+			sql:  "update t set a = {field Missing} where {}",
+			want: `unknown field "Missing" in "{field Missing}" at line 1, column 18`,
+		},
+		{
+			sql:  "select {} from t where a = {field A}",
+			want: `cannot use "{field A}" in a select query at line 1, column 28`,
+		},
+	}
+	for i, tt := range errorTests {
+		schema := NewSchema(WithDialect(Postgres))
+		_, err := schema.Prepare(row, tt.sql)
+		if err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+			continue
+		}
+		if got, want := err.Error(), tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestPrepareErrorPosition(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{
+			sql: `
+				select {}
+				from rows
+				where name = ?
+				order by {alias r dodgy¥}`,
+			want: `cannot expand "alias r dodgy¥" in "select order by" clause: unrecognised input near "¥" at line 5, column 14`,
+		},
+		{
+			sql: `select {}
+				from rows
+				where name = 'unterminated`,
+			want: `unrecognised input near "'unterminated" at line 3, column 18`,
+		},
+		{
+			sql: `update rows
+				set name = $1
+				where id = ?`,
+			want: `cannot mix numbered and unnumbered placeholders in "update rows\n\t\t\t\tset name = $1\n\t\t\t\twhere id = ?" at line 3, column 16`,
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(Postgres))
+		_, err := schema.Prepare(Row{}, tt.sql)
+		if err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+			continue
+		}
+		if got, want := err.Error(), tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestOrdinalColumns(t *testing.T) {
+	type Row struct {
+		Count int64
+		Label string
+	}
+	schema := NewSchema(WithDialect(Postgres))
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "select {ordinal} count(*), max(name) from users",
+			want:  "select count(*), max(name) from users",
+		},
+		{
+			query: "{ordinal} select 1, 'x'",
+			want:  "select 1, 'x'",
+		},
+	}
+	for i, tt := range tests {
+		stmt, err := schema.Prepare(Row{}, tt.query)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if got := stmt.String(); got != tt.want {
+			t.Errorf("%d: got=%q, want=%q", i, got, tt.want)
+		}
+		if !stmt.ordinal {
+			t.Errorf("%d: got=false, want=true", i)
+		}
+	}
+
+	// duplicate and empty column names are scanned by position
+	for _, columns := range [][]string{{"x", "x"}, {"", ""}, {"label", "count"}} {
+		db := rowsDBWithColumns(t, 2, columns...)
+		sess := NewSession(context.Background(), db, schema)
+		var rows []Row
+		_, err := sess.Select(&rows, "select {ordinal} 1, 2")
+		wantNoError(t, err)
+		if got, want := fmt.Sprint(rows), "[{1 name} {2 name}]"; got != want {
+			t.Errorf("%q: got=%q, want=%q", columns, got, want)
+		}
+		db.Close()
+	}
+
+	// without the token, duplicate names are an error
+	db := rowsDBWithColumns(t, 2, "x", "x")
+	defer db.Close()
+	sess := NewSession(context.Background(), db, schema)
+	var rows []Row
+	if _, err := sess.Select(&rows, "select 1, 2"); err == nil {
+		t.Error("got=nil, want=error")
+	}
+
+	// the column count must match
+	db3 := rowsDBWithColumns(t, 2, "count")
+	defer db3.Close()
+	sess = NewSession(context.Background(), db3, schema)
+	_, err := sess.Select(&rows, "select {ordinal} 1")
+	if got, want := fmt.Sprint(err), "query returned 1 columns, expected 2 columns for ordinal mapping"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestTableToken(t *testing.T) {
+	type UserAccount struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	tests := []struct {
+		options []SchemaOption
+		query   string
+		want    string
+	}{
+		{
+			query: "select {} from {table} where id = ?",
+			want:  `select "id", "name" from "user_account" where id = $1`,
+		},
+		{
+			query: "select {alias u} from {table u} where u.id in (select id from {table} where name = ?)",
+			want:  `select u."id", u."name" from "user_account" u where u.id in (select id from "user_account" where name = $1)`,
+		},
+		{
+			query: `update {TABLE "U"} set {} where {}`,
+			want:  `update "user_account" "U" set "name" = $1 where "id" = $2`,
+		},
+		{
+			options: []SchemaOption{WithDialect(MySQL)},
+			query:   "delete from {table} where {}",
+			want:    "delete from `user_account` where `id` = ?",
+		},
+		{
+			options: []SchemaOption{WithNamingConvention(SameCase)},
+			query:   "select {} from {table}",
+			want:    `select "ID", "Name" from "UserAccount"`,
+		},
+		{
+			options: []SchemaOption{WithTables(TablesConfig{
+				reflect.TypeOf(UserAccount{}): {TableName: "app.accounts"},
+			})},
+			query: "select {} from {table a}",
+			want:  `select "id", "name" from "app"."accounts" a`,
+		},
+		{
+			options: []SchemaOption{WithPluralizer(func(name string) string { return name + "s" })},
+			query:   "select {} from {table}",
+			want:    `select "id", "name" from "user_accounts"`,
+		},
+		{
+			options: []SchemaOption{WithIdentifierCase(FoldUpper)},
+			query:   "select {} from {table}",
+			want:    `SELECT "ID", "NAME" FROM "USER_ACCOUNT"`,
+		},
+		{
+			options: []SchemaOption{
+				WithIdentifierCase(FoldUpper),
+				WithTables(TablesConfig{
+					reflect.TypeOf(UserAccount{}): {TableName: "app.accounts"},
+				}),
+			},
+			query: "select {} from {table}",
+			want:  `SELECT "ID", "NAME" FROM "APP"."ACCOUNTS"`,
+		},
+	}
+	for i, tt := range tests {
+		options := append([]SchemaOption{WithDialect(Postgres)}, tt.options...)
+		schema := NewSchema(options...)
+		stmt, err := schema.Prepare(UserAccount{}, tt.query)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if got := stmt.String(); got != tt.want {
+			t.Errorf("%d: got=%q, want=%q", i, got, tt.want)
+		}
+	}
+
+	schema := NewSchema(WithDialect(Postgres))
+	if _, err := schema.Prepare(UserAccount{}, "select {} from {table a b}"); err == nil {
+		t.Error("want error for invalid table token, got nil")
+	}
+	// the table name of a sharded table depends on the row
+	schema = NewSchema(WithDialect(Postgres), WithTables(TablesConfig{
+		reflect.TypeOf(UserAccount{}): {
+			ShardFunc: func(row interface{}) string { return "user_account_0" },
+		},
+	}))
+	_, err := schema.Prepare(UserAccount{}, "select {} from {table}")
+	if got, want := fmt.Sprint(err), `cannot expand "{table}" for sqlr.UserAccount, which has a ShardFunc at line 1, column 16`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
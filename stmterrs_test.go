@@ -16,9 +16,11 @@ type FakeDB struct {
 	lastInsertId    int64
 	lastInsertIdErr error
 	queryErr        error
+	queries         []string // queries received, in order
 }
 
 func (db *FakeDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	db.queries = append(db.queries, query)
 	if db.execErr != nil {
 		return nil, db.execErr
 	}
@@ -38,6 +40,7 @@ func (db *FakeDB) RowsAffected() (int64, error) {
 }
 
 func (db *FakeDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	db.queries = append(db.queries, query)
 	return nil, db.queryErr
 }
 
@@ -83,12 +86,12 @@ func TestSelectStmt1Errors(t *testing.T) {
 		{
 			row:        Row{},
 			sql:        "select {} from {} where {}",
-			errPrepare: `cannot expand "{}" in "select from" clause`,
+			errPrepare: `cannot expand "{}" in "select from" clause at line 1, column 16`,
 		},
 		{
 			row:        Row{},
 			sql:        "select {dodgy¥} from xx where {}",
-			errPrepare: `cannot expand "dodgy¥" in "select columns" clause: unrecognised input near "¥"`,
+			errPrepare: `cannot expand "dodgy¥" in "select columns" clause: unrecognised input near "¥" at line 1, column 8`,
 		},
 	}
 
@@ -168,7 +171,7 @@ func TestSelectStmt2Errors(t *testing.T) {
 		{
 			dest:       &validRows,
 			sql:        "select {} from table {} where {}",
-			errPrepare: `cannot expand "{}" in "select from" clause`,
+			errPrepare: `cannot expand "{}" in "select from" clause at line 1, column 22`,
 			args:       []interface{}{},
 		},
 		{
@@ -233,12 +236,12 @@ func TestInsertRowStmtErrors(t *testing.T) {
 		{
 			sql:     "insert into table values {}",
 			row:     &Row{},
-			errText: `cannot expand "insert values" clause because "insert columns" clause is missing`,
+			errText: `cannot expand "insert values" clause because "insert columns" clause is missing at line 1, column 26`,
 		},
 		{
 			sql:     "insert into table({}) values({all})",
 			row:     &Row{},
-			errText: `columns for "insert values" clause must match the "insert columns" clause`,
+			errText: `columns for "insert values" clause must match the "insert columns" clause at line 1, column 30`,
 		},
 	}
 
@@ -282,7 +285,7 @@ func TestExecRowStmtErrors(t *testing.T) {
 		{
 			sql:     "update table {}",
 			row:     &Row{},
-			errText: `cannot expand "{}" in "update table" clause`,
+			errText: `cannot expand "{}" in "update table" clause at line 1, column 14`,
 		},
 	}
 
@@ -328,15 +331,15 @@ func TestInvalidStmts(t *testing.T) {
 		},
 		{
 			fn:   func() (interface{}, error) { return sess.Row(&row).Exec("insert into xyz values({})") },
-			want: `cannot expand "insert values" clause because "insert columns" clause is missing`,
+			want: `cannot expand "insert values" clause because "insert columns" clause is missing at line 1, column 24`,
 		},
 		{
 			fn:   func() (interface{}, error) { return sess.Row(&row).Exec("insert into xyz({}) values({pk})") },
-			want: `columns for "insert values" clause must match the "insert columns" clause`,
+			want: `columns for "insert values" clause must match the "insert columns" clause at line 1, column 28`,
 		},
 		{
 			fn:   func() (interface{}, error) { return sess.Row(&row).Exec("update {} this is not valid SQL") },
-			want: `cannot expand "{}" in "update table" clause`,
+			want: `cannot expand "{}" in "update table" clause at line 1, column 8`,
 		},
 		{
 			fn:   func() (interface{}, error) { return sess.Row(&row).Exec("update rows set {} where {} and number=?") },
@@ -348,11 +351,11 @@ func TestInvalidStmts(t *testing.T) {
 		},
 		{
 			fn:   func() (interface{}, error) { return sess.Row(&row).Exec("select {alias} from rows") },
-			want: `cannot expand "alias" in "select columns" clause: missing ident after 'alias'`,
+			want: `cannot expand "alias" in "select columns" clause: missing ident after 'alias' at line 1, column 8`,
 		},
 		{
 			fn:   func() (interface{}, error) { return sess.Select(&row, "select {'col1} from rows") },
-			want: `cannot expand "'col1" in "select columns" clause: unrecognised input near "'col1"`,
+			want: `cannot expand "'col1" in "select columns" clause: unrecognised input near "'col1" at line 1, column 8`,
 		},
 		{
 			fn:   func() (interface{}, error) { return sess.Select(&notRow, "select {} from rows") },
@@ -1,8 +1,12 @@
 package sqlr
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/jjeffery/kv"
 	"github.com/jjeffery/sqlr/private/column"
@@ -20,9 +24,16 @@ type Table struct {
 	pk        []*Column
 	nk        []*Column
 	autoincr  *Column
+	generated []*Column
 	createdAt *Column
 	updatedAt *Column
 	version   *Column
+	deletedAt *Column
+	cfg       *TableConfig // configuration supplied when the schema was created, if any
+
+	// columns keyed by lower-case column name, only used when the schema
+	// matches column names case-insensitively
+	lowerColumns map[string]*Column
 }
 
 // getRowType converts a row instance into a row type.
@@ -67,7 +78,11 @@ func getTableName(schema *Schema, rowType reflect.Type, cfg *TableConfig) string
 		if convention == nil {
 			convention = defaultNamingConvention
 		}
-		return convention.TableName(rowTypeName)
+		tableName := convention.TableName(rowTypeName)
+		if schema.pluralizer != nil {
+			tableName = schema.pluralizer(tableName)
+		}
+		return tableName
 	}
 
 	// anonymous type: table name cannot be determined
@@ -76,18 +91,29 @@ func getTableName(schema *Schema, rowType reflect.Type, cfg *TableConfig) string
 
 // newTable returns a new Table value for the row type. If cfg is non-nil,
 // then it must have already been checked for any inconsistencies.
-func newTable(schema *Schema, rowType reflect.Type, cfg *TableConfig) *Table {
-	columnNamer := schema.columnNamer()
+// If convention is non-nil, it is used for naming columns instead of
+// the schema's naming convention.
+func newTable(schema *Schema, rowType reflect.Type, cfg *TableConfig, convention NamingConvention) *Table {
+	var stripPrefix string
+	if cfg != nil {
+		stripPrefix = cfg.StripFieldPrefix
+	}
+	columnNamer := schema.columnNamer(convention, stripPrefix)
 	tbl := &Table{
 		schema:    schema,
 		rowType:   rowType,
-		tableName: getTableName(schema, rowType, cfg),
+		tableName: schema.foldIdent(getTableName(schema, rowType, cfg)),
+		cfg:       cfg,
 	}
 
-	for _, colInfo := range column.ListForType(rowType) {
+	for _, colInfo := range listColumns(schema, rowType, cfg) {
 		if colInfo.Tag.Ignore {
 			continue
 		}
+		if schema.protobuf && strings.HasPrefix(colInfo.Field.Name, "XXX_") {
+			// internal state of a message generated by older versions of protoc-gen-go
+			continue
+		}
 		var colConfig ColumnConfig
 		var hasColConfig bool
 		if cfg != nil {
@@ -96,6 +122,10 @@ func newTable(schema *Schema, rowType reflect.Type, cfg *TableConfig) *Table {
 		if colConfig.Ignore {
 			continue
 		}
+		if !dialectMatches(schema.getDialect(), colInfo.Tag.Dialects) {
+			// column does not exist for this database
+			continue
+		}
 
 		col := &Column{
 			columnName:    columnNamer.ColumnName(colInfo),
@@ -106,6 +136,7 @@ func newTable(schema *Schema, rowType reflect.Type, cfg *TableConfig) *Table {
 			json:          colInfo.Tag.JSON,
 			naturalKey:    colInfo.Tag.NaturalKey,
 			version:       colInfo.Tag.Version,
+			generated:     colInfo.Tag.Generated,
 			zeroValue:     reflect.Zero(colInfo.Field.Type).Interface(),
 		}
 
@@ -119,15 +150,49 @@ func newTable(schema *Schema, rowType reflect.Type, cfg *TableConfig) *Table {
 				col.emptyNull = colConfig.EmptyNull
 				col.json = colConfig.JSON
 				col.naturalKey = colConfig.NaturalKey
+				col.generated = colConfig.Generated
 			} else {
 				col.primaryKey = col.primaryKey || colConfig.PrimaryKey
 				col.autoIncrement = col.autoIncrement || colConfig.AutoIncrement
 				col.emptyNull = col.emptyNull || colConfig.EmptyNull
 				col.json = col.json || colConfig.JSON
 				col.naturalKey = col.naturalKey || colConfig.NaturalKey
+				col.generated = col.generated || colConfig.Generated
 			}
 		}
 
+		col.columnName = schema.foldIdent(col.columnName)
+		col.writeExpr = colConfig.WriteExpr
+		col.readExpr = colConfig.ReadExpr
+		col.codec = colConfig.Codec
+		col.array = colInfo.Array
+		serialized := col.json || col.codec != nil
+		col.intBool = !serialized && (colInfo.Tag.IntBool || schema.intBools) && isBoolType(colInfo.Field.Type)
+		col.date = !serialized && colInfo.Tag.Date && isTimeType(colInfo.Field.Type)
+		col.deleted = !serialized && colInfo.Tag.Deleted && isTimeType(colInfo.Field.Type)
+		col.emptyNull = col.emptyNull || col.deleted
+		if !serialized && !col.date && isTimeType(colInfo.Field.Type) {
+			switch colInfo.Tag.Epoch {
+			case "s":
+				col.epoch = time.Second
+			case "ms":
+				col.epoch = time.Millisecond
+			}
+		}
+
+		// compression only applies to JSON columns, and compressed
+		// data cannot be stored in a jsonb column
+		col.gzip = col.json && colInfo.Tag.Gzip
+		col.jsonb = col.json && colInfo.Tag.JSONB && !col.gzip
+		col.hstore = !serialized && colInfo.Tag.Hstore && isStringMap(colInfo.Field.Type)
+
+		// permitted values in the struct tag take precedence over the
+		// values registered for the field type
+		col.enum = colInfo.Tag.Enum
+		if len(col.enum) == 0 && !serialized {
+			col.enum = schema.enumFor(colInfo.Field.Type)
+		}
+
 		tbl.cols = append(tbl.cols, col)
 
 		if col.primaryKey {
@@ -142,6 +207,12 @@ func newTable(schema *Schema, rowType reflect.Type, cfg *TableConfig) *Table {
 		if col.version {
 			tbl.version = col
 		}
+		if col.deleted {
+			tbl.deletedAt = col
+		}
+		if col.generated {
+			tbl.generated = append(tbl.generated, col)
+		}
 		// TODO(jpj): we should have another way to define these columns
 		// apart from their field names.
 		if col.info.FieldNames == "CreatedAt" {
@@ -152,14 +223,109 @@ func newTable(schema *Schema, rowType reflect.Type, cfg *TableConfig) *Table {
 		}
 	}
 
+	if schema.caseInsensitive {
+		tbl.lowerColumns = lowerColumnMap(tbl.cols)
+	}
+
 	return tbl
 }
 
+// withFields returns a copy of the table that only has the columns for the
+// named fields and the primary key columns. The name of a struct field
+// includes the columns for all of its fields.
+func (tbl *Table) withFields(fieldNames []string) (*Table, error) {
+	matched := make([]bool, len(fieldNames))
+	var cols []*Column
+	for _, col := range tbl.cols {
+		include := col.primaryKey
+		for i, fieldName := range fieldNames {
+			if col.info.FieldNames == fieldName || strings.HasPrefix(col.info.FieldNames, fieldName+".") {
+				include = true
+				matched[i] = true
+			}
+		}
+		if include {
+			cols = append(cols, col)
+		}
+	}
+	for i, fieldName := range fieldNames {
+		if !matched[i] {
+			return nil, fmt.Errorf("unknown field %q in %s", fieldName, tbl.rowType)
+		}
+	}
+	tbl2 := *tbl
+	tbl2.cols = cols
+	if tbl.lowerColumns != nil {
+		tbl2.lowerColumns = lowerColumnMap(cols)
+	}
+	return &tbl2, nil
+}
+
+// lowerColumnMap returns the columns keyed by lower-case column name. If
+// the column has a name in its struct tag that differs from the column name,
+// it is also keyed by the lower-case tag name, unless that would conflict
+// with a column name. If two columns have the same lower-case name, the
+// name maps to nil, as it is ambiguous.
+func lowerColumnMap(cols []*Column) map[string]*Column {
+	m := make(map[string]*Column, len(cols))
+	for _, col := range cols {
+		key := strings.ToLower(col.Name())
+		if _, ok := m[key]; ok {
+			m[key] = nil
+			continue
+		}
+		m[key] = col
+	}
+	for _, col := range cols {
+		if tagName := col.info.Tag.Name; tagName != "" {
+			key := strings.ToLower(tagName)
+			if _, ok := m[key]; !ok {
+				m[key] = col
+			}
+		}
+	}
+	return m
+}
+
+// listColumns returns the column information for the row type. Fields
+// with a codec in the table configuration are mapped to a single column,
+// even if they are structs, maps or slices.
+func listColumns(schema *Schema, rowType reflect.Type, cfg *TableConfig) []*column.Info {
+	var serialized map[string]bool
+	if cfg != nil {
+		for fieldNames, colConfig := range cfg.Columns {
+			if colConfig.Codec != nil {
+				if serialized == nil {
+					serialized = make(map[string]bool)
+				}
+				serialized[fieldNames] = true
+			}
+		}
+	}
+	if serialized != nil {
+		colInfos := column.ListForTypeSerialized(rowType, serialized)
+		if schema.usePostgresArrays() {
+			return colInfos
+		}
+		var list []*column.Info
+		for _, colInfo := range colInfos {
+			if !colInfo.Array {
+				list = append(list, colInfo)
+			}
+		}
+		return list
+	}
+	if schema.usePostgresArrays() {
+		return column.ListForTypeWithArrays(rowType)
+	}
+	return column.ListForType(rowType)
+}
+
 func newTableWithConfig(schema *Schema, rowType reflect.Type, config *TableConfig) (*Table, error) {
 	// check that all of the field names in the config match field names in the row type
 	if len(config.Columns) > 0 {
 		fieldPaths := make(map[string]bool)
-		for _, colInfo := range column.ListForType(rowType) {
+		for _, colInfo := range listColumns(schema, rowType, config) {
 			fieldPaths[colInfo.FieldNames] = true
 		}
 
@@ -170,11 +336,30 @@ func newTableWithConfig(schema *Schema, rowType reflect.Type, config *TableConfi
 		}
 	}
 
-	tbl := newTable(schema, rowType, config)
+	tbl := newTable(schema, rowType, config, nil)
+
+	for _, col := range tbl.Columns() {
+		if col.writeExpr != "" {
+			if n, err := countPlaceholders(col.writeExpr); err != nil || n != 1 {
+				return nil, fmt.Errorf("%s: write expression for field %s must contain one placeholder: %q",
+					rowType, col.info.FieldNames, col.writeExpr)
+			}
+		}
+		if col.readExpr != "" {
+			if n, err := countPlaceholders(col.readExpr); err != nil || n != 0 {
+				return nil, fmt.Errorf("%s: read expression for field %s cannot contain placeholders: %q",
+					rowType, col.info.FieldNames, col.readExpr)
+			}
+		}
+	}
 
 	var versionCols []string
 	var autoIncrementCols []string
+	var deletedCols []string
 	for _, col := range tbl.Columns() {
+		if col.deleted {
+			deletedCols = append(deletedCols, col.Name())
+		}
 		if col.AutoIncrement() {
 			autoIncrementCols = append(autoIncrementCols, col.Name())
 		}
@@ -186,10 +371,17 @@ func newTableWithConfig(schema *Schema, rowType reflect.Type, config *TableConfi
 	if len(versionCols) > 1 {
 		return nil, fmt.Errorf("%s: multiple version columns not permitted (%v)", rowType, versionCols)
 	}
+	if len(deletedCols) > 1 {
+		return nil, fmt.Errorf("%s: multiple deleted columns not permitted (%v)", rowType, deletedCols)
+	}
 	if len(autoIncrementCols) > 1 {
 		return nil, fmt.Errorf("%s: multiple autoincrement columns not permitted (%v)", rowType, versionCols)
 	}
 
+	if err := tbl.checkEpochUnits(); err != nil {
+		return nil, err
+	}
+
 	if tbl.version != nil {
 		kind := tbl.version.info.Field.Type.Kind()
 		if kind != reflect.Int && kind != reflect.Int32 && kind != reflect.Int64 {
@@ -197,14 +389,63 @@ func newTableWithConfig(schema *Schema, rowType reflect.Type, config *TableConfi
 		}
 	}
 
+	if schema.introspectDB != nil {
+		if err := tbl.introspectColumns(context.Background(), schema.introspectDB); err != nil {
+			return nil, fmt.Errorf("%s: %v", rowType, err)
+		}
+	}
+
 	return tbl, nil
 }
 
+// checkEpochUnits returns an error if a column has an epoch unit
+// in its struct tag other than "s" or "ms".
+func (tbl *Table) checkEpochUnits() error {
+	for _, col := range tbl.Columns() {
+		switch col.info.Tag.Epoch {
+		case "", "s", "ms":
+		default:
+			return fmt.Errorf("%s: unknown epoch unit %q for field %s, must be s or ms",
+				tbl.rowType, col.info.Tag.Epoch, col.info.FieldNames)
+		}
+	}
+	return nil
+}
+
 // Name returns the name of the table.
 func (tbl *Table) Name() string {
 	return tbl.tableName
 }
 
+// isSharded reports whether the rows of the table are stored in more
+// than one database table, as determined by the ShardFunc.
+func (tbl *Table) isSharded() bool {
+	return tbl.cfg != nil && tbl.cfg.ShardFunc != nil
+}
+
+// nameFor returns the name of the database table for the row, which
+// is the table name unless the table is sharded.
+func (tbl *Table) nameFor(row interface{}) string {
+	if tbl.isSharded() {
+		return tbl.schema.foldIdent(tbl.cfg.ShardFunc(row))
+	}
+	return tbl.tableName
+}
+
+// notDeleted returns the condition that excludes soft-deleted rows, or a
+// blank string if the table has no deleted column or the schema includes
+// soft-deleted rows in queries. If alias is not blank, the column name is
+// qualified by alias.
+func (tbl *Table) notDeleted(alias string) string {
+	if tbl.deletedAt == nil || tbl.schema.includeDeleted {
+		return ""
+	}
+	if alias != "" {
+		alias += "."
+	}
+	return fmt.Sprintf("%s`%s` is null", alias, tbl.deletedAt.Name())
+}
+
 // RowType returns the row type, which is always a struct.
 func (tbl *Table) RowType() reflect.Type {
 	return tbl.rowType
@@ -231,6 +472,18 @@ func (tbl *Table) Columns() []*Column {
 	return columnSlice(tbl.cols)
 }
 
+// storedColumns returns the columns that are stored in the database table,
+// which excludes any columns with a read expression.
+func (tbl *Table) storedColumns() []*Column {
+	cols := make([]*Column, 0, len(tbl.cols))
+	for _, col := range tbl.cols {
+		if col.readExpr == "" {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
 func (tbl *Table) getRowValue(row interface{}) (reflect.Value, error) {
 	rowValue := reflect.ValueOf(row)
 	for rowValue.Type().Kind() == reflect.Ptr {
@@ -245,6 +498,29 @@ func (tbl *Table) getRowValue(row interface{}) (reflect.Value, error) {
 	return rowValue, nil
 }
 
+// checkZeroPK returns an error if any primary key field of row that is
+// not generated by the database has its zero value. Rows of a table with
+// an autoincrement column are not checked. The op is the prefix of the
+// error message.
+func (tbl *Table) checkZeroPK(op string, row interface{}) error {
+	if tbl.autoincr != nil {
+		return nil
+	}
+	rowValue, err := tbl.getRowValue(row)
+	if err != nil {
+		return err
+	}
+	for _, col := range tbl.pk {
+		if col.generated {
+			continue
+		}
+		if reflect.DeepEqual(col.info.Index.ValueRO(rowValue).Interface(), col.zeroValue) {
+			return fmt.Errorf("%s: primary key field %s of %s has zero value", op, col.info.FieldNames, tbl.rowType)
+		}
+	}
+	return nil
+}
+
 func (tbl *Table) mustGetRowValue(row interface{}) reflect.Value {
 	rowValue, err := tbl.getRowValue(row)
 	if err != nil {
@@ -284,15 +560,38 @@ type Column struct {
 	columnName    string
 	primaryKey    bool
 	autoIncrement bool
+	generated     bool
 	version       bool
 	json          bool
+	jsonb         bool
+	gzip          bool
+	hstore        bool
 	naturalKey    bool
 	emptyNull     bool
+	array         bool
+	intBool       bool
+	date          bool
+	deleted       bool
+	epoch         time.Duration // unit of a time stored as a Unix time integer, or zero
+	codec         Codec         // marshals the field, or nil
+	writeExpr     string        // SQL expression for the value written, or blank
+	readExpr      string        // SQL expression for the value read, or blank
+	introspected  bool          // renamed to match the name of a database column
+	enum          []string
 	zeroValue     interface{}
 
 	info *column.Info
 }
 
+// marshal returns the contents of the field value v to be stored in the
+// column, using the column's codec if it has one, otherwise JSON.
+func (col *Column) marshal(v interface{}) ([]byte, error) {
+	if col.codec != nil {
+		return col.codec.Marshal(v)
+	}
+	return json.Marshal(v)
+}
+
 // Name returns the name of the database column.
 func (col *Column) Name() string {
 	return col.columnName
@@ -320,6 +619,12 @@ func (col *Column) AutoIncrement() bool {
 	return col.autoIncrement
 }
 
+// Generated returns true if the value of this column is generated by the
+// database, and so the column is not included in insert statements.
+func (col *Column) Generated() bool {
+	return col.generated
+}
+
 // Version returns true if this  column is an optimistic locking version column.
 func (col *Column) Version() bool {
 	return col.version
@@ -342,6 +647,64 @@ func (col *Column) JSON() bool {
 	return col.json
 }
 
+// Gzip returns true if the column's JSON is gzip-compressed before being
+// stored in the database column, and decompressed when it is read.
+func (col *Column) Gzip() bool {
+	return col.gzip
+}
+
+// Enum returns the permitted values for the column, as specified by the
+// "enum" keyword in the struct tag, or registered for the field's type
+// using the WithEnum schema option. Returns nil if any value is permitted.
+func (col *Column) Enum() []string {
+	return col.enum
+}
+
+// Comment returns the description of the column, as specified by the
+// "comment" keyword in the struct tag. Returns an empty string if there
+// is no comment.
+func (col *Column) Comment() string {
+	return col.info.Tag.Comment
+}
+
+// checkEnum returns an error if the column has a set of permitted values,
+// and the field value is not one of them.
+func (col *Column) checkEnum(fieldValue reflect.Value) error {
+	enum := col.Enum()
+	if len(enum) == 0 {
+		return nil
+	}
+	for fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			// NULL is permitted, the database constraint decides
+			return nil
+		}
+		fieldValue = fieldValue.Elem()
+	}
+	value, err := enumLabel(fieldValue)
+	if err != nil {
+		return err
+	}
+	if col.emptyNull && value == "" {
+		return nil
+	}
+	if col.permitted(value) {
+		return nil
+	}
+	return fmt.Errorf("invalid value %q for field %q: must be one of %s",
+		value, col.info.Field.Name, strings.Join(enum, ", "))
+}
+
+// permitted reports whether value is one of the column's permitted values.
+func (col *Column) permitted(value string) bool {
+	for _, permitted := range col.Enum() {
+		if value == permitted {
+			return true
+		}
+	}
+	return false
+}
+
 func columnSlice(src []*Column) []*Column {
 	dest := make([]*Column, len(src), len(src))
 	copy(dest, src)
@@ -0,0 +1,204 @@
+package sqlr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ColumnDiffKind describes the nature of a difference between a column
+// derived from a row type and the corresponding column in the database.
+type ColumnDiffKind int
+
+// Kinds of column differences.
+const (
+	ColumnAdded   ColumnDiffKind = iota + 1 // column in row type, but not in database
+	ColumnRemoved                           // column in database, but not in row type
+	ColumnChanged                           // column type in database is not compatible with field type
+)
+
+func (k ColumnDiffKind) String() string {
+	switch k {
+	case ColumnAdded:
+		return "added"
+	case ColumnRemoved:
+		return "removed"
+	case ColumnChanged:
+		return "changed"
+	}
+	return fmt.Sprintf("ColumnDiffKind(%d)", int(k))
+}
+
+// ColumnDiff describes a difference between a column derived from the
+// row type and the corresponding column in the live database table.
+type ColumnDiff struct {
+	Kind ColumnDiffKind
+
+	// ColumnName is the name of the column.
+	ColumnName string
+
+	// Column is the column derived from the row type. It is nil if
+	// the column exists in the database but not in the row type.
+	Column *Column
+
+	// DBType is the data type of the column as reported by the
+	// database. It is empty if the column does not exist in the database.
+	DBType string
+
+	// AllowDestructive must be set for MigrationSQL to generate SQL
+	// for a removed or changed column, as dropping a column or changing
+	// its type can result in the loss of data.
+	AllowDestructive bool
+}
+
+func (d ColumnDiff) String() string {
+	switch d.Kind {
+	case ColumnAdded:
+		return fmt.Sprintf("%s: added", d.ColumnName)
+	case ColumnRemoved:
+		return fmt.Sprintf("%s: removed (%s)", d.ColumnName, d.DBType)
+	case ColumnChanged:
+		return fmt.Sprintf("%s: changed (%s => %s)", d.ColumnName, d.DBType, fieldTypeFamily(d.Column))
+	}
+	return d.ColumnName
+}
+
+// dbColumn contains information about a column obtained by
+// querying the database.
+type dbColumn struct {
+	name   string
+	dbType string
+}
+
+// Diff compares the columns derived from the row type with the columns
+// in the database table, which are obtained by querying the database's
+// information schema.
+//
+// The added columns are present in the row type but not the database table,
+// the removed columns are present in the database table but not the row type,
+// and the changed columns are present in both, but the database column type
+// is not compatible with the type of the associated struct field.
+//
+// Diff is intended as the foundation for schema migration tooling. The result
+// can be passed to MigrationSQL to produce ALTER TABLE statements.
+func (tbl *Table) Diff(ctx context.Context, db Querier) (added, removed, changed []ColumnDiff, err error) {
+	dbCols, err := tbl.queryDBColumns(ctx, db)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(dbCols) == 0 {
+		return nil, nil, nil, fmt.Errorf("table %s not found", tbl.tableName)
+	}
+	added, removed, changed = diffColumns(tbl.storedColumns(), dbCols)
+	return added, removed, changed, nil
+}
+
+// diffColumns compares the columns derived from a row type with the columns
+// obtained from the database. Column names are compared case-insensitively.
+func diffColumns(cols []*Column, dbCols []dbColumn) (added, removed, changed []ColumnDiff) {
+	dbColMap := make(map[string]dbColumn, len(dbCols))
+	for _, dbCol := range dbCols {
+		dbColMap[strings.ToLower(dbCol.name)] = dbCol
+	}
+	colMap := make(map[string]*Column, len(cols))
+	for _, col := range cols {
+		colMap[strings.ToLower(col.columnName)] = col
+	}
+
+	for _, col := range cols {
+		dbCol, ok := dbColMap[strings.ToLower(col.columnName)]
+		if !ok {
+			added = append(added, ColumnDiff{
+				Kind:       ColumnAdded,
+				ColumnName: col.columnName,
+				Column:     col,
+			})
+			continue
+		}
+		if !compatibleFamilies(fieldTypeFamily(col), dbTypeFamily(dbCol.dbType)) {
+			changed = append(changed, ColumnDiff{
+				Kind:       ColumnChanged,
+				ColumnName: col.columnName,
+				Column:     col,
+				DBType:     dbCol.dbType,
+			})
+		}
+	}
+
+	for _, dbCol := range dbCols {
+		if _, ok := colMap[strings.ToLower(dbCol.name)]; !ok {
+			removed = append(removed, ColumnDiff{
+				Kind:       ColumnRemoved,
+				ColumnName: dbCol.name,
+				DBType:     dbCol.dbType,
+			})
+		}
+	}
+
+	return added, removed, changed
+}
+
+// queryDBColumns queries the database for the columns in the table. The
+// query used depends on the dialect. Returns an empty list if the table
+// does not exist.
+func (tbl *Table) queryDBColumns(ctx context.Context, db Querier) ([]dbColumn, error) {
+	dialect := tbl.schema.getDialect()
+	var schemaName string
+	tableName := unquoteIdent(tbl.tableName)
+	if i := strings.LastIndex(tableName, "."); i >= 0 {
+		schemaName, tableName = tableName[:i], tableName[i+1:]
+	}
+
+	var query string
+	var args []interface{}
+	if dialectName(dialect) == dialectSQLite {
+		query = "select name, type from pragma_table_info(?) order by cid"
+		args = append(args, tableName)
+	} else {
+		query = "select column_name, data_type from information_schema.columns where table_name = " +
+			dialect.Placeholder(1)
+		args = append(args, tableName)
+		if schemaName != "" {
+			query += " and table_schema = " + dialect.Placeholder(2)
+			args = append(args, schemaName)
+		} else {
+			switch dialectName(dialect) {
+			case dialectPostgres:
+				query += " and table_schema = current_schema()"
+			case dialectMySQL:
+				query += " and table_schema = database()"
+			case dialectMSSQL:
+				query += " and table_schema = schema_name()"
+			}
+		}
+		query += " order by ordinal_position"
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot query columns for table %s: %v", tbl.tableName, err)
+	}
+	defer rows.Close()
+	var dbCols []dbColumn
+	for rows.Next() {
+		var dbCol dbColumn
+		if err := rows.Scan(&dbCol.name, &dbCol.dbType); err != nil {
+			return nil, fmt.Errorf("cannot scan columns for table %s: %v", tbl.tableName, err)
+		}
+		dbCols = append(dbCols, dbCol)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot query columns for table %s: %v", tbl.tableName, err)
+	}
+	return dbCols, nil
+}
+
+// unquoteIdent removes any quotes from an identifier, which may
+// consist of multiple parts separated by a period.
+func unquoteIdent(ident string) string {
+	parts := strings.Split(ident, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(part, "\"`[] \t")
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,96 @@
+package sqlr
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffColumns(t *testing.T) {
+	type Row struct {
+		ID      int64 `sql:"primary key"`
+		Name    string
+		Amount  float64
+		Active  bool
+		Created time.Time
+		Notes   string
+		Data    []byte
+	}
+	tbl := NewSchema().TableFor(Row{})
+
+	dbCols := []dbColumn{
+		{name: "id", dbType: "bigint"},
+		{name: "NAME", dbType: "character varying"},
+		{name: "amount", dbType: "numeric"},
+		{name: "active", dbType: "tinyint"},
+		{name: "created", dbType: "integer"},
+		{name: "data", dbType: "text"},
+		{name: "legacy", dbType: "text"},
+	}
+
+	added, removed, changed := diffColumns(tbl.cols, dbCols)
+
+	names := func(diffs []ColumnDiff) []string {
+		var names []string
+		for _, d := range diffs {
+			names = append(names, d.ColumnName)
+		}
+		return names
+	}
+
+	if got, want := names(added), []string{"notes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("added: got=%v, want=%v", got, want)
+	}
+	if got, want := names(removed), []string{"legacy"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("removed: got=%v, want=%v", got, want)
+	}
+	if got, want := names(changed), []string{"created", "data"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changed: got=%v, want=%v", got, want)
+	}
+	if got, want := added[0].Kind, ColumnAdded; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if removed[0].Column != nil {
+		t.Errorf("removed column: got=%v, want=nil", removed[0].Column)
+	}
+	if got, want := changed[0].String(), "created: changed (integer => time)"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestDBTypeFamily(t *testing.T) {
+	tests := []struct {
+		dbType string
+		want   typeFamily
+	}{
+		{"integer", familyInteger},
+		{"BIGINT", familyInteger},
+		{"int(11) unsigned", familyInteger},
+		{"int8", familyInteger},
+		{"bigserial", familyInteger},
+		{"interval", familyUnknown},
+		{"point", familyUnknown},
+		{"boolean", familyBool},
+		{"bit", familyBool},
+		{"varchar", familyText},
+		{"character varying", familyText},
+		{"nvarchar", familyText},
+		{"jsonb", familyText},
+		{"bytea", familyBinary},
+		{"varbinary", familyBinary},
+		{"BLOB", familyBinary},
+		{"timestamp with time zone", familyTime},
+		{"datetime2", familyTime},
+		{"date", familyTime},
+		{"double precision", familyFloat},
+		{"REAL", familyFloat},
+		{"numeric", familyFloat},
+		{"", familyUnknown},
+		{"geometry", familyUnknown},
+	}
+	for _, tt := range tests {
+		if got, want := dbTypeFamily(tt.dbType), tt.want; got != want {
+			t.Errorf("%s: got=%v, want=%v", tt.dbType, got, want)
+		}
+	}
+}
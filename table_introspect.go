@@ -0,0 +1,81 @@
+package sqlr
+
+import (
+	"context"
+	"strings"
+)
+
+// introspectColumns queries the database for the columns of the table, and
+// renames any column that does not match a database column to the database
+// column with the same normalized name. Columns named in a struct tag or in
+// the table's config are not renamed. Returns an error if the database cannot
+// be queried, in which case the table is unchanged.
+func (tbl *Table) introspectColumns(ctx context.Context, db Querier) error {
+	dbCols, err := tbl.queryDBColumns(ctx, db)
+	if err != nil {
+		return err
+	}
+	if len(dbCols) == 0 {
+		return nil
+	}
+	exact := make(map[string]bool, len(dbCols))
+	normalized := make(map[string]string, len(dbCols))
+	for _, dbCol := range dbCols {
+		exact[dbCol.name] = true
+		normalized[normalizeColumnName(dbCol.name)] = dbCol.name
+	}
+	var renamed bool
+	for _, col := range tbl.cols {
+		if exact[col.columnName] || col.info.Tag.Name != "" {
+			continue
+		}
+		if tbl.cfg != nil && tbl.cfg.Columns[col.info.FieldNames].ColumnName != "" {
+			continue
+		}
+		name, ok := normalized[normalizeColumnName(col.columnName)]
+		if !ok {
+			name, ok = normalized[normalizeColumnName(col.info.FieldNames)]
+		}
+		if ok {
+			col.columnName = name
+			col.introspected = true
+			renamed = true
+		}
+	}
+	if renamed && tbl.lowerColumns != nil {
+		tbl.lowerColumns = lowerColumnMap(tbl.cols)
+	}
+	return nil
+}
+
+// copyIntrospectedNames renames the columns of the table to the names
+// found by introspecting the columns of from, which is the table for the
+// same row type in the schema that tbl's schema was derived from. The
+// database is not queried again, as it is the same database.
+func (tbl *Table) copyIntrospectedNames(from *Table) {
+	names := make(map[string]string)
+	for _, col := range from.cols {
+		if col.introspected {
+			names[col.info.FieldNames] = col.columnName
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	for _, col := range tbl.cols {
+		if name, ok := names[col.info.FieldNames]; ok {
+			col.columnName = name
+			col.introspected = true
+		}
+	}
+	if tbl.lowerColumns != nil {
+		tbl.lowerColumns = lowerColumnMap(tbl.cols)
+	}
+}
+
+// normalizeColumnName returns name in lower case without any underscores
+// or periods, so that names that differ only in case and word separators
+// compare equal.
+func normalizeColumnName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", ".", "").Replace(name))
+}
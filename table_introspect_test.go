@@ -0,0 +1,196 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestColumnIntrospection(t *testing.T) {
+	type Address struct {
+		Street string
+	}
+	type UserAccount struct {
+		UserID   int64 `sql:"primary key"`
+		FullName string
+		EMail    string
+		Address  Address
+		Notes    string `sql:"remarks"`
+		Missing  string
+	}
+	tests := []struct {
+		dbColumns string
+		want      string
+	}{
+		{
+			dbColumns: "USERID,Full_Name,e_mail,addressstreet,remarks",
+			want:      `select "USERID", "Full_Name", "e_mail", "addressstreet", "remarks", "missing" from user_account`,
+		},
+		{
+			// table does not exist, so column names follow the naming convention
+			dbColumns: "",
+			want:      `select "user_id", "full_name", "e_mail", "address_street", "remarks", "missing" from user_account`,
+		},
+	}
+	for i, tt := range tests {
+		db := columnsDB(tt.dbColumns)
+		schema := NewSchema(WithDialect(Postgres), WithColumnIntrospection(db))
+		stmt, err := schema.Prepare(UserAccount{}, "select {} from user_account")
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if got := stmt.String(); got != tt.want {
+			t.Errorf("%d: got=%q, want=%q", i, got, tt.want)
+		}
+		db.Close()
+	}
+}
+
+func TestColumnIntrospectionError(t *testing.T) {
+	type UserAccount struct {
+		UserID   int64 `sql:"primary key"`
+		FullName string
+	}
+	rows := columnsDB("USERID,FULLNAME")
+	defer rows.Close()
+	db := &flakyDB{rows: rows, fails: 1, err: errors.New("query error")}
+	schema := NewSchema(WithDialect(Postgres), WithColumnIntrospection(db))
+
+	// the error is returned, and the table is not kept
+	_, err := schema.Prepare(UserAccount{}, "select {} from user_account")
+	if got, want := fmt.Sprint(err), "cannot query columns for table user_account: query error"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	// the database is queried again
+	stmt, err := schema.Prepare(UserAccount{}, "select {} from user_account")
+	wantNoError(t, err)
+	if got, want := stmt.String(), `select "USERID", "FULLNAME" from user_account`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestColumnIntrospectionTablesConfig(t *testing.T) {
+	type UserAccount struct {
+		UserID   int64 `sql:"primary key"`
+		FullName string
+		EMail    string
+	}
+	db := columnsDB("USERID,FULLNAME,EMAIL_ADDRESS")
+	defer db.Close()
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithColumnIntrospection(db),
+		WithTables(TablesConfig{
+			(*UserAccount)(nil): {
+				Columns: ColumnsConfig{
+					"EMail": {ColumnName: "email_address"},
+				},
+			},
+		}),
+	)
+	stmt, err := schema.Prepare(UserAccount{}, "select {} from user_account")
+	wantNoError(t, err)
+	if got, want := stmt.String(), `select "USERID", "FULLNAME", "email_address" from user_account`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	// the error is returned when the schema is created
+	errDB := columnsDB("error")
+	defer errDB.Close()
+	_, err = NewSchemaE(
+		WithDialect(Postgres),
+		WithColumnIntrospection(errDB),
+		WithTables(TablesConfig{(*UserAccount)(nil): {}}),
+	)
+	if err == nil {
+		t.Error("got=nil, want error")
+	}
+}
+
+func TestColumnIntrospectionWithDialect(t *testing.T) {
+	type UserAccount struct {
+		UserID   int64 `sql:"primary key"`
+		FullName string
+	}
+	type Widget struct {
+		WidgetID int64 `sql:"primary key"`
+		FullName string
+	}
+	rows := columnsDB("USERID,FULLNAME")
+	defer rows.Close()
+	db := &flakyDB{rows: rows}
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithColumnIntrospection(db),
+		WithTables(TablesConfig{(*UserAccount)(nil): {}}),
+	)
+	if got, want := db.calls, 1; got != want {
+		t.Fatalf("calls: got=%d, want=%d", got, want)
+	}
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	// the derived schema copies the column names, and does not query
+	// the database with the placeholders of its own dialect
+	ansi := sess.WithDialect(ANSISQL).schema
+	stmt, err := ansi.Prepare(UserAccount{}, "select {} from user_account")
+	wantNoError(t, err)
+	if got, want := stmt.String(), `select "USERID", "FULLNAME" from user_account`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	if got, want := db.calls, 1; got != want {
+		t.Errorf("calls: got=%d, want=%d", got, want)
+	}
+
+	// tables not configured are introspected once, by the original schema
+	stmt, err = ansi.Prepare(Widget{}, "select {} from widget")
+	wantNoError(t, err)
+	if got, want := stmt.String(), `select "widget_id", "FULLNAME" from widget`; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	_, err = schema.Prepare(Widget{}, "select {} from widget")
+	wantNoError(t, err)
+	if got, want := db.calls, 2; got != want {
+		t.Errorf("calls: got=%d, want=%d", got, want)
+	}
+}
+
+func TestNormalizeColumnName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"user_id", "userid"},
+		{"UserID", "userid"},
+		{"USER_ID", "userid"},
+		{"Address.Street", "addressstreet"},
+	}
+	for _, tt := range tests {
+		if got := normalizeColumnName(tt.name); got != tt.want {
+			t.Errorf("%s: got=%q, want=%q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// columnsDB returns a database that returns the comma-separated column
+// names in dbColumns, with a data type of "text", for any query. It mimics
+// a query of the database's information schema. If dbColumns is "error",
+// every query returns an error.
+func columnsDB(dbColumns string) *sql.DB {
+	conn := &rowsConn{columns: []string{"column_name", "data_type"}}
+	if dbColumns == "error" {
+		conn.queryErr = errors.New("query error")
+	}
+	if dbColumns != "" {
+		for _, name := range strings.Split(dbColumns, ",") {
+			conn.values = append(conn.values, []driver.Value{name, "text"})
+		}
+	}
+	return openRowsDB(conn)
+}
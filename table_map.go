@@ -24,6 +24,16 @@ func (tm *tableMap) add(rowType reflect.Type, tbl *Table) *Table {
 	return v.(*Table)
 }
 
+// tables returns all of the tables in the map, in no particular order.
+func (tm *tableMap) all() []*Table {
+	var tables []*Table
+	tm.tables.Range(func(_, v interface{}) bool {
+		tables = append(tables, v.(*Table))
+		return true
+	})
+	return tables
+}
+
 // lookup a table based on its row type in the map. Returns nil
 // if not found.
 func (tm *tableMap) lookup(rowType reflect.Type) *Table {
@@ -0,0 +1,131 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// InTx runs fn as a single unit of work within a database transaction.
+// If fn returns nil the unit of work is committed, otherwise it is rolled
+// back and the error returned by fn is returned. If fn panics, the unit of
+// work is rolled back and the panic is propagated.
+//
+// If the session's querier is a *sql.DB or a *sql.Conn, InTx begins a new
+// transaction and passes fn a new session that uses the transaction. The
+// new session shares the schema, row handlers and query budget of the
+// calling session.
+//
+// If the session's querier is already a *sql.Tx, InTx creates a savepoint
+// instead of starting a new transaction, and fn is passed the calling
+// session. On success the savepoint is released, and on failure the
+// transaction is rolled back to the savepoint, leaving any work performed
+// earlier in the enclosing transaction intact. This makes it possible to
+// nest calls to InTx.
+func (sess *Session) InTx(fn func(tx *Session) error) error {
+	switch querier := baseQuerier(sess.querier).(type) {
+	case *sql.Tx:
+		return sess.inSavepoint(fn)
+	case interface {
+		BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error)
+	}:
+		tx, err := querier.BeginTx(sess.context, nil)
+		if err != nil {
+			return err
+		}
+		return sess.inNewTx(tx, fn)
+	}
+	return fmt.Errorf("InTx does not support querier of type %T", sess.querier)
+}
+
+// inNewTx runs fn in a new session using tx, and commits or rolls back tx.
+func (sess *Session) inNewTx(tx *sql.Tx, fn func(tx *Session) error) (err error) {
+	txSess := sess.txSession(tx)
+	committed := false
+	defer func() {
+		txSess.Close()
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+	if err := fn(txSess); err != nil {
+		return err
+	}
+	txSess.Flush()
+	committed = true
+	return tx.Commit()
+}
+
+// txSession returns a new session that uses tx. The new session is derived
+// from sess, so it uses the same schema (which might have been changed by
+// WithDialect), row handlers and query budget. Select queries are not retried
+// by the new session, even if the schema has the WithReadRetry option.
+func (sess *Session) txSession(tx *sql.Tx) *Session {
+	txSess := NewSession(sess.context, tx, sess.schema)
+	txSess.rowHandlers = sess.rowHandlers
+	for querier := sess.querier; querier != nil; {
+		if bq, ok := querier.(*budgetQuerier); ok {
+			txSess.querier = &budgetQuerier{querier: txSess.querier, budget: bq.budget}
+			break
+		}
+		wrapper, ok := querier.(interface{ base() Querier })
+		if !ok {
+			break
+		}
+		querier = wrapper.base()
+	}
+	return txSess
+}
+
+// inSavepoint runs fn inside a savepoint of the session's transaction.
+func (sess *Session) inSavepoint(fn func(tx *Session) error) (err error) {
+	dialect := sess.schema.getDialect()
+	sess.savepoints++
+	name := fmt.Sprintf("sqlr_sp_%d", sess.savepoints)
+	defer func() { sess.savepoints-- }()
+
+	if _, err := sess.querier.ExecContext(sess.context, savepointSQL(dialect, name)); err != nil {
+		return fmt.Errorf("cannot create savepoint: %v", err)
+	}
+	released := false
+	defer func() {
+		if !released {
+			sess.querier.ExecContext(sess.context, rollbackSavepointSQL(dialect, name))
+		}
+	}()
+	if err := fn(sess); err != nil {
+		return err
+	}
+	released = true
+	if query := releaseSavepointSQL(dialect, name); query != "" {
+		if _, err := sess.querier.ExecContext(sess.context, query); err != nil {
+			return fmt.Errorf("cannot release savepoint: %v", err)
+		}
+	}
+	return nil
+}
+
+// savepointSQL returns the SQL statement that creates a savepoint.
+func savepointSQL(dialect Dialect, name string) string {
+	if dialectName(dialect) == dialectMSSQL {
+		return "save transaction " + name
+	}
+	return "savepoint " + name
+}
+
+// rollbackSavepointSQL returns the SQL statement that rolls back to a savepoint.
+func rollbackSavepointSQL(dialect Dialect, name string) string {
+	if dialectName(dialect) == dialectMSSQL {
+		return "rollback transaction " + name
+	}
+	return "rollback to savepoint " + name
+}
+
+// releaseSavepointSQL returns the SQL statement that releases a savepoint,
+// or an empty string if the dialect does not release savepoints.
+func releaseSavepointSQL(dialect Dialect, name string) string {
+	if dialectName(dialect) == dialectMSSQL {
+		return ""
+	}
+	return "release savepoint " + name
+}
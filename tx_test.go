@@ -0,0 +1,125 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestSavepointSQL(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		create   string
+		rollback string
+		release  string
+	}{
+		{
+			dialect:  Postgres,
+			create:   "savepoint sqlr_sp_1",
+			rollback: "rollback to savepoint sqlr_sp_1",
+			release:  "release savepoint sqlr_sp_1",
+		},
+		{
+			dialect:  MySQL,
+			create:   "savepoint sqlr_sp_1",
+			rollback: "rollback to savepoint sqlr_sp_1",
+			release:  "release savepoint sqlr_sp_1",
+		},
+		{
+			dialect:  SQLite,
+			create:   "savepoint sqlr_sp_1",
+			rollback: "rollback to savepoint sqlr_sp_1",
+			release:  "release savepoint sqlr_sp_1",
+		},
+		{
+			dialect:  MSSQL,
+			create:   "save transaction sqlr_sp_1",
+			rollback: "rollback transaction sqlr_sp_1",
+			release:  "",
+		},
+	}
+	for tn, tt := range tests {
+		if got, want := savepointSQL(tt.dialect, "sqlr_sp_1"), tt.create; got != want {
+			t.Errorf("%d: create: got=%q, want=%q", tn, got, want)
+		}
+		if got, want := rollbackSavepointSQL(tt.dialect, "sqlr_sp_1"), tt.rollback; got != want {
+			t.Errorf("%d: rollback: got=%q, want=%q", tn, got, want)
+		}
+		if got, want := releaseSavepointSQL(tt.dialect, "sqlr_sp_1"), tt.release; got != want {
+			t.Errorf("%d: release: got=%q, want=%q", tn, got, want)
+		}
+	}
+}
+
+func TestInTxUnsupportedQuerier(t *testing.T) {
+	sess := NewSession(context.Background(), &FakeDB{}, NewSchema())
+	defer sess.Close()
+	err := sess.InTx(func(tx *Session) error {
+		t.Fatal("unexpected call to fn")
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if got, want := err.Error(), "InTx does not support querier of type *sqlr.FakeDB"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestInTxSession(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 2)
+	defer db.Close()
+	schema := NewSchema(WithDialect(Postgres))
+	sess := NewSessionWithBudget(context.Background(), db, schema, time.Minute)
+	defer sess.Close()
+	var handled int
+	sess.HandleRows(func(rows []*Row) {
+		handled += len(rows)
+	})
+
+	mysql := sess.WithDialect(MySQL)
+	err := mysql.InTx(func(tx *Session) error {
+		if got, want := tx.Schema(), mysql.Schema(); got != want {
+			t.Error("want schema of the calling session")
+		}
+		if _, ok := baseQuerier(tx.querier).(*sql.Tx); !ok {
+			t.Errorf("got querier %T, want *sql.Tx", baseQuerier(tx.querier))
+		}
+		bq, ok := tx.querier.(*budgetQuerier)
+		if !ok {
+			t.Fatalf("got querier %T, want *budgetQuerier", tx.querier)
+		}
+		if got, want := bq.budget, sess.querier.(*budgetQuerier).budget; got != want {
+			t.Error("want budget shared with the calling session")
+		}
+		var rows []*Row
+		_, err := tx.Select(&rows, "select {} from rows")
+		return err
+	})
+	wantNoError(t, err)
+	if got, want := handled, 2; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+
+	// the transaction session is closed if fn panics
+	var txSess *Session
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("want panic")
+			}
+		}()
+		sess.InTx(func(tx *Session) error {
+			txSess = tx
+			panic("fn panics")
+		})
+	}()
+	if txSess.context.Err() == nil {
+		t.Error("want transaction session closed")
+	}
+}
@@ -0,0 +1,85 @@
+package sqlr
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// UpdateJSON merges a partial JSON document into a JSONB column of an existing
+// row, without reading the row first. The row identifies the database row by its
+// primary key, and fieldName is the name of the struct field for the column, which
+// must have the "json" struct tag. The partial document is marshaled into JSON and
+// merged with the existing column contents on the database server:
+//  n, err := sess.UpdateJSON(&widget, "Attributes", map[string]interface{}{
+//      "color": "red",
+//  })
+// Top-level keys in the partial document replace the same keys in the column,
+// and all other keys are unchanged. Because the merge is performed in a single
+// UPDATE statement, concurrent merges of different keys do not overwrite each
+// other. If the column is NULL, it is set to the partial document.
+//
+// The field in the row is not updated. UpdateJSON returns the number of rows
+// updated, which should be zero or one.
+//
+// Only PostgreSQL supports merging JSONB documents.
+func (sess *Session) UpdateJSON(row interface{}, fieldName string, partial interface{}) (int, error) {
+	query, err := updateJSONSQL(sess.schema, row, fieldName)
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.Marshal(partial)
+	if err != nil {
+		return 0, fmt.Errorf("cannot marshal partial JSON for field %q: %v", fieldName, err)
+	}
+	stmt, err := sess.schema.Prepare(row, query)
+	if err != nil {
+		return 0, err
+	}
+	tbl := stmt.tbl
+	if rc := sess.schema.rowCacheFor(tbl.rowType); rc != nil {
+		defer rc.invalidateRow(tbl, row)
+	}
+	result, err := stmt.exec(sess.context, sess.querier, row, string(data))
+	if err != nil {
+		return 0, tbl.wrapRowError(err, row, "cannot update JSON")
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, tbl.wrapRowError(err, row, "cannot retrieve rows affected")
+	}
+	return int(n), nil
+}
+
+// updateJSONSQL returns the SQL for merging a partial JSON document into
+// the column for the field.
+func updateJSONSQL(schema *Schema, row interface{}, fieldName string) (string, error) {
+	dialect := schema.getDialect()
+	if !isPostgres(dialect) {
+		return "", fmt.Errorf("UpdateJSON is not supported for dialect %s", dialectName(dialect))
+	}
+	tbl, err := schema.tableFor(row)
+	if err != nil {
+		return "", err
+	}
+	if len(tbl.PrimaryKey()) == 0 {
+		return "", fmt.Errorf("UpdateJSON requires a primary key in %s", tbl.rowType)
+	}
+	var col *Column
+	for _, c := range tbl.Columns() {
+		if c.info.FieldNames == fieldName {
+			col = c
+			break
+		}
+	}
+	if col == nil {
+		return "", fmt.Errorf("unknown field %q in %s", fieldName, tbl.rowType)
+	}
+	if !col.JSON() || col.Gzip() {
+		return "", fmt.Errorf("field %q must be an uncompressed JSON column", fieldName)
+	}
+	name := dialect.Quote(col.Name())
+	return fmt.Sprintf(
+		"update %s set %s = coalesce(%s, '{}'::jsonb) || ?::jsonb where {}",
+		dialect.Quote(tbl.nameFor(row)), name, name,
+	), nil
+}
@@ -0,0 +1,91 @@
+package sqlr
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestUpdateJSONSQL(t *testing.T) {
+	type Widget struct {
+		ID         int64 `sql:"primary key"`
+		Name       string
+		Attributes map[string]interface{} `sql:"json"`
+		Archive    map[string]interface{} `sql:"json gzip"`
+	}
+	type NoKey struct {
+		Attributes map[string]interface{} `sql:"json"`
+	}
+	tests := []struct {
+		dialect   Dialect
+		row       interface{}
+		fieldName string
+		want      string
+		errText   string
+	}{
+		{
+			dialect:   Postgres,
+			row:       &Widget{},
+			fieldName: "Attributes",
+			want:      `update "widget" set "attributes" = coalesce("attributes", '{}'::jsonb) || $1::jsonb where "id" = $2`,
+		},
+		{
+			dialect:   MySQL,
+			row:       &Widget{},
+			fieldName: "Attributes",
+			errText:   "UpdateJSON is not supported for dialect mysql",
+		},
+		{
+			dialect:   Postgres,
+			row:       &Widget{},
+			fieldName: "Name",
+			errText:   `field "Name" must be an uncompressed JSON column`,
+		},
+		{
+			dialect:   Postgres,
+			row:       &Widget{},
+			fieldName: "Archive",
+			errText:   `field "Archive" must be an uncompressed JSON column`,
+		},
+		{
+			dialect:   Postgres,
+			row:       &Widget{},
+			fieldName: "Missing",
+			errText:   `unknown field "Missing" in sqlr.Widget`,
+		},
+		{
+			dialect:   Postgres,
+			row:       &NoKey{},
+			fieldName: "Attributes",
+			errText:   "UpdateJSON requires a primary key in sqlr.NoKey",
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		query, err := updateJSONSQL(schema, tt.row, tt.fieldName)
+		var errText string
+		if err != nil {
+			errText = err.Error()
+		}
+		if errText != tt.errText {
+			t.Errorf("%d: got error %q, want %q", i, errText, tt.errText)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		stmt, err := schema.Prepare(tt.row, query)
+		if err != nil {
+			t.Errorf("%d: cannot prepare: %v", i, err)
+			continue
+		}
+		if got := stmt.String(); got != tt.want {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, tt.want)
+		}
+		args, err := stmt.getArgs(&Widget{ID: 7}, []interface{}{`{"a":1}`})
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		} else if got, want := fmt.Sprint(args), `[{"a":1} 7]`; got != want {
+			t.Errorf("%d: got args=%s, want=%s", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package sqlr
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// UpdateRowReturning updates one row in the database, and then scans all of
+// the columns of the updated row back into the row, which must be a pointer
+// to a struct with a primary key. This keeps the row consistent with the
+// database when the update fires triggers that modify columns, or when
+// columns are recomputed by the database.
+//
+// For PostgreSQL the row is updated and read back in one statement using a
+// RETURNING clause, which reflects the changes made by BEFORE triggers. For
+// other dialects, and for rows with a version field, the row is updated with
+// UpdateRow and then read back with GetRow. (SQLite triggers can only modify
+// rows in AFTER triggers, which are not reflected in a RETURNING clause, and
+// SQL Server does not permit an OUTPUT clause for a table with triggers.)
+//
+// If there is no database row with the primary key, sql.ErrNoRows is
+// returned.
+func (sess *Session) UpdateRowReturning(row interface{}) error {
+	tbl, err := sess.schema.tableFor(row)
+	if err != nil {
+		return err
+	}
+	if len(tbl.pk) == 0 {
+		return fmt.Errorf("UpdateRowReturning requires a primary key in %s", tbl.rowType)
+	}
+	rowValue := tbl.mustGetRowValue(row)
+	if !rowValue.CanAddr() {
+		return fmt.Errorf("UpdateRowReturning requires *%s", tbl.rowType)
+	}
+
+	query := updateReturningSQL(sess.schema.getDialect(), tbl, row)
+	if query == "" {
+		n, err := sess.UpdateRow(row)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return sql.ErrNoRows
+		}
+		found, err := sess.GetRow(row)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return sql.ErrNoRows
+		}
+		return nil
+	}
+
+	if rc := sess.schema.rowCacheFor(tbl.rowType); rc != nil {
+		defer rc.invalidateRow(tbl, row)
+	}
+	if tbl.updatedAt != nil {
+		updatedAtValue := tbl.updatedAt.info.Index.ValueRW(rowValue)
+		updatedAtValue.Set(reflect.ValueOf(time.Now()))
+	}
+	stmt, err := sess.schema.Prepare(row, query)
+	if err != nil {
+		return err
+	}
+	args, err := stmt.getArgs(row, nil)
+	if err != nil {
+		return err
+	}
+	n, err := stmt.selectRows(sess.context, sess.querier, row, args...)
+	if err != nil {
+		return tbl.wrapRowError(err, row, "cannot update row")
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// updateReturningSQL returns the SQL for updating the row and returning
+// all of its columns in one statement. Returns a blank string if this is
+// not possible for the dialect, or if the row has a version field, which
+// requires the checks performed by UpdateRow.
+func updateReturningSQL(dialect Dialect, tbl *Table, row interface{}) string {
+	if tbl.version != nil {
+		return ""
+	}
+	if !isPostgres(dialect) {
+		return ""
+	}
+	return fmt.Sprintf("update %s set {} where {} returning {}", dialect.Quote(tbl.nameFor(row)))
+}
@@ -0,0 +1,92 @@
+package sqlr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateRowReturningSQL(t *testing.T) {
+	type Widget struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	type Versioned struct {
+		ID      int64 `sql:"primary key"`
+		Version int64 `sql:"version"`
+	}
+	tests := []struct {
+		dialect Dialect
+		row     interface{}
+		want    []string
+	}{
+		{
+			dialect: Postgres,
+			row:     &Widget{ID: 1},
+			want: []string{
+				`update "widget" set "name" = $1 where "id" = $2 returning "id", "name"`,
+			},
+		},
+		{
+			dialect: SQLite,
+			row:     &Widget{ID: 1},
+			want: []string{
+				"update `widget` set `name` = ? where `id` = ?",
+				"select `id`, `name` from `widget` where `id` = ?",
+			},
+		},
+		{
+			dialect: Postgres,
+			row:     &Versioned{ID: 1, Version: 2},
+			want: []string{
+				`update "versioned" set "version" = $1 where "id" = $2 and "version" = $3`,
+				`select "id", "version" from "versioned" where "id" = $1`,
+			},
+		},
+	}
+	for i, tt := range tests {
+		db := &FakeDB{rowsAffected: 1, queryErr: errors.New("query error")}
+		sess := NewSession(context.Background(), db, NewSchema(WithDialect(tt.dialect)))
+		if err := sess.UpdateRowReturning(tt.row); err == nil {
+			t.Errorf("%d: got=nil, want=error", i)
+		}
+		if got := db.queries; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateRowReturning(t *testing.T) {
+	type Widget struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 1)
+	defer db.Close()
+	sess := NewSession(context.Background(), db, NewSchema(WithDialect(Postgres)))
+	row := Widget{ID: 1, Name: "old name"}
+	wantNoError(t, sess.UpdateRowReturning(&row))
+	if got, want := row.Name, "name"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	if err := sess.UpdateRowReturning(row); err == nil || err.Error() != "UpdateRowReturning requires *sqlr.Widget" {
+		t.Errorf("got=%v, want=error", err)
+	}
+	type NoKey struct {
+		Name string
+	}
+	if err := sess.UpdateRowReturning(&NoKey{}); err == nil || err.Error() != "UpdateRowReturning requires a primary key in sqlr.NoKey" {
+		t.Errorf("got=%v, want=error", err)
+	}
+
+	// no rows returned
+	db0 := rowsDB(t, 0)
+	defer db0.Close()
+	sess = NewSession(context.Background(), db0, NewSchema(WithDialect(Postgres)))
+	if err := sess.UpdateRowReturning(&row); err != sql.ErrNoRows {
+		t.Errorf("got=%v, want=%v", err, sql.ErrNoRows)
+	}
+}
@@ -0,0 +1,416 @@
+package sqlr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// UpsertRow inserts one row into the database, or if a row with the same
+// primary key already exists, updates the existing row. It returns the
+// number of rows affected, as reported by the database driver.
+//
+// Upsert is supported for PostgreSQL and SQLite, which use an
+// "on conflict" clause, for MySQL, which uses an "on duplicate key
+// update" clause, and for SQL Server, which uses a "merge" statement.
+//
+// If the row has a created at field, it is set for the insert but is
+// not changed by the update. If the row has a version field, the version
+// is 1 for the insert, and is incremented by the update. The row's version
+// field is set to the version stored in the database. Unlike UpdateRow,
+// the update is not conditional on the previous version of the row.
+//
+// If the primary key is an auto-increment column, a row with a zero primary
+// key is always inserted as a new row. A row with a non-zero primary key is
+// inserted with that value, so that it updates the existing row if there is
+// one. (For SQL Server the identity value is never inserted, so a new row is
+// assigned the next identity value.)
+//
+// If the row has an auto-increment field, it is set to the value of the
+// auto-increment column of the inserted or updated row. For PostgreSQL
+// and SQLite, if the conflicting row is not updated because there are no
+// columns to update, the auto-increment field is not changed and zero
+// rows are affected.
+func (sess *Session) UpsertRow(row interface{}) (int, error) {
+	tbl, err := sess.schema.tableFor(row)
+	if err != nil {
+		return 0, err
+	}
+	if sess.schema.zeroPKGuard {
+		if err := tbl.checkZeroPK("UpsertRow", row); err != nil {
+			return 0, err
+		}
+	}
+	return sess.upsertRow(row, tbl, tbl.PrimaryKey(), "")
+}
+
+// UpsertOnConflict is similar to UpsertRow, but the conflict target is
+// specified explicitly instead of being the primary key. The conflict target
+// is a comma-separated list of column names that match a unique constraint or
+// unique index.
+//
+// If the unique index is a partial index, the predicate must match the
+// predicate of the index, otherwise the database will not be able to infer the
+// index. The "where" keyword is optional.
+//  // create unique index users_email on users(email) where deleted_at is null
+//  n, err := sess.UpsertOnConflict(&user, "email", "where deleted_at is null")
+//
+// UpsertOnConflict is supported for PostgreSQL, SQLite and SQL Server. MySQL
+// does not permit the conflict target to be specified, and a predicate is not
+// supported for SQL Server.
+func (sess *Session) UpsertOnConflict(row interface{}, conflictTarget string, predicate string) (int, error) {
+	tbl, err := sess.schema.tableFor(row)
+	if err != nil {
+		return 0, err
+	}
+	if sess.schema.zeroPKGuard {
+		if err := tbl.checkZeroPK("UpsertOnConflict", row); err != nil {
+			return 0, err
+		}
+	}
+	target, err := tbl.conflictTarget(conflictTarget)
+	if err != nil {
+		return 0, err
+	}
+	return sess.upsertRow(row, tbl, target, predicate)
+}
+
+func (sess *Session) upsertRow(row interface{}, tbl *Table, target []*Column, predicate string) (int, error) {
+	dialect := sess.schema.getDialect()
+	query, returnCols, err := upsertSQL(dialect, tbl, row, target, predicate)
+	if err != nil {
+		return 0, err
+	}
+	if rc := sess.schema.rowCacheFor(tbl.rowType); rc != nil {
+		defer rc.invalidateRow(tbl, row)
+	}
+
+	var rowValue reflect.Value
+	if tbl.createdAt != nil || tbl.updatedAt != nil || tbl.version != nil || tbl.autoincr != nil {
+		rowValue = tbl.mustGetRowValue(row)
+		if !rowValue.CanAddr() {
+			return 0, fmt.Errorf("UpsertRow requires *%s to update fields", tbl.rowType)
+		}
+		if tbl.createdAt != nil || tbl.updatedAt != nil {
+			nowValue := reflect.ValueOf(time.Now())
+			if tbl.createdAt != nil {
+				tbl.createdAt.info.Index.ValueRW(rowValue).Set(nowValue)
+			}
+			if tbl.updatedAt != nil {
+				tbl.updatedAt.info.Index.ValueRW(rowValue).Set(nowValue)
+			}
+		}
+		if tbl.version != nil {
+			// the version for the insert, the update increments the stored version
+			tbl.version.info.Index.ValueRW(rowValue).SetInt(1)
+		}
+	}
+
+	if len(returnCols) > 0 {
+		found, err := sess.queryReturned(row, tbl, rowValue, query, returnCols, "cannot upsert row")
+		if err != nil || !found {
+			return 0, err
+		}
+		return 1, nil
+	}
+
+	stmt, err := sess.schema.Prepare(row, query)
+	if err != nil {
+		return 0, err
+	}
+	result, err := stmt.exec(sess.context, sess.querier, row)
+	if err != nil {
+		return 0, tbl.wrapRowError(err, row, "cannot upsert row")
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, tbl.wrapRowError(err, row, "cannot retrieve rows affected")
+	}
+	if tbl.autoincr != nil {
+		// the update sets the last insert id to the id of the existing row
+		lastInsertID, err := result.LastInsertId()
+		if err != nil {
+			return 0, tbl.wrapRowError(err, row, "cannot retrieve last insert id")
+		}
+		tbl.autoincr.info.Index.ValueRW(rowValue).SetInt(lastInsertID)
+	}
+	if tbl.version != nil {
+		// the dialect cannot return the version from the upsert statement
+		query := fmt.Sprintf("select %s from %s where {}", dialect.Quote(tbl.version.columnName), dialect.Quote(tbl.nameFor(row)))
+		if _, err := sess.queryReturned(row, tbl, rowValue, query, []*Column{tbl.version}, "cannot obtain version"); err != nil {
+			return 0, err
+		}
+	}
+	return int(n), nil
+}
+
+// conflictTarget returns the columns named in the comma-separated list.
+func (tbl *Table) conflictTarget(columnNames string) ([]*Column, error) {
+	var target []*Column
+	for _, name := range strings.Split(columnNames, ",") {
+		name = unquoteIdent(strings.TrimSpace(name))
+		if name == "" {
+			return nil, fmt.Errorf("invalid conflict target %q", columnNames)
+		}
+		var found *Column
+		for _, col := range tbl.Columns() {
+			if strings.EqualFold(col.Name(), name) {
+				found = col
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("unknown column %q in conflict target for table %s", name, tbl.Name())
+		}
+		target = append(target, found)
+	}
+	return target, nil
+}
+
+// upsertSQL returns the SQL for inserting the row into the table, or updating
+// the existing row on conflict with the target columns. The predicate is
+// optional, and is used for conflicts with a partial unique index.
+//
+// If the statement returns the auto-increment and version columns of the
+// inserted or updated row, these columns are returned in returnCols.
+func upsertSQL(dialect Dialect, tbl *Table, row interface{}, target []*Column, predicate string) (query string, returnCols []*Column, err error) {
+	if len(target) == 0 {
+		return "", nil, fmt.Errorf("upsert requires a primary key or conflict target for %s", tbl.rowType)
+	}
+	predicate = strings.TrimSpace(predicate)
+	if len(predicate) >= 5 && strings.EqualFold(predicate[:5], "where") {
+		predicate = strings.TrimSpace(predicate[5:])
+	}
+	if strings.ContainsAny(predicate, ";?") {
+		return "", nil, fmt.Errorf("invalid conflict predicate %q", predicate)
+	}
+
+	// columns updated on conflict
+	var updateCols []*Column
+	isTarget := make(map[*Column]bool)
+	for _, col := range target {
+		isTarget[col] = true
+	}
+	for _, col := range tbl.Columns() {
+		if isTarget[col] || col.PrimaryKey() || col.AutoIncrement() || col.Generated() || col.readExpr != "" || col == tbl.createdAt || col == tbl.version {
+			continue
+		}
+		updateCols = append(updateCols, col)
+	}
+	tableName := dialect.Quote(tbl.nameFor(row))
+	if tbl.autoincr != nil {
+		returnCols = append(returnCols, tbl.autoincr)
+	}
+
+	// The auto-increment column is not normally inserted, but when it is in
+	// the conflict target a non-zero value must be inserted, otherwise the
+	// conflict can never occur. A zero value inserts a new row.
+	insertSQL := fmt.Sprintf("insert into %s({}) values({})", tableName)
+	if tbl.autoincr != nil && isTarget[tbl.autoincr] {
+		autoincrValue := tbl.autoincr.info.Index.ValueRO(tbl.mustGetRowValue(row))
+		if !reflect.DeepEqual(autoincrValue.Interface(), tbl.autoincr.zeroValue) {
+			insertSQL = upsertInsertSQL(dialect, tableName, tbl)
+		}
+	}
+	if tbl.version != nil {
+		returnCols = append(returnCols, tbl.version)
+	}
+
+	var sb bytes.Buffer
+	switch dialectName(dialect) {
+	case dialectPostgres, dialectSQLite:
+		sb.WriteString(insertSQL)
+		sb.WriteString(" on conflict(")
+		for i, col := range target {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(dialect.Quote(col.Name()))
+		}
+		sb.WriteString(")")
+		if predicate != "" {
+			sb.WriteString(" where ")
+			sb.WriteString(predicate)
+		}
+		if len(updateCols) == 0 && tbl.version == nil {
+			sb.WriteString(" do nothing")
+		} else {
+			sb.WriteString(" do update set ")
+			for i, col := range updateCols {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				name := dialect.Quote(col.Name())
+				fmt.Fprintf(&sb, "%s = excluded.%s", name, name)
+			}
+			if tbl.version != nil {
+				if len(updateCols) > 0 {
+					sb.WriteString(", ")
+				}
+				name := dialect.Quote(tbl.version.Name())
+				fmt.Fprintf(&sb, "%s = %s.%s + 1", name, tableName, name)
+			}
+		}
+		if len(returnCols) > 0 {
+			sb.WriteString(" returning ")
+			for i, col := range returnCols {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				sb.WriteString(dialect.Quote(col.Name()))
+			}
+		}
+	case dialectMySQL:
+		if predicate != "" {
+			return "", nil, errors.New("mysql does not support a predicate for the conflict target")
+		}
+		if !sameColumns(target, tbl.PrimaryKey()) {
+			return "", nil, errors.New("mysql does not support a conflict target other than the primary key")
+		}
+		sb.WriteString(insertSQL)
+		sb.WriteString(" on duplicate key update ")
+		var assignments []string
+		if tbl.autoincr != nil {
+			// so that the last insert id is the id of the updated row
+			name := dialect.Quote(tbl.autoincr.Name())
+			assignments = append(assignments, fmt.Sprintf("%s = last_insert_id(%s)", name, name))
+		}
+		for _, col := range updateCols {
+			name := dialect.Quote(col.Name())
+			assignments = append(assignments, fmt.Sprintf("%s = values(%s)", name, name))
+		}
+		if tbl.version != nil {
+			name := dialect.Quote(tbl.version.Name())
+			assignments = append(assignments, fmt.Sprintf("%s = %s + 1", name, name))
+		}
+		if len(assignments) == 0 {
+			// no-op update, so that a duplicate key is not an error
+			name := dialect.Quote(target[0].Name())
+			assignments = append(assignments, fmt.Sprintf("%s = values(%s)", name, name))
+		}
+		sb.WriteString(strings.Join(assignments, ", "))
+		// the auto-increment and version columns are obtained separately
+		returnCols = nil
+	case dialectMSSQL:
+		if predicate != "" {
+			return "", nil, errors.New("mssql does not support a predicate for the conflict target")
+		}
+		return mssqlMergeSQL(dialect, tableName, tbl, target, updateCols, returnCols), returnCols, nil
+	default:
+		return "", nil, fmt.Errorf("upsert is not supported for dialect %s", dialectName(dialect))
+	}
+	return sb.String(), returnCols, nil
+}
+
+// upsertInsertSQL returns an insert statement for the row that includes the
+// auto-increment column, which is needed when the auto-increment column is
+// the conflict target.
+func upsertInsertSQL(dialect Dialect, tableName string, tbl *Table) string {
+	var names, values []string
+	for _, col := range tbl.Columns() {
+		if col != tbl.autoincr && !columnFilterInsertable(col) {
+			continue
+		}
+		fieldRef := func() string { return "{field " + col.info.FieldNames + "}" }
+		names = append(names, dialect.Quote(col.Name()))
+		values = append(values, writeValue(col, dialect, fieldRef))
+	}
+	return fmt.Sprintf("insert into %s(%s) values(%s)", tableName, strings.Join(names, ", "), strings.Join(values, ", "))
+}
+
+// mssqlMergeSQL returns the SQL Server merge statement for an upsert. The
+// source row is a select of the row's fields, so that the merge statement
+// can refer to each value more than once. The source row includes the
+// auto-increment column if it is in the conflict target, but the identity
+// value is never inserted.
+func mssqlMergeSQL(dialect Dialect, tableName string, tbl *Table, target []*Column, updateCols []*Column, returnCols []*Column) string {
+	var insertCols, sourceCols []*Column
+	for _, col := range tbl.Columns() {
+		if columnFilterInsertable(col) {
+			insertCols = append(insertCols, col)
+			sourceCols = append(sourceCols, col)
+		} else if col == tbl.autoincr {
+			for _, targetCol := range target {
+				if targetCol == col {
+					sourceCols = append(sourceCols, col)
+				}
+			}
+		}
+	}
+	var sb bytes.Buffer
+	fmt.Fprintf(&sb, "merge into %s with (holdlock) as t using (select ", tableName)
+	for i, col := range sourceCols {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fieldRef := func() string { return "{field " + col.info.FieldNames + "}" }
+		fmt.Fprintf(&sb, "%s as %s", writeValue(col, dialect, fieldRef), dialect.Quote(col.Name()))
+	}
+	sb.WriteString(") as s on ")
+	for i, col := range target {
+		if i > 0 {
+			sb.WriteString(" and ")
+		}
+		name := dialect.Quote(col.Name())
+		fmt.Fprintf(&sb, "t.%s = s.%s", name, name)
+	}
+	if len(updateCols) > 0 || tbl.version != nil {
+		sb.WriteString(" when matched then update set ")
+		for i, col := range updateCols {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			name := dialect.Quote(col.Name())
+			fmt.Fprintf(&sb, "t.%s = s.%s", name, name)
+		}
+		if tbl.version != nil {
+			if len(updateCols) > 0 {
+				sb.WriteString(", ")
+			}
+			name := dialect.Quote(tbl.version.Name())
+			fmt.Fprintf(&sb, "t.%s = t.%s + 1", name, name)
+		}
+	}
+	sb.WriteString(" when not matched then insert(")
+	for i, col := range insertCols {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(dialect.Quote(col.Name()))
+	}
+	sb.WriteString(") values(")
+	for i, col := range insertCols {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("s." + dialect.Quote(col.Name()))
+	}
+	sb.WriteString(")")
+	if len(returnCols) > 0 {
+		sb.WriteString(" output ")
+		for i, col := range returnCols {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString("inserted." + dialect.Quote(col.Name()))
+		}
+	}
+	sb.WriteString(";")
+	return sb.String()
+}
+
+// sameColumns reports whether a and b contain the same columns in the same order.
+func sameColumns(a, b []*Column) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
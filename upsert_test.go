@@ -0,0 +1,186 @@
+package sqlr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpsertSQL(t *testing.T) {
+	type User struct {
+		ID        int64 `sql:"primary key autoincrement"`
+		Email     string
+		Name      string
+		CreatedAt time.Time
+		DeletedAt *time.Time
+	}
+	type Versioned struct {
+		ID      int64 `sql:"primary key"`
+		Version int64 `sql:"version"`
+	}
+	type Key struct {
+		ID int64 `sql:"primary key"`
+	}
+	tests := []struct {
+		dialect   Dialect
+		row       interface{}
+		target    string
+		predicate string
+		want      string
+		errText   string
+	}{
+		{
+			dialect: Postgres,
+			row:     User{},
+			want:    `insert into "user"({}) values({}) on conflict("id") do update set "email" = excluded."email", "name" = excluded."name", "deleted_at" = excluded."deleted_at" returning "id"`,
+		},
+		{
+			dialect:   Postgres,
+			row:       User{},
+			target:    "email",
+			predicate: "where deleted_at is null",
+			want:      `insert into "user"({}) values({}) on conflict("email") where deleted_at is null do update set "name" = excluded."name", "deleted_at" = excluded."deleted_at" returning "id"`,
+		},
+		{
+			dialect:   SQLite,
+			row:       User{},
+			target:    `"Email", name`,
+			predicate: "deleted_at is null",
+			want:      "insert into `user`({}) values({}) on conflict(`email`, `name`) where deleted_at is null do update set `deleted_at` = excluded.`deleted_at` returning `id`",
+		},
+		{
+			dialect: MySQL,
+			row:     User{},
+			want:    "insert into `user`({}) values({}) on duplicate key update `id` = last_insert_id(`id`), `email` = values(`email`), `name` = values(`name`), `deleted_at` = values(`deleted_at`)",
+		},
+		{
+			dialect: Postgres,
+			row:     User{ID: 5},
+			want:    `insert into "user"("id", "email", "name", "created_at", "deleted_at") values({field ID}, {field Email}, {field Name}, {field CreatedAt}, {field DeletedAt}) on conflict("id") do update set "email" = excluded."email", "name" = excluded."name", "deleted_at" = excluded."deleted_at" returning "id"`,
+		},
+		{
+			// the auto-increment column is only inserted when it is the target
+			dialect: Postgres,
+			row:     User{ID: 5},
+			target:  "email",
+			want:    `insert into "user"({}) values({}) on conflict("email") do update set "name" = excluded."name", "deleted_at" = excluded."deleted_at" returning "id"`,
+		},
+		{
+			dialect: SQLite,
+			row:     User{ID: 5},
+			want:    "insert into `user`(`id`, `email`, `name`, `created_at`, `deleted_at`) values({field ID}, {field Email}, {field Name}, {field CreatedAt}, {field DeletedAt}) on conflict(`id`) do update set `email` = excluded.`email`, `name` = excluded.`name`, `deleted_at` = excluded.`deleted_at` returning `id`",
+		},
+		{
+			dialect: MySQL,
+			row:     User{ID: 5},
+			want:    "insert into `user`(`id`, `email`, `name`, `created_at`, `deleted_at`) values({field ID}, {field Email}, {field Name}, {field CreatedAt}, {field DeletedAt}) on duplicate key update `id` = last_insert_id(`id`), `email` = values(`email`), `name` = values(`name`), `deleted_at` = values(`deleted_at`)",
+		},
+		{
+			dialect: MSSQL,
+			row:     User{ID: 5},
+			want:    "merge into [user] with (holdlock) as t using (select {field ID} as [id], {field Email} as [email], {field Name} as [name], {field CreatedAt} as [created_at], {field DeletedAt} as [deleted_at]) as s on t.[id] = s.[id] when matched then update set t.[email] = s.[email], t.[name] = s.[name], t.[deleted_at] = s.[deleted_at] when not matched then insert([email], [name], [created_at], [deleted_at]) values(s.[email], s.[name], s.[created_at], s.[deleted_at]) output inserted.[id];",
+		},
+		{
+			dialect: Postgres,
+			row:     Key{},
+			want:    `insert into "key"({}) values({}) on conflict("id") do nothing`,
+		},
+		{
+			dialect: MySQL,
+			row:     Key{},
+			want:    "insert into `key`({}) values({}) on duplicate key update `id` = values(`id`)",
+		},
+		{
+			dialect:   MySQL,
+			row:       User{},
+			target:    "email",
+			predicate: "where deleted_at is null",
+			errText:   "mysql does not support a predicate for the conflict target",
+		},
+		{
+			dialect: MySQL,
+			row:     User{},
+			target:  "email",
+			errText: "mysql does not support a conflict target other than the primary key",
+		},
+		{
+			dialect: MSSQL,
+			row:     User{},
+			target:  "email",
+			want:    "merge into [user] with (holdlock) as t using (select {field Email} as [email], {field Name} as [name], {field CreatedAt} as [created_at], {field DeletedAt} as [deleted_at]) as s on t.[email] = s.[email] when matched then update set t.[name] = s.[name], t.[deleted_at] = s.[deleted_at] when not matched then insert([email], [name], [created_at], [deleted_at]) values(s.[email], s.[name], s.[created_at], s.[deleted_at]) output inserted.[id];",
+		},
+		{
+			dialect: MSSQL,
+			row:     Key{},
+			want:    "merge into [key] with (holdlock) as t using (select {field ID} as [id]) as s on t.[id] = s.[id] when not matched then insert([id]) values(s.[id]);",
+		},
+		{
+			dialect:   MSSQL,
+			row:       User{},
+			target:    "email",
+			predicate: "where deleted_at is null",
+			errText:   "mssql does not support a predicate for the conflict target",
+		},
+		{
+			dialect: ANSISQL,
+			row:     User{},
+			errText: "upsert is not supported for dialect ansi",
+		},
+		{
+			dialect: Postgres,
+			row:     User{},
+			target:  "email, address",
+			errText: `unknown column "address" in conflict target for table user`,
+		},
+		{
+			dialect:   Postgres,
+			row:       User{},
+			target:    "email",
+			predicate: "where deleted_at is null; drop table user",
+			errText:   `invalid conflict predicate "deleted_at is null; drop table user"`,
+		},
+		{
+			dialect: Postgres,
+			row:     Versioned{},
+			want:    `insert into "versioned"({}) values({}) on conflict("id") do update set "version" = "versioned"."version" + 1 returning "version"`,
+		},
+		{
+			dialect: MySQL,
+			row:     Versioned{},
+			want:    "insert into `versioned`({}) values({}) on duplicate key update `version` = `version` + 1",
+		},
+		{
+			dialect: MSSQL,
+			row:     Versioned{},
+			want:    "merge into [versioned] with (holdlock) as t using (select {field ID} as [id], {field Version} as [version]) as s on t.[id] = s.[id] when matched then update set t.[version] = t.[version] + 1 when not matched then insert([id], [version]) values(s.[id], s.[version]) output inserted.[version];",
+		},
+	}
+	for i, tt := range tests {
+		schema := NewSchema(WithDialect(tt.dialect))
+		tbl := schema.TableFor(tt.row)
+		target := tbl.PrimaryKey()
+		var err error
+		if tt.target != "" {
+			target, err = tbl.conflictTarget(tt.target)
+		}
+		var got string
+		if err == nil {
+			got, _, err = upsertSQL(schema.getDialect(), tbl, tt.row, target, tt.predicate)
+		}
+		var errText string
+		if err != nil {
+			errText = err.Error()
+		}
+		if errText != tt.errText {
+			t.Errorf("%d: got error %q, want %q", i, errText, tt.errText)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%d: got=%q\nwant=%q", i, got, tt.want)
+		}
+		if tt.want != "" {
+			if _, err := schema.Prepare(tt.row, got); err != nil {
+				t.Errorf("%d: cannot prepare: %v", i, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package sqlr
+
+// WithDialect returns a copy of the session that uses dialect for subsequent
+// queries. The original session is not changed. This is useful for running a
+// query against a database that uses a different SQL dialect, such as a read
+// replica running a different engine, without creating a new schema and session.
+//
+// The copy uses a schema with the same options as the session's schema, but
+// with its own statement cache and table information, so statements prepared
+// for one dialect are never used for another. The schema for each dialect is
+// created the first time it is needed and is reused by later calls. Column
+// names found by WithColumnIntrospection are copied from the session's schema,
+// and the database is not queried again.
+//
+// The copy shares the context, querier and row handlers of the session. Closing
+// the copy does not close the original session, but the copy cannot be used
+// once the original session has been closed.
+func (sess *Session) WithDialect(dialect Dialect) *Session {
+	if dialect == nil {
+		panic("dialect cannot be nil")
+	}
+	return &Session{
+		context:     sess.context,
+		cancel:      func() {},
+		querier:     sess.querier,
+		schema:      sess.schema.withDialect(dialect),
+		rowHandlers: sess.rowHandlers,
+		savepoints:  sess.savepoints,
+		lastQuery:   sess.lastQuery,
+	}
+}
+
+// withDialect returns a schema with the same options as s, except that it
+// uses dialect. If dialect is the dialect of s, then s is returned.
+func (s *Schema) withDialect(dialect Dialect) *Schema {
+	if dialect == s.getDialect() {
+		return s
+	}
+	if v, ok := s.dialectSchemas.Load(dialect); ok {
+		return v.(*Schema)
+	}
+
+	// The statement cache, table map and func map are not copied, as their
+	// contents depend on the dialect. Row caches are shared, because rows
+	// are the same regardless of the dialect used to query them. The database
+	// is not introspected with the derived dialect: column names found by
+	// introspection are copied from the tables of s.
+	derived := &Schema{
+		dialect:         dialect,
+		convention:      s.convention,
+		fieldMap:        s.fieldMap,
+		identMap:        s.identMap,
+		key:             s.key,
+		postgresArrays:  s.postgresArrays,
+		maxRows:         s.maxRows,
+		readRetries:     s.readRetries,
+		lastQuery:       s.lastQuery,
+		sliceExpansion:  s.sliceExpansion,
+		cacheAudit:      s.cacheAudit,
+		decimalCheck:    s.decimalCheck,
+		includeDeleted:  s.includeDeleted,
+		dialectParent:   s,
+		pluralizer:      s.pluralizer,
+		zeroPKGuard:     s.zeroPKGuard,
+		tvp:             s.tvp,
+		intBools:        s.intBools,
+		identCase:       s.identCase,
+		identCaseSet:    s.identCaseSet,
+		caseInsensitive: s.caseInsensitive,
+		protobuf:        s.protobuf,
+		afterScan:       s.afterScan,
+		conflictError:   s.conflictError,
+		enums:           s.enums,
+		rowCaches:       s.rowCaches,
+	}
+	if !derived.identCaseSet {
+		derived.identCase = defaultIdentCase(dialect)
+	}
+	if !supportsValuesList(dialect) {
+		// an IN list is equivalent, and works for every dialect
+		derived.sliceExpansion = ExpandList
+	}
+
+	// tables configured when the schema was created
+	for _, tbl := range s.tableMap.all() {
+		if tbl.cfg != nil {
+			derivedTbl, err := newTableWithConfig(derived, tbl.rowType, tbl.cfg)
+			if err != nil {
+				// the config has already been checked by s
+				panic(err)
+			}
+			derivedTbl.copyIntrospectedNames(tbl)
+			derived.tableMap.add(tbl.rowType, derivedTbl)
+		}
+	}
+
+	v, _ := s.dialectSchemas.LoadOrStore(dialect, derived)
+	return v.(*Schema)
+}
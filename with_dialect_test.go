@@ -0,0 +1,81 @@
+package sqlr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSessionWithDialect(t *testing.T) {
+	type Row struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	type Configured struct {
+		ID   int64 `sql:"primary key"`
+		Name string
+	}
+	db := rowsDB(t, 1)
+	defer db.Close()
+	schema := NewSchema(
+		WithDialect(Postgres),
+		WithLastQuery(),
+		WithTables(TablesConfig{
+			reflect.TypeOf(Configured{}): {TableName: "configured_rows"},
+		}),
+	)
+	sess := NewSession(context.Background(), db, schema)
+	defer sess.Close()
+
+	mysql := sess.WithDialect(MySQL)
+	if mysql.Schema() == schema {
+		t.Fatal("got same schema, want schema for MySQL")
+	}
+	if got, want := sess.WithDialect(MySQL).Schema(), mysql.Schema(); got != want {
+		t.Error("want schema for MySQL to be reused")
+	}
+	if got, want := sess.WithDialect(Postgres).Schema(), schema; got != want {
+		t.Error("want same schema for same dialect")
+	}
+
+	var rows []*Row
+	tests := []struct {
+		sess *Session
+		want string
+	}{
+		{sess: mysql, want: "select `id`, `name` from rows where `id` = ?"},
+		{sess: sess, want: `select "id", "name" from rows where "id" = $1`},
+		{sess: mysql, want: "select `id`, `name` from rows where `id` = ?"},
+	}
+	for i, tt := range tests {
+		_, err := tt.sess.Select(&rows, "select {} from rows where {}", 1)
+		wantNoError(t, err)
+		if query, _ := sess.LastQuery(); query != tt.want {
+			t.Errorf("%d: got=%q, want=%q", i, query, tt.want)
+		}
+	}
+
+	// configured tables are available for the new dialect
+	if got, want := mysql.Schema().TableFor(Configured{}).Name(), "configured_rows"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	// the identifier case follows the dialect unless specified
+	if got, want := sess.WithDialect(Oracle).Schema().TableFor(Row{}).Name(), "ROW"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	// slices are expanded to an IN list for dialects without VALUES lists
+	values := NewSchema(WithDialect(Postgres), WithSliceExpansion(ExpandValues))
+	if got, want := values.withDialect(MSSQL).sliceExpansion, ExpandList; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := values.withDialect(SQLite).sliceExpansion, ExpandValues; got != want {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	// closing the copy does not close the original session
+	mysql.Close()
+	_, err := sess.Select(&rows, "select {} from rows")
+	wantNoError(t, err)
+}